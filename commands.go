@@ -0,0 +1,313 @@
+package main
+
+import (
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"harness-copy-project/services"
+)
+
+// commands are the commands of the tool besides the copy itself. Every command reads its
+// flags and runs its operation.
+func commands() []*cli.Command {
+	return []*cli.Command{
+		{
+			Name:   "export",
+			Usage:  "Write a project to a local directory bundle that can be imported later",
+			Action: exportBundle,
+			Flags: flags(accountFlags("the project"), []cli.Flag{
+				&cli.StringFlag{
+					Name:     "org",
+					Usage:    "The org of the project to export.",
+					Required: true,
+				},
+				&cli.StringFlag{
+					Name:     "project",
+					Usage:    "The project to export.",
+					Required: true,
+				},
+				&cli.StringFlag{
+					Name:     "bundle",
+					Usage:    "The directory the bundle is written to.",
+					Required: true,
+				},
+				&cli.BoolFlag{
+					Name:     "copyCDComponents",
+					Usage:    "If set to 'true', then it will export the Continuous Delivery components.",
+					Required: false,
+					Value:    false,
+				},
+				&cli.BoolFlag{
+					Name:     "copyFFComponents",
+					Usage:    "If set to 'true, then it will export the Feature Flag components.",
+					Required: false,
+					Value:    false,
+				},
+				&cli.StringFlag{
+					Name:     "secretValues",
+					Usage:    "The path to a YAML file that maps secret identifiers to the values written to the bundle. Only used with 'includeSecretValues'.",
+					Required: false,
+				},
+				&cli.BoolFlag{
+					Name:     "includeSecretValues",
+					Usage:    "If set to 'true', then the values from 'secretValues' are written to the bundle in plain text. Otherwise every secret is exported with a placeholder value.",
+					Required: false,
+					Value:    false,
+				},
+				&cli.IntFlag{
+					Name:     "entityConcurrency",
+					Usage:    "The number of pipelines, input sets, templates and files that are exported at the same time.",
+					Required: false,
+					Value:    1,
+				},
+				&cli.BoolFlag{
+					Name:     "showProgressBar",
+					Usage:    "If set to 'true, then it will show the progress bar for items as they are exported.",
+					Required: false,
+					Value:    false,
+				},
+				logLevelFlag(),
+			}, renameFlags("exported"), clientFlags()),
+		},
+		{
+			Name:   "import",
+			Usage:  "Create a project from a bundle written by the export command",
+			Action: importBundle,
+			Flags: flags(accountFlags("the target project"), []cli.Flag{
+				&cli.StringFlag{
+					Name:     "bundle",
+					Usage:    "The directory of the bundle to import.",
+					Required: true,
+				},
+				&cli.StringFlag{
+					Name:     "org",
+					Usage:    "The org the project is imported to.",
+					Required: true,
+				},
+				&cli.StringFlag{
+					Name:     "project",
+					Usage:    "The project the bundle is imported to. Defaults to the exported project.",
+					Required: false,
+				},
+				&cli.StringFlag{
+					Name:     "conflictStrategy",
+					Usage:    "What to do with an entity that already exists in the target. Valid values are 'skip' and 'fail'.",
+					Required: false,
+					Value:    services.ConflictSkip,
+				},
+				&cli.StringFlag{
+					Name:     "checkpointFile",
+					Usage:    "The path to the file that records every entity imported.",
+					Required: false,
+					Value:    "checkpoint.json",
+				},
+				&cli.StringFlag{
+					Name:     "journalFile",
+					Usage:    "The path to the file every entity created in the target is appended to, used by the rollback command.",
+					Required: false,
+					Value:    "journal.jsonl",
+				},
+				&cli.BoolFlag{
+					Name:     "resume",
+					Usage:    "If set to 'true', then entities recorded in the checkpoint file by a previous run are skipped.",
+					Required: false,
+					Value:    false,
+				},
+				&cli.BoolFlag{
+					Name:     "showProgressBar",
+					Usage:    "If set to 'true, then it will show the progress bar for entries as they are imported.",
+					Required: false,
+					Value:    false,
+				},
+				logLevelFlag(),
+			}, clientFlags()),
+		},
+		{
+			Name:   "rollback",
+			Usage:  "Delete the entities a copy or an import created in a target project, as recorded in the journal file",
+			Action: rollback,
+			Flags: flags(accountFlags("the target project"), []cli.Flag{
+				&cli.StringFlag{
+					Name:     "org",
+					Usage:    "The org of the target project.",
+					Required: true,
+				},
+				&cli.StringFlag{
+					Name:     "project",
+					Usage:    "The target project to roll back.",
+					Required: true,
+				},
+				&cli.StringFlag{
+					Name:     "journalFile",
+					Usage:    "The path to the journal file written by the copy or the import.",
+					Required: false,
+					Value:    "journal.jsonl",
+				},
+				logLevelFlag(),
+			}, clientFlags()),
+		},
+		{
+			Name:   "graph",
+			Usage:  "Write the references between the entities of a project as DOT, Mermaid and JSON",
+			Action: dependencyGraph,
+			Flags: flags(accountFlags("the project"), []cli.Flag{
+				&cli.StringFlag{
+					Name:     "org",
+					Usage:    "The org of the project.",
+					Required: true,
+				},
+				&cli.StringFlag{
+					Name:     "project",
+					Usage:    "The project to graph.",
+					Required: true,
+				},
+				&cli.StringFlag{
+					Name:     "outputDir",
+					Usage:    "The directory the graph files are written to, named after the project.",
+					Required: false,
+					Value:    ".",
+				},
+				&cli.StringSliceFlag{
+					Name:     "format",
+					Usage:    "The formats the graph is written in. Valid values are 'dot', 'mermaid' and 'json'.",
+					Required: false,
+					Value:    cli.NewStringSlice(services.GraphFormatNames()...),
+				},
+				&cli.StringFlag{
+					Name:     "entity",
+					Usage:    "The type of the entity to limit the graph to, such as 'Pipeline' or 'Template'. Requires '--identifier'.",
+					Required: false,
+				},
+				&cli.StringFlag{
+					Name:     "identifier",
+					Usage:    "The identifier of the entity to limit the graph to. Input sets and triggers are 'pipeline/identifier', infrastructures 'environment/identifier'.",
+					Required: false,
+				},
+				logLevelFlag(),
+			}, clientFlags()),
+		},
+		{
+			Name:   "freeze",
+			Usage:  "Freeze the source projects of the CSV file, updating the freeze window of a previous run",
+			Action: freezeProjects,
+			Flags: flags(accountFlags("the source projects"), []cli.Flag{
+				&cli.StringFlag{
+					Name:     "csvPath",
+					Usage:    "The path to the CSV file with the projects, the source projects are frozen.",
+					Required: true,
+				},
+				logLevelFlag(),
+			}, freezeFlags(), clientFlags()),
+		},
+		{
+			Name:   "unfreeze",
+			Usage:  "Disable or delete the freeze window of the tool in the source projects of the CSV file",
+			Action: unfreezeProjects,
+			Flags: flags(accountFlags("the source projects"), []cli.Flag{
+				&cli.StringFlag{
+					Name:     "csvPath",
+					Usage:    "The path to the CSV file with the projects, the source projects are unfrozen.",
+					Required: true,
+				},
+				&cli.BoolFlag{
+					Name:     "delete",
+					Usage:    "If set to 'true', then the freeze window is deleted instead of disabled.",
+					Required: false,
+					Value:    false,
+				},
+				logLevelFlag(),
+			}, clientFlags()),
+		},
+		{
+			Name:   "freeze-status",
+			Usage:  "List the freeze window of the tool in the source projects of the CSV file",
+			Action: freezeStatus,
+			Flags: flags(accountFlags("the source projects"), []cli.Flag{
+				&cli.StringFlag{
+					Name:     "csvPath",
+					Usage:    "The path to the CSV file with the projects, the source projects are listed.",
+					Required: true,
+				},
+				logLevelFlag(),
+			}, clientFlags()),
+		},
+		{
+			Name:   "mirror",
+			Usage:  "Keep the target projects of the CSV file in sync with their source, copying what changed on an interval",
+			Action: mirrorProjects,
+			Flags: flags([]cli.Flag{
+				&cli.StringFlag{
+					Name:     "csvPath",
+					Usage:    "The path to the CSV file with the projects to mirror.",
+					Required: true,
+				},
+				&cli.BoolFlag{
+					Name:     "copyCDComponents",
+					Usage:    "If set to 'true', then it will mirror the Continuous Delivery components.",
+					Required: false,
+					Value:    false,
+				},
+				&cli.BoolFlag{
+					Name:     "copyFFComponents",
+					Usage:    "If set to 'true', then it will mirror the Feature Flag components.",
+					Required: false,
+					Value:    false,
+				},
+				&cli.BoolFlag{
+					Name:     "copyOrgDependencies",
+					Usage:    "If set to 'true', then the org connectors, secrets, templates and variables referenced by the project are copied to the target org.",
+					Required: false,
+					Value:    false,
+				},
+				&cli.StringFlag{
+					Name:     "secretValues",
+					Usage:    "The path to a YAML file that maps secret identifiers to the values used when copying secrets.",
+					Required: false,
+				},
+				&cli.DurationFlag{
+					Name:     "interval",
+					Usage:    "The time between the start of two cycles, such as '5m' or '1h'.",
+					Required: false,
+					Value:    5 * time.Minute,
+				},
+				&cli.IntFlag{
+					Name:     "cycles",
+					Usage:    "The number of cycles to run before stopping. '0' runs until the command is interrupted.",
+					Required: false,
+					Value:    0,
+				},
+				&cli.BoolFlag{
+					Name:     "delete",
+					Usage:    "If set to 'true', then the entities the source project no longer has are deleted from the target project.",
+					Required: false,
+					Value:    false,
+				},
+				&cli.StringFlag{
+					Name:     "changeLog",
+					Usage:    "The path to the file the changes of every cycle are appended to, one JSON line per project and cycle.",
+					Required: false,
+					Value:    "mirror.jsonl",
+				},
+				&cli.StringFlag{
+					Name:     "syncFile",
+					Usage:    "The path to the file that records when each project was last mirrored.",
+					Required: false,
+					Value:    "sync.json",
+				},
+				&cli.StringFlag{
+					Name:     "journalFile",
+					Usage:    "The path to the file every entity created in the target is appended to, used by the rollback command.",
+					Required: false,
+					Value:    "journal.jsonl",
+				},
+				&cli.IntFlag{
+					Name:     "entityConcurrency",
+					Usage:    "The number of pipelines, input sets, templates and files that are copied at the same time within a project.",
+					Required: false,
+					Value:    1,
+				},
+				logLevelFlag(),
+			}, endpointFlags(), renameFlags("copied"), clientFlags()),
+		},
+	}
+}
@@ -0,0 +1,381 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"go.uber.org/zap"
+	"harness-copy-project/model"
+	"harness-copy-project/operation"
+	"harness-copy-project/services"
+)
+
+// freezeFlags configure the freeze window the source projects are frozen with
+func freezeFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:     "freezeName",
+			Usage:    "The name of the freeze window the source project is frozen with once it is copied.",
+			Required: false,
+			Value:    services.FREEZE_NAME,
+		},
+		&cli.StringFlag{
+			Name:     "freezeDescription",
+			Usage:    "The description of the freeze window. '{targetOrg}' and '{targetProject}' are replaced with the project the source was copied to.",
+			Required: false,
+			Value:    services.FREEZE_DESCRIPTION,
+		},
+		&cli.StringFlag{
+			Name:     "freezeTimeZone",
+			Usage:    "The time zone of the freeze window.",
+			Required: false,
+			Value:    services.FREEZE_TIME_ZONE,
+		},
+		&cli.StringFlag{
+			Name:     "freezeStartTime",
+			Usage:    "The start of the freeze window as '" + services.FREEZE_START_TIME_LAYOUT + "' in its time zone. Defaults to the time the project is frozen.",
+			Required: false,
+		},
+		&cli.StringFlag{
+			Name:     "freezeDuration",
+			Usage:    "The duration of the freeze window, such as '30m', '12h' or '365d'.",
+			Required: false,
+			Value:    services.FREEZE_DURATION,
+		},
+		&cli.StringSliceFlag{
+			Name:     "freezeServices",
+			Usage:    "The services the freeze window applies to. Defaults to every service.",
+			Required: false,
+		},
+		&cli.StringSliceFlag{
+			Name:     "freezeEnvironments",
+			Usage:    "The environments the freeze window applies to. Defaults to every environment.",
+			Required: false,
+		},
+	}
+}
+
+// clientFlags configure the retries and rate limit of the requests sent to Harness.
+// Every command gets its own flags because cli flags keep the parsed value.
+func clientFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.IntFlag{
+			Name:     "retries",
+			Usage:    "The number of times a request failing with a 429, a 5xx or a network error is retried.",
+			Required: false,
+			Value:    3,
+		},
+		&cli.DurationFlag{
+			Name:     "retryWaitTime",
+			Usage:    "The initial wait before retrying a request, doubled with jitter on every retry.",
+			Required: false,
+			Value:    time.Second,
+		},
+		&cli.DurationFlag{
+			Name:     "retryMaxWaitTime",
+			Usage:    "The maximum wait before retrying a request.",
+			Required: false,
+			Value:    30 * time.Second,
+		},
+		&cli.Float64Flag{
+			Name:     "requestsPerSecond",
+			Usage:    "The maximum number of requests per second sent to Harness by all projects together. '0' does not limit requests.",
+			Required: false,
+			Value:    0,
+		},
+	}
+}
+
+// endpointFlags configure the accounts of a command that reads from one account and
+// writes to another
+func endpointFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:     "apiToken",
+			Usage:    "The API token that will be used to authenticate with the Harness Account.",
+			Required: false,
+		},
+		&cli.StringFlag{
+			Name:     "accountId",
+			Usage:    "The account ID that contains the source and target organizations.",
+			Required: false,
+		},
+		&cli.StringFlag{
+			Name:     "baseUrl",
+			Usage:    "The URL of the harness instance that your projects reside in.",
+			Required: false,
+		},
+		&cli.StringFlag{
+			Name:     "sourceApiToken",
+			Usage:    "The API token of the account the projects are copied from. Defaults to '--apiToken'.",
+			Required: false,
+		},
+		&cli.StringFlag{
+			Name:     "sourceAccountId",
+			Usage:    "The account ID that contains the source organizations. Defaults to '--accountId'.",
+			Required: false,
+		},
+		&cli.StringFlag{
+			Name:     "sourceBaseUrl",
+			Usage:    "The URL of the harness instance the projects are copied from. Defaults to '--baseUrl'.",
+			Required: false,
+		},
+		&cli.StringFlag{
+			Name:     "targetApiToken",
+			Usage:    "The API token of the account the projects are copied to. Defaults to '--apiToken'.",
+			Required: false,
+		},
+		&cli.StringFlag{
+			Name:     "targetAccountId",
+			Usage:    "The account ID that contains the target organizations. Defaults to '--accountId'.",
+			Required: false,
+		},
+		&cli.StringFlag{
+			Name:     "targetBaseUrl",
+			Usage:    "The URL of the harness instance the projects are copied to. Defaults to '--baseUrl'.",
+			Required: false,
+		},
+	}
+}
+
+// accountFlags configure the single account a command works in. Unlike the accounts of
+// a copy they are required.
+func accountFlags(projects string) []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:     "apiToken",
+			Usage:    "The API token that will be used to authenticate with the Harness Account.",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "accountId",
+			Usage:    "The account ID that contains " + projects + ".",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "baseUrl",
+			Usage:    "The URL of the harness instance of " + projects + ".",
+			Required: true,
+		},
+	}
+}
+
+// logLevelFlag filters the logs printed once a project is done
+func logLevelFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:     "logLevel",
+		Usage:    "Defines the level of logs returned.  Valid responses are 'info', 'warn' and 'error'.",
+		Required: false,
+		Value:    "error",
+	}
+}
+
+// renameFlags configure how the entities of a command are renamed
+func renameFlags(entities string) []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:     "renameRules",
+			Usage:    "The path to a YAML file with the rules that rename the identifiers and names of the " + entities + " entities.",
+			Required: false,
+		},
+		&cli.StringFlag{
+			Name:     "referenceRemap",
+			Usage:    "The path to a YAML file that maps references to connectors, secrets and templates that are not copied to the references used in the target.",
+			Required: false,
+		},
+	}
+}
+
+// flags joins the flags of a command with the shared ones
+func flags(groups ...[]cli.Flag) []cli.Flag {
+	joined := []cli.Flag{}
+	for _, group := range groups {
+		joined = append(joined, group...)
+	}
+	return joined
+}
+
+// account reads the account of a command that only works in one account
+func account(c *cli.Context) operation.Endpoint {
+	return operation.Endpoint{
+		Token:   c.String("apiToken"),
+		Account: c.String("accountId"),
+		BaseURL: c.String("baseUrl"),
+	}
+}
+
+// endpoint reads the account of one side of the copy. The side specific flags default to
+// '--apiToken', '--accountId' and '--baseUrl'.
+func endpoint(c *cli.Context, side string) operation.Endpoint {
+	value := func(flag, fallback string) string {
+		if v := c.String(side + flag); v != "" {
+			return v
+		}
+		return c.String(fallback)
+	}
+
+	return operation.Endpoint{
+		Token:   value("ApiToken", "apiToken"),
+		Account: value("AccountId", "accountId"),
+		BaseURL: value("BaseUrl", "baseUrl"),
+	}
+}
+
+// freezeWindow reads the freeze window of a command
+func freezeWindow(c *cli.Context) model.FreezeWindow {
+	return model.FreezeWindow{
+		Name:         c.String("freezeName"),
+		Description:  c.String("freezeDescription"),
+		TimeZone:     c.String("freezeTimeZone"),
+		StartTime:    c.String("freezeStartTime"),
+		Duration:     c.String("freezeDuration"),
+		Services:     c.StringSlice("freezeServices"),
+		Environments: c.StringSlice("freezeEnvironments"),
+	}
+}
+
+// renamer reads the rename rules and the reference remap of a command
+func renamer(c *cli.Context) (*services.Renamer, error) {
+	importRenameRules := operation.ImportRenameRules{
+		Path: c.String("renameRules"),
+	}
+
+	rules, err := importRenameRules.Exec()
+	if err != nil {
+		globalLogger.Error("Failed to read rename rules",
+			zap.String("renameRules", c.String("renameRules")),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	importReferenceRemap := operation.ImportReferenceRemap{
+		Path: c.String("referenceRemap"),
+	}
+
+	remap, err := importReferenceRemap.Exec()
+	if err != nil {
+		globalLogger.Error("Failed to read reference remap",
+			zap.String("referenceRemap", c.String("referenceRemap")),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	rename, err := services.NewRenamer(rules, remap)
+	if err != nil {
+		globalLogger.Error("Invalid rename rules",
+			zap.String("renameRules", c.String("renameRules")),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	return rename, nil
+}
+
+// secretValues reads the values secrets are created with
+func secretValues(c *cli.Context) (map[string]string, error) {
+	importSecretValues := operation.ImportSecretValues{
+		Path: c.String("secretValues"),
+	}
+
+	values, err := importSecretValues.Exec()
+	if err != nil {
+		globalLogger.Error("Failed to read secret values",
+			zap.String("secretValues", c.String("secretValues")),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// projectsCsv reads the CSV file with the projects of a command
+func projectsCsv(c *cli.Context) (*operation.CSV, error) {
+	importCsv := operation.ImportCSV{
+		CsvPath: c.String("csvPath"),
+	}
+
+	csvData, err := importCsv.Exec()
+	if err != nil {
+		globalLogger.Error("Failed to pull CSV data",
+			zap.String("csvPath", c.String("csvPath")),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	return csvData, nil
+}
+
+// freezeCsv reads the CSV file of a command and the freeze window of every row. Every
+// freeze window is checked before any project is worked on.
+func freezeCsv(c *cli.Context) (*operation.CSV, error) {
+	csvData, err := projectsCsv(c)
+	if err != nil {
+		return nil, err
+	}
+
+	// ONLY THE COMMANDS WITH THE FREEZE WINDOW FLAGS SET THEM, THE OTHERS READ THEM AS EMPTY
+	window := freezeWindow(c)
+	for i := range csvData.Freeze {
+		csvData.Freeze[i] = operation.MergeFreezeWindow(window, csvData.Freeze[i])
+		if err := services.ValidateFreezeWindow(csvData.Freeze[i]); err != nil {
+			globalLogger.Error("Invalid freeze window",
+				zap.String("Source Project", csvData.SourceProject[i]),
+				zap.Error(err),
+			)
+			return nil, err
+		}
+	}
+
+	return csvData, nil
+}
+
+func clientConfig(c *cli.Context) services.ClientConfig {
+	return services.ClientConfig{
+		Retries:      c.Int("retries"),
+		RetryWait:    c.Duration("retryWaitTime"),
+		RetryMaxWait: c.Duration("retryMaxWaitTime"),
+		Limiter:      services.NewRateLimiter(c.Float64("requestsPerSecond")),
+	}
+}
+
+// endpoints reads the source and the target account of a command, every side must have
+// a token, an account and a URL
+func endpoints(c *cli.Context) (operation.Endpoint, operation.Endpoint, error) {
+	var missing []string
+	if c.String("csvPath") == "" {
+		missing = append(missing, "csvPath")
+	}
+	source := endpoint(c, "source")
+	target := endpoint(c, "target")
+	for _, side := range []struct {
+		name     string
+		endpoint operation.Endpoint
+	}{{"source", source}, {"target", target}} {
+		if side.endpoint.Token == "" {
+			missing = append(missing, "apiToken or "+side.name+"ApiToken")
+		}
+		if side.endpoint.Account == "" {
+			missing = append(missing, "accountId or "+side.name+"AccountId")
+		}
+		if side.endpoint.BaseURL == "" {
+			missing = append(missing, "baseUrl or "+side.name+"BaseUrl")
+		}
+	}
+	if len(missing) > 0 {
+		err := fmt.Errorf("required flags \"%v\" not set", strings.Join(missing, ", "))
+		globalLogger.Error("Missing required flags",
+			zap.Strings("flags", missing),
+			zap.Error(err),
+		)
+		return source, target, err
+	}
+
+	return source, target, nil
+}
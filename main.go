@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"fmt"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/urfave/cli/v2"
@@ -16,11 +18,11 @@ import (
 )
 
 var Version = "development"
-var errs []error
+var totals operation.Totals
 var globalLogger *zap.Logger
 var globalLogBuffer bytes.Buffer
-var loopLogger *zap.Logger
 var SummaryReport []model.ProjectSummary
+var PlanReport []model.ProjectPlan
 
 func main() {
 
@@ -31,7 +33,7 @@ func main() {
 	// Create a core that writes to the buffer
 	globalCore := zapcore.NewCore(
 		zapcore.NewJSONEncoder(globalConfig.EncoderConfig),
-		zapcore.AddSync(&globalLogBuffer),
+		zapcore.Lock(zapcore.AddSync(&globalLogBuffer)),
 		globalConfig.Level,
 	)
 
@@ -43,10 +45,10 @@ func main() {
 	defer globalLogger.Sync()
 	defer func() {
 		stopTime := time.Now()
-		apiCalls := services.GetApiCalls()
-		projects := services.GetProjects()
 		duration := stopTime.Sub(startTime)
 
+		apiCalls := totals.GetApiCalls()
+
 		var avgApiCallDuration time.Duration
 		if apiCalls > 0 {
 			avgApiCallDuration = duration / time.Duration(apiCalls)
@@ -56,9 +58,10 @@ func main() {
 
 		globalLogger.Info("Harness Copy Project has completed.",
 			zap.Int("Number of API Calls: ", apiCalls),
+			zap.Int("Number of Retries: ", totals.GetRetries()),
 			zap.String("Run Duration: ", duration.String()),
 			zap.Duration("Average API Call Duration: ", avgApiCallDuration),
-			zap.Int("Number of projects moved: ", projects),
+			zap.Int("Number of projects moved: ", totals.GetProjects()),
 			zap.String("Stop Time: ", stopTime.Format("12:00:00")),
 		)
 
@@ -74,26 +77,12 @@ func main() {
 		Version: Version,
 		Usage:   "Non-official Harness CLI to copy project between organizations",
 		Action:  run,
-		Flags: []cli.Flag{
+		// NOT REQUIRED HERE SO THE EXPORT AND IMPORT COMMANDS CAN RUN WITHOUT THEM, THEY ARE CHECKED IN run
+		Flags: flags([]cli.Flag{
 			&cli.StringFlag{
 				Name:     "csvPath",
 				Usage:    "The path to the CSV file that contains the source and target project information.",
-				Required: true,
-			},
-			&cli.StringFlag{
-				Name:     "apiToken",
-				Usage:    "The API token that will be used to authenticate with the Harness Account.",
-				Required: true,
-			},
-			&cli.StringFlag{
-				Name:     "accountId",
-				Usage:    "The account ID that contains both the source and target orgnaizations.",
-				Required: true,
-			},
-			&cli.StringFlag{
-				Name:     "baseUrl",
-				Usage:    "The URL of the harness instance that your projects reside in.",
-				Required: true,
+				Required: false,
 			},
 			&cli.BoolFlag{
 				Name:     "copyCDComponents",
@@ -107,34 +96,173 @@ func main() {
 				Required: false,
 				Value:    false,
 			},
+			&cli.BoolFlag{
+				Name:     "copyOrgDependencies",
+				Usage:    "If set to 'true', then the org connectors, secrets, templates and variables referenced by the project are copied to the target org.",
+				Required: false,
+				Value:    false,
+			},
 			&cli.BoolFlag{
 				Name:     "showProgressBar",
 				Usage:    "If set to 'true, then it will show the progress bar for items as they are copied.",
 				Required: false,
 				Value:    false,
 			},
+			logLevelFlag(),
 			&cli.StringFlag{
-				Name:     "logLevel",
-				Usage:    "Defines the level of logs returned.  Valid responses are 'info', 'warn' and 'error'.",
+				Name:     "secretValues",
+				Usage:    "The path to a YAML file that maps secret identifiers to the values used when copying secrets.",
 				Required: false,
-				Value:    "error",
 			},
-		},
+			&cli.BoolFlag{
+				Name:     "plan",
+				Usage:    "If set to 'true', then it will only report what would be copied without creating anything in the target.",
+				Required: false,
+				Value:    false,
+			},
+			&cli.StringFlag{
+				Name:     "planOutput",
+				Usage:    "The path to the JSON file the plan is written to when running with '--plan'.",
+				Required: false,
+				Value:    "plan.json",
+			},
+			&cli.BoolFlag{
+				Name:     "verify",
+				Usage:    "If set to 'true', then every target project is compared with its source project once it is copied. Only applied with '--sync' when set explicitly.",
+				Required: false,
+				Value:    true,
+			},
+			&cli.StringFlag{
+				Name:     "verifyOutput",
+				Usage:    "The path to the JSON file the differences found when verifying the target projects are written to.",
+				Required: false,
+				Value:    "verify.json",
+			},
+			&cli.IntFlag{
+				Name:     "parallelProjects",
+				Usage:    "The number of projects from the CSV file that are copied at the same time.",
+				Required: false,
+				Value:    1,
+			},
+			&cli.IntFlag{
+				Name:     "entityConcurrency",
+				Usage:    "The number of pipelines, input sets, templates and files that are copied at the same time within a project.",
+				Required: false,
+				Value:    1,
+			},
+			&cli.StringFlag{
+				Name:     "conflictStrategy",
+				Usage:    "What to do with an entity that already exists in the target. Valid values are 'skip', 'overwrite' and 'fail'.",
+				Required: false,
+				Value:    services.ConflictSkip,
+			},
+			&cli.StringFlag{
+				Name:     "checkpointFile",
+				Usage:    "The path to the file that records every entity created in the target.",
+				Required: false,
+				Value:    "checkpoint.json",
+			},
+			&cli.BoolFlag{
+				Name:     "resume",
+				Usage:    "If set to 'true', then entities recorded in the checkpoint file by a previous run are skipped.",
+				Required: false,
+				Value:    false,
+			},
+			&cli.StringFlag{
+				Name:     "journalFile",
+				Usage:    "The path to the file every entity created in the target is appended to, used by the rollback command.",
+				Required: false,
+				Value:    "journal.jsonl",
+			},
+			&cli.BoolFlag{
+				Name:     "freezeFirst",
+				Usage:    "If set to 'true', then the source project is frozen before it is copied. The freeze is lifted when the copy fails, unless the project was frozen before.",
+				Required: false,
+				Value:    false,
+			},
+			&cli.BoolFlag{
+				Name:     "sync",
+				Usage:    "If set to 'true', then only the entities created or modified since the previous run are copied, replacing the ones in the target.",
+				Required: false,
+				Value:    false,
+			},
+			&cli.StringFlag{
+				Name:     "syncFile",
+				Usage:    "The path to the file that records when the copy of each project last completed, used by '--sync'.",
+				Required: false,
+				Value:    "sync.json",
+			},
+			&cli.StringFlag{
+				Name:     "gitStore",
+				Usage:    "The path to a YAML file with the git repository inline pipelines, templates and input sets are written to, so they are created as remote entities.",
+				Required: false,
+			},
+		}, endpointFlags(), renameFlags("copied"), freezeFlags(), clientFlags()),
+		Commands: commands(),
 	}
 
-	// Run the CLI app
-	app.Run(os.Args)
+	// Run the CLI app, reporting the error a command stopped with
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
 }
 
 func run(c *cli.Context) error {
-	importCsv := operation.ImportCSV{
-		CsvPath: c.String("csvPath"),
+	source, target, err := endpoints(c)
+	if err != nil {
+		return err
 	}
 
-	csvData, err := importCsv.Exec()
+	csvData, err := freezeCsv(c)
 	if err != nil {
-		globalLogger.Error("Failed to pull CSV data",
-			zap.String("csvPath", c.String("csvPath")),
+		return err
+	}
+
+	secretValues, err := secretValues(c)
+	if err != nil {
+		return err
+	}
+
+	rename, err := renamer(c)
+	if err != nil {
+		return err
+	}
+
+	checkpoint, err := services.LoadCheckpoint(c.String("checkpointFile"), c.Bool("resume"))
+	if err != nil {
+		globalLogger.Error("Failed to load checkpoint",
+			zap.String("checkpointFile", c.String("checkpointFile")),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	syncState, err := services.LoadSyncState(c.String("syncFile"))
+	if err != nil {
+		globalLogger.Error("Failed to load sync state",
+			zap.String("syncFile", c.String("syncFile")),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	importGitStore := operation.ImportGitStore{
+		Path: c.String("gitStore"),
+	}
+
+	gitConfig, err := importGitStore.Exec()
+	if err != nil {
+		globalLogger.Error("Failed to read git store",
+			zap.String("gitStore", c.String("gitStore")),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	gitStore, err := services.NewGitStore(gitConfig)
+	if err != nil {
+		globalLogger.Error("Invalid git store",
+			zap.String("gitStore", c.String("gitStore")),
 			zap.Error(err),
 		)
 		return err
@@ -142,97 +270,354 @@ func run(c *cli.Context) error {
 
 	logLevel := strings.ToLower(c.String("logLevel"))
 
-	for i := 0; i < len(csvData.SourceOrg); i++ {
-		// Create a new log buffer for the project
-		var loopLogBuffer bytes.Buffer
-		var copyResult bool
+	conflictStrategy := strings.ToLower(c.String("conflictStrategy"))
+	if !services.ValidConflictStrategy(conflictStrategy) {
+		err := fmt.Errorf("invalid conflict strategy '%v'", c.String("conflictStrategy"))
+		globalLogger.Error("Invalid conflict strategy",
+			zap.String("conflictStrategy", c.String("conflictStrategy")),
+			zap.Error(err),
+		)
+		return err
+	}
 
-		// Initialize and configure the logger for the project
-		loopConfig := zap.NewProductionConfig()
-		loopConfig.Level = zap.NewAtomicLevelAt(zapcore.InfoLevel) // Set to Info, Debug, or Error for more verbose logging
+	parallelProjects := c.Int("parallelProjects")
 
-		loopCore := zapcore.NewCore(
-			zapcore.NewJSONEncoder(loopConfig.EncoderConfig),
-			zapcore.AddSync(&loopLogBuffer),
-			loopConfig.Level,
+	// Progress bars of projects copied at the same time would overwrite each other
+	showPB := c.Bool("showProgressBar")
+	if showPB && parallelProjects > 1 {
+		globalLogger.Warn("Progress bars are disabled when projects are copied in parallel",
+			zap.Int("parallelProjects", parallelProjects),
 		)
+		showPB = false
+	}
+
+	// A sync only copies what changed, listing both projects again is only done on request
+	verify := c.Bool("verify") && (!c.Bool("sync") || c.IsSet("verify"))
+
+	copyProjects := operation.CopyProjects{
+		Config: operation.Config{
+			Source:            source,
+			Target:            target,
+			CopyCD:            c.Bool("copyCDComponents"),
+			CopyFF:            c.Bool("copyFFComponents"),
+			CopyOrgDeps:       c.Bool("copyOrgDependencies"),
+			ShowPB:            showPB,
+			LogLevel:          logLevel,
+			Plan:              c.Bool("plan"),
+			SecretValues:      secretValues,
+			Checkpoint:        checkpoint,
+			Journal:           services.OpenJournal(c.String("journalFile")),
+			Rename:            rename,
+			GitStore:          gitStore,
+			FreezeFirst:       c.Bool("freezeFirst"),
+			SyncState:         syncState,
+			Sync:              c.Bool("sync"),
+			Verify:            verify,
+			EntityConcurrency: c.Int("entityConcurrency"),
+			Client:            clientConfig(c),
+			ConflictStrategy:  conflictStrategy,
+		},
+		CSV:      csvData,
+		Parallel: parallelProjects,
+		Totals:   &totals,
+	}
 
-		loopLogger = zap.New(loopCore)
+	SummaryReport, PlanReport = copyProjects.Exec()
 
-		// Increment the number of projects moved
-		services.IncrementProjects()
+	// Parse and filter error messages for the global operation
+	operation.ParseAndPrintGlobalLogs(globalLogBuffer.String(), logLevel)
 
-		// Create a new copy operation
-		cp := operation.Copy{
-			Config: operation.Config{
-				Token:    c.String("apiToken"),
-				Account:  c.String("accountId"),
-				BaseURL:  c.String("baseUrl"),
-				Logger:   loopLogger,
-				CopyCD:   c.Bool("copyCDComponents"),
-				CopyFF:   c.Bool("copyFFComponents"),
-				ShowPB:   c.Bool("showProgressBar"),
-				LogLevel: logLevel,
-			},
-			Source: operation.NoName{
-				Org:     csvData.SourceOrg[i],
-				Project: csvData.SourceProject[i],
-			},
-			Target: operation.NoName{
-				Org:     csvData.TargetOrg[i],
-				Project: csvData.TargetProject[i],
-			},
+	if c.Bool("plan") {
+		operation.PrintPlan(PlanReport)
+		if err := operation.WritePlanJSON(c.String("planOutput"), PlanReport); err != nil {
+			globalLogger.Error("Failed to write plan",
+				zap.String("planOutput", c.String("planOutput")),
+				zap.Error(err),
+			)
+			return err
 		}
+		fmt.Printf("Plan written to '%v' \n", c.String("planOutput"))
+		return nil
+	}
 
-		// Check for missing or empty values
-		if cp.Source.Org == "" || cp.Source.Project == "" || cp.Target.Org == "" {
-			loopLogger.Error("Invalid CSV data. Missing required fields.",
-				zap.String("Source Org", cp.Source.Org),
-				zap.String("Source Project", cp.Source.Project),
-				zap.String("Target Org", cp.Target.Org),
-			)
-			continue // Skip this iteration if required data is missing
+	operation.OperationSummary(SummaryReport)
+
+	if !verify {
+		return nil
+	}
+
+	verifications := []model.ProjectVerification{}
+	for _, summary := range SummaryReport {
+		if summary.Verification != nil {
+			verifications = append(verifications, *summary.Verification)
 		}
+	}
+	if err := operation.WriteVerifyJSON(c.String("verifyOutput"), verifications); err != nil {
+		globalLogger.Error("Failed to write verification",
+			zap.String("verifyOutput", c.String("verifyOutput")),
+			zap.Error(err),
+		)
+		return err
+	}
+	fmt.Printf("Verification written to '%v' \n", c.String("verifyOutput"))
 
-		// Use source project name if target project name is missing
-		if cp.Target.Project == "" {
-			cp.Target.Project = cp.Source.Project
+	return nil
+}
+
+// exportBundle writes a single project to a bundle directory
+func exportBundle(c *cli.Context) error {
+	// THE BUNDLE IS PLAIN FILES, SO SECRET VALUES ARE ONLY WRITTEN TO IT ON REQUEST
+	var values map[string]string
+	if c.Bool("includeSecretValues") {
+		var err error
+		if values, err = secretValues(c); err != nil {
+			return err
 		}
+	} else if c.String("secretValues") != "" {
+		fmt.Printf(operation.Yellow + "Secret values are not written to the bundle without 'includeSecretValues', every secret is exported with a placeholder value \n" + operation.Reset)
+		globalLogger.Warn("Secret values ignored without includeSecretValues",
+			zap.String("secretValues", c.String("secretValues")),
+		)
+	}
+
+	rename, err := renamer(c)
+	if err != nil {
+		return err
+	}
 
-		fmt.Printf("Moving project '%v' from org '%v' to org '%v'. The target project will be named '%v'\n", cp.Source.Project, cp.Source.Org, cp.Target.Org, cp.Target.Project)
+	return operation.ExportBundle{
+		Config: operation.Config{
+			Source:            account(c),
+			Logger:            globalLogger,
+			CopyCD:            c.Bool("copyCDComponents"),
+			CopyFF:            c.Bool("copyFFComponents"),
+			ShowPB:            c.Bool("showProgressBar"),
+			LogLevel:          strings.ToLower(c.String("logLevel")),
+			SecretValues:      values,
+			Rename:            rename,
+			EntityConcurrency: c.Int("entityConcurrency"),
+			Client:            clientConfig(c),
+		},
+		Source: operation.NoName{
+			Org:     c.String("org"),
+			Project: c.String("project"),
+		},
+		Dir:    c.String("bundle"),
+		Totals: &totals,
+	}.Exec()
+}
 
-		// Execute the copy operation from source to target operation
-		if err := cp.Exec(); err != nil {
-			loopLogger.Error("Failed to Copy Project",
-				zap.String("Source Project", cp.Source.Project),
-				zap.String("Target Project", cp.Target.Project),
-				zap.Error(err),
-			)
-			errs = append(errs, err)
-			continue
+// importBundle creates a project from a bundle directory
+func importBundle(c *cli.Context) error {
+	checkpoint, err := services.LoadCheckpoint(c.String("checkpointFile"), c.Bool("resume"))
+	if err != nil {
+		globalLogger.Error("Failed to load checkpoint",
+			zap.String("checkpointFile", c.String("checkpointFile")),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	// Entries of a bundle can only be created, the update calls need the live source
+	conflictStrategy := strings.ToLower(c.String("conflictStrategy"))
+	if conflictStrategy != services.ConflictSkip && conflictStrategy != services.ConflictFail {
+		err := fmt.Errorf("invalid conflict strategy '%v'", c.String("conflictStrategy"))
+		globalLogger.Error("Invalid conflict strategy",
+			zap.String("conflictStrategy", c.String("conflictStrategy")),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	return operation.ImportBundle{
+		Config: operation.Config{
+			Target:           account(c),
+			Logger:           globalLogger,
+			ShowPB:           c.Bool("showProgressBar"),
+			LogLevel:         strings.ToLower(c.String("logLevel")),
+			Checkpoint:       checkpoint,
+			Journal:          services.OpenJournal(c.String("journalFile")),
+			Client:           clientConfig(c),
+			ConflictStrategy: conflictStrategy,
+		},
+		Target: operation.NoName{
+			Org:     c.String("org"),
+			Project: c.String("project"),
+		},
+		Dir:    c.String("bundle"),
+		Totals: &totals,
+	}.Exec()
+}
+
+// rollback deletes the entities journaled as created in a single target project
+func rollback(c *cli.Context) error {
+	return operation.RollbackProject{
+		Config: operation.Config{
+			Target:   account(c),
+			Logger:   globalLogger,
+			LogLevel: strings.ToLower(c.String("logLevel")),
+			Client:   clientConfig(c),
+		},
+		Target: operation.NoName{
+			Org:     c.String("org"),
+			Project: c.String("project"),
+		},
+		JournalFile: c.String("journalFile"),
+		Totals:      &totals,
+	}.Exec()
+}
+
+// dependencyGraph writes the references between the entities of a single project
+func dependencyGraph(c *cli.Context) error {
+	var focus *services.GraphNode
+	if c.String("entity") != "" || c.String("identifier") != "" {
+		if c.String("entity") == "" || c.String("identifier") == "" {
+			err := fmt.Errorf("'--entity' and '--identifier' must be set together")
+			globalLogger.Error("Invalid graph focus", zap.Error(err))
+			return err
 		}
+		focus = &services.GraphNode{
+			Entity: c.String("entity"),
+			Key:    c.String("identifier"),
+		}
+	}
 
-		// Validate the copy operation
-		copyResult = operation.ValidateAndLogCopy(cp, loopLogger)
+	return operation.GraphProject{
+		Config: operation.Config{
+			Source:   account(c),
+			Logger:   globalLogger,
+			LogLevel: strings.ToLower(c.String("logLevel")),
+			Client:   clientConfig(c),
+		},
+		Source: operation.NoName{
+			Org:     c.String("org"),
+			Project: c.String("project"),
+		},
+		Focus:     focus,
+		OutputDir: c.String("outputDir"),
+		Formats:   c.StringSlice("format"),
+		Totals:    &totals,
+	}.Exec()
+}
 
-		loopLogger.Info(fmt.Sprintf("Project '%v' has been copied to org: '%v' \n", cp.Source.Project, cp.Target.Org))
+// freezeProjects freezes the source project of every row of the CSV file
+func freezeProjects(c *cli.Context) error {
+	csvData, err := freezeCsv(c)
+	if err != nil {
+		return err
+	}
 
-		// Reset the API call counter
-		services.ResetAllCounters()
+	return operation.FreezeProjects{
+		Config: freezeConfig(c),
+		CSV:    csvData,
+		Totals: &totals,
+	}.Exec()
+}
 
-		// Parse and filter error messages for the project
-		operation.ParseAndPrintProjectLogs(loopLogBuffer.String(), logLevel, cp.Source.Project)
+// unfreezeProjects disables or deletes the freeze window of the tool in the source
+// project of every row of the CSV file
+func unfreezeProjects(c *cli.Context) error {
+	csvData, err := freezeCsv(c)
+	if err != nil {
+		return err
+	}
 
-		// Create a summary report for the project
-		currentProjectSummary := operation.ProjectCopySummary(cp.Source.Project, cp.Target.Project, copyResult)
-		SummaryReport = append(SummaryReport, currentProjectSummary)
+	return operation.UnfreezeProjects{
+		Config: freezeConfig(c),
+		CSV:    csvData,
+		Delete: c.Bool("delete"),
+		Totals: &totals,
+	}.Exec()
+}
 
+// freezeStatus lists the freeze window of the tool in the source project of every row of
+// the CSV file
+func freezeStatus(c *cli.Context) error {
+	csvData, err := freezeCsv(c)
+	if err != nil {
+		return err
 	}
 
-	// Parse and filter error messages for the global operation
-	operation.ParseAndPrintGlobalLogs(globalLogBuffer.String(), logLevel)
+	return operation.ListFreezes{
+		Config: freezeConfig(c),
+		CSV:    csvData,
+		Totals: &totals,
+	}.Exec()
+}
 
-	operation.OperationSummary(SummaryReport)
+// freezeConfig reads the configuration shared by the freeze commands
+func freezeConfig(c *cli.Context) operation.Config {
+	return operation.Config{
+		Source:   account(c),
+		Logger:   globalLogger,
+		LogLevel: strings.ToLower(c.String("logLevel")),
+		Client:   clientConfig(c),
+	}
+}
 
-	return nil
+// mirrorProjects copies what changed in the source projects of the CSV file to their
+// target on an interval, until the number of cycles is reached or the command is
+// interrupted
+func mirrorProjects(c *cli.Context) error {
+	source, target, err := endpoints(c)
+	if err != nil {
+		return err
+	}
+
+	csvData, err := projectsCsv(c)
+	if err != nil {
+		return err
+	}
+
+	secretValues, err := secretValues(c)
+	if err != nil {
+		return err
+	}
+
+	rename, err := renamer(c)
+	if err != nil {
+		return err
+	}
+
+	syncState, err := services.LoadSyncState(c.String("syncFile"))
+	if err != nil {
+		globalLogger.Error("Failed to load sync state",
+			zap.String("syncFile", c.String("syncFile")),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	// AN INTERRUPT LETS THE PROJECT BEING MIRRORED FINISH, A SECOND ONE STOPS AT ONCE
+	ctx, stop := signal.NotifyContext(c.Context, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	return operation.MirrorProjects{
+		Config: operation.Config{
+			Source:            source,
+			Target:            target,
+			Logger:            globalLogger,
+			CopyCD:            c.Bool("copyCDComponents"),
+			CopyFF:            c.Bool("copyFFComponents"),
+			CopyOrgDeps:       c.Bool("copyOrgDependencies"),
+			LogLevel:          strings.ToLower(c.String("logLevel")),
+			SecretValues:      secretValues,
+			Journal:           services.OpenJournal(c.String("journalFile")),
+			Rename:            rename,
+			SyncState:         syncState,
+			Sync:              true,
+			EntityConcurrency: c.Int("entityConcurrency"),
+			Client:            clientConfig(c),
+		},
+		CSV:       csvData,
+		Interval:  c.Duration("interval"),
+		Cycles:    c.Int("cycles"),
+		Delete:    c.Bool("delete"),
+		ChangeLog: c.String("changeLog"),
+		Totals:    &totals,
+	}.Exec(ctx)
 }
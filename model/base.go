@@ -48,6 +48,7 @@ type LogEntry struct {
 	ResourceGroup    string `json:"resource group,omitempty"`
 	Role             string `json:"role,omitempty"`
 	RoleAssignments  string `json:"role assignments,omitempty"`
+	Secret           string `json:"secret,omitempty"`
 	Service          string `json:"service,omitempty"`
 	ServiceAccount   string `json:"service account,omitempty"`
 	ServiceOvericde  string `json:"service override,omitempty"`
@@ -65,5 +66,6 @@ type (
 		SourceProject string
 		TargetProject string
 		Successful    bool
+		Verification  *ProjectVerification
 	}
 )
@@ -0,0 +1,22 @@
+package model
+
+type BundleEntry struct {
+	Entity       string            `json:"entity"`
+	Identifier   string            `json:"identifier"`
+	Method       string            `json:"method"`
+	Path         string            `json:"path"`
+	ContentType  string            `json:"contentType"`
+	Params       map[string]string `json:"params,omitempty"`
+	Form         map[string]string `json:"form,omitempty"`
+	Body         string            `json:"body,omitempty"`
+	Content      string            `json:"content,omitempty"`
+	ContentField string            `json:"contentField,omitempty"`
+}
+
+type BundleManifest struct {
+	Version       int           `json:"version"`
+	ExportedAt    string        `json:"exportedAt"`
+	SourceOrg     string        `json:"sourceOrg"`
+	SourceProject string        `json:"sourceProject"`
+	Entries       []BundleEntry `json:"entries"`
+}
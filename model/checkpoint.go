@@ -0,0 +1,8 @@
+package model
+
+type CheckpointEntry struct {
+	Project    string `json:"project"`
+	Operation  string `json:"operation"`
+	Identifier string `json:"identifier"`
+	Time       int64  `json:"time"`
+}
@@ -0,0 +1,8 @@
+package model
+
+type ConflictEntry struct {
+	Entity     string `json:"entity"`
+	Identifier string `json:"identifier"`
+	Strategy   string `json:"strategy"`
+	Reason     string `json:"reason,omitempty"`
+}
@@ -17,7 +17,9 @@ type ListEnvironmentData struct {
 }
 
 type ListEnvironmentContent struct {
-	Environment Environment `json:"environment"`
+	Environment    Environment `json:"environment"`
+	CreatedAt      int64       `json:"createdAt"`
+	LastModifiedAt int64       `json:"lastModifiedAt"`
 }
 
 type Environment struct {
@@ -7,6 +7,7 @@ type FreezeRequest struct {
 type Freeze struct {
 	Name              string         `yaml:"name"`
 	Identifier        string         `yaml:"identifier"`
+	Description       string         `yaml:"description,omitempty"`
 	EntityConfigs     []EntityConfig `yaml:"entityConfigs"`
 	Status            string         `yaml:"status"`
 	OrgIdentifier     string         `yaml:"orgIdentifier"`
@@ -20,8 +21,9 @@ type EntityConfig struct {
 }
 
 type Entity struct {
-	Type       string `yaml:"type"`
-	FilterType string `yaml:"filterType"`
+	Type       string   `yaml:"type"`
+	FilterType string   `yaml:"filterType"`
+	EntityRefs []string `yaml:"entityRefs,omitempty"`
 }
 
 type Window struct {
@@ -30,6 +32,20 @@ type Window struct {
 	Duration  string `yaml:"duration"`
 }
 
+// FreezeWindow is how the source project is frozen once it is copied. Empty fields keep
+// the defaults of the tool.
+type FreezeWindow struct {
+	Name        string
+	Description string
+	TimeZone    string
+	StartTime   string // "2006-01-02 15:04" in the time zone, now when empty
+	Duration    string
+
+	// The services and environments frozen, all of them when empty
+	Services     []string
+	Environments []string
+}
+
 type FreezeResponse struct {
 	Status           string                  `json:"status"`
 	Code             string                  `json:"code,omitempty"`
@@ -67,3 +83,15 @@ type FreezeResponseMessage struct {
 	FailureTypes   []string               `json:"failureTypes"`
 	AdditionalInfo map[string]interface{} `json:"additionalInfo"`
 }
+
+// FreezeStatus is the freeze window of the tool in a project
+type FreezeStatus struct {
+	Org         string `json:"org"`
+	Project     string `json:"project"`
+	Status      string `json:"status"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	TimeZone    string `json:"timeZone,omitempty"`
+	StartTime   string `json:"startTime,omitempty"`
+	Duration    string `json:"duration,omitempty"`
+}
@@ -0,0 +1,50 @@
+package model
+
+// GitLocation is the file a remote entity is stored in
+type GitLocation struct {
+	ConnectorRef string `json:"connectorRef"`
+	RepoName     string `json:"repoName"`
+	Branch       string `json:"branch"`
+	FilePath     string `json:"filePath"`
+}
+
+type PipelineImportRequest struct {
+	PipelineName        string `json:"pipelineName"`
+	PipelineDescription string `json:"pipelineDescription,omitempty"`
+}
+
+type TemplateImportRequest struct {
+	TemplateVersion     string `json:"templateVersion"`
+	TemplateName        string `json:"templateName"`
+	TemplateDescription string `json:"templateDescription,omitempty"`
+}
+
+type InputSetImportRequest struct {
+	InputSetName        string `json:"inputSetName"`
+	InputSetDescription string `json:"inputSetDescription,omitempty"`
+}
+
+// GitStore is the repository inline entities are written to, so they are created as
+// remote entities in the target
+type GitStore struct {
+	// The local clone the files are written to, committed in and pushed from
+	WorkTree string `yaml:"workTree"`
+	Remote   string `yaml:"remote"`
+
+	// Where the target reads the files from
+	ConnectorRef string `yaml:"connectorRef"`
+	RepoName     string `yaml:"repoName"`
+	Branch       string `yaml:"branch"`
+
+	// The types of entities written to git, every supported type when empty
+	Entities []string  `yaml:"entities"`
+	Layout   GitLayout `yaml:"layout"`
+}
+
+// GitLayout is the path of the file of each type of entity. Paths can use the {org},
+// {project}, {identifier}, {pipeline} and {version} placeholders.
+type GitLayout struct {
+	Pipelines string `yaml:"pipelines"`
+	Templates string `yaml:"templates"`
+	InputSets string `yaml:"inputSets"`
+}
@@ -0,0 +1,27 @@
+package model
+
+type BlockedEntry struct {
+	Entity     string `json:"entity"`
+	Identifier string `json:"identifier"`
+	BlockedBy  string `json:"blockedBy"`
+}
+
+// DependencyGraph is the JSON export of the dependency graph of a project
+type DependencyGraph struct {
+	Org     string           `json:"org"`
+	Project string           `json:"project"`
+	Nodes   []DependencyNode `json:"nodes"`
+	Edges   []DependencyEdge `json:"edges"`
+}
+
+type DependencyNode struct {
+	ID         string `json:"id"`
+	Entity     string `json:"entity"`
+	Identifier string `json:"identifier"`
+}
+
+// DependencyEdge goes from an entity to an entity it references
+type DependencyEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
@@ -19,6 +19,8 @@ type InfraDefListData struct {
 type InfraDefListContent struct {
 	Infrastructure        Infrastructure        `json:"infrastructure"`
 	EntityValidityDetails EntityValidityDetails `json:"entityValidityDetails"`
+	CreatedAt             int64                 `json:"createdAt"`
+	LastModifiedAt        int64                 `json:"lastModifiedAt"`
 }
 
 type Infrastructure struct {
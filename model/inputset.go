@@ -20,6 +20,8 @@ type ListInputsetContent struct {
 	PipelineIdentifier    string                `json:"pipelineIdentifier"`
 	InputSetType          string                `json:"inputSetType"`
 	EntityValidityDetails EntityValidityDetails `json:"entityValidityDetails"`
+	CreatedAt             int64                 `json:"createdAt"`
+	LastUpdatedAt         int64                 `json:"lastUpdatedAt"`
 }
 
 type GetInputsetResponse struct {
@@ -29,12 +31,15 @@ type GetInputsetResponse struct {
 }
 
 type GetInputsetData struct {
-	Account            string `json:"accountId"`
-	OrgIdentifier      string `json:"orgIdentifier"`
-	ProjectIdentifier  string `json:"projectIdentifier"`
-	PipelineIdentifier string `json:"pipelineIdentifier"`
-	Identifier         string `json:"identifier"`
-	Yaml               string `json:"inputSetYaml"`
-	Name               string `json:"name"`
-	Outdated           bool   `json:"outdated"`
+	Account            string      `json:"accountId"`
+	OrgIdentifier      string      `json:"orgIdentifier"`
+	ProjectIdentifier  string      `json:"projectIdentifier"`
+	PipelineIdentifier string      `json:"pipelineIdentifier"`
+	Identifier         string      `json:"identifier"`
+	Yaml               string      `json:"inputSetYaml"`
+	Name               string      `json:"name"`
+	Outdated           bool        `json:"outdated"`
+	StoreType          StoreType   `json:"storeType"`
+	GitDetails         *GitDetails `json:"gitDetails,omitempty"`
+	ConnectorRef       *string     `json:"connectorRef,omitempty"`
 }
@@ -0,0 +1,21 @@
+package model
+
+type JournalEntry struct {
+	Action      string `json:"action"`
+	Entity      string `json:"entity"`
+	Identifier  string `json:"identifier"`
+	Account     string `json:"account"`
+	Org         string `json:"org"`
+	Project     string `json:"project,omitempty"`
+	Environment string `json:"environment,omitempty"`
+	Pipeline    string `json:"pipeline,omitempty"`
+	Version     string `json:"version,omitempty"`
+	Time        int64  `json:"time"`
+}
+
+type RollbackEntry struct {
+	Entity     string `json:"entity"`
+	Identifier string `json:"identifier"`
+	Status     string `json:"status"`
+	Reason     string `json:"reason,omitempty"`
+}
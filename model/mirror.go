@@ -0,0 +1,22 @@
+package model
+
+type MirrorChange struct {
+	Action     string `json:"action"`
+	Entity     string `json:"entity"`
+	Identifier string `json:"identifier"`
+	Error      string `json:"error,omitempty"`
+}
+
+type MirrorCycle struct {
+	Cycle         int            `json:"cycle"`
+	StartedAt     int64          `json:"startedAt"`
+	FinishedAt    int64          `json:"finishedAt"`
+	SourceOrg     string         `json:"sourceOrg"`
+	SourceProject string         `json:"sourceProject"`
+	TargetOrg     string         `json:"targetOrg"`
+	TargetProject string         `json:"targetProject"`
+	Synced        bool           `json:"synced"`
+	Unchanged     int            `json:"unchanged"`
+	Changes       []MirrorChange `json:"changes"`
+	Errors        []string       `json:"errors,omitempty"`
+}
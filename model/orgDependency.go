@@ -0,0 +1,8 @@
+package model
+
+type OrgDependencyEntry struct {
+	Entity     string `json:"entity"`
+	Identifier string `json:"identifier"`
+	Status     string `json:"status"`
+	Reason     string `json:"reason,omitempty"`
+}
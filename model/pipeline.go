@@ -41,6 +41,7 @@ type GitDetails struct {
 	FilePath string `json:"filePath"`
 	RepoName string `json:"repoName"`
 	RepoURL  string `json:"repoUrl"`
+	Branch   string `json:"branch"`
 }
 
 type Status string
@@ -59,4 +60,6 @@ type PipelineGetData struct {
 	YAMLPipeline          string                `json:"yamlPipeline"`
 	EntityValidityDetails EntityValidityDetails `json:"entityValidityDetails"`
 	StoreType             StoreType             `json:"storeType"`
+	GitDetails            *GitDetails           `json:"gitDetails,omitempty"`
+	ConnectorRef          *string               `json:"connectorRef,omitempty"`
 }
@@ -0,0 +1,17 @@
+package model
+
+type PlanEntry struct {
+	Entity     string `json:"entity"`
+	Identifier string `json:"identifier"`
+	Name       string `json:"name,omitempty"`
+	Action     string `json:"action"`
+	Reason     string `json:"reason,omitempty"`
+}
+
+type ProjectPlan struct {
+	SourceOrg     string      `json:"sourceOrg"`
+	SourceProject string      `json:"sourceProject"`
+	TargetOrg     string      `json:"targetOrg"`
+	TargetProject string      `json:"targetProject"`
+	Entries       []PlanEntry `json:"entries"`
+}
@@ -0,0 +1,32 @@
+package model
+
+type RenameRules struct {
+	Identifiers []RenameRule `yaml:"identifiers"`
+	Names       []RenameRule `yaml:"names"`
+}
+
+type RenameRule struct {
+	Entity  string `yaml:"entity,omitempty"`
+	From    string `yaml:"from,omitempty"`
+	Pattern string `yaml:"pattern,omitempty"`
+	To      string `yaml:"to"`
+}
+
+// ReferenceRemap maps references to entities that are not copied, such as the connectors,
+// secrets and templates of the org or the account, to the reference used in the target
+type ReferenceRemap struct {
+	Connectors map[string]string `yaml:"connectors"`
+	Secrets    map[string]string `yaml:"secrets"`
+	Templates  map[string]string `yaml:"templates"`
+
+	// The repositories and branches remote entities are imported from
+	Repositories map[string]string `yaml:"repositories"`
+	Branches     map[string]string `yaml:"branches"`
+}
+
+type RemapEntry struct {
+	Entity string `json:"entity"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Count  int    `json:"count"`
+}
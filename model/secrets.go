@@ -0,0 +1,48 @@
+package model
+
+type SecretListResponse struct {
+	Status        string         `json:"status"`
+	Data          SecretListData `json:"data"`
+	CorrelationID string         `json:"correlationId"`
+}
+
+type SecretListData struct {
+	TotalPages    int64            `json:"totalPages"`
+	TotalItems    int64            `json:"totalItems"`
+	PageItemCount int64            `json:"pageItemCount"`
+	PageSize      int64            `json:"pageSize"`
+	Content       []*SecretContent `json:"content"`
+	PageIndex     int64            `json:"pageIndex"`
+	Empty         bool             `json:"empty"`
+}
+
+type SecretContent struct {
+	Secret    Secret `json:"secret"`
+	CreatedAt int64  `json:"createdAt"`
+	UpdatedAt int64  `json:"updatedAt"`
+	Draft     bool   `json:"draft"`
+}
+
+type Secret struct {
+	Type              string                 `json:"type"`
+	Name              string                 `json:"name"`
+	Identifier        string                 `json:"identifier"`
+	OrgIdentifier     string                 `json:"orgIdentifier,omitempty"`
+	ProjectIdentifier string                 `json:"projectIdentifier,omitempty"`
+	Tags              map[string]string      `json:"tags"`
+	Description       string                 `json:"description"`
+	Spec              map[string]interface{} `json:"spec"`
+}
+
+type CreateSecretRequest struct {
+	Secret *Secret `json:"secret"`
+}
+
+type SecretType string
+
+const (
+	SecretText       SecretType = "SecretText"
+	SecretFile       SecretType = "SecretFile"
+	SSHKey           SecretType = "SSHKey"
+	WinRmCredentials SecretType = "WinRmCredentials"
+)
@@ -0,0 +1,7 @@
+package model
+
+// SyncFile records when the copy of each project last completed, in milliseconds
+type SyncFile struct {
+	UpdatedAt int64            `json:"updatedAt"`
+	Projects  map[string]int64 `json:"projects"`
+}
@@ -14,6 +14,8 @@ type TemplateListResultElement struct {
 	Scope          string  `json:"scope"`
 	StoreType      string  `json:"store_type"`
 	StableTemplate bool    `json:"stable_template"`
+	Created        int64   `json:"created"`
+	Updated        int64   `json:"updated"`
 }
 
 type TemplateGetResult struct {
@@ -23,13 +25,16 @@ type TemplateGetResult struct {
 }
 
 type TemplateGetData struct {
-	Account           string    `json:"accountId"`
-	OrgIdentifier     string    `json:"orgIdentifier"`
-	ProjectIdentifier string    `json:"projectIdentifier"`
-	Identifier        string    `json:"identifier"`
-	Yaml              string    `json:"yaml"`
-	VersionLabel      string    `json:"versionLabel"`
-	StoreType         StoreType `json:"storeType"`
+	Account           string      `json:"accountId"`
+	OrgIdentifier     string      `json:"orgIdentifier"`
+	ProjectIdentifier string      `json:"projectIdentifier"`
+	Identifier        string      `json:"identifier"`
+	Yaml              string      `json:"yaml"`
+	VersionLabel      string      `json:"versionLabel"`
+	Name              string      `json:"name"`
+	StoreType         StoreType   `json:"storeType"`
+	GitDetails        *GitDetails `json:"gitDetails,omitempty"`
+	ConnectorRef      *string     `json:"connectorRef,omitempty"`
 }
 
 type CreateTemplateRequest struct {
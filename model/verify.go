@@ -0,0 +1,18 @@
+package model
+
+type VerifyEntry struct {
+	Entity     string   `json:"entity"`
+	Identifier string   `json:"identifier"`
+	Status     string   `json:"status"`
+	Fields     []string `json:"fields,omitempty"`
+}
+
+type ProjectVerification struct {
+	SourceOrg     string        `json:"sourceOrg"`
+	SourceProject string        `json:"sourceProject"`
+	TargetOrg     string        `json:"targetOrg"`
+	TargetProject string        `json:"targetProject"`
+	Passed        bool          `json:"passed"`
+	Entries       []VerifyEntry `json:"entries"`
+	Errors        []string      `json:"errors,omitempty"`
+}
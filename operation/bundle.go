@@ -0,0 +1,201 @@
+package operation
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"harness-copy-project/services"
+
+	"go.uber.org/zap"
+)
+
+type (
+	// ExportBundle writes a single project of the source account to a bundle directory
+	ExportBundle struct {
+		Config Config
+		Source NoName
+		Dir    string
+		Totals *Totals
+	}
+
+	// ImportBundle creates a project of the target account from a bundle directory
+	ImportBundle struct {
+		Config Config
+		Target NoName
+		Dir    string
+		Totals *Totals
+	}
+)
+
+func (o ExportBundle) Exec() error {
+	var loopLogBuffer bytes.Buffer
+
+	config := o.Config
+	config.Logger = NewProjectLogger(&loopLogBuffer)
+	cp := Copy{
+		Config: config,
+		Source: o.Source,
+		Stats:  services.NewStats(),
+	}
+
+	fmt.Printf("Exporting project '%v' from org '%v' to '%v'\n", cp.Source.Project, cp.Source.Org, o.Dir)
+
+	err := cp.Export(o.Dir)
+	o.Totals.AddStats(cp.Stats)
+	ParseAndPrintProjectLogs(loopLogBuffer.String(), cp.Config.LogLevel, cp.Source.Project)
+	if err != nil {
+		o.Config.Logger.Error("Failed to export project",
+			zap.String("Project", cp.Source.Project),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	if !PrintExport(&cp, o.Dir) {
+		return fmt.Errorf("project '%v' was not completely exported", cp.Source.Project)
+	}
+
+	return nil
+}
+
+func (o ImportBundle) Exec() error {
+	var loopLogBuffer bytes.Buffer
+
+	config := o.Config
+	config.Logger = NewProjectLogger(&loopLogBuffer)
+	cp := Copy{
+		Config: config,
+		Target: o.Target,
+		Stats:  services.NewStats(),
+	}
+
+	err := cp.Import(o.Dir)
+	o.Totals.AddStats(cp.Stats)
+	ParseAndPrintProjectLogs(loopLogBuffer.String(), cp.Config.LogLevel, cp.Target.Project)
+	if err != nil {
+		o.Config.Logger.Error("Failed to import bundle",
+			zap.String("bundle", o.Dir),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	if !PrintImport(&cp) {
+		return fmt.Errorf("bundle '%v' was not completely imported", o.Dir)
+	}
+
+	return nil
+}
+
+// Export writes the source project to a bundle in dir. Every create call is written
+// to the bundle instead of being sent, so nothing is created in the account.
+func (o *Copy) Export(dir string) error {
+
+	if o.Stats == nil {
+		o.Stats = services.NewStats()
+	}
+
+	bundle, err := services.NewBundle(dir, o.Source.Org, o.Source.Project)
+	if err != nil {
+		return err
+	}
+
+	api := services.ApiRequest{
+		Client:      services.NewClient(o.Config.Client, o.Stats),
+		Token:       o.Config.Source.Token,
+		Account:     o.Config.Source.Account,
+		BaseURL:     o.Config.Source.BaseURL,
+		Stats:       o.Stats,
+		Concurrency: o.Config.EntityConcurrency,
+		Bundle:      bundle,
+		Rename:      o.Config.Rename.Project(o.Stats),
+	}
+
+	// THE BUNDLE KEEPS THE SOURCE ORG AND PROJECT, THEY ARE REPLACED WHEN IMPORTING IT
+	o.Target = o.Source
+
+	if err := api.ValidateProject(o.Source.Org, o.Source.Project, o.Config.Logger); err != nil {
+		return err
+	}
+
+	operations := []services.Operation{
+		services.NewProjectOperation(&api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.Logger),
+	}
+	api.Graph = o.dependencyGraph(&api)
+	operations = append(operations, o.entityOperations(&api)...)
+
+	for _, op := range operations {
+		if err := op.Copy(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Import replays a bundle written by Export into the target project
+func (o *Copy) Import(dir string) error {
+
+	if o.Stats == nil {
+		o.Stats = services.NewStats()
+	}
+
+	bundle, err := services.OpenBundle(dir)
+	if err != nil {
+		return err
+	}
+
+	o.Source = NoName{
+		Org:     bundle.Manifest.SourceOrg,
+		Project: bundle.Manifest.SourceProject,
+	}
+	if o.Target.Project == "" {
+		o.Target.Project = o.Source.Project
+	}
+
+	api := services.ApiRequest{
+		Client:      services.NewClient(o.Config.Client, o.Stats),
+		Token:       o.Config.Target.Token,
+		Account:     o.Config.Target.Account,
+		BaseURL:     o.Config.Target.BaseURL,
+		Stats:       o.Stats,
+		Concurrency: o.Config.EntityConcurrency,
+		Conflict:    o.Config.ConflictStrategy,
+		Checkpoint:  o.Config.Checkpoint.Project(o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project),
+		Journal:     o.Config.Journal,
+	}
+
+	return services.NewBundleImportOperation(&api, bundle, o.Target.Org, o.Target.Project, o.Config.Logger, o.Config.ShowPB).Copy()
+}
+
+// PrintExport reports the entities written to the bundle
+func PrintExport(cp *Copy, dir string) bool {
+	fmt.Printf("Project '%v' has been exported to '%v' \n", cp.Source.Project, dir)
+
+	success := services.ValidateCopy(confirmEntityCounts(cp))
+
+	if pending := cp.Stats.GetSecretsPendingValue(); len(pending) > 0 {
+		fmt.Printf(Yellow+"Secrets exported with a placeholder value: %v \n"+Reset, strings.Join(pending, ", "))
+	}
+
+	printRemaps(cp)
+
+	return success
+}
+
+// PrintImport reports the entries of the bundle that could not be imported
+func PrintImport(cp *Copy) bool {
+	fmt.Printf("Bundle of project '%v' has been imported to '%v' \n", cp.Source.Project, cp.Target.Project)
+
+	success := ConfirmSuccessfulCopy("Bundle entries", cp.Stats.GetBundleTotal(), cp.Stats.GetBundleMoved())
+
+	if conflicts := cp.Stats.GetConflicts(); len(conflicts) > 0 {
+		fmt.Printf(Yellow+"Entities that already existed in project '%v': \n"+Reset, cp.Target.Project)
+		for _, c := range conflicts {
+			fmt.Printf(Yellow+"  %v '%v': %v \n"+Reset, c.Entity, c.Identifier, c.Strategy)
+		}
+	}
+
+	return success
+}
@@ -0,0 +1,250 @@
+package operation
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"harness-copy-project/model"
+	"harness-copy-project/services"
+
+	"go.uber.org/zap"
+)
+
+type (
+	// FreezeProjects freezes the source project of every row of a CSV file
+	FreezeProjects struct {
+		Config Config
+		CSV    *CSV
+		Totals *Totals
+	}
+
+	// UnfreezeProjects disables or deletes the freeze window of the tool in the source
+	// project of every row of a CSV file
+	UnfreezeProjects struct {
+		Config Config
+		CSV    *CSV
+		Delete bool
+		Totals *Totals
+	}
+
+	// ListFreezes lists the freeze window of the tool in the source project of every row
+	// of a CSV file
+	ListFreezes struct {
+		Config Config
+		CSV    *CSV
+		Totals *Totals
+	}
+)
+
+func (o FreezeProjects) Exec() error {
+	var statuses []model.FreezeStatus
+	failed := 0
+	for i := range o.CSV.SourceOrg {
+		var loopLogBuffer bytes.Buffer
+		cp := freezeCopy(o.Config, o.CSV, i, &loopLogBuffer)
+
+		err := cp.Freeze()
+		status, _ := cp.FreezeStatus()
+		o.Totals.AddStats(cp.Stats)
+		ParseAndPrintProjectLogs(loopLogBuffer.String(), cp.Config.LogLevel, cp.Source.Project)
+		if err != nil {
+			o.Config.Logger.Error("Failed to Freeze Project",
+				zap.String("Source Project", cp.Source.Project),
+				zap.Error(err),
+			)
+			o.Totals.AddError(err)
+			failed++
+		}
+		statuses = append(statuses, status)
+	}
+
+	PrintFreezeStatus(statuses)
+
+	if failed > 0 {
+		return fmt.Errorf("%v projects were not frozen", failed)
+	}
+	return nil
+}
+
+func (o UnfreezeProjects) Exec() error {
+	var statuses []model.FreezeStatus
+	failed := 0
+	for i := range o.CSV.SourceOrg {
+		var loopLogBuffer bytes.Buffer
+		cp := freezeCopy(o.Config, o.CSV, i, &loopLogBuffer)
+
+		status, err := cp.Unfreeze(o.Delete)
+		o.Totals.AddStats(cp.Stats)
+		ParseAndPrintProjectLogs(loopLogBuffer.String(), cp.Config.LogLevel, cp.Source.Project)
+		if err != nil {
+			o.Config.Logger.Error("Failed to unfreeze project",
+				zap.String("Source Project", cp.Source.Project),
+				zap.Error(err),
+			)
+			o.Totals.AddError(err)
+			failed++
+		}
+		statuses = append(statuses, model.FreezeStatus{
+			Org:     cp.Source.Org,
+			Project: cp.Source.Project,
+			Status:  status,
+		})
+	}
+
+	PrintFreezeStatus(statuses)
+
+	if failed > 0 {
+		return fmt.Errorf("%v projects were not unfrozen", failed)
+	}
+	return nil
+}
+
+func (o ListFreezes) Exec() error {
+	var statuses []model.FreezeStatus
+	failed := 0
+	for i := range o.CSV.SourceOrg {
+		var loopLogBuffer bytes.Buffer
+		cp := freezeCopy(o.Config, o.CSV, i, &loopLogBuffer)
+
+		status, err := cp.FreezeStatus()
+		o.Totals.AddStats(cp.Stats)
+		ParseAndPrintProjectLogs(loopLogBuffer.String(), cp.Config.LogLevel, cp.Source.Project)
+		if err != nil {
+			o.Config.Logger.Error("Failed to fetch project freeze",
+				zap.String("Source Project", cp.Source.Project),
+				zap.Error(err),
+			)
+			o.Totals.AddError(err)
+			failed++
+			status.Status = "Unknown"
+		}
+		statuses = append(statuses, status)
+	}
+
+	PrintFreezeStatus(statuses)
+
+	if failed > 0 {
+		return fmt.Errorf("the freeze window of %v projects could not be fetched", failed)
+	}
+	return nil
+}
+
+// freezeCopy creates the operation of a freeze command for a row of the CSV file
+func freezeCopy(config Config, csv *CSV, i int, buffer *bytes.Buffer) Copy {
+	target := NoName{
+		Org:     csv.TargetOrg[i],
+		Project: csv.TargetProject[i],
+	}
+	if target.Project == "" {
+		target.Project = csv.SourceProject[i]
+	}
+
+	config.Logger = NewProjectLogger(buffer)
+	config.Freeze = csv.Freeze[i]
+
+	return Copy{
+		Config: config,
+		Source: NoName{
+			Org:     csv.SourceOrg[i],
+			Project: csv.SourceProject[i],
+		},
+		Target: target,
+		Stats:  services.NewStats(),
+	}
+}
+
+// Unfreeze disables the freeze window of the tool in the source project, or deletes it.
+// It returns the status the project is left with.
+func (o *Copy) Unfreeze(remove bool) (string, error) {
+
+	if o.Stats == nil {
+		o.Stats = services.NewStats()
+	}
+
+	// THE SOURCE PROJECT IS FROZEN IN THE SOURCE ACCOUNT
+	api := o.apiRequest().Source
+
+	return api.UnfreezeProject(o.Source.Org, o.Source.Project, remove, o.Config.Logger)
+}
+
+// LiftFreeze disables the freeze of a freeze-first copy that failed, so the source
+// project can be used again. A freeze that was already enabled before the copy is kept.
+func (o *Copy) LiftFreeze() {
+	o.printLiftFreeze(o.liftFreeze())
+}
+
+// liftFreeze disables the freeze this copy enabled, without reporting it
+func (o *Copy) liftFreeze() error {
+	if !o.enabledFreeze {
+		return nil
+	}
+	_, err := o.Unfreeze(false)
+	if err != nil {
+		o.Config.Logger.Error("Failed to lift the freeze of the source project",
+			zap.String("Source Project", o.Source.Project),
+			zap.Error(err),
+		)
+	}
+	return err
+}
+
+// printLiftFreeze outputs whether the freeze of a failed freeze-first copy was lifted
+func (o *Copy) printLiftFreeze(err error) {
+	if !o.enabledFreeze {
+		fmt.Printf(Yellow+"Source project: %v stays frozen, it was frozen before this copy. \n"+Reset, o.Source.Project)
+		return
+	}
+	if err != nil {
+		fmt.Printf(Red+"Source project: %v is still frozen, run the 'unfreeze' command to lift the freeze. Err: %v \n"+Reset, o.Source.Project, err)
+		return
+	}
+	fmt.Printf(Yellow+"Source project: %v has been unfrozen. \n"+Reset, o.Source.Project)
+}
+
+// FreezeStatus returns the freeze window of the tool in the source project
+func (o *Copy) FreezeStatus() (model.FreezeStatus, error) {
+
+	if o.Stats == nil {
+		o.Stats = services.NewStats()
+	}
+
+	api := o.apiRequest().Source
+
+	return api.ProjectFreezeStatus(o.Source.Org, o.Source.Project, o.Config.Logger)
+}
+
+// PrintFreezeStatus outputs the freeze window of the tool in every project
+func PrintFreezeStatus(statuses []model.FreezeStatus) {
+	maxProjectLen := len("Project")
+	maxStatusLen := len("Status")
+	for _, status := range statuses {
+		if len(status.Org+"/"+status.Project) > maxProjectLen {
+			maxProjectLen = len(status.Org + "/" + status.Project)
+		}
+		if len(status.Status) > maxStatusLen {
+			maxStatusLen = len(status.Status)
+		}
+	}
+
+	rowFmt := fmt.Sprintf("%%-%ds  %%-%ds  %%s\n", maxProjectLen, maxStatusLen)
+
+	fmt.Printf("\nFreeze windows:\n")
+	fmt.Printf(rowFmt, "Project", "Status", "Window")
+	fmt.Println(strings.Repeat("-", maxProjectLen+maxStatusLen+30))
+
+	for _, status := range statuses {
+		statusColor := Yellow
+		window := ""
+		switch status.Status {
+		case services.FREEZE_ENABLED:
+			statusColor = Green
+		case services.FREEZE_NONE:
+			statusColor = Reset
+		}
+		if status.StartTime != "" {
+			window = fmt.Sprintf("%v %v for %v", status.StartTime, status.TimeZone, status.Duration)
+		}
+		fmt.Printf(statusColor+rowFmt+Reset, status.Org+"/"+status.Project, status.Status, window)
+	}
+}
@@ -0,0 +1,34 @@
+package operation
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+	"harness-copy-project/model"
+)
+
+type ImportGitStore struct {
+	Path string
+}
+
+// Reads a YAML file with the git repository the inline entities are written to. Without
+// a file the entities are created inline.
+func (m ImportGitStore) Exec() (model.GitStore, error) {
+	store := model.GitStore{}
+
+	if m.Path == "" {
+		return store, nil
+	}
+
+	data, err := os.ReadFile(m.Path)
+	if err != nil {
+		return store, fmt.Errorf("error opening file: %v", err)
+	}
+
+	if err := yaml.Unmarshal(data, &store); err != nil {
+		return store, fmt.Errorf("error reading git store: %v", err)
+	}
+
+	return store, nil
+}
@@ -0,0 +1,128 @@
+package operation
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"harness-copy-project/services"
+
+	"go.uber.org/zap"
+)
+
+// GraphProject writes the references between the entities of a single project
+type GraphProject struct {
+	Config    Config
+	Source    NoName
+	Focus     *services.GraphNode
+	OutputDir string
+	Formats   []string
+	Totals    *Totals
+}
+
+func (o GraphProject) Exec() error {
+	var loopLogBuffer bytes.Buffer
+
+	// ONLY CD ENTITIES REFERENCE EACH OTHER
+	config := o.Config
+	config.Logger = NewProjectLogger(&loopLogBuffer)
+	config.CopyCD = true
+	cp := Copy{
+		Config: config,
+		Source: o.Source,
+		Stats:  services.NewStats(),
+	}
+
+	graph, err := cp.Graph(o.Focus)
+	o.Totals.AddStats(cp.Stats)
+	ParseAndPrintProjectLogs(loopLogBuffer.String(), cp.Config.LogLevel, cp.Source.Project)
+	if err != nil {
+		o.Config.Logger.Error("Failed to build dependency graph",
+			zap.String("Project", cp.Source.Project),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	files, err := cp.WriteGraph(graph, o.OutputDir, o.Formats)
+	if err != nil {
+		o.Config.Logger.Error("Failed to write dependency graph",
+			zap.String("outputDir", o.OutputDir),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	PrintGraph(cp.Source.Project, graph, files)
+
+	return nil
+}
+
+// Graph builds the dependency graph of the source project. With a focus entity only the
+// entities it references and the entities that reference it are kept.
+func (o *Copy) Graph(focus *services.GraphNode) (*services.Graph, error) {
+
+	if o.Stats == nil {
+		o.Stats = services.NewStats()
+	}
+
+	api := o.apiRequest().Source
+
+	if err := api.ValidateProject(o.Source.Org, o.Source.Project, o.Config.Logger); err != nil {
+		return nil, err
+	}
+
+	graph, err := api.BuildGraph(o.Source.Org, o.Source.Project, o.Config.CopyCD, o.Config.CopyFF, o.Config.Logger)
+	if err != nil {
+		return nil, err
+	}
+	if focus == nil {
+		return graph, nil
+	}
+	return graph.Closure(*focus)
+}
+
+// WriteGraph writes the graph to dir once per format, in files named after the project
+func (o *Copy) WriteGraph(graph *services.Graph, dir string, formats []string) ([]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	files := []string{}
+	for _, format := range formats {
+		extension, ok := services.GraphFormats[format]
+		if !ok {
+			return files, fmt.Errorf("unsupported graph format '%s'", format)
+		}
+
+		file := filepath.Join(dir, o.Source.Project+extension)
+		f, err := os.Create(file)
+		if err != nil {
+			return files, err
+		}
+		err = graph.WriteGraph(f, format, o.Source.Org, o.Source.Project)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return files, err
+		}
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
+// PrintGraph reports the size of the graph and the files it was written to
+func PrintGraph(project string, graph *services.Graph, files []string) {
+	edges := 0
+	for _, node := range graph.Nodes() {
+		edges += len(graph.Dependencies(node))
+	}
+
+	fmt.Printf("Dependency graph of project '%v': %d entities, %d references \n", project, len(graph.Nodes()), edges)
+	for _, file := range files {
+		fmt.Printf("  %v \n", file)
+	}
+}
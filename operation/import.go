@@ -4,6 +4,9 @@ import (
 	"encoding/csv"
 	"fmt"
 	"os"
+	"strings"
+
+	"harness-copy-project/model"
 )
 
 type ImportCSV struct {
@@ -15,6 +18,20 @@ type CSV struct {
 	SourceProject []string
 	TargetOrg     []string
 	TargetProject []string
+
+	// The freeze window of each row, set by the optional freeze columns
+	Freeze []model.FreezeWindow
+}
+
+// The optional columns that set the freeze window of a row, after the four project columns
+var freezeColumns = map[string]func(w *model.FreezeWindow, value string){
+	"freezeName":         func(w *model.FreezeWindow, value string) { w.Name = value },
+	"freezeDescription":  func(w *model.FreezeWindow, value string) { w.Description = value },
+	"freezeTimeZone":     func(w *model.FreezeWindow, value string) { w.TimeZone = value },
+	"freezeStartTime":    func(w *model.FreezeWindow, value string) { w.StartTime = value },
+	"freezeDuration":     func(w *model.FreezeWindow, value string) { w.Duration = value },
+	"freezeServices":     func(w *model.FreezeWindow, value string) { w.Services = splitList(value) },
+	"freezeEnvironments": func(w *model.FreezeWindow, value string) { w.Environments = splitList(value) },
 }
 
 func (m ImportCSV) Exec() (*CSV, error) {
@@ -40,6 +57,17 @@ func (m ImportCSV) Exec() (*CSV, error) {
 		TargetProject: []string{},
 	}
 
+	// The header names the optional columns
+	var optional []string
+	if len(records) > 0 && len(records[0]) > 4 {
+		optional = records[0][4:]
+		for _, column := range optional {
+			if _, ok := freezeColumns[strings.TrimSpace(column)]; !ok {
+				return nil, fmt.Errorf("invalid CSV format. Unknown column '%v'", column)
+			}
+		}
+	}
+
 	// Loops through each line of the CSV and adds to the parsedCSV struct
 	for i, row := range records {
 		if i == 0 {
@@ -47,10 +75,18 @@ func (m ImportCSV) Exec() (*CSV, error) {
 			// Skip header row
 		}
 
-		if len(row) != 4 {
-			return nil, fmt.Errorf("invalid CSV format. Expected 4 columns, but got %d", len(row))
+		if len(row) != 4+len(optional) {
+			return nil, fmt.Errorf("invalid CSV format. Expected %d columns, but got %d", 4+len(optional), len(row))
 		}
 
+		window := model.FreezeWindow{}
+		for j, column := range optional {
+			if value := strings.TrimSpace(row[4+j]); value != "" {
+				freezeColumns[strings.TrimSpace(column)](&window, value)
+			}
+		}
+		parsedCSV.Freeze = append(parsedCSV.Freeze, window)
+
 		parsedCSV.SourceOrg = append(parsedCSV.SourceOrg, row[0])
 		parsedCSV.SourceProject = append(parsedCSV.SourceProject, row[1])
 		parsedCSV.TargetOrg = append(parsedCSV.TargetOrg, row[2])
@@ -59,3 +95,36 @@ func (m ImportCSV) Exec() (*CSV, error) {
 
 	return parsedCSV, nil
 }
+
+// splitList splits a list of identifiers separated by spaces or semicolons
+func splitList(value string) []string {
+	return strings.FieldsFunc(value, func(r rune) bool {
+		return r == ';' || r == ' '
+	})
+}
+
+// MergeFreezeWindow sets the fields of the freeze window of a run that a CSV row sets
+func MergeFreezeWindow(run, row model.FreezeWindow) model.FreezeWindow {
+	if row.Name != "" {
+		run.Name = row.Name
+	}
+	if row.Description != "" {
+		run.Description = row.Description
+	}
+	if row.TimeZone != "" {
+		run.TimeZone = row.TimeZone
+	}
+	if row.StartTime != "" {
+		run.StartTime = row.StartTime
+	}
+	if row.Duration != "" {
+		run.Duration = row.Duration
+	}
+	if row.Services != nil {
+		run.Services = row.Services
+	}
+	if row.Environments != nil {
+		run.Environments = row.Environments
+	}
+	return run
+}
@@ -0,0 +1,223 @@
+package operation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"harness-copy-project/model"
+	"harness-copy-project/services"
+
+	"go.uber.org/zap"
+)
+
+// MirrorProjects copies what changed in the source projects of a CSV file to their
+// target on an interval, until the number of cycles is reached or the context is done.
+// Every cycle is a sync, so only the first one copies whole projects.
+type MirrorProjects struct {
+	Config    Config
+	CSV       *CSV
+	Interval  time.Duration
+	Cycles    int
+	Delete    bool
+	ChangeLog string
+	Totals    *Totals
+}
+
+// Exec lets the project being mirrored finish when ctx is done, then stops
+func (o MirrorProjects) Exec(ctx context.Context) error {
+	for cycle := 1; o.Cycles == 0 || cycle <= o.Cycles; cycle++ {
+		startedAt := time.Now()
+
+		for i := range o.CSV.SourceOrg {
+			if ctx.Err() != nil {
+				break
+			}
+			o.mirrorProject(cycle,
+				NoName{
+					Org:     o.CSV.SourceOrg[i],
+					Project: o.CSV.SourceProject[i],
+				},
+				NoName{
+					Org:     o.CSV.TargetOrg[i],
+					Project: o.CSV.TargetProject[i],
+				},
+			)
+		}
+
+		if cycle == o.Cycles {
+			break
+		}
+
+		// THE INTERVAL IS COUNTED FROM THE START OF THE CYCLE, A CYCLE THAT TOOK LONGER
+		// IS FOLLOWED BY THE NEXT ONE AT ONCE
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Until(startedAt.Add(o.Interval))):
+		}
+		if ctx.Err() != nil {
+			fmt.Printf("Mirror stopped after cycle %v \n", cycle)
+			break
+		}
+	}
+
+	fmt.Printf("Changes written to '%v' \n", o.ChangeLog)
+
+	return nil
+}
+
+// mirrorProject runs a cycle of a single CSV row and appends it to the change log
+func (o MirrorProjects) mirrorProject(cycle int, source, target NoName) {
+	var loopLogBuffer bytes.Buffer
+
+	if source.Org == "" || source.Project == "" || target.Org == "" {
+		o.Config.Logger.Error("Invalid CSV data. Missing required fields.",
+			zap.String("Source Org", source.Org),
+			zap.String("Source Project", source.Project),
+			zap.String("Target Org", target.Org),
+		)
+		return
+	}
+	if target.Project == "" {
+		target.Project = source.Project
+	}
+
+	config := o.Config
+	config.Logger = NewProjectLogger(&loopLogBuffer)
+	cp := Copy{
+		Config: config,
+		Source: source,
+		Target: target,
+		Stats:  services.NewStats(),
+	}
+
+	result := cp.Mirror(cycle, o.Delete)
+	o.Totals.AddStats(cp.Stats)
+
+	PrintMirrorCycle(result)
+	ParseAndPrintProjectLogs(loopLogBuffer.String(), cp.Config.LogLevel, cp.Source.Project)
+
+	if err := AppendMirrorLog(o.ChangeLog, result); err != nil {
+		o.Config.Logger.Error("Failed to write change log",
+			zap.String("changeLog", o.ChangeLog),
+			zap.Error(err),
+		)
+	}
+}
+
+// Mirror runs a single cycle of a mirror. The entities created or modified in the source
+// project since the previous cycle are copied to the target, replacing the ones that
+// already exist there, and with remove the entities the source no longer has are
+// deleted from the target. The source project is never frozen.
+func (o *Copy) Mirror(cycle int, remove bool) model.MirrorCycle {
+
+	if o.Stats == nil {
+		o.Stats = services.NewStats()
+	}
+
+	// A MIRROR IS A SYNC THAT RUNS OVER AND OVER
+	o.Config.Sync = true
+	o.Config.Plan = false
+
+	result := model.MirrorCycle{
+		Cycle:         cycle,
+		StartedAt:     time.Now().UnixMilli(),
+		SourceOrg:     o.Source.Org,
+		SourceProject: o.Source.Project,
+		TargetOrg:     o.Target.Org,
+		TargetProject: o.Target.Project,
+	}
+
+	if err := o.Exec(); err != nil {
+		result.Errors = append(result.Errors, err.Error())
+	}
+	result.Changes = services.MirrorChanges(o.Stats)
+	result.Unchanged = o.Stats.GetUnchanged()
+
+	for _, c := range entityCounts(o.Stats) {
+		if c.total != c.copied {
+			result.Errors = append(result.Errors, fmt.Sprintf("%v: %v of %v copied", c.entityType, c.copied, c.total))
+		}
+	}
+
+	// THE NEXT CYCLE ONLY STARTS FROM THIS ONE WHEN EVERY ENTITY MADE IT ACROSS, OTHERWISE
+	// THE ENTITIES THAT FAILED ARE COPIED AGAIN
+	if len(result.Errors) == 0 {
+		if err := o.CompleteSync(); err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("failed to update sync state: %v", err))
+		} else {
+			result.Synced = true
+		}
+	}
+
+	// NOTHING IS DELETED WHILE THE TARGET IS BEHIND THE SOURCE
+	if remove && result.Synced {
+		api := o.apiRequest()
+		deletes, errs := api.MirrorDeletes(o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.CopyCD, o.Config.CopyFF, o.Config.Logger)
+		result.Changes = append(result.Changes, deletes...)
+		result.Errors = append(result.Errors, errs...)
+	}
+
+	result.FinishedAt = time.Now().UnixMilli()
+
+	return result
+}
+
+// PrintMirrorCycle reports the changes a cycle made to the target project
+func PrintMirrorCycle(cycle model.MirrorCycle) {
+	counts := map[string]int{}
+	for _, c := range cycle.Changes {
+		if c.Error == "" {
+			counts[c.Action]++
+		}
+	}
+
+	fmt.Printf("Cycle %v of project '%v' -> '%v': %v created, %v updated, %v deleted, %v unchanged \n",
+		cycle.Cycle,
+		cycle.SourceProject,
+		cycle.TargetProject,
+		counts[services.MIRROR_CREATE],
+		counts[services.MIRROR_UPDATE],
+		counts[services.MIRROR_DELETE],
+		cycle.Unchanged,
+	)
+
+	for _, c := range cycle.Changes {
+		if c.Error != "" {
+			fmt.Printf(Red+"  %v %v '%v' failed: %v \n"+Reset, c.Action, c.Entity, c.Identifier, c.Error)
+		} else {
+			fmt.Printf("  %v %v '%v' \n", c.Action, c.Entity, c.Identifier)
+		}
+	}
+
+	for _, err := range cycle.Errors {
+		fmt.Printf(Red+"  %v \n"+Reset, err)
+	}
+}
+
+// AppendMirrorLog appends a cycle to the change log, one JSON line per project and cycle
+func AppendMirrorLog(path string, cycle model.MirrorCycle) error {
+	if path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(cycle)
+	if err != nil {
+		return fmt.Errorf("error encoding change log entry: %v", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("error opening change log: %v", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("error writing change log: %v", err)
+	}
+
+	return nil
+}
@@ -1,10 +1,12 @@
 package operation
 
 import (
-	// "fmt"
+	"strings"
+	"time"
+
+	"harness-copy-project/model"
 	"harness-copy-project/services"
 
-	"github.com/go-resty/resty/v2"
 	"go.uber.org/zap"
 )
 
@@ -16,15 +18,36 @@ const (
 )
 
 type (
+	// Endpoint is the Harness account a project is read from or created in
+	Endpoint struct {
+		Token   string
+		Account string
+		BaseURL string
+	}
+
 	Config struct {
-		Token    string
-		Account  string
-		BaseURL  string
-		Logger   *zap.Logger
-		CopyCD   bool
-		CopyFF   bool
-		ShowPB   bool
-		LogLevel string
+		Source            Endpoint
+		Target            Endpoint
+		Logger            *zap.Logger
+		CopyCD            bool
+		CopyFF            bool
+		CopyOrgDeps       bool
+		ShowPB            bool
+		LogLevel          string
+		Plan              bool
+		SecretValues      map[string]string
+		Checkpoint        *services.Checkpoint
+		Journal           *services.Journal
+		Rename            *services.Renamer
+		GitStore          *services.GitStore
+		EntityConcurrency int
+		Client            services.ClientConfig
+		ConflictStrategy  string
+		Freeze            model.FreezeWindow
+		FreezeFirst       bool
+		SyncState         *services.SyncState
+		Sync              bool
+		Verify            bool
 	}
 
 	// NOT SURE WHICH NAME TO CHOSE TO THAT TYPE
@@ -37,84 +60,283 @@ type (
 		Config Config
 		Source NoName
 		Target NoName
+		Plan   *services.Plan
+		Stats  *services.Stats
+
+		Verification *model.ProjectVerification
+
+		// When the copy started, recorded once it completes so a sync can start from it
+		startedAt time.Time
+
+		// Whether this copy enabled the freeze of the source project, so a failed
+		// freeze-first copy only lifts a freeze it enabled
+		enabledFreeze bool
+
+		// What Finish found, reported by ValidateAndLogCopy
+		syncErr   error
+		freezeErr error
 	}
 )
 
 func (o *Copy) Exec() error {
 
-	api := services.ApiRequest{
-		Client:  resty.New(),
-		Token:   o.Config.Token,
-		Account: o.Config.Account,
-		BaseURL: o.Config.BaseURL,
+	if o.Stats == nil {
+		o.Stats = services.NewStats()
+	}
+
+	o.startedAt = time.Now()
+
+	api := o.apiRequest()
+	api.Conflict = o.Config.ConflictStrategy
+
+	// A SYNC ONLY COPIES THE ENTITIES MODIFIED SINCE THE PREVIOUS RUN AND UPDATES THEM
+	if o.Config.Sync {
+		api.Sync = o.Config.SyncState.Project(o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project)
+		api.Conflict = services.ConflictOverwrite
+	}
+
+	// IN PLAN MODE NOTHING IS CREATED, EACH OPERATION ONLY RECORDS WHAT IT WOULD DO
+	if o.Config.Plan {
+		api.Plan = services.NewPlan()
+		o.Plan = api.Plan
+	} else {
+		api.Checkpoint = o.Config.Checkpoint.Project(o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project)
+		api.Journal = o.Config.Journal
 	}
 
 	var operations []services.Operation
 
 	// SOURCE PORJECT MUST EXIST.  RETURNS AN ERROR IF CAN'T BE FOUND/DOES NOT EXIST.
-	if err := api.ValidateProject(o.Source.Org, o.Source.Project, o.Config.Logger); err != nil {
+	if err := api.Source.ValidateProject(o.Source.Org, o.Source.Project, o.Config.Logger); err != nil {
 		return err
 	}
 	if err := api.ValidateProject(o.Target.Org, o.Target.Project, o.Config.Logger); err != nil {
 		// CREATE NEW PROJECT IF IT DOES NOT EXIST IN THE TARGET ORG
 		operations = append(operations, services.NewProjectOperation(&api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.Logger))
 		operations = append(operations, services.RemoveCurrentUserOperation(&api, o.Target.Org, o.Target.Project, o.Config.Logger))
+	} else if api.Plan != nil {
+		api.Plan.Record("Project", o.Target.Project, o.Target.Project, true, nil)
+	}
+
+	// THE ORG ENTITIES THE PROJECT REFERENCES MUST EXIST BEFORE ITS ENTITIES ARE CREATED
+	if o.Config.CopyOrgDeps && (o.Config.CopyCD || o.Config.CopyFF) {
+		operations = append(operations, services.NewOrgDependencyOperation(&api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.SecretValues, o.Config.CopyCD, o.Config.CopyFF, o.Config.Logger, o.Config.ShowPB))
 	}
 
+	api.Graph = o.dependencyGraph(api.Source)
+	operations = append(operations, o.entityOperations(&api)...)
+
+	for _, op := range operations {
+		if err := op.Copy(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// entityOperation is an operation and the type of the entities it copies
+type entityOperation struct {
+	entity string
+	op     services.Operation
+}
+
+// entityOperations lists the operations that copy the entities of the project, in the
+// order they must be created. The default order is kept unless the dependency graph of
+// the project needs another one.
+func (o *Copy) entityOperations(api *services.ApiRequest) []services.Operation {
+	var operations []entityOperation
+
 	if o.Config.CopyCD || o.Config.CopyFF {
-		operations = append(operations, services.NewConnectorOperation(&api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.Logger, o.Config.ShowPB))
-		operations = append(operations, services.NewEnvironmentOperation(&api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.Logger, o.Config.ShowPB))
-		operations = append(operations, services.NewEnvGroupOperation(&api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.Logger, o.Config.ShowPB))
+		operations = append(operations, entityOperation{"Secret", services.NewSecretOperation(api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.SecretValues, o.Config.Logger, o.Config.ShowPB)})
+		operations = append(operations, entityOperation{"Connector", services.NewConnectorOperation(api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.Logger, o.Config.ShowPB)})
+		operations = append(operations, entityOperation{"Secret", services.NewProjectManagerSecretOperation(api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.SecretValues, o.Config.Logger, o.Config.ShowPB)})
+		operations = append(operations, entityOperation{"Environment", services.NewEnvironmentOperation(api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.Logger, o.Config.ShowPB)})
+		operations = append(operations, entityOperation{"Environment Group", services.NewEnvGroupOperation(api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.Logger, o.Config.ShowPB)})
 	}
 
 	if o.Config.CopyCD {
-		operations = append(operations, services.NewVariableOperation(&api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.Logger, o.Config.ShowPB))
-		operations = append(operations, services.NewFileStoreOperation(&api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.Logger, o.Config.ShowPB))
-		operations = append(operations, services.NewInfrastructureOperation(&api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.Logger, o.Config.ShowPB))
-		operations = append(operations, services.NewServiceOperation(&api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.Logger, o.Config.ShowPB))
-		operations = append(operations, services.NewServiceOverrideOperation(&api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.Logger, o.Config.ShowPB))
-		operations = append(operations, services.NewTemplateOperation(&api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.Logger, o.Config.ShowPB))
-		operations = append(operations, services.NewPipelineOperation(&api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.Logger, o.Config.ShowPB))
-		operations = append(operations, services.NewInputsetOperation(&api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.Logger, o.Config.ShowPB))
-		// // operations = append(operations, services.NewSecretOperation(&api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project))
-		operations = append(operations, services.NewTagOperation(&api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.Logger, o.Config.ShowPB))
-		operations = append(operations, services.NewUserScopeOperation(&api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.Logger, o.Config.ShowPB))
-		operations = append(operations, services.NewUserGroupOperation(&api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.Logger, o.Config.ShowPB))
-		operations = append(operations, services.NewServiceAccountOperation(&api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.Logger, o.Config.ShowPB))
-		operations = append(operations, services.NewRoleOperation(&api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.Logger, o.Config.ShowPB))
-		operations = append(operations, services.NewResourceGroupOperation(&api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.Logger, o.Config.ShowPB))
-		operations = append(operations, services.NewRoleAssignmentOperation(&api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.Logger, o.Config.ShowPB))
-		operations = append(operations, services.NewTriggerOperation(&api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.Logger, o.Config.ShowPB))
+		operations = append(operations, entityOperation{"Variable", services.NewVariableOperation(api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.Logger, o.Config.ShowPB)})
+		operations = append(operations, entityOperation{"File Store", services.NewFileStoreOperation(api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.Logger, o.Config.ShowPB)})
+		operations = append(operations, entityOperation{"Infrastructure", services.NewInfrastructureOperation(api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.Logger, o.Config.ShowPB)})
+		operations = append(operations, entityOperation{"Service", services.NewServiceOperation(api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.Logger, o.Config.ShowPB)})
+		operations = append(operations, entityOperation{"Service Override", services.NewServiceOverrideOperation(api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.Logger, o.Config.ShowPB)})
+		operations = append(operations, entityOperation{"Template", services.NewTemplateOperation(api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.Logger, o.Config.ShowPB)})
+		operations = append(operations, entityOperation{"Pipeline", services.NewPipelineOperation(api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.Logger, o.Config.ShowPB)})
+		operations = append(operations, entityOperation{"Input Set", services.NewInputsetOperation(api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.Logger, o.Config.ShowPB)})
+		operations = append(operations, entityOperation{"Tag", services.NewTagOperation(api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.Logger, o.Config.ShowPB)})
+		operations = append(operations, entityOperation{"User", services.NewUserScopeOperation(api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.Logger, o.Config.ShowPB)})
+		operations = append(operations, entityOperation{"User Group", services.NewUserGroupOperation(api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.Logger, o.Config.ShowPB)})
+		operations = append(operations, entityOperation{"Service Account", services.NewServiceAccountOperation(api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.Logger, o.Config.ShowPB)})
+		operations = append(operations, entityOperation{"Role", services.NewRoleOperation(api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.Logger, o.Config.ShowPB)})
+		operations = append(operations, entityOperation{"Resource Group", services.NewResourceGroupOperation(api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.Logger, o.Config.ShowPB)})
+		operations = append(operations, entityOperation{"Role Assignment", services.NewRoleAssignmentOperation(api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.Logger, o.Config.ShowPB)})
+		operations = append(operations, entityOperation{"Trigger", services.NewTriggerOperation(api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.Logger, o.Config.ShowPB)})
 	}
 
 	if o.Config.CopyFF {
-		operations = append(operations, services.NewFeatureFlagOperation(&api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.Logger, o.Config.ShowPB))
-		operations = append(operations, services.NewTargets(&api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.Logger, o.Config.ShowPB))
-		operations = append(operations, services.NewTargetGroups(&api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.Logger, o.Config.ShowPB))
+		operations = append(operations, entityOperation{"Feature Flag", services.NewFeatureFlagOperation(api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.Logger, o.Config.ShowPB)})
+		operations = append(operations, entityOperation{"Target", services.NewTargets(api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.Logger, o.Config.ShowPB)})
+		operations = append(operations, entityOperation{"Target Group", services.NewTargetGroups(api, o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.Logger, o.Config.ShowPB)})
 	}
 
-	for _, op := range operations {
-		if err := op.Copy(); err != nil {
-			return err
+	entities := make([]string, len(operations))
+	for i, e := range operations {
+		entities[i] = e.entity
+	}
+
+	ordered := []services.Operation{}
+	for _, i := range api.Graph.Order(entities) {
+		ordered = append(ordered, operations[i].op)
+	}
+	return ordered
+}
+
+// dependencyGraph builds the dependency graph of the source project. Without it the
+// entities are copied in the default order.
+func (o *Copy) dependencyGraph(source *services.ApiRequest) *services.Graph {
+	if !o.Config.CopyCD && !o.Config.CopyFF {
+		return nil
+	}
+
+	// THE GRAPH FETCHES EVERY ENTITY OF THE PROJECT. A PLAN CREATES NOTHING TO ORDER, AND A
+	// SYNC ONLY COPIES THE ENTITIES THAT CHANGED TO A TARGET THAT ALREADY HAS THE OTHERS
+	if o.Config.Plan || o.Config.Sync {
+		return nil
+	}
+
+	graph, err := source.BuildGraph(o.Source.Org, o.Source.Project, o.Config.CopyCD, o.Config.CopyFF, o.Config.Logger)
+	if err != nil {
+		o.Config.Logger.Warn("Failed to build the dependency graph, copying in the default order",
+			zap.Error(err),
+		)
+		return nil
+	}
+	return graph
+}
+
+// Verify compares the target project with the source project once the copy is done
+func (o *Copy) Verify() model.ProjectVerification {
+
+	if o.Stats == nil {
+		o.Stats = services.NewStats()
+	}
+
+	api := o.apiRequest()
+
+	verification := api.Verify(o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project, o.Config.CopyCD, o.Config.CopyFF, o.Config.Logger)
+	o.Verification = &verification
+
+	return verification
+}
+
+// CompleteSync records that the source project was copied as it was when the copy
+// started, so the next sync only copies what changed since. It is not recorded while an
+// entity could not be replaced, so the next sync tries it again.
+func (o *Copy) CompleteSync() error {
+	if o.startedAt.IsZero() {
+		return nil
+	}
+	return o.Config.SyncState.Project(o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project).CompleteCopy(o.Stats, o.startedAt)
+}
+
+// Finish verifies a copy once it is done, records the sync state of a complete copy and
+// freezes the source project of a successful one. It returns whether the copy succeeded,
+// ValidateAndLogCopy reports the results.
+func (o *Copy) Finish() bool {
+	copied := true
+	for _, c := range entityCounts(o.Stats) {
+		if c.total != c.copied {
+			copied = false
 		}
 	}
 
-	return nil
+	// Compare what landed in the target with the source before freezing it
+	verified := true
+	if o.Config.Verify {
+		verified = o.Verify().Passed
+	}
+
+	// EVERY ENTITY MADE IT ACROSS, THE NEXT SYNC STARTS FROM THIS COPY
+	if copied {
+		if o.syncErr = o.CompleteSync(); o.syncErr != nil {
+			o.Config.Logger.Warn("Failed to update sync state", zap.Error(o.syncErr))
+		}
+	}
+
+	succeeded := copied && verified
+
+	// IN FREEZE-FIRST MODE THE SOURCE PROJECT IS ALREADY FROZEN
+	if succeeded && !o.Config.FreezeFirst {
+		if o.freezeErr = o.Freeze(); o.freezeErr != nil {
+			o.Config.Logger.Error("Failed to Freeze Project",
+				zap.String("Source Project", o.Source.Project),
+				zap.Error(o.freezeErr),
+			)
+			succeeded = false
+		}
+	}
+
+	return succeeded
+}
+
+// SyncedSince returns when the copy the sync started from began, zero without one
+func (o *Copy) SyncedSince() time.Time {
+	if !o.Config.Sync {
+		return time.Time{}
+	}
+	return o.Config.SyncState.Project(o.Source.Org, o.Source.Project, o.Target.Org, o.Target.Project).Since()
 }
 
 func (o *Copy) Freeze() error {
 
-	api := services.ApiRequest{
-		Client:  resty.New(),
-		Token:   o.Config.Token,
-		Account: o.Config.Account,
-		BaseURL: o.Config.BaseURL,
+	if o.Stats == nil {
+		o.Stats = services.NewStats()
+	}
+
+	// THE SOURCE PROJECT IS FROZEN IN THE SOURCE ACCOUNT
+	api := o.apiRequest().Source
+
+	window := o.Config.Freeze
+	if window.Description == "" {
+		window.Description = services.FREEZE_DESCRIPTION
 	}
+	window.Description = strings.NewReplacer(
+		"{targetOrg}", o.Target.Org,
+		"{targetProject}", o.Target.Project,
+	).Replace(window.Description)
 
-	freezeOperation := services.FreezeSourceProjectOperation(&api, o.Source.Org, o.Source.Project, o.Config.Logger)
-	if err := freezeOperation.Copy(); err != nil {
+	freezeOperation := services.FreezeSourceProjectOperation(api, o.Source.Org, o.Source.Project, window, o.Config.Logger)
+	wasEnabled, err := freezeOperation.Freeze()
+	if err != nil {
 		return err
 	}
+	o.enabledFreeze = !wasEnabled
 
 	return nil
 }
+
+// apiRequest creates the request to the target account, reading the source project from
+// the source account. Both share the client, so retries and the rate limit apply to both.
+func (o *Copy) apiRequest() services.ApiRequest {
+	client := services.NewClient(o.Config.Client, o.Stats)
+
+	return services.ApiRequest{
+		Client:      client,
+		Token:       o.Config.Target.Token,
+		Account:     o.Config.Target.Account,
+		BaseURL:     o.Config.Target.BaseURL,
+		Stats:       o.Stats,
+		Concurrency: o.Config.EntityConcurrency,
+		Rename:      o.Config.Rename.Project(o.Stats),
+		Git:         o.Config.GitStore,
+		Source: &services.ApiRequest{
+			Client:      client,
+			Token:       o.Config.Source.Token,
+			Account:     o.Config.Source.Account,
+			BaseURL:     o.Config.Source.BaseURL,
+			Stats:       o.Stats,
+			Concurrency: o.Config.EntityConcurrency,
+		},
+	}
+}
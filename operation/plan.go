@@ -0,0 +1,81 @@
+package operation
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"harness-copy-project/model"
+	"harness-copy-project/services"
+)
+
+// Function to create a plan report for each project
+func ProjectPlanReport(cp Copy) model.ProjectPlan {
+	var projectPlan model.ProjectPlan
+	projectPlan.SourceOrg = cp.Source.Org
+	projectPlan.SourceProject = cp.Source.Project
+	projectPlan.TargetOrg = cp.Target.Org
+	projectPlan.TargetProject = cp.Target.Project
+	projectPlan.Entries = []model.PlanEntry{}
+	if cp.Plan != nil {
+		projectPlan.Entries = cp.Plan.Entries
+	}
+	return projectPlan
+}
+
+// Function to print the plan of all projects in a human readable format
+func PrintPlan(plans []model.ProjectPlan) {
+	for _, plan := range plans {
+		maxEntityLen := len("Entity")
+		maxIdentifierLen := len("Identifier")
+		maxActionLen := len(services.PlanFailValidation)
+		for _, entry := range plan.Entries {
+			if len(entry.Entity) > maxEntityLen {
+				maxEntityLen = len(entry.Entity)
+			}
+			if len(entry.Identifier) > maxIdentifierLen {
+				maxIdentifierLen = len(entry.Identifier)
+			}
+		}
+
+		rowFmt := fmt.Sprintf("%%-%ds  %%-%ds  %%-%ds  %%s\n", maxEntityLen, maxIdentifierLen, maxActionLen)
+
+		fmt.Printf("\nPlan for moving project '%v' from org '%v' to project '%v' in org '%v':\n", plan.SourceProject, plan.SourceOrg, plan.TargetProject, plan.TargetOrg)
+		fmt.Printf(rowFmt, "Entity", "Identifier", "Action", "Reason")
+		fmt.Println(strings.Repeat("-", maxEntityLen+maxIdentifierLen+maxActionLen+14))
+
+		counts := map[string]int{}
+		for _, entry := range plan.Entries {
+			counts[entry.Action]++
+			actionColor := ""
+			switch entry.Action {
+			case services.PlanCreate:
+				actionColor = Green
+			case services.PlanAlreadyExists:
+				actionColor = Yellow
+			case services.PlanFailValidation:
+				actionColor = Red
+			}
+			fmt.Printf(actionColor+rowFmt+Reset, entry.Entity, entry.Identifier, entry.Action, entry.Reason)
+		}
+
+		fmt.Printf("%v to create, %v already exist, %v will fail validation\n",
+			counts[services.PlanCreate],
+			counts[services.PlanAlreadyExists],
+			counts[services.PlanFailValidation],
+		)
+	}
+}
+
+// Function to write the plan of all projects to a JSON file
+func WritePlanJSON(path string, plans []model.ProjectPlan) error {
+	data, err := json.MarshalIndent(plans, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding plan: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("error writing plan file: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package operation
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+
+	"harness-copy-project/model"
+	"harness-copy-project/services"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Keeps the output of the projects copied at the same time apart
+var outputMu sync.Mutex
+
+// CopyProjects copies every row of a CSV file, Parallel projects at a time
+type CopyProjects struct {
+	Config   Config
+	CSV      *CSV
+	Parallel int
+	Totals   *Totals
+
+	// Copies a single row, CopyProject unless a test replaces it
+	copyRow func(config Config, source, target NoName, totals *Totals) (*model.ProjectSummary, *model.ProjectPlan)
+}
+
+// Exec returns the summary of every project copied, or its plan in plan mode, in the
+// order of the CSV file
+func (o CopyProjects) Exec() ([]model.ProjectSummary, []model.ProjectPlan) {
+	copyRow := o.copyRow
+	if copyRow == nil {
+		copyRow = CopyProject
+	}
+
+	parallel := o.Parallel
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	// Results are stored by CSV row so the reports keep the order of the CSV file
+	summaries := make([]*model.ProjectSummary, len(o.CSV.SourceOrg))
+	plans := make([]*model.ProjectPlan, len(o.CSV.SourceOrg))
+
+	rows := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < parallel; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range rows {
+				rowConfig := o.Config
+				if i < len(o.CSV.Freeze) {
+					rowConfig.Freeze = o.CSV.Freeze[i]
+				}
+				summaries[i], plans[i] = copyRow(rowConfig,
+					NoName{
+						Org:     o.CSV.SourceOrg[i],
+						Project: o.CSV.SourceProject[i],
+					},
+					NoName{
+						Org:     o.CSV.TargetOrg[i],
+						Project: o.CSV.TargetProject[i],
+					},
+					o.Totals,
+				)
+			}
+		}()
+	}
+
+	for i := range o.CSV.SourceOrg {
+		rows <- i
+	}
+	close(rows)
+	wg.Wait()
+
+	summaryReport := []model.ProjectSummary{}
+	planReport := []model.ProjectPlan{}
+	for i := range summaries {
+		if summaries[i] != nil {
+			summaryReport = append(summaryReport, *summaries[i])
+		}
+		if plans[i] != nil {
+			planReport = append(planReport, *plans[i])
+		}
+	}
+
+	return summaryReport, planReport
+}
+
+// CopyProject copies a single CSV row. Every project has its own logger and Stats so
+// several projects can be copied at the same time. The report of a project is printed
+// in one block once the copy has finished.
+func CopyProject(config Config, source, target NoName, totals *Totals) (*model.ProjectSummary, *model.ProjectPlan) {
+	// Create a new log buffer for the project
+	var loopLogBuffer bytes.Buffer
+	var copyResult bool
+
+	loopLogger := NewProjectLogger(&loopLogBuffer)
+
+	// Increment the number of projects moved
+	totals.AddProject()
+
+	// Create a new copy operation
+	config.Logger = loopLogger
+	cp := Copy{
+		Config: config,
+		Source: source,
+		Target: target,
+		Stats:  services.NewStats(),
+	}
+
+	// Count the API calls and retries of the project once it is done
+	defer totals.AddStats(cp.Stats)
+
+	// Check for missing or empty values
+	if cp.Source.Org == "" || cp.Source.Project == "" || cp.Target.Org == "" {
+		loopLogger.Error("Invalid CSV data. Missing required fields.",
+			zap.String("Source Org", cp.Source.Org),
+			zap.String("Source Project", cp.Source.Project),
+			zap.String("Target Org", cp.Target.Org),
+		)
+		return nil, nil // Skip this row if required data is missing
+	}
+
+	// Use source project name if target project name is missing
+	if cp.Target.Project == "" {
+		cp.Target.Project = cp.Source.Project
+	}
+
+	fmt.Printf("Moving project '%v' from org '%v' to org '%v'. The target project will be named '%v'\n", cp.Source.Project, cp.Source.Org, cp.Target.Org, cp.Target.Project)
+
+	// In freeze-first mode nothing can change in the source project while it is copied
+	if cp.Config.FreezeFirst && !cp.Config.Plan {
+		if err := cp.Freeze(); err != nil {
+			loopLogger.Error("Failed to Freeze Project",
+				zap.String("Source Project", cp.Source.Project),
+				zap.Error(err),
+			)
+			fmt.Printf(Red+"Error encountered while freezing project: '%v', it is not copied.  Err: %v \n"+Reset, cp.Source.Project, err)
+			totals.AddError(err)
+			return nil, nil
+		}
+	}
+
+	// Execute the copy operation from source to target operation
+	if err := cp.Exec(); err != nil {
+		loopLogger.Error("Failed to Copy Project",
+			zap.String("Source Project", cp.Source.Project),
+			zap.String("Target Project", cp.Target.Project),
+			zap.Error(err),
+		)
+		totals.AddError(err)
+		if cp.Config.FreezeFirst && !cp.Config.Plan {
+			cp.LiftFreeze()
+		}
+		return nil, nil
+	}
+
+	// In plan mode nothing was copied, so there is nothing to validate or freeze
+	if cp.Config.Plan {
+		plan := ProjectPlanReport(cp)
+
+		outputMu.Lock()
+		defer outputMu.Unlock()
+
+		ParseAndPrintProjectLogs(loopLogBuffer.String(), cp.Config.LogLevel, cp.Source.Project)
+		return nil, &plan
+	}
+
+	// Verify, record and freeze the copy while other projects are copied, only the
+	// report waits for the output
+	copyResult = cp.Finish()
+	var liftErr error
+	if !copyResult && cp.Config.FreezeFirst {
+		liftErr = cp.liftFreeze()
+	}
+
+	loopLogger.Info(fmt.Sprintf("Project '%v' has been copied to org: '%v' \n", cp.Source.Project, cp.Target.Org))
+
+	// Keep the output of each project together
+	outputMu.Lock()
+	defer outputMu.Unlock()
+
+	// Validate the copy operation
+	ValidateAndLogCopy(&cp, loopLogger)
+	if !copyResult && cp.Config.FreezeFirst {
+		cp.printLiftFreeze(liftErr)
+	}
+
+	// Parse and filter error messages for the project
+	ParseAndPrintProjectLogs(loopLogBuffer.String(), cp.Config.LogLevel, cp.Source.Project)
+
+	// Create a summary report for the project
+	currentProjectSummary := ProjectCopySummary(cp.Source.Project, cp.Target.Project, copyResult)
+	currentProjectSummary.Verification = cp.Verification
+
+	return &currentProjectSummary, nil
+}
+
+// NewProjectLogger creates the logger of a single project, writing to its own buffer
+func NewProjectLogger(buffer *bytes.Buffer) *zap.Logger {
+	loopConfig := zap.NewProductionConfig()
+	loopConfig.Level = zap.NewAtomicLevelAt(zapcore.InfoLevel) // Set to Info, Debug, or Error for more verbose logging
+
+	loopCore := zapcore.NewCore(
+		zapcore.NewJSONEncoder(loopConfig.EncoderConfig),
+		zapcore.Lock(zapcore.AddSync(buffer)),
+		loopConfig.Level,
+	)
+
+	return zap.New(loopCore)
+}
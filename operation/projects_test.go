@@ -0,0 +1,147 @@
+package operation
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"harness-copy-project/model"
+	"harness-copy-project/services"
+)
+
+// csvOf builds a CSV of n rows, copying project pN of org src to org dst
+func csvOf(n int) *CSV {
+	csv := &CSV{}
+	for i := 0; i < n; i++ {
+		csv.SourceOrg = append(csv.SourceOrg, "src")
+		csv.SourceProject = append(csv.SourceProject, fmt.Sprintf("p%d", i))
+		csv.TargetOrg = append(csv.TargetOrg, "dst")
+		csv.TargetProject = append(csv.TargetProject, fmt.Sprintf("p%d", i))
+		csv.Freeze = append(csv.Freeze, model.FreezeWindow{Name: fmt.Sprintf("freeze p%d", i)})
+	}
+	return csv
+}
+
+func TestCopyProjects_ParallelKeepsCsvOrder(t *testing.T) {
+	total := 20
+	var running, most int32
+
+	var mu sync.Mutex
+	copied := map[string]int{}
+
+	o := CopyProjects{
+		Config:   Config{LogLevel: "info"},
+		CSV:      csvOf(total),
+		Parallel: 4,
+		Totals:   &Totals{},
+		copyRow: func(config Config, source, target NoName, totals *Totals) (*model.ProjectSummary, *model.ProjectPlan) {
+			totals.AddProject()
+
+			now := atomic.AddInt32(&running, 1)
+			defer atomic.AddInt32(&running, -1)
+			for {
+				seen := atomic.LoadInt32(&most)
+				if now <= seen || atomic.CompareAndSwapInt32(&most, seen, now) {
+					break
+				}
+			}
+
+			// EVERY ROW GETS ITS OWN FREEZE WINDOW, THE SHARED CONFIG IS NOT CHANGED
+			assert.Equal(t, "freeze "+source.Project, config.Freeze.Name)
+
+			mu.Lock()
+			copied[source.Project]++
+			mu.Unlock()
+
+			// THE LATER ROWS FINISH FIRST
+			var i int
+			fmt.Sscanf(source.Project, "p%d", &i)
+			time.Sleep(time.Duration(total-i) * time.Millisecond / 2)
+
+			// EVERY PROJECT COUNTS ITS OWN API CALLS
+			stats := services.NewStats()
+			for c := 0; c <= i; c++ {
+				stats.IncrementApiCalls()
+			}
+			stats.IncrementRetries()
+
+			totals.AddStats(stats)
+
+			if i%7 == 3 {
+				totals.AddError(errors.New("copy of " + source.Project + " failed"))
+				return nil, nil
+			}
+			summary := ProjectCopySummary(source.Project, target.Project, i%5 != 4)
+			return &summary, nil
+		},
+	}
+
+	summaries, plans := o.Exec()
+
+	for i := 0; i < total; i++ {
+		assert.Equal(t, 1, copied[fmt.Sprintf("p%d", i)], "p%d", i)
+	}
+	assert.LessOrEqual(t, most, int32(4))
+	assert.Greater(t, most, int32(1))
+
+	// THE SUMMARIES FOLLOW THE CSV FILE, NOT THE ORDER THE PROJECTS FINISHED IN
+	want := []model.ProjectSummary{}
+	for i := 0; i < total; i++ {
+		if i%7 != 3 {
+			want = append(want, ProjectCopySummary(fmt.Sprintf("p%d", i), fmt.Sprintf("p%d", i), i%5 != 4))
+		}
+	}
+	assert.Equal(t, want, summaries)
+	assert.Empty(t, plans)
+
+	// THE TOTALS ADD UP EVERY PROJECT, WHATEVER ORDER THEY FINISHED IN
+	assert.Equal(t, total, o.Totals.GetProjects())
+	assert.Equal(t, total*(total+1)/2, o.Totals.GetApiCalls())
+	assert.Equal(t, total, o.Totals.GetRetries())
+	assert.ElementsMatch(t, []error{
+		errors.New("copy of p3 failed"),
+		errors.New("copy of p10 failed"),
+		errors.New("copy of p17 failed"),
+	}, o.Totals.GetErrors())
+}
+
+func TestCopyProjects_Plans(t *testing.T) {
+	o := CopyProjects{
+		CSV:      csvOf(6),
+		Parallel: 3,
+		copyRow: func(config Config, source, target NoName, totals *Totals) (*model.ProjectSummary, *model.ProjectPlan) {
+			assert.True(t, config.Plan)
+			return nil, &model.ProjectPlan{SourceProject: source.Project, TargetProject: target.Project}
+		},
+	}
+	o.Config.Plan = true
+
+	summaries, plans := o.Exec()
+
+	assert.Empty(t, summaries)
+	assert.Len(t, plans, 6)
+	for i, plan := range plans {
+		assert.Equal(t, fmt.Sprintf("p%d", i), plan.SourceProject)
+	}
+}
+
+func TestCopyProject_MissingFieldsAreSkipped(t *testing.T) {
+	csv := csvOf(3)
+	csv.SourceOrg[0] = ""
+	csv.SourceProject[1] = ""
+	csv.TargetOrg[2] = ""
+
+	// NO ROW IS COMPLETE, SO NOTHING IS SENT TO HARNESS
+	totals := &Totals{}
+	summaries, plans := CopyProjects{Config: Config{LogLevel: "info"}, CSV: csv, Parallel: 2, Totals: totals}.Exec()
+
+	assert.Empty(t, summaries)
+	assert.Empty(t, plans)
+	assert.Equal(t, 3, totals.GetProjects())
+	assert.Empty(t, totals.GetErrors())
+	assert.Zero(t, totals.GetApiCalls())
+}
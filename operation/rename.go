@@ -0,0 +1,59 @@
+package operation
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+	"harness-copy-project/model"
+)
+
+type ImportRenameRules struct {
+	Path string
+}
+
+// Reads a YAML file with the rules that rename the identifiers and names of the
+// copied entities. Without a file nothing is renamed.
+func (m ImportRenameRules) Exec() (model.RenameRules, error) {
+	rules := model.RenameRules{}
+
+	if m.Path == "" {
+		return rules, nil
+	}
+
+	data, err := os.ReadFile(m.Path)
+	if err != nil {
+		return rules, fmt.Errorf("error opening file: %v", err)
+	}
+
+	if err := yaml.Unmarshal(data, &rules); err != nil {
+		return rules, fmt.Errorf("error reading rename rules: %v", err)
+	}
+
+	return rules, nil
+}
+
+type ImportReferenceRemap struct {
+	Path string
+}
+
+// Reads a YAML file mapping the references to connectors, secrets and templates
+// that are not copied to the references used in the target.
+func (m ImportReferenceRemap) Exec() (model.ReferenceRemap, error) {
+	remap := model.ReferenceRemap{}
+
+	if m.Path == "" {
+		return remap, nil
+	}
+
+	data, err := os.ReadFile(m.Path)
+	if err != nil {
+		return remap, fmt.Errorf("error opening file: %v", err)
+	}
+
+	if err := yaml.Unmarshal(data, &remap); err != nil {
+		return remap, fmt.Errorf("error reading reference remap: %v", err)
+	}
+
+	return remap, nil
+}
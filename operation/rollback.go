@@ -0,0 +1,120 @@
+package operation
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"harness-copy-project/model"
+	"harness-copy-project/services"
+
+	"go.uber.org/zap"
+)
+
+// RollbackProject deletes the entities journaled as created in a single target project
+type RollbackProject struct {
+	Config      Config
+	Target      NoName
+	JournalFile string
+	Totals      *Totals
+}
+
+func (o RollbackProject) Exec() error {
+	var loopLogBuffer bytes.Buffer
+
+	config := o.Config
+	config.Logger = NewProjectLogger(&loopLogBuffer)
+	config.Journal = services.OpenJournal(o.JournalFile)
+	cp := Copy{
+		Config: config,
+		Target: o.Target,
+		Stats:  services.NewStats(),
+	}
+
+	fmt.Printf("Rolling back project '%v' in org '%v' from '%v'\n", cp.Target.Project, cp.Target.Org, o.JournalFile)
+
+	entries, err := cp.Rollback()
+	o.Totals.AddStats(cp.Stats)
+	ParseAndPrintProjectLogs(loopLogBuffer.String(), cp.Config.LogLevel, cp.Target.Project)
+	if err != nil {
+		o.Config.Logger.Error("Failed to roll back project",
+			zap.String("Project", cp.Target.Project),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	if !PrintRollback(cp.Target.Project, entries) {
+		return fmt.Errorf("project '%v' was not completely rolled back", cp.Target.Project)
+	}
+
+	return nil
+}
+
+// Rollback deletes the entities the journal records as created in the target project
+func (o *Copy) Rollback() ([]model.RollbackEntry, error) {
+
+	if o.Stats == nil {
+		o.Stats = services.NewStats()
+	}
+
+	api := services.ApiRequest{
+		Client:  services.NewClient(o.Config.Client, o.Stats),
+		Token:   o.Config.Target.Token,
+		Account: o.Config.Target.Account,
+		BaseURL: o.Config.Target.BaseURL,
+		Stats:   o.Stats,
+		Journal: o.Config.Journal,
+	}
+
+	return api.Rollback(o.Target.Org, o.Target.Project, o.Config.Logger)
+}
+
+// PrintRollback reports every journaled entity of the project and if it was deleted
+func PrintRollback(project string, entries []model.RollbackEntry) bool {
+	if len(entries) == 0 {
+		fmt.Printf(Yellow+"Nothing to roll back, the journal has no entity created in project '%v' \n"+Reset, project)
+		return true
+	}
+
+	maxEntityLen := len("Entity")
+	maxIdentifierLen := len("Identifier")
+	maxStatusLen := len(services.RollbackUnsupported)
+	for _, entry := range entries {
+		if len(entry.Entity) > maxEntityLen {
+			maxEntityLen = len(entry.Entity)
+		}
+		if len(entry.Identifier) > maxIdentifierLen {
+			maxIdentifierLen = len(entry.Identifier)
+		}
+	}
+
+	rowFmt := fmt.Sprintf("%%-%ds  %%-%ds  %%-%ds  %%s\n", maxEntityLen, maxIdentifierLen, maxStatusLen)
+
+	fmt.Printf("\nRollback of project '%v':\n", project)
+	fmt.Printf(rowFmt, "Entity", "Identifier", "Status", "Reason")
+	fmt.Println(strings.Repeat("-", maxEntityLen+maxIdentifierLen+maxStatusLen+14))
+
+	success := true
+	counts := map[string]int{}
+	for _, entry := range entries {
+		counts[entry.Status]++
+		statusColor := Green
+		switch entry.Status {
+		case services.RollbackFailed:
+			statusColor = Red
+			success = false
+		case services.RollbackUnsupported:
+			statusColor = Yellow
+		}
+		fmt.Printf(statusColor+rowFmt+Reset, entry.Entity, entry.Identifier, entry.Status, entry.Reason)
+	}
+
+	fmt.Printf("%v deleted, %v failed, %v unsupported\n",
+		counts[services.RollbackDeleted],
+		counts[services.RollbackFailed],
+		counts[services.RollbackUnsupported],
+	)
+
+	return success
+}
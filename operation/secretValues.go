@@ -0,0 +1,34 @@
+package operation
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+type ImportSecretValues struct {
+	Path string
+}
+
+// Reads a YAML file mapping secret identifiers to their values. A key can be
+// scoped to a single source project with 'sourceProject/identifier'. For file
+// secrets the value is the path of the file to upload.
+func (m ImportSecretValues) Exec() (map[string]string, error) {
+	values := map[string]string{}
+
+	if m.Path == "" {
+		return values, nil
+	}
+
+	data, err := os.ReadFile(m.Path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening file: %v", err)
+	}
+
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("error reading secret values: %v", err)
+	}
+
+	return values, nil
+}
@@ -0,0 +1,79 @@
+package operation
+
+import (
+	"sync"
+
+	"harness-copy-project/services"
+)
+
+// Totals adds up the projects, API calls and errors of every project a command works
+// on, reported once the command completes. A nil Totals counts nothing.
+type Totals struct {
+	mu       sync.Mutex
+	projects int
+	apiCalls int
+	retries  int
+	errs     []error
+}
+
+// AddProject counts a project the command worked on
+func (t *Totals) AddProject() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.projects++
+}
+
+// AddStats counts the API calls and retries of a project once it is done
+func (t *Totals) AddStats(stats *services.Stats) {
+	if t == nil || stats == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.apiCalls += stats.GetApiCalls()
+	t.retries += stats.GetRetries()
+}
+
+// AddError records the error a project failed with
+func (t *Totals) AddError(err error) {
+	if t == nil || err == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.errs = append(t.errs, err)
+}
+
+func (t *Totals) GetProjects() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.projects
+}
+
+func (t *Totals) GetApiCalls() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.apiCalls
+}
+
+func (t *Totals) GetRetries() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.retries
+}
+
+func (t *Totals) GetErrors() []error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return append([]error{}, t.errs...)
+}
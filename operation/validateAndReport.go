@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"time"
 
 	"harness-copy-project/model"
 	"harness-copy-project/services"
@@ -176,100 +177,201 @@ func ProjectCopySummary(sourceProject, targetProject string, copyStatus bool) mo
 	return projectSummary
 }
 
-// Function to validate and log the copy operation
-func ValidateAndLogCopy(cp Copy, logger *zap.Logger) bool {
-	var projectErr []bool
-
+// ValidateAndLogCopy reports a copy once Finish has verified, recorded and frozen it
+func ValidateAndLogCopy(cp *Copy, logger *zap.Logger) bool {
 	// Output project copy complete message
 	fmt.Printf("Project '%v' has been copied to '%v' \n", cp.Source.Project, cp.Target.Project)
 
 	// Output project entity counts
-	projectErr = append(projectErr, ConfirmSuccessfulCopy("Connectors", services.GetConnectorsTotal(), services.GetConnectorsMoved()))
-	projectErr = append(projectErr, ConfirmSuccessfulCopy("Environments", services.GetEnvironmentsTotal(), services.GetEnvironmentsMoved()))
-	projectErr = append(projectErr, ConfirmSuccessfulCopy("Environment Groups", services.GetEnvironmentGroupsTotal(), services.GetEnvironmentGroupsMoved()))
-	projectErr = append(projectErr, ConfirmSuccessfulCopy("Feature Flags", services.GetFeatureFlagsTotal(), services.GetFeatureFlagsMoved()))
-	projectErr = append(projectErr, ConfirmSuccessfulCopy("File Stores", services.GetFileStoresTotal(), services.GetFileStoresMoved()))
-	projectErr = append(projectErr, ConfirmSuccessfulCopy("Infrastructure", services.GetInfrastructureTotal(), services.GetInfrastructureMoved()))
-	projectErr = append(projectErr, ConfirmSuccessfulCopy("Input sets", services.GetInputSetsTotal(), services.GetInputSetsMoved()))
-	projectErr = append(projectErr, ConfirmSuccessfulCopy("Pipelines", services.GetPipelinesTotal(), services.GetPipelinesMoved()))
-	projectErr = append(projectErr, ConfirmSuccessfulCopy("Resource Groups", services.GetResourceGroupsTotal(), services.GetResourceGroupsMoved()))
-	projectErr = append(projectErr, ConfirmSuccessfulCopy("Role Assignments", services.GetRoleAssignmentsTotal(), services.GetRoleAssignmentsMoved()))
-	projectErr = append(projectErr, ConfirmSuccessfulCopy("Roles", services.GetRolesTotal(), services.GetRolesMoved()))
-	projectErr = append(projectErr, ConfirmSuccessfulCopy("Service Overrides", services.GetOverridesTotal(), services.GetOverridesMoved()))
-	projectErr = append(projectErr, ConfirmSuccessfulCopy("Services", services.GetServicesTotal(), services.GetServicesMoved()))
-	projectErr = append(projectErr, ConfirmSuccessfulCopy("Tags", services.GetTagsTotal(), services.GetTagsMoved()))
-	projectErr = append(projectErr, ConfirmSuccessfulCopy("Target Groups", services.GetTargetGroupsTotal(), services.GetTargetGroupsMoved()))
-	projectErr = append(projectErr, ConfirmSuccessfulCopy("Targets", services.GetTargetsTotal(), services.GetTargetsMoved()))
-	projectErr = append(projectErr, ConfirmSuccessfulCopy("Templates", services.GetTemplatesTotal(), services.GetTemplatesMoved()))
-	projectErr = append(projectErr, ConfirmSuccessfulCopy("User Groups", services.GetUserGroupsTotal(), services.GetUserGroupsMoved()))
-	projectErr = append(projectErr, ConfirmSuccessfulCopy("Users", services.GetUsersTotal(), services.GetUsersMoved()))
-	projectErr = append(projectErr, ConfirmSuccessfulCopy("Variables", services.GetVariablesTotal(), services.GetVariablesMoved()))
-	projectErr = append(projectErr, ConfirmSuccessfulCopy("Triggers", services.GetTriggersTotal(), services.GetTriggersMoved()))
-
-	if services.ValidateCopy(projectErr) {
-		if err := cp.Freeze(); err != nil {
-			logger.Error("Failed to Freeze Project",
-				zap.String("Source Project", cp.Source.Project),
-				zap.Error(err),
-			)
-			fmt.Printf(Red+"Error encountered while freezing project: '%v'.  Err: %v \n"+Reset, cp.Source.Project, err)
-			return false
+	projectErr := confirmEntityCounts(cp)
+
+	// Output secrets that were created with a placeholder value
+	if pending := cp.Stats.GetSecretsPendingValue(); len(pending) > 0 {
+		fmt.Printf(Yellow+"Secrets that must be re-entered in project '%v': %v \n"+Reset, cp.Target.Project, strings.Join(pending, ", "))
+	}
+
+	// Output entities that already existed in the target and the strategy applied to them
+	if conflicts := cp.Stats.GetConflicts(); len(conflicts) > 0 {
+		fmt.Printf(Yellow+"Entities that already existed in project '%v': \n"+Reset, cp.Target.Project)
+		for _, c := range conflicts {
+			if c.Reason != "" {
+				fmt.Printf(Yellow+"  %v '%v': %v (%v) \n"+Reset, c.Entity, c.Identifier, c.Strategy, c.Reason)
+			} else {
+				fmt.Printf(Yellow+"  %v '%v': %v \n"+Reset, c.Entity, c.Identifier, c.Strategy)
+			}
 		}
-	} else {
+	}
+
+	// Output the org entities the project depends on
+	if deps := cp.Stats.GetOrgDependencies(); len(deps) > 0 {
+		fmt.Printf("Org dependencies pulled into org '%v': \n", cp.Target.Org)
+		for _, d := range deps {
+			statusColor := Reset
+			switch d.Status {
+			case services.OrgDependencyExisting:
+				statusColor = Yellow
+			case services.OrgDependencyMissing, services.OrgDependencyFailed:
+				statusColor = Red
+			}
+			if d.Reason != "" {
+				fmt.Printf(statusColor+"  %v '%v': %v (%v) \n"+Reset, d.Entity, d.Identifier, d.Status, d.Reason)
+			} else {
+				fmt.Printf(statusColor+"  %v '%v': %v \n"+Reset, d.Entity, d.Identifier, d.Status)
+			}
+		}
+	}
+
+	// Output entities skipped because an entity they depend on failed
+	if blocked := cp.Stats.GetBlocked(); len(blocked) > 0 {
+		fmt.Printf(Red + "Entities not copied because a dependency failed: \n" + Reset)
+		for _, b := range blocked {
+			fmt.Printf(Red+"  %v '%v': blocked by %v \n"+Reset, b.Entity, b.Identifier, b.BlockedBy)
+		}
+	}
+
+	// Output references rewritten with the reference remap
+	printRemaps(cp)
+
+	// Output the entities a sync left as they are
+	if unchanged := cp.Stats.GetUnchanged(); unchanged > 0 {
+		fmt.Printf("Entities not modified since %v: %v \n", cp.SyncedSince().Format(time.RFC3339), unchanged)
+	}
+
+	if cp.Verification != nil {
+		PrintVerification(*cp.Verification)
+	}
+
+	if cp.syncErr != nil {
+		fmt.Printf(Yellow+"The next sync of project '%v' copies it again: %v \n"+Reset, cp.Source.Project, cp.syncErr)
+	}
+
+	// IN FREEZE-FIRST MODE THE SOURCE PROJECT IS ALREADY FROZEN, THE CALLER LIFTS THE FREEZE
+	// WHEN THE COPY FAILED
+	if !services.ValidateCopy(projectErr) {
 		fmt.Printf(Red+"Error encountered while copying project: '%v'. \n"+Reset, cp.Target.Project)
-		fmt.Printf(Red+"Source project: %v has not be froozen. \n"+Reset, cp.Source.Project)
+		if !cp.Config.FreezeFirst {
+			fmt.Printf(Red+"Source project: %v has not be froozen. \n"+Reset, cp.Source.Project)
+		}
+		return false
+	}
+	if cp.Verification != nil && !cp.Verification.Passed {
+		fmt.Printf(Red+"Project '%v' does not match the source project. \n"+Reset, cp.Target.Project)
+		if !cp.Config.FreezeFirst {
+			fmt.Printf(Red+"Source project: %v has not be froozen. \n"+Reset, cp.Source.Project)
+		}
+		return false
+	}
+	if cp.Config.FreezeFirst {
+		fmt.Printf(Green+"Source project: %v stays frozen. \n"+Reset, cp.Source.Project)
+	} else if cp.freezeErr != nil {
+		fmt.Printf(Red+"Error encountered while freezing project: '%v'.  Err: %v \n"+Reset, cp.Source.Project, cp.freezeErr)
 		return false
 	}
 
 	// Output project entity counts to logger
 	logger.Info("Project Migration Status:",
-		zap.Int("ConnectorsTotal", services.GetConnectorsTotal()),
-		zap.Int("ConnectorsMoved", services.GetConnectorsMoved()),
-		zap.Int("EnvironmentsTotal", services.GetEnvironmentsTotal()),
-		zap.Int("EnvironmentsMoved", services.GetEnvironmentsMoved()),
-		zap.Int("EnvironmentGroupsTotal", services.GetEnvironmentGroupsTotal()),
-		zap.Int("EnvironmentGroupsMoved", services.GetEnvironmentGroupsMoved()),
-		zap.Int("FeatureFlagsTotal", services.GetFeatureFlagsTotal()),
-		zap.Int("FeatureFlagsMoved", services.GetFeatureFlagsMoved()),
-		zap.Int("FileStoresTotal", services.GetFileStoresTotal()),
-		zap.Int("FileStoresMoved", services.GetFileStoresMoved()),
-		zap.Int("InfrastructureTotal", services.GetInfrastructureTotal()),
-		zap.Int("InfrastructureMoved", services.GetInfrastructureMoved()),
-		zap.Int("InputSetsTotal", services.GetInputSetsTotal()),
-		zap.Int("InputSetsMoved", services.GetInputSetsMoved()),
-		zap.Int("PipelinesTotal", services.GetPipelinesTotal()),
-		zap.Int("PipelinesMoved", services.GetPipelinesMoved()),
-		zap.Int("ResourceGroupsTotal", services.GetResourceGroupsTotal()),
-		zap.Int("ResourceGroupsMoved", services.GetResourceGroupsMoved()),
-		zap.Int("RoleAssignmentsTotal", services.GetRoleAssignmentsTotal()),
-		zap.Int("RoleAssignmentsMoved", services.GetRoleAssignmentsMoved()),
-		zap.Int("RolesTotal", services.GetRolesTotal()),
-		zap.Int("RolesMoved", services.GetRolesMoved()),
-		zap.Int("OverridesTotal", services.GetOverridesTotal()),
-		zap.Int("OverridesMoved", services.GetOverridesMoved()),
-		zap.Int("ServicesTotal", services.GetServicesTotal()),
-		zap.Int("ServicesMoved", services.GetServicesMoved()),
-		zap.Int("TagsTotal", services.GetTagsTotal()),
-		zap.Int("TagsMoved", services.GetTagsMoved()),
-		zap.Int("TargetGroupsTotal", services.GetTargetGroupsTotal()),
-		zap.Int("TargetGroupsMoved", services.GetTargetGroupsMoved()),
-		zap.Int("TargetsTotal", services.GetTargetsTotal()),
-		zap.Int("TargetsMoved", services.GetTargetsMoved()),
-		zap.Int("TemplatesTotal", services.GetTemplatesTotal()),
-		zap.Int("TemplatesMoved", services.GetTemplatesMoved()),
-		zap.Int("TriggersTotal", services.GetTriggersTotal()),
-		zap.Int("TriggersMoved", services.GetTriggersMoved()),
-		zap.Int("UserGroupsTotal", services.GetUserGroupsTotal()),
-		zap.Int("UserGroupsMoved", services.GetUserGroupsMoved()),
-		zap.Int("UsersTotal", services.GetUsersTotal()),
-		zap.Int("UsersMoved", services.GetUsersMoved()),
-		zap.Int("VariablesTotal", services.GetVariablesTotal()),
-		zap.Int("VariablesMoved", services.GetVariablesMoved()),
+		zap.Int("ApiCalls", cp.Stats.GetApiCalls()),
+		zap.Int("Retries", cp.Stats.GetRetries()),
+		zap.Int("ConnectorsTotal", cp.Stats.GetConnectorsTotal()),
+		zap.Int("ConnectorsMoved", cp.Stats.GetConnectorsMoved()),
+		zap.Int("EnvironmentsTotal", cp.Stats.GetEnvironmentsTotal()),
+		zap.Int("EnvironmentsMoved", cp.Stats.GetEnvironmentsMoved()),
+		zap.Int("EnvironmentGroupsTotal", cp.Stats.GetEnvironmentGroupsTotal()),
+		zap.Int("EnvironmentGroupsMoved", cp.Stats.GetEnvironmentGroupsMoved()),
+		zap.Int("FeatureFlagsTotal", cp.Stats.GetFeatureFlagsTotal()),
+		zap.Int("FeatureFlagsMoved", cp.Stats.GetFeatureFlagsMoved()),
+		zap.Int("FileStoresTotal", cp.Stats.GetFileStoresTotal()),
+		zap.Int("FileStoresMoved", cp.Stats.GetFileStoresMoved()),
+		zap.Int("InfrastructureTotal", cp.Stats.GetInfrastructureTotal()),
+		zap.Int("InfrastructureMoved", cp.Stats.GetInfrastructureMoved()),
+		zap.Int("InputSetsTotal", cp.Stats.GetInputSetsTotal()),
+		zap.Int("InputSetsMoved", cp.Stats.GetInputSetsMoved()),
+		zap.Int("PipelinesTotal", cp.Stats.GetPipelinesTotal()),
+		zap.Int("PipelinesMoved", cp.Stats.GetPipelinesMoved()),
+		zap.Int("ResourceGroupsTotal", cp.Stats.GetResourceGroupsTotal()),
+		zap.Int("ResourceGroupsMoved", cp.Stats.GetResourceGroupsMoved()),
+		zap.Int("RoleAssignmentsTotal", cp.Stats.GetRoleAssignmentsTotal()),
+		zap.Int("RoleAssignmentsMoved", cp.Stats.GetRoleAssignmentsMoved()),
+		zap.Int("RolesTotal", cp.Stats.GetRolesTotal()),
+		zap.Int("RolesMoved", cp.Stats.GetRolesMoved()),
+		zap.Int("SecretsTotal", cp.Stats.GetSecretsTotal()),
+		zap.Int("SecretsMoved", cp.Stats.GetSecretsMoved()),
+		zap.Strings("SecretsPendingValue", cp.Stats.GetSecretsPendingValue()),
+		zap.Any("Conflicts", cp.Stats.GetConflicts()),
+		zap.Any("Remaps", cp.Stats.GetRemaps()),
+		zap.Any("OrgDependencies", cp.Stats.GetOrgDependencies()),
+		zap.Any("Blocked", cp.Stats.GetBlocked()),
+		zap.Int("OverridesTotal", cp.Stats.GetOverridesTotal()),
+		zap.Int("OverridesMoved", cp.Stats.GetOverridesMoved()),
+		zap.Int("ServicesTotal", cp.Stats.GetServicesTotal()),
+		zap.Int("ServicesMoved", cp.Stats.GetServicesMoved()),
+		zap.Int("TagsTotal", cp.Stats.GetTagsTotal()),
+		zap.Int("TagsMoved", cp.Stats.GetTagsMoved()),
+		zap.Int("TargetGroupsTotal", cp.Stats.GetTargetGroupsTotal()),
+		zap.Int("TargetGroupsMoved", cp.Stats.GetTargetGroupsMoved()),
+		zap.Int("TargetsTotal", cp.Stats.GetTargetsTotal()),
+		zap.Int("TargetsMoved", cp.Stats.GetTargetsMoved()),
+		zap.Int("TemplatesTotal", cp.Stats.GetTemplatesTotal()),
+		zap.Int("TemplatesMoved", cp.Stats.GetTemplatesMoved()),
+		zap.Int("TriggersTotal", cp.Stats.GetTriggersTotal()),
+		zap.Int("TriggersMoved", cp.Stats.GetTriggersMoved()),
+		zap.Int("UserGroupsTotal", cp.Stats.GetUserGroupsTotal()),
+		zap.Int("UserGroupsMoved", cp.Stats.GetUserGroupsMoved()),
+		zap.Int("UsersTotal", cp.Stats.GetUsersTotal()),
+		zap.Int("UsersMoved", cp.Stats.GetUsersMoved()),
+		zap.Int("VariablesTotal", cp.Stats.GetVariablesTotal()),
+		zap.Int("VariablesMoved", cp.Stats.GetVariablesMoved()),
 	)
 
 	return true
 }
 
+// entityCount is the number of entities of one type found in the source and copied
+type entityCount struct {
+	entityType string
+	total      int
+	copied     int
+}
+
+// entityCounts lists the entity counts of a copy in the order they are reported
+func entityCounts(stats *services.Stats) []entityCount {
+	return []entityCount{
+		{"Connectors", stats.GetConnectorsTotal(), stats.GetConnectorsMoved()},
+		{"Environments", stats.GetEnvironmentsTotal(), stats.GetEnvironmentsMoved()},
+		{"Environment Groups", stats.GetEnvironmentGroupsTotal(), stats.GetEnvironmentGroupsMoved()},
+		{"Feature Flags", stats.GetFeatureFlagsTotal(), stats.GetFeatureFlagsMoved()},
+		{"File Stores", stats.GetFileStoresTotal(), stats.GetFileStoresMoved()},
+		{"Infrastructure", stats.GetInfrastructureTotal(), stats.GetInfrastructureMoved()},
+		{"Input sets", stats.GetInputSetsTotal(), stats.GetInputSetsMoved()},
+		{"Pipelines", stats.GetPipelinesTotal(), stats.GetPipelinesMoved()},
+		{"Resource Groups", stats.GetResourceGroupsTotal(), stats.GetResourceGroupsMoved()},
+		{"Role Assignments", stats.GetRoleAssignmentsTotal(), stats.GetRoleAssignmentsMoved()},
+		{"Roles", stats.GetRolesTotal(), stats.GetRolesMoved()},
+		{"Secrets", stats.GetSecretsTotal(), stats.GetSecretsMoved()},
+		{"Service Overrides", stats.GetOverridesTotal(), stats.GetOverridesMoved()},
+		{"Services", stats.GetServicesTotal(), stats.GetServicesMoved()},
+		{"Tags", stats.GetTagsTotal(), stats.GetTagsMoved()},
+		{"Target Groups", stats.GetTargetGroupsTotal(), stats.GetTargetGroupsMoved()},
+		{"Targets", stats.GetTargetsTotal(), stats.GetTargetsMoved()},
+		{"Templates", stats.GetTemplatesTotal(), stats.GetTemplatesMoved()},
+		{"User Groups", stats.GetUserGroupsTotal(), stats.GetUserGroupsMoved()},
+		{"Users", stats.GetUsersTotal(), stats.GetUsersMoved()},
+		{"Variables", stats.GetVariablesTotal(), stats.GetVariablesMoved()},
+		{"Triggers", stats.GetTriggersTotal(), stats.GetTriggersMoved()},
+	}
+}
+
+// confirmEntityCounts outputs the number of entities found and copied for every entity type
+func confirmEntityCounts(cp *Copy) []bool {
+	var projectErr []bool
+
+	for _, c := range entityCounts(cp.Stats) {
+		projectErr = append(projectErr, ConfirmSuccessfulCopy(c.entityType, c.total, c.copied))
+	}
+
+	return projectErr
+}
+
 func ConfirmSuccessfulCopy(entityType string, total, copied int) bool {
 	var entityColor string
 	var success bool
@@ -289,3 +391,15 @@ func ConfirmSuccessfulCopy(entityType string, total, copied int) bool {
 
 	return success
 }
+
+// Function to print the references rewritten with the reference remap
+func printRemaps(cp *Copy) {
+	remaps := cp.Stats.GetRemaps()
+	if len(remaps) == 0 {
+		return
+	}
+	fmt.Printf("References remapped in project '%v': \n", cp.Target.Project)
+	for _, r := range remaps {
+		fmt.Printf("  %v '%v' -> '%v' (%d) \n", r.Entity, r.From, r.To, r.Count)
+	}
+}
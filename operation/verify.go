@@ -0,0 +1,69 @@
+package operation
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"harness-copy-project/model"
+	"harness-copy-project/services"
+)
+
+// Function to print the verification of a project in a human readable format
+func PrintVerification(verification model.ProjectVerification) {
+	if len(verification.Entries) == 0 && len(verification.Errors) == 0 {
+		fmt.Printf(Green+"Project '%v' matches the source project '%v' \n"+Reset, verification.TargetProject, verification.SourceProject)
+		return
+	}
+
+	maxEntityLen := len("Entity")
+	maxIdentifierLen := len("Identifier")
+	maxStatusLen := len(services.VerifyDivergent)
+	for _, entry := range verification.Entries {
+		if len(entry.Entity) > maxEntityLen {
+			maxEntityLen = len(entry.Entity)
+		}
+		if len(entry.Identifier) > maxIdentifierLen {
+			maxIdentifierLen = len(entry.Identifier)
+		}
+	}
+
+	rowFmt := fmt.Sprintf("%%-%ds  %%-%ds  %%-%ds  %%s\n", maxEntityLen, maxIdentifierLen, maxStatusLen)
+
+	fmt.Printf("\nDifferences between project '%v' and the source project '%v':\n", verification.TargetProject, verification.SourceProject)
+	fmt.Printf(rowFmt, "Entity", "Identifier", "Status", "Fields")
+	fmt.Println(strings.Repeat("-", maxEntityLen+maxIdentifierLen+maxStatusLen+14))
+
+	counts := map[string]int{}
+	for _, entry := range verification.Entries {
+		counts[entry.Status]++
+		statusColor := Red
+		if entry.Status == services.VerifyExtra {
+			statusColor = Yellow
+		}
+		fmt.Printf(statusColor+rowFmt+Reset, entry.Entity, entry.Identifier, entry.Status, strings.Join(entry.Fields, ", "))
+	}
+
+	for _, err := range verification.Errors {
+		fmt.Printf(Red+"Verification error: %v \n"+Reset, err)
+	}
+
+	fmt.Printf("%v missing, %v divergent, %v extra\n",
+		counts[services.VerifyMissing],
+		counts[services.VerifyDivergent],
+		counts[services.VerifyExtra],
+	)
+}
+
+// Function to write the verification of all projects to a JSON file
+func WriteVerifyJSON(path string, verifications []model.ProjectVerification) error {
+	data, err := json.MarshalIndent(verifications, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding verification: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("error writing verification file: %v", err)
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -13,10 +14,33 @@ import (
 // const BaseURL = "https://app.harness.io"
 
 type ApiRequest struct {
-	Client  *resty.Client
-	Token   string
-	Account string
-	BaseURL string
+	Client      *resty.Client
+	Token       string
+	Account     string
+	BaseURL     string
+	Plan        *Plan
+	Checkpoint  *ProjectCheckpoint
+	Stats       *Stats
+	Concurrency int
+	Conflict    string
+	Bundle      *Bundle
+	Journal     *Journal
+	Rename      *Renamer
+	Graph       *Graph
+	Git         *GitStore
+	Sync        *ProjectSync
+
+	// The account the project is copied from, when it is not the target account
+	Source *ApiRequest
+}
+
+// source returns the request used to read the source project. Without a separate source
+// account the source project is read with the target credentials.
+func (api *ApiRequest) source() *ApiRequest {
+	if api.Source == nil {
+		return api
+	}
+	return api.Source
 }
 
 type Operation interface {
@@ -85,14 +109,23 @@ func handleErrorResponse(resp *resty.Response) error {
 		return err
 	}
 	if result.Code == "DUPLICATE_FIELD" {
-		return nil
+		return ErrAlreadyExists
 	}
 	if strings.Contains(result.Message, "already exists") {
-		return nil
+		return ErrAlreadyExists
 	}
 	return fmt.Errorf("%s: %s", result.Code, removeNewLine(result.Message))
 }
 
+// ignoreAlreadyExists is used by the calls that are not part of an entity copy, where a
+// resource that already exists is not a conflict
+func ignoreAlreadyExists(err error) error {
+	if errors.Is(err, ErrAlreadyExists) {
+		return nil
+	}
+	return err
+}
+
 func removeNewLine(value string) string {
 	return strings.ReplaceAll(value, "\n", "")
 }
@@ -0,0 +1,440 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/schollz/progressbar/v3"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+	"harness-copy-project/model"
+)
+
+// The bundle format written by this version. Bundles with another version are rejected.
+const BUNDLE_VERSION = 1
+
+const BUNDLE_MANIFEST = "bundle.json"
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Bundle is a project exported to a local directory. When ApiRequest.Bundle is set,
+// every create call writes its request to the bundle instead of sending it, so the
+// bundle holds the requests that recreate the project and can be replayed into any
+// org and project.
+type Bundle struct {
+	mu       sync.Mutex
+	dir      string
+	Manifest model.BundleManifest
+}
+
+// NewBundle creates an empty bundle in dir for a project of the source org
+func NewBundle(dir, org, project string) (*Bundle, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("error creating bundle directory: %v", err)
+	}
+	return &Bundle{
+		dir: dir,
+		Manifest: model.BundleManifest{
+			Version:       BUNDLE_VERSION,
+			ExportedAt:    time.Now().UTC().Format(time.RFC3339),
+			SourceOrg:     org,
+			SourceProject: project,
+			Entries:       []model.BundleEntry{},
+		},
+	}, nil
+}
+
+// OpenBundle reads the manifest of a bundle written by an export
+func OpenBundle(dir string) (*Bundle, error) {
+	data, err := os.ReadFile(filepath.Join(dir, BUNDLE_MANIFEST))
+	if err != nil {
+		return nil, fmt.Errorf("error opening bundle: %v", err)
+	}
+
+	b := &Bundle{
+		dir: dir,
+	}
+	if err := json.Unmarshal(data, &b.Manifest); err != nil {
+		return nil, fmt.Errorf("error reading bundle manifest: %v", err)
+	}
+	if b.Manifest.Version != BUNDLE_VERSION {
+		return nil, fmt.Errorf("unsupported bundle version %d, expected %d", b.Manifest.Version, BUNDLE_VERSION)
+	}
+
+	return b, nil
+}
+
+// Add writes the request that creates an entity. A string body is stored as it is,
+// any other body is stored as JSON. The content of a multipart upload is stored in a
+// file of its own.
+func (b *Bundle) Add(entry model.BundleEntry, body interface{}, content []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if entry.Method == "" {
+		entry.Method = resty.MethodPost
+	}
+
+	dir := strings.ToLower(strings.ReplaceAll(entry.Entity, " ", "-"))
+	if err := os.MkdirAll(filepath.Join(b.dir, dir), 0700); err != nil {
+		return fmt.Errorf("error creating bundle directory: %v", err)
+	}
+	name := fmt.Sprintf("%04d-%s", len(b.Manifest.Entries), unsafeFileChars.ReplaceAllString(entry.Identifier, "_"))
+
+	if body != nil {
+		var data []byte
+		ext := ".json"
+		switch v := body.(type) {
+		case string:
+			data = []byte(v)
+			if strings.Contains(entry.ContentType, "yaml") || !json.Valid(data) {
+				ext = ".yaml"
+			}
+		default:
+			var err error
+			if data, err = json.MarshalIndent(v, "", "  "); err != nil {
+				return fmt.Errorf("error encoding %s %s: %v", entry.Entity, entry.Identifier, err)
+			}
+		}
+		entry.Body = filepath.Join(dir, name+ext)
+		if err := os.WriteFile(filepath.Join(b.dir, entry.Body), data, 0600); err != nil {
+			return fmt.Errorf("error writing bundle: %v", err)
+		}
+	}
+
+	if content != nil {
+		entry.Content = filepath.Join(dir, name+".content")
+		if err := os.WriteFile(filepath.Join(b.dir, entry.Content), content, 0600); err != nil {
+			return fmt.Errorf("error writing bundle: %v", err)
+		}
+	}
+
+	b.Manifest.Entries = append(b.Manifest.Entries, entry)
+
+	return b.save()
+}
+
+// save writes the manifest to a temporary file first so an interrupted export never
+// leaves a truncated manifest behind
+func (b *Bundle) save() error {
+	data, err := json.MarshalIndent(b.Manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding bundle manifest: %v", err)
+	}
+
+	file := filepath.Join(b.dir, BUNDLE_MANIFEST)
+	if err := os.WriteFile(file+".tmp", data, 0600); err != nil {
+		return fmt.Errorf("error writing bundle manifest: %v", err)
+	}
+	if err := os.Rename(file+".tmp", file); err != nil {
+		return fmt.Errorf("error writing bundle manifest: %v", err)
+	}
+
+	return nil
+}
+
+// yamlIdentifier reads the identifier of the entity described by a YAML document,
+// with the version label for templates
+func yamlIdentifier(input string) string {
+	doc := map[string]map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(input), &doc); err != nil {
+		return ""
+	}
+	for _, entity := range doc {
+		identifier := fmt.Sprintf("%v", entity["identifier"])
+		if version, ok := entity["versionLabel"]; ok {
+			identifier += "/" + fmt.Sprintf("%v", version)
+		}
+		return identifier
+	}
+	return ""
+}
+
+type BundleImportContext struct {
+	api           *ApiRequest
+	bundle        *Bundle
+	targetOrg     string
+	targetProject string
+	logger        *zap.Logger
+	showPB        bool
+}
+
+func NewBundleImportOperation(api *ApiRequest, bundle *Bundle, targetOrg, targetProject string, logger *zap.Logger, showPB bool) BundleImportContext {
+	return BundleImportContext{
+		api:           api,
+		bundle:        bundle,
+		targetOrg:     targetOrg,
+		targetProject: targetProject,
+		logger:        logger,
+		showPB:        showPB,
+	}
+}
+
+// Copy replays the requests of the bundle in the order they were exported, with the
+// org and project of the bundle replaced by the target ones. The project itself is only
+// created when it does not exist in the target org.
+func (c BundleImportContext) Copy() error {
+
+	c.logger.Info("Importing bundle",
+		zap.String("sourceProject", c.bundle.Manifest.SourceProject),
+		zap.String("targetProject", c.targetProject),
+	)
+
+	var bar *progressbar.ProgressBar
+
+	if c.showPB {
+		bar = progressbar.Default(int64(len(c.bundle.Manifest.Entries)), "Bundle    ")
+	}
+
+	for _, entry := range c.bundle.Manifest.Entries {
+
+		c.api.Stats.IncrementBundleTotal()
+
+		if c.api.Checkpoint.Done(entry.Entity, entry.Identifier) {
+			c.logger.Info("Skipping entity imported by a previous run",
+				zap.String("entity", entry.Entity),
+				zap.String("identifier", entry.Identifier),
+			)
+			c.api.Stats.IncrementBundleMoved()
+			if c.showPB {
+				bar.Add(1)
+			}
+			continue
+		}
+
+		var err error
+		if entry.Entity == "Project" {
+			err = c.importProject(entry)
+		} else {
+			err = c.api.importEntry(c.bundle, entry, c.targetOrg, c.targetProject, c.logger)
+			err = c.api.resolveConflict(entry.Entity, entry.Identifier, err, nil, c.logger)
+		}
+
+		if err != nil {
+			c.logger.Error("Failed to import entity",
+				zap.String("entity", entry.Entity),
+				zap.String("identifier", entry.Identifier),
+				zap.Error(err),
+			)
+		} else {
+			c.api.Stats.IncrementBundleMoved()
+			if err := c.api.Checkpoint.Record(entry.Entity, entry.Identifier); err != nil {
+				c.logger.Warn("Failed to update checkpoint", zap.Error(err))
+			}
+		}
+		if c.showPB {
+			bar.Add(1)
+		}
+	}
+	if c.showPB {
+		bar.Finish()
+	}
+
+	return nil
+}
+
+// importProject creates the target project from the exported one, unless it exists
+func (c BundleImportContext) importProject(entry model.BundleEntry) error {
+	if err := c.api.ValidateProject(c.targetOrg, c.targetProject, c.logger); err == nil {
+		return nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(c.bundle.dir, entry.Body))
+	if err != nil {
+		return fmt.Errorf("error reading bundle: %v", err)
+	}
+	wrapped := model.ProjectWrapper{}
+	if err := json.Unmarshal(data, &wrapped); err != nil || wrapped.Project == nil {
+		return fmt.Errorf("error reading project from bundle: %v", err)
+	}
+	wrapped.Project.Identifier = c.targetProject
+	wrapped.Project.OrgIdentifier = c.targetOrg
+
+	if err := c.api.CreateProject(wrapped.Project, c.logger); err != nil {
+		return err
+	}
+
+	// THE CURRENT USER IS ADDED TO THE PROJECT WHEN IT IS CREATED
+	return RemoveCurrentUserOperation(c.api, c.targetOrg, c.targetProject, c.logger).Copy()
+}
+
+// importEntry sends a request of the bundle to the target
+func (api *ApiRequest) importEntry(b *Bundle, entry model.BundleEntry, org, project string, logger *zap.Logger) error {
+
+	logger.Info("Importing entity",
+		zap.String("entity", entry.Entity),
+		zap.String("identifier", entry.Identifier),
+	)
+
+	api.Stats.IncrementApiCalls()
+
+	params := map[string]string{
+		"accountIdentifier": api.Account,
+	}
+	for k, v := range entry.Params {
+		params[k] = v
+	}
+	for k := range scopeKeys(org, project) {
+		if _, ok := params[k]; ok {
+			params[k] = scopeKeys(org, project)[k]
+		}
+	}
+
+	req := api.Client.R().
+		SetHeader("x-api-key", api.Token).
+		SetQueryParams(params)
+
+	var body string
+	if entry.Body != "" {
+		data, err := os.ReadFile(filepath.Join(b.dir, entry.Body))
+		if err != nil {
+			return fmt.Errorf("error reading bundle: %v", err)
+		}
+		body = replaceScope(string(data), org, project)
+		req.SetHeader("Content-Type", entry.ContentType).
+			SetBody(body)
+	}
+
+	if entry.Form != nil {
+		form := map[string]string{}
+		for k, v := range entry.Form {
+			form[k] = replaceScope(v, org, project)
+		}
+		req.SetHeader("Content-Type", "multipart/form-data").
+			SetMultipartFormData(form)
+	}
+
+	if entry.Content != "" {
+		content, err := os.ReadFile(filepath.Join(b.dir, entry.Content))
+		if err != nil {
+			return fmt.Errorf("error reading bundle: %v", err)
+		}
+		req.SetMultipartField(entry.ContentField, path.Base(entry.Identifier), "application/octet-stream", bytes.NewReader(content))
+	}
+
+	resp, err := req.Execute(entry.Method, api.BaseURL+entry.Path)
+	if err != nil {
+		logger.Error("Failed to send request to import ",
+			zap.String("identifier", entry.Identifier),
+			zap.Error(err),
+		)
+		return err
+	}
+	if resp.IsError() {
+		var errorResponse map[string]interface{}
+		if err := json.Unmarshal(resp.Body(), &errorResponse); err == nil {
+			if code, ok := errorResponse["code"].(string); ok && (code == "DUPLICATE_FIELD" || code == "409") {
+				logger.Info("Duplicate entity found",
+					zap.String("entity", entry.Entity),
+					zap.String("identifier", entry.Identifier),
+				)
+				return ErrAlreadyExists
+			}
+		}
+		logger.Error("Error response from API when importing ",
+			zap.String("identifier", entry.Identifier),
+			zap.String("response",
+				resp.String(),
+			),
+		)
+		return handleErrorResponse(resp)
+	}
+
+	// ENTRIES THAT UPDATE AN ENTITY, SUCH AS THE STABLE VERSION OF A TEMPLATE, CREATE NOTHING
+	if entry.Method == resty.MethodPost {
+		api.recordCreated(importedEntry(entry, body, org, project), logger)
+	}
+
+	return nil
+}
+
+// importedEntry is the journal entry of an imported bundle entry. Entities scoped to an
+// environment or a pipeline, and templates, were exported as "scope/identifier".
+func importedEntry(entry model.BundleEntry, body, org, project string) model.JournalEntry {
+	journal := model.JournalEntry{
+		Entity:     entry.Entity,
+		Identifier: entry.Identifier,
+		Org:        org,
+		Project:    project,
+	}
+
+	scope, identifier, _ := strings.Cut(entry.Identifier, "/")
+	switch entry.Entity {
+	case "Infrastructure", "Target Group", "Service Override":
+		journal.Environment, journal.Identifier = scope, identifier
+	case "Input Set":
+		journal.Pipeline, journal.Identifier = scope, identifier
+	case "Template":
+		journal.Identifier, journal.Version = scope, identifier
+	case "Trigger":
+		journal.Pipeline = yamlValue(body, "pipelineIdentifier")
+	case "Target":
+		target := model.Target{}
+		json.Unmarshal([]byte(body), &target)
+		journal.Environment = target.Environment
+	case "File Store":
+		journal.Identifier = entry.Form["identifier"]
+	}
+
+	return journal
+}
+
+// scopeKeys are the fields that hold the org and project of an entity
+func scopeKeys(org, project string) map[string]string {
+	return map[string]string{
+		"orgIdentifier":     org,
+		"projectIdentifier": project,
+		"org":               org,
+		"project":           project,
+	}
+}
+
+// replaceScope moves a JSON or YAML document to another org and project. Anything else
+// is returned as it is.
+func replaceScope(input, org, project string) string {
+	var value interface{}
+	if err := json.Unmarshal([]byte(input), &value); err == nil {
+		if _, ok := value.(map[string]interface{}); ok {
+			data, err := json.Marshal(replaceScopeValue(value, scopeKeys(org, project)))
+			if err == nil {
+				return string(data)
+			}
+		}
+		return input
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal([]byte(input), &node); err != nil || node.Kind != yaml.DocumentNode {
+		return input
+	}
+	return updateYaml(input, org, project)
+}
+
+func replaceScopeValue(value interface{}, keys map[string]string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, field := range v {
+			if replacement, ok := keys[k]; ok {
+				if _, isString := field.(string); isString {
+					v[k] = replacement
+					continue
+				}
+			}
+			v[k] = replaceScopeValue(field, keys)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = replaceScopeValue(item, keys)
+		}
+	}
+	return value
+}
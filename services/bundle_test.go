@@ -0,0 +1,132 @@
+package services
+
+import (
+	"encoding/json"
+	"io"
+	"io/fs"
+	"mime"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"harness-copy-project/model"
+)
+
+// bodyOf returns the body of the last request sent to the path. A multipart form is
+// returned as its spec and file joined with '|'.
+func bodyOf(t *testing.T, fake *fakeHarness, path string) string {
+	requests := fake.sent("", path)
+	if !assert.NotEmpty(t, requests) {
+		return ""
+	}
+	r := requests[len(requests)-1]
+
+	mediaType, params, _ := mime.ParseMediaType(r.ContentType)
+	if mediaType != "multipart/form-data" {
+		return r.Body
+	}
+	form, err := multipart.NewReader(strings.NewReader(r.Body), params["boundary"]).ReadForm(1 << 20)
+	assert.NoError(t, err)
+	file, _ := form.File["file"][0].Open()
+	content, _ := io.ReadAll(file)
+	return form.Value["spec"][0] + "|" + string(content)
+}
+
+func TestBundle_ExportAndImport(t *testing.T) {
+	dir := t.TempDir()
+	logger := zap.NewNop()
+
+	bundle, err := NewBundle(dir, "src", "src")
+	assert.NoError(t, err)
+
+	export := &ApiRequest{
+		Client: resty.New(),
+		Stats:  NewStats(),
+		Bundle: bundle,
+	}
+
+	pipeline := "pipeline:\n  identifier: build\n  orgIdentifier: src\n  projectIdentifier: src\n"
+	assert.NoError(t, export.createPipeline("src", "src", pipeline, logger))
+	assert.NoError(t, export.addConnector(&model.ConnectorContent{
+		Connector: model.Connector{
+			Identifier:        "git",
+			OrgIdentifier:     "src",
+			ProjectIdentifier: "src",
+		},
+	}, logger))
+	assert.NoError(t, export.createSecretFile(&model.Secret{
+		Identifier:        "key",
+		OrgIdentifier:     "src",
+		ProjectIdentifier: "src",
+	}, []byte("secret"), logger))
+
+	// NOTHING IS SENT WHILE EXPORTING
+	assert.Equal(t, 0, export.Stats.GetApiCalls())
+
+	opened, err := OpenBundle(dir)
+	assert.NoError(t, err)
+	assert.Len(t, opened.Manifest.Entries, 3)
+	assert.Equal(t, "build", opened.Manifest.Entries[0].Identifier)
+
+	fake := newFakeHarness(t)
+	api := fake.api()
+	api.Conflict = ConflictSkip
+
+	assert.NoError(t, NewBundleImportOperation(api, opened, "dst", "copy", logger, false).Copy())
+	assert.Equal(t, 3, api.Stats.GetBundleTotal())
+	assert.Equal(t, 3, api.Stats.GetBundleMoved())
+
+	// THE PIPELINE IS CREATED IN THE PROJECT IT IS IMPORTED TO
+	assert.Equal(t, []string{"build"}, fake.pipelineIdentifiers("dst", "copy"))
+	assert.Contains(t, bodyOf(t, fake, CREATE_PIPELINE), "orgIdentifier: dst")
+	assert.Contains(t, bodyOf(t, fake, CREATE_PIPELINE), "projectIdentifier: copy")
+
+	connector := model.ConnectorContent{}
+	assert.NoError(t, json.Unmarshal([]byte(bodyOf(t, fake, CONNECTORCREATE)), &connector))
+	assert.Equal(t, "dst", connector.Connector.OrgIdentifier)
+	assert.Equal(t, "copy", connector.Connector.ProjectIdentifier)
+
+	assert.Contains(t, bodyOf(t, fake, SECRETFILES), `"projectIdentifier":"copy"`)
+	assert.Contains(t, bodyOf(t, fake, SECRETFILES), "|secret")
+}
+
+func TestBundle_OnlyReadableByOwner(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "bundle")
+
+	bundle, err := NewBundle(dir, "src", "src")
+	assert.NoError(t, err)
+	assert.NoError(t, bundle.Add(model.BundleEntry{Entity: "Secret", Identifier: "key", Path: SECRETFILES}, map[string]string{"identifier": "key"}, []byte("secret")))
+
+	// THE BUNDLE CAN HOLD SECRET VALUES, SO NO OTHER USER CAN READ ANY OF IT
+	modes := 0
+	assert.NoError(t, filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		want := fs.FileMode(0600)
+		if d.IsDir() {
+			want = fs.ModeDir | 0700
+		}
+		assert.Equal(t, want, info.Mode(), path)
+		modes++
+		return nil
+	}))
+	assert.Equal(t, 5, modes)
+}
+
+func TestOpenBundle_RejectsUnknownVersion(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, BUNDLE_MANIFEST), []byte(`{"version":99}`), 0644))
+
+	_, err := OpenBundle(dir)
+	assert.ErrorContains(t, err, "unsupported bundle version 99")
+}
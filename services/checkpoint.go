@@ -0,0 +1,190 @@
+package services
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"harness-copy-project/model"
+)
+
+// Checkpoint records every entity created in the target so a failed copy can be
+// resumed without repeating the entities that already made it across. The state is
+// kept per project, per operation and per entity identifier, and the file holds one
+// JSON line per entity, appended as soon as it is created.
+type Checkpoint struct {
+	path     string
+	mu       sync.Mutex
+	projects map[string]map[string]map[string]bool
+
+	// Set until the first entity is recorded when a run does not resume, so the file of
+	// the previous run is replaced
+	truncate bool
+}
+
+// ProjectCheckpoint is the part of a checkpoint that belongs to a single project copy.
+// A nil ProjectCheckpoint never reports entities as done and records nothing.
+type ProjectCheckpoint struct {
+	checkpoint *Checkpoint
+	key        string
+}
+
+// LoadCheckpoint opens the state file at path. Existing state is only loaded when
+// resuming, otherwise the copy starts from a clean state. A loaded file is compacted so
+// every entity is listed once.
+func LoadCheckpoint(path string, resume bool) (*Checkpoint, error) {
+	c := &Checkpoint{
+		path:     path,
+		projects: map[string]map[string]map[string]bool{},
+		truncate: !resume,
+	}
+
+	if !resume {
+		return c, nil
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error opening checkpoint file: %v", err)
+	}
+	defer file.Close()
+
+	entries := []model.CheckpointEntry{}
+	var broken error
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		// ONLY THE LAST LINE MAY BE CUT SHORT BY AN INTERRUPTED RUN
+		if broken != nil {
+			return nil, broken
+		}
+		entry := model.CheckpointEntry{}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			broken = fmt.Errorf("error reading checkpoint file at line %d: %v", line, err)
+			continue
+		}
+		if c.projects[entry.Project][entry.Operation][entry.Identifier] {
+			continue
+		}
+		c.add(entry.Project, entry.Operation, entry.Identifier)
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading checkpoint file: %v", err)
+	}
+
+	if err := c.compact(entries); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Project returns the checkpoint of a single source to target project copy
+func (c *Checkpoint) Project(sourceOrg, sourceProject, targetOrg, targetProject string) *ProjectCheckpoint {
+	if c == nil {
+		return nil
+	}
+	return &ProjectCheckpoint{
+		checkpoint: c,
+		key:        fmt.Sprintf("%s/%s->%s/%s", sourceOrg, sourceProject, targetOrg, targetProject),
+	}
+}
+
+// Done reports if the entity was created by a previous run
+func (p *ProjectCheckpoint) Done(operation, identifier string) bool {
+	if p == nil {
+		return false
+	}
+	p.checkpoint.mu.Lock()
+	defer p.checkpoint.mu.Unlock()
+
+	return p.checkpoint.projects[p.key][operation][identifier]
+}
+
+// Record marks the entity as created and appends it to the state file
+func (p *ProjectCheckpoint) Record(operation, identifier string) error {
+	if p == nil {
+		return nil
+	}
+	p.checkpoint.mu.Lock()
+	defer p.checkpoint.mu.Unlock()
+
+	p.checkpoint.add(p.key, operation, identifier)
+
+	return p.checkpoint.append(model.CheckpointEntry{
+		Project:    p.key,
+		Operation:  operation,
+		Identifier: identifier,
+		Time:       time.Now().Unix(),
+	})
+}
+
+func (c *Checkpoint) add(project, operation, identifier string) {
+	if c.projects[project] == nil {
+		c.projects[project] = map[string]map[string]bool{}
+	}
+	if c.projects[project][operation] == nil {
+		c.projects[project][operation] = map[string]bool{}
+	}
+	c.projects[project][operation][identifier] = true
+}
+
+// append writes a single entity to the end of the state file
+func (c *Checkpoint) append(entry model.CheckpointEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("error encoding checkpoint entry: %v", err)
+	}
+
+	flags := os.O_APPEND | os.O_CREATE | os.O_WRONLY
+	if c.truncate {
+		flags |= os.O_TRUNC
+	}
+	file, err := os.OpenFile(c.path, flags, 0644)
+	if err != nil {
+		return fmt.Errorf("error opening checkpoint file: %v", err)
+	}
+	defer file.Close()
+	c.truncate = false
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("error writing checkpoint file: %v", err)
+	}
+
+	return file.Sync()
+}
+
+// compact rewrites the state file with every entity once. It is written to a temporary
+// file first so an interrupted run never leaves a truncated checkpoint behind.
+func (c *Checkpoint) compact(entries []model.CheckpointEntry) error {
+	data := []byte{}
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("error encoding checkpoint entry: %v", err)
+		}
+		data = append(append(data, line...), '\n')
+	}
+
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("error writing checkpoint file: %v", err)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return fmt.Errorf("error writing checkpoint file: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func checkpointLines(t *testing.T, path string) []string {
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+func TestCheckpoint_ResumeSkipsRecordedEntities(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+
+	checkpoint, err := LoadCheckpoint(path, false)
+	assert.NoError(t, err)
+	project := checkpoint.Project("src", "app", "dst", "app")
+	assert.NoError(t, project.Record("Pipeline", "deploy"))
+	assert.NoError(t, project.Record("Pipeline", "build"))
+	assert.NoError(t, checkpoint.Project("src", "other", "dst", "other").Record("Pipeline", "deploy"))
+
+	// EVERY ENTITY IS APPENDED AS ONE LINE
+	assert.Len(t, checkpointLines(t, path), 3)
+
+	checkpoint, err = LoadCheckpoint(path, true)
+	assert.NoError(t, err)
+	project = checkpoint.Project("src", "app", "dst", "app")
+	assert.True(t, project.Done("Pipeline", "deploy"))
+	assert.True(t, project.Done("Pipeline", "build"))
+	assert.False(t, project.Done("Pipeline", "release"))
+	assert.False(t, project.Done("Connector", "deploy"))
+	assert.False(t, checkpoint.Project("src", "app", "dst", "copy").Done("Pipeline", "deploy"))
+
+	// A RESUMED RUN APPENDS TO THE FILE OF THE PREVIOUS ONE
+	assert.NoError(t, project.Record("Pipeline", "release"))
+	assert.Len(t, checkpointLines(t, path), 4)
+
+	var nilProject *ProjectCheckpoint
+	assert.False(t, nilProject.Done("Pipeline", "deploy"))
+	assert.NoError(t, nilProject.Record("Pipeline", "deploy"))
+}
+
+func TestCheckpoint_WithoutResumeStartsClean(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+
+	checkpoint, err := LoadCheckpoint(path, false)
+	assert.NoError(t, err)
+	assert.NoError(t, checkpoint.Project("src", "app", "dst", "app").Record("Pipeline", "deploy"))
+
+	checkpoint, err = LoadCheckpoint(path, false)
+	assert.NoError(t, err)
+	project := checkpoint.Project("src", "app", "dst", "app")
+	assert.False(t, project.Done("Pipeline", "deploy"))
+
+	// THE FILE OF THE PREVIOUS RUN IS ONLY REPLACED ONCE SOMETHING IS RECORDED
+	assert.Len(t, checkpointLines(t, path), 1)
+	assert.NoError(t, project.Record("Pipeline", "build"))
+	lines := checkpointLines(t, path)
+	if assert.Len(t, lines, 1) {
+		assert.Contains(t, lines[0], `"identifier":"build"`)
+	}
+}
+
+func TestCheckpoint_LoadCompactsTheFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+
+	entry := `{"project":"src/app->dst/app","operation":"Pipeline","identifier":"deploy","time":1}`
+	other := `{"project":"src/app->dst/app","operation":"Pipeline","identifier":"build","time":2}`
+	// THE LAST LINE WAS CUT SHORT BY AN INTERRUPTED RUN
+	assert.NoError(t, os.WriteFile(path, []byte(entry+"\n"+entry+"\n"+other+"\n"+`{"project":"src/app`), 0644))
+
+	checkpoint, err := LoadCheckpoint(path, true)
+	assert.NoError(t, err)
+	assert.True(t, checkpoint.Project("src", "app", "dst", "app").Done("Pipeline", "build"))
+	lines := checkpointLines(t, path)
+	if assert.Len(t, lines, 2) {
+		assert.Contains(t, lines[0], `"identifier":"deploy"`)
+		assert.Contains(t, lines[1], `"identifier":"build"`)
+	}
+
+	// ONLY THE LAST LINE MAY BE BROKEN
+	assert.NoError(t, os.WriteFile(path, []byte(`{"project"`+"\n"+entry+"\n"), 0644))
+	_, err = LoadCheckpoint(path, true)
+	assert.Error(t, err)
+}
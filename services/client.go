@@ -0,0 +1,128 @@
+package services
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// ClientConfig defines how requests to Harness are retried and rate limited
+type ClientConfig struct {
+	Retries      int
+	RetryWait    time.Duration
+	RetryMaxWait time.Duration
+	Limiter      *RateLimiter
+}
+
+// NewClient returns a client that retries GET, PUT and DELETE requests failing with 429,
+// 5xx or a network error using a jittered exponential backoff. A POST creates an entity,
+// and a create that reached the server may have succeeded, so it is only retried when it
+// is rate limited or could not connect. A POST that only reads adds retryIdempotent.
+// A 429 waits for its Retry-After header when present. Every attempt, retries included,
+// waits for the shared rate limiter and every retry is counted in stats.
+func NewClient(config ClientConfig, stats *Stats) *resty.Client {
+	client := resty.New().
+		SetRetryCount(config.Retries).
+		SetRetryWaitTime(config.RetryWait).
+		SetRetryMaxWaitTime(config.RetryMaxWait).
+		SetRetryResetReaders(true).
+		SetRetryAfter(retryAfter).
+		AddRetryCondition(func(resp *resty.Response, err error) bool {
+			if resp != nil && resp.Request != nil {
+				switch resp.Request.Method {
+				case http.MethodGet, http.MethodPut, http.MethodDelete:
+					return retryIdempotent(resp, err)
+				}
+			}
+			if err != nil {
+				return notSent(err)
+			}
+			return resp.StatusCode() == http.StatusTooManyRequests
+		})
+
+	client.OnBeforeRequest(func(c *resty.Client, req *resty.Request) error {
+		if req.Attempt > 1 {
+			stats.IncrementRetries()
+		}
+		config.Limiter.Wait()
+		return nil
+	})
+
+	return client
+}
+
+// retryIdempotent retries a request that can be sent again without side effects
+func retryIdempotent(resp *resty.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode() == http.StatusTooManyRequests || resp.StatusCode() >= http.StatusInternalServerError
+}
+
+// notSent tells whether a request failed before it reached the server
+func notSent(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// retryAfter reads the Retry-After header of a 429, either in seconds or as a date.
+// A zero duration falls back to the jittered backoff.
+func retryAfter(c *resty.Client, resp *resty.Response) (time.Duration, error) {
+	if resp == nil || resp.StatusCode() != http.StatusTooManyRequests {
+		return 0, nil
+	}
+
+	value := resp.Header().Get("Retry-After")
+	if value == "" {
+		return 0, nil
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date), nil
+	}
+
+	return 0, nil
+}
+
+// RateLimiter spaces requests evenly to stay under a number of requests per second.
+// A single limiter is shared by every operation and project. A nil RateLimiter does
+// not limit anything.
+type RateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func NewRateLimiter(requestsPerSecond float64) *RateLimiter {
+	if requestsPerSecond <= 0 {
+		return nil
+	}
+	return &RateLimiter{
+		interval: time.Duration(float64(time.Second) / requestsPerSecond),
+	}
+}
+
+// Wait blocks until the next request is allowed
+func (l *RateLimiter) Wait() {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	wait := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	time.Sleep(wait)
+}
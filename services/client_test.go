@@ -0,0 +1,147 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_RetriesAndHonorsRetryAfter(t *testing.T) {
+	var attempts int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&attempts, 1) {
+		case 1:
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.Write([]byte(`{"status":"SUCCESS"}`))
+		}
+	}))
+	defer server.Close()
+
+	stats := NewStats()
+	client := NewClient(ClientConfig{
+		Retries:      3,
+		RetryWait:    10 * time.Millisecond,
+		RetryMaxWait: 2 * time.Second,
+	}, stats)
+
+	start := time.Now()
+	resp, err := client.R().Get(server.URL)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	assert.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+	assert.Equal(t, 2, stats.GetRetries())
+	assert.GreaterOrEqual(t, time.Since(start), time.Second, "Retry-After was not honored")
+}
+
+func TestClient_DoesNotRetryClientErrors(t *testing.T) {
+	var attempts int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	stats := NewStats()
+	client := NewClient(ClientConfig{
+		Retries:   3,
+		RetryWait: 10 * time.Millisecond,
+	}, stats)
+
+	resp, err := client.R().Get(server.URL)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+	assert.Equal(t, 0, stats.GetRetries())
+}
+
+func TestRateLimiter_SpacesRequests(t *testing.T) {
+	limiter := NewRateLimiter(20)
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		limiter.Wait()
+	}
+
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+	assert.Nil(t, NewRateLimiter(0))
+}
+
+func TestClient_DoesNotRetryCreatesThatReachedTheServer(t *testing.T) {
+	var attempts int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	stats := NewStats()
+	client := NewClient(ClientConfig{
+		Retries:   3,
+		RetryWait: 10 * time.Millisecond,
+	}, stats)
+
+	// THE CREATE MAY HAVE SUCCEEDED BEHIND THE GATEWAY, SENDING IT AGAIN WOULD FIND IT
+	resp, err := client.R().Post(server.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+
+	// A POST THAT ONLY READS IS RETRIED LIKE A GET
+	atomic.StoreInt32(&attempts, 0)
+	resp, err = client.R().AddRetryCondition(retryIdempotent).Post(server.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode())
+	assert.Equal(t, int32(4), atomic.LoadInt32(&attempts))
+}
+
+func TestClient_RetriesCreatesThatWereNotSent(t *testing.T) {
+	var attempts int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"status":"SUCCESS"}`))
+	}))
+	defer server.Close()
+
+	stats := NewStats()
+	client := NewClient(ClientConfig{
+		Retries:   2,
+		RetryWait: 10 * time.Millisecond,
+	}, stats)
+
+	// A RATE LIMITED CREATE WAS NOT APPLIED
+	resp, err := client.R().Post(server.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&attempts))
+
+	// A CREATE THAT COULD NOT CONNECT NEVER LEFT
+	closed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	closed.Close()
+
+	stats = NewStats()
+	client = NewClient(ClientConfig{
+		Retries:   2,
+		RetryWait: 10 * time.Millisecond,
+	}, stats)
+
+	_, err = client.R().Post(closed.URL)
+	assert.Error(t, err)
+	assert.Equal(t, 2, stats.GetRetries())
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"sync"
+
+	"github.com/schollz/progressbar/v3"
+)
+
+var barMu sync.Mutex
+
+// forEach calls fn once for every index from 0 to n-1. At most api.Concurrency calls
+// run at the same time, without a concurrency set the calls run one after another.
+func (api *ApiRequest) forEach(n int, fn func(i int)) {
+	workers := api.Concurrency
+	if workers > n {
+		workers = n
+	}
+	if workers <= 1 {
+		for i := 0; i < n; i++ {
+			fn(i)
+		}
+		return
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
+
+// forEachLevel calls fn once for every key, level by level of the dependency graph so an
+// entity is created after the entities of the same type it references
+func (api *ApiRequest) forEachLevel(entity string, keys []string, fn func(i int)) {
+	for _, level := range api.Graph.Levels(entity, keys) {
+		api.forEach(len(level), func(i int) {
+			fn(level[i])
+		})
+	}
+}
+
+// growBar adds n to the maximum of a bar that is shared between workers
+func growBar(bar *progressbar.ProgressBar, n int) {
+	barMu.Lock()
+	defer barMu.Unlock()
+
+	bar.ChangeMax(bar.GetMax() + n)
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"harness-copy-project/model"
+)
+
+func TestForEach_CallsEveryIndexOnce(t *testing.T) {
+	api := &ApiRequest{Concurrency: 4}
+
+	calls := make([]int32, 100)
+	var running, most int32
+	api.forEach(len(calls), func(i int) {
+		now := atomic.AddInt32(&running, 1)
+		for {
+			seen := atomic.LoadInt32(&most)
+			if now <= seen || atomic.CompareAndSwapInt32(&most, seen, now) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		atomic.AddInt32(&calls[i], 1)
+		atomic.AddInt32(&running, -1)
+	})
+
+	for i, c := range calls {
+		assert.Equal(t, int32(1), c, "index %v", i)
+	}
+	assert.LessOrEqual(t, most, int32(4))
+	assert.Greater(t, most, int32(1))
+}
+
+func TestForEach_WithoutConcurrencyRunsInOrder(t *testing.T) {
+	api := &ApiRequest{}
+
+	order := []int{}
+	api.forEach(5, func(i int) {
+		order = append(order, i)
+	})
+
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, order)
+}
+
+func TestForEachLevel_DependenciesFinishFirst(t *testing.T) {
+	// EVERY TEMPLATE USES THE ONE BEFORE IT, EVERY OTHER ONE ALSO USES THE FIRST
+	api := &ApiRequest{Concurrency: 8, Graph: NewGraph()}
+	keys := []string{}
+	for i := 0; i < 40; i++ {
+		keys = append(keys, fmt.Sprintf("t%d", i))
+	}
+	for i := 1; i < len(keys); i++ {
+		node := GraphNode{Entity: "Template", Key: keys[i]}
+		api.Graph.deps[node] = []GraphNode{{Entity: "Template", Key: keys[i-1]}}
+		if i%2 == 0 {
+			api.Graph.deps[node] = append(api.Graph.deps[node], GraphNode{Entity: "Template", Key: keys[0]})
+		}
+	}
+
+	var mu sync.Mutex
+	done := map[string]bool{}
+	api.forEachLevel("Template", keys, func(i int) {
+		node := GraphNode{Entity: "Template", Key: keys[i]}
+
+		mu.Lock()
+		for _, dep := range api.Graph.deps[node] {
+			assert.True(t, done[dep.Key], "%v started before %v", keys[i], dep.Key)
+		}
+		mu.Unlock()
+
+		time.Sleep(time.Millisecond)
+
+		mu.Lock()
+		assert.False(t, done[keys[i]], "%v was called twice", keys[i])
+		done[keys[i]] = true
+		mu.Unlock()
+	})
+
+	assert.Len(t, done, len(keys))
+}
+
+func TestStats_ConcurrentUpdates(t *testing.T) {
+	stats := NewStats()
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				stats.IncrementApiCalls()
+				stats.IncrementPipelinesTotal()
+				stats.IncrementPipelinesMoved()
+				stats.AddCreated(model.JournalEntry{Entity: "Pipeline", Identifier: fmt.Sprintf("p%d_%d", w, i)})
+				stats.AddConflict(model.ConflictEntry{Entity: "Pipeline", Identifier: fmt.Sprintf("p%d_%d", w, i), Strategy: ConflictSkip})
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	assert.Equal(t, 800, stats.GetApiCalls())
+	assert.Equal(t, 800, stats.GetPipelinesTotal())
+	assert.Equal(t, 800, stats.GetPipelinesMoved())
+	assert.Len(t, stats.GetCreated(), 800)
+	assert.Len(t, stats.GetConflicts(), 800)
+}
+
+func TestPipelineCopy_Concurrent(t *testing.T) {
+	fake := newFakeHarness(t)
+	total := 60
+	for i := 0; i < total; i++ {
+		identifier := fmt.Sprintf("pipeline_%d", i)
+		fake.addPipeline("src", "src", identifier, fmt.Sprintf("pipeline:\n  identifier: %s\n  orgIdentifier: src\n  projectIdentifier: src\n", identifier))
+		fake.addInputset("src", "src", identifier, "defaults")
+		// EVERY FIFTH PIPELINE IS ALREADY IN THE TARGET
+		if i%5 == 0 {
+			fake.addPipeline("target", "target", identifier, fmt.Sprintf("pipeline:\n  identifier: %s\n", identifier))
+		}
+	}
+
+	api := fake.api()
+	api.Concurrency = 8
+	api.Conflict = ConflictSkip
+	api.Graph = NewGraph()
+	// EVERY PIPELINE RUNS THE ONE BEFORE IT AS A CHILD PIPELINE, EVERY TENTH ONE STARTS A CHAIN
+	for i := 1; i < total; i++ {
+		if i%10 != 0 {
+			api.Graph.deps[GraphNode{Entity: "Pipeline", Key: fmt.Sprintf("pipeline_%d", i)}] = []GraphNode{{Entity: "Pipeline", Key: fmt.Sprintf("pipeline_%d", i-1)}}
+		}
+	}
+
+	assert.NoError(t, NewPipelineOperation(api, "src", "src", "target", "target", zap.NewNop(), false).Copy())
+	assert.NoError(t, NewInputsetOperation(api, "src", "src", "target", "target", zap.NewNop(), false).Copy())
+
+	// EVERY PIPELINE IS IN THE TARGET ONCE
+	want := []string{}
+	for i := 0; i < total; i++ {
+		want = append(want, fmt.Sprintf("pipeline_%d", i))
+	}
+	assert.ElementsMatch(t, want, fake.pipelineIdentifiers("target", "target"))
+
+	assert.Equal(t, total, api.Stats.GetPipelinesTotal())
+	assert.Equal(t, total, api.Stats.GetPipelinesMoved())
+	assert.Len(t, api.Stats.GetConflicts(), total/5)
+	assert.Len(t, api.Stats.GetCreated(), total-total/5+total)
+
+	assert.Equal(t, total, api.Stats.GetInputSetsTotal())
+	assert.Equal(t, total, api.Stats.GetInputSetsMoved())
+	for _, pipeline := range want {
+		assert.Equal(t, []string{"defaults"}, fake.inputsetIdentifiers("target", "target", pipeline))
+	}
+}
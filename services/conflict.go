@@ -0,0 +1,146 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+	"harness-copy-project/model"
+)
+
+const (
+	ConflictSkip      = "skip"
+	ConflictOverwrite = "overwrite"
+	ConflictFail      = "fail"
+)
+
+// Returned by the create calls when the entity already exists in the target
+var ErrAlreadyExists = errors.New("already exists in the target project")
+
+// Returned by the overwrite of an entity that is made of nothing but what identifies it
+var errNothingToReplace = errors.New(reasonSameAsCopy)
+
+// Reasons a conflict is skipped under the overwrite strategy
+const (
+	reasonNotReplaceable = "the existing entity can not be overwritten"
+	reasonSameAsCopy     = "the existing entity is the same as the copy"
+)
+
+// keepExisting is the overwrite of an entity that has nothing to replace, such as the
+// membership of a user or a role assignment
+func keepExisting() error {
+	return errNothingToReplace
+}
+
+func ValidConflictStrategy(strategy string) bool {
+	switch strategy {
+	case ConflictSkip, ConflictOverwrite, ConflictFail:
+		return true
+	}
+	return false
+}
+
+// resolveConflict applies the conflict strategy when a create call reports the entity
+// already exists in the target. Other errors are returned as they are. A nil overwrite
+// means the existing entity can not be replaced, so it is skipped, and so is an entity
+// keepExisting finds nothing to replace in.
+func (api *ApiRequest) resolveConflict(entity, identifier string, err error, overwrite func() error, logger *zap.Logger) error {
+	if !errors.Is(err, ErrAlreadyExists) {
+		return err
+	}
+
+	entry := model.ConflictEntry{
+		Entity:     entity,
+		Identifier: identifier,
+		Strategy:   ConflictSkip,
+	}
+
+	switch api.Conflict {
+	case ConflictFail:
+		entry.Strategy = ConflictFail
+		err = fmt.Errorf("%s %s %w", entity, identifier, ErrAlreadyExists)
+	case ConflictOverwrite:
+		if overwrite == nil {
+			entry.Reason = reasonNotReplaceable
+			err = nil
+			break
+		}
+		if err = overwrite(); errors.Is(err, errNothingToReplace) {
+			entry.Reason = reasonSameAsCopy
+			err = nil
+			break
+		}
+		entry.Strategy = ConflictOverwrite
+		if err != nil {
+			entry.Reason = removeNewLine(err.Error())
+		}
+	default:
+		err = nil
+	}
+
+	api.Stats.AddConflict(entry)
+
+	logger.Info("Entity already exists in the target project",
+		zap.String("entity", entity),
+		zap.String("identifier", identifier),
+		zap.String("strategy", entry.Strategy),
+		zap.String("reason", entry.Reason),
+	)
+
+	return err
+}
+
+// NotReplaced lists the entities the overwrite strategy had to skip. They may still
+// differ from the source project.
+func NotReplaced(stats *Stats) []model.ConflictEntry {
+	skipped := []model.ConflictEntry{}
+	for _, c := range stats.GetConflicts() {
+		if c.Strategy == ConflictSkip && c.Reason == reasonNotReplaceable {
+			skipped = append(skipped, c)
+		}
+	}
+	return skipped
+}
+
+// updateEntity replaces an entity that already exists in the target. The body is the
+// same as the one sent to create the entity.
+func (api *ApiRequest) updateEntity(path, contentType string, body interface{}, params map[string]string, logger *zap.Logger) error {
+
+	logger.Info("Updating entity",
+		zap.String("path", path),
+	)
+
+	api.Stats.IncrementApiCalls()
+
+	query := map[string]string{
+		"accountIdentifier": api.Account,
+	}
+	for k, v := range params {
+		query[k] = v
+	}
+
+	resp, err := api.Client.R().
+		SetHeader("x-api-key", api.Token).
+		SetHeader("Content-Type", contentType).
+		SetBody(body).
+		SetQueryParams(query).
+		Put(api.BaseURL + path)
+	if err != nil {
+		logger.Error("Failed to send request to update ",
+			zap.String("path", path),
+			zap.Error(err),
+		)
+		return err
+	}
+	if resp.IsError() {
+		logger.Error("Error response from API when updating ",
+			zap.String("path", path),
+			zap.String("response",
+				resp.String(),
+			),
+		)
+		return handleErrorResponse(resp)
+	}
+
+	return nil
+}
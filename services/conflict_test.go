@@ -0,0 +1,88 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"harness-copy-project/model"
+)
+
+func copyExistingPipeline(t *testing.T, strategy string) (*ApiRequest, *fakeHarness) {
+	fake := newFakeHarness(t)
+	fake.addPipeline("src", "src", "existing", "pipeline:\n  identifier: existing\n  orgIdentifier: src\n  projectIdentifier: src\n")
+	fake.addPipeline("target", "target", "existing", "pipeline:\n  identifier: existing\n  orgIdentifier: target\n  projectIdentifier: target\n")
+
+	api := fake.api()
+	api.Conflict = strategy
+
+	err := NewPipelineOperation(api, "src", "src", "target", "target", zap.NewNop(), false).Copy()
+	assert.Nil(t, err)
+
+	return api, fake
+}
+
+func TestPipelineCopy_ConflictSkip(t *testing.T) {
+	api, fake := copyExistingPipeline(t, ConflictSkip)
+
+	assert.Empty(t, fake.sent(http.MethodPut, ""))
+	assert.Equal(t, 1, api.Stats.GetPipelinesMoved())
+	assert.Equal(t, []model.ConflictEntry{
+		{Entity: "Pipeline", Identifier: "existing", Strategy: ConflictSkip},
+	}, api.Stats.GetConflicts())
+}
+
+func TestPipelineCopy_ConflictOverwrite(t *testing.T) {
+	api, fake := copyExistingPipeline(t, ConflictOverwrite)
+
+	assert.Len(t, fake.sent(http.MethodPut, CREATE_PIPELINE+"/existing"), 1)
+	assert.Len(t, fake.sent(http.MethodPut, ""), 1)
+	assert.Equal(t, 1, api.Stats.GetPipelinesMoved())
+	assert.Equal(t, []model.ConflictEntry{
+		{Entity: "Pipeline", Identifier: "existing", Strategy: ConflictOverwrite},
+	}, api.Stats.GetConflicts())
+}
+
+func TestPipelineCopy_ConflictFail(t *testing.T) {
+	api, fake := copyExistingPipeline(t, ConflictFail)
+
+	assert.Empty(t, fake.sent(http.MethodPut, ""))
+	assert.Equal(t, 0, api.Stats.GetPipelinesMoved())
+	assert.Equal(t, []model.ConflictEntry{
+		{Entity: "Pipeline", Identifier: "existing", Strategy: ConflictFail},
+	}, api.Stats.GetConflicts())
+}
+
+func TestResolveConflict_OverwriteNotSupported(t *testing.T) {
+	api := &ApiRequest{
+		Stats:    NewStats(),
+		Conflict: ConflictOverwrite,
+	}
+
+	err := api.resolveConflict("Role Assignment", "ra", ErrAlreadyExists, nil, zap.NewNop())
+	assert.Nil(t, err)
+
+	other := errors.New("boom")
+	assert.Equal(t, other, api.resolveConflict("Role Assignment", "ra", other, nil, zap.NewNop()))
+
+	conflicts := api.Stats.GetConflicts()
+	assert.Len(t, conflicts, 1)
+	assert.Equal(t, ConflictSkip, conflicts[0].Strategy)
+	assert.NotEmpty(t, conflicts[0].Reason)
+}
+
+func TestResolveConflict_NothingToReplace(t *testing.T) {
+	api := &ApiRequest{
+		Stats:    NewStats(),
+		Conflict: ConflictOverwrite,
+	}
+
+	err := api.resolveConflict("User", "user@example.com", ErrAlreadyExists, keepExisting, zap.NewNop())
+	assert.Nil(t, err)
+
+	assert.Equal(t, []model.ConflictEntry{
+		{Entity: "User", Identifier: "user@example.com", Strategy: ConflictSkip, Reason: reasonSameAsCopy},
+	}, api.Stats.GetConflicts())
+}
@@ -2,6 +2,8 @@ package services
 
 import (
 	"encoding/json"
+	"fmt"
+	"strconv"
 
 	"github.com/schollz/progressbar/v3"
 	"go.uber.org/zap"
@@ -39,7 +41,7 @@ func (c ConnectorContext) Copy() error {
 		zap.String("project", c.sourceProject),
 	)
 
-	connectors, err := c.api.listConnectors(c.sourceOrg, c.sourceProject, c.logger)
+	connectors, err := c.api.source().listConnectors(c.sourceOrg, c.sourceProject, c.logger)
 	if err != nil {
 		c.logger.Error("Failed to retrive connectors",
 			zap.String("Project", c.sourceProject),
@@ -48,6 +50,21 @@ func (c ConnectorContext) Copy() error {
 		return err
 	}
 
+	existing := map[string]bool{}
+	if c.api.Plan != nil {
+		targetConnectors, err := c.api.listConnectors(c.targetOrg, c.targetProject, c.logger)
+		if err != nil {
+			c.logger.Error("Failed to list the connectors of the target project",
+				zap.String("Project", c.targetProject),
+				zap.Error(err),
+			)
+			return err
+		}
+		for _, cn := range targetConnectors {
+			existing[cn.Connector.Identifier] = true
+		}
+	}
+
 	var bar *progressbar.ProgressBar
 
 	if c.showPB {
@@ -56,25 +73,75 @@ func (c ConnectorContext) Copy() error {
 
 	for _, cn := range connectors {
 
-		IncrementConnectorsTotal()
+		c.api.Stats.IncrementConnectorsTotal()
+
+		if c.api.Checkpoint.Done("Connector", cn.Connector.Identifier) {
+			c.logger.Info("Skipping connector copied by a previous run",
+				zap.String("identifier", cn.Connector.Identifier),
+			)
+			c.api.Stats.IncrementConnectorsMoved()
+			if c.showPB {
+				bar.Add(1)
+			}
+			continue
+		}
+
+		if c.api.unchanged("Connector", cn.Connector.Identifier, cn.LastModifiedAt, c.logger) {
+			c.api.Stats.IncrementConnectorsMoved()
+			if c.showPB {
+				bar.Add(1)
+			}
+			continue
+		}
 
 		c.logger.Info("Processing connector",
 			zap.String("connector", cn.Connector.Name),
 			zap.String("targetProject", c.targetProject),
 		)
 
+		if c.api.Plan != nil {
+			var err error
+			if !cn.EntityValidityDetails.Valid && cn.EntityValidityDetails.InvalidYAML != nil {
+				err = fmt.Errorf("entity is marked as invalid in the source project")
+			}
+			c.api.Plan.Record("Connector", cn.Connector.Identifier, cn.Connector.Name, existing[c.api.Rename.Identifier("Connector", cn.Connector.Identifier)], err)
+			if c.showPB {
+				bar.Add(1)
+			}
+			continue
+		}
+
+		if c.api.blocked("Connector", cn.Connector.Identifier, c.logger) {
+			if c.showPB {
+				bar.Add(1)
+			}
+			continue
+		}
+
+		identifier := cn.Connector.Identifier
+		if err := c.api.Rename.Object("Connector", cn); err != nil {
+			c.logger.Warn("Failed to rename connector", zap.Error(err))
+		}
+
 		cn.Connector.OrgIdentifier = c.targetOrg
 		cn.Connector.ProjectIdentifier = c.targetProject
 
 		err = c.api.addConnector(cn, c.logger)
+		err = c.api.resolveConflict("Connector", cn.Connector.Identifier, err, func() error {
+			return c.api.updateConnector(cn, c.logger)
+		}, c.logger)
 
 		if err != nil {
 			c.logger.Error("Failed to create connector",
 				zap.String("connector", cn.Connector.Name),
 				zap.Error(err),
 			)
+			c.api.Graph.Failed("Connector", identifier)
 		} else {
-			IncrementConnectorsMoved()
+			c.api.Stats.IncrementConnectorsMoved()
+			if err := c.api.Checkpoint.Record("Connector", identifier); err != nil {
+				c.logger.Warn("Failed to update checkpoint", zap.Error(err))
+			}
 		}
 		if c.showPB {
 			bar.Add(1)
@@ -94,54 +161,68 @@ func (api *ApiRequest) listConnectors(org, project string, logger *zap.Logger) (
 		zap.String("project", project),
 	)
 
-	IncrementApiCalls()
+	connectors := []*model.ConnectorContent{}
 
-	resp, err := api.Client.R().
-		SetHeader("x-api-key", api.Token).
-		SetHeader("Content-Type", "application/json").
-		SetQueryParams(map[string]string{
-			"accountIdentifier": api.Account,
-			"orgIdentifier":     org,
-			"projectIdentifier": project,
-			"pageSize":          "100",
-		}).
-		Post(api.BaseURL + CONNECTORLOOKUP)
-	if err != nil {
-		logger.Error("Failed to request to list of connectors",
-			zap.Error(err),
-		)
-		return nil, err
-	}
-	if resp.IsError() {
-		logger.Error("Error response from API when listing connectors",
-			zap.String("response",
-				resp.String(),
-			),
-		)
-		return nil, handleErrorResponse(resp)
-	}
+	err := api.paginate(PAGE_SIZE, func(page int) (listPage, error) {
+		api.Stats.IncrementApiCalls()
 
-	result := model.ConnectorListResult{}
-	err = json.Unmarshal(resp.Body(), &result)
-	if err != nil {
-		logger.Error("Failed to parse response from API",
-			zap.Error(err),
-		)
-		return nil, err
-	}
+		resp, err := api.Client.R().
+			AddRetryCondition(retryIdempotent).
+			SetHeader("x-api-key", api.Token).
+			SetHeader("Content-Type", "application/json").
+			SetQueryParams(map[string]string{
+				"accountIdentifier": api.Account,
+				"orgIdentifier":     org,
+				"projectIdentifier": project,
+				"pageIndex":         strconv.Itoa(page),
+				"pageSize":          strconv.Itoa(PAGE_SIZE),
+			}).
+			Post(api.BaseURL + CONNECTORLOOKUP)
+		if err != nil {
+			logger.Error("Failed to request to list of connectors",
+				zap.Error(err),
+			)
+			return listPage{}, err
+		}
+		if resp.IsError() {
+			logger.Error("Error response from API when listing connectors",
+				zap.String("response",
+					resp.String(),
+				),
+			)
+			return listPage{}, handleErrorResponse(resp)
+		}
 
-	connectors := []*model.ConnectorContent{}
-	for _, c := range result.Data.Content {
-		if !c.HarnessManaged {
-			newConnectors := c
-			connectors = append(connectors, &newConnectors)
-		} else {
-			logger.Warn("Skipping connector because it is managed by Harness.",
-				zap.String("connector", c.Connector.Name),
-				zap.String("status", c.Status.Status),
-				zap.Bool("harnessManaged", c.HarnessManaged),
+		result := model.ConnectorListResult{}
+		err = json.Unmarshal(resp.Body(), &result)
+		if err != nil {
+			logger.Error("Failed to parse response from API",
+				zap.Error(err),
 			)
+			return listPage{}, err
+		}
+
+		for _, c := range result.Data.Content {
+			if !c.HarnessManaged {
+				newConnectors := c
+				connectors = append(connectors, &newConnectors)
+			} else {
+				logger.Warn("Skipping connector because it is managed by Harness.",
+					zap.String("connector", c.Connector.Name),
+					zap.String("status", c.Status.Status),
+					zap.Bool("harnessManaged", c.HarnessManaged),
+				)
+			}
 		}
+
+		return listPage{
+			index: int(result.Data.PageIndex),
+			total: int(result.Data.TotalPages),
+			items: len(result.Data.Content),
+		}, nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return connectors, nil
@@ -154,7 +235,17 @@ func (api *ApiRequest) addConnector(connector *model.ConnectorContent, logger *z
 		zap.String("project", connector.Connector.ProjectIdentifier),
 	)
 
-	IncrementApiCalls()
+	// WHEN EXPORTING THE REQUEST IS WRITTEN TO THE BUNDLE INSTEAD OF BEING SENT
+	if api.Bundle != nil {
+		return api.Bundle.Add(model.BundleEntry{
+			Entity:      "Connector",
+			Identifier:  connector.Connector.Identifier,
+			Path:        CONNECTORCREATE,
+			ContentType: "application/json",
+		}, connector, nil)
+	}
+
+	api.Stats.IncrementApiCalls()
 
 	resp, err := api.Client.R().
 		SetHeader("x-api-key", api.Token).
@@ -176,11 +267,11 @@ func (api *ApiRequest) addConnector(connector *model.ConnectorContent, logger *z
 		var errorResponse map[string]interface{}
 		if err := json.Unmarshal(resp.Body(), &errorResponse); err == nil {
 			if code, ok := errorResponse["code"].(string); ok && code == "DUPLICATE_FIELD" {
-				// Log as a warning and skip the error
-				logger.Info("Duplicate connector found, ignoring error",
+				// The conflict strategy decides what happens to the existing entity
+				logger.Info("Duplicate connector found",
 					zap.String("connector", connector.Connector.Name),
 				)
-				return nil
+				return ErrAlreadyExists
 			}
 		} else {
 			logger.Error(
@@ -194,5 +285,16 @@ func (api *ApiRequest) addConnector(connector *model.ConnectorContent, logger *z
 		return handleErrorResponse(resp)
 	}
 
+	api.recordCreated(model.JournalEntry{
+		Entity:     "Connector",
+		Identifier: connector.Connector.Identifier,
+		Org:        connector.Connector.OrgIdentifier,
+		Project:    connector.Connector.ProjectIdentifier,
+	}, logger)
+
 	return nil
 }
+
+func (api *ApiRequest) updateConnector(connector *model.ConnectorContent, logger *zap.Logger) error {
+	return api.updateEntity(CONNECTORCREATE, "application/json", connector, nil, logger)
+}
@@ -1,538 +1,911 @@
 package services
 
-// Define counter variables
-var apiCalls int = 0
-
-var projects int = 0
+import (
+	"sync"
+
+	"harness-copy-project/model"
+)
+
+// Stats holds the counters of a single project copy. Every copy has its own Stats so
+// several projects can be copied at the same time without mixing their results.
+type Stats struct {
+	mu sync.Mutex
+
+	apiCalls               int
+	retries                int
+	conflicts              []model.ConflictEntry
+	created                []model.JournalEntry
+	remaps                 []model.RemapEntry
+	orgDependencies        []model.OrgDependencyEntry
+	blocked                []model.BlockedEntry
+	unchanged              int
+	bundleTotal            int
+	bundleMoved            int
+	connectorsTotal        int
+	connectorsMoved        int
+	environmentsTotal      int
+	environmentsMoved      int
+	environmentGroupsTotal int
+	environmentGroupsMoved int
+	featureFlagsTotal      int
+	featureFlagsMoved      int
+	fileStoresTotal        int
+	fileStoresMoved        int
+	infrastructureTotal    int
+	infrastructureMoved    int
+	inputSetTotal          int
+	inputSetMoved          int
+	overridesTotal         int
+	overridesMoved         int
+	pipelinesTotal         int
+	pipelinesMoved         int
+	resourceGroupsTotal    int
+	resourceGroupsMoved    int
+	roleAssignmentsTotal   int
+	roleAssignmentsMoved   int
+	rolesTotal             int
+	rolesMoved             int
+	secretsTotal           int
+	secretsMoved           int
+	secretsPendingValue    []string
+	servicesTotal          int
+	servicesMoved          int
+	serviceAccountsTotal   int
+	serviceAccountsMoved   int
+	tagsTotal              int
+	tagsMoved              int
+	targetGroupsTotal      int
+	targetGroupsMoved      int
+	targetsTotal           int
+	targetsMoved           int
+	templatesTotal         int
+	templatesMoved         int
+	triggersTotal          int
+	triggersMoved          int
+	userGroupsTotal        int
+	userGroupsMoved        int
+	usersTotal             int
+	usersMoved             int
+	variablesTotal         int
+	variablesMoved         int
+}
+
+func NewStats() *Stats {
+	return &Stats{}
+}
 
-var connectorsTotal int = 0
+// Define counter functions to increment and get values
 
-var connectorsMoved int = 0
+// API Calls
+func (s *Stats) IncrementApiCalls() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-var environmentsTotal int = 0
+	s.apiCalls++
+}
 
-var environmentsMoved int = 0
+func (s *Stats) GetApiCalls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-var environmentGroupsTotal int = 0
+	return s.apiCalls
+}
 
-var environmentGroupsMoved int = 0
+// Retries
+func (s *Stats) IncrementRetries() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-var featureFlagsTotal int = 0
+	s.retries++
+}
 
-var featureFlagsMoved int = 0
+func (s *Stats) GetRetries() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-var fileStoresTotal int = 0
+	return s.retries
+}
 
-var fileStoresMoved int = 0
+// Conflicts
+func (s *Stats) AddConflict(entry model.ConflictEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-var infrastructureTotal int = 0
+	s.conflicts = append(s.conflicts, entry)
+}
 
-var infrastructureMoved int = 0
+func (s *Stats) GetConflicts() []model.ConflictEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-var inputSetTotal int = 0
+	return append([]model.ConflictEntry{}, s.conflicts...)
+}
 
-var inputSetMoved int = 0
+// Entities created in the target
+func (s *Stats) AddCreated(entry model.JournalEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-var overridesTotal int = 0
+	s.created = append(s.created, entry)
+}
 
-var overridesMoved int = 0
+func (s *Stats) GetCreated() []model.JournalEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-var pipelinesTotal int = 0
+	return append([]model.JournalEntry{}, s.created...)
+}
 
-var pipelinesMoved int = 0
+// Remapped references
+func (s *Stats) AddRemap(entity, from, to string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-var resourceGroupsTotal int = 0
+	for i := range s.remaps {
+		if s.remaps[i].Entity == entity && s.remaps[i].From == from && s.remaps[i].To == to {
+			s.remaps[i].Count++
+			return
+		}
+	}
+	s.remaps = append(s.remaps, model.RemapEntry{Entity: entity, From: from, To: to, Count: 1})
+}
 
-var resourceGroupsMoved int = 0
+func (s *Stats) GetRemaps() []model.RemapEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-var roleAssignmentsTotal int = 0
+	return append([]model.RemapEntry{}, s.remaps...)
+}
 
-var roleAssignmentsMoved int = 0
+// Org dependencies
+func (s *Stats) AddOrgDependency(entry model.OrgDependencyEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-var rolesTotal int = 0
+	s.orgDependencies = append(s.orgDependencies, entry)
+}
 
-var rolesMoved int = 0
+func (s *Stats) GetOrgDependencies() []model.OrgDependencyEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-var servicesTotal int = 0
+	return append([]model.OrgDependencyEntry{}, s.orgDependencies...)
+}
 
-var servicesMoved int = 0
+// Entities skipped because a dependency failed
+func (s *Stats) AddBlocked(entry model.BlockedEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-var serviceAccountsTotal int = 0
+	s.blocked = append(s.blocked, entry)
+}
 
-var serviceAccountsMoved int = 0
+func (s *Stats) GetBlocked() []model.BlockedEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-var tagsTotal int = 0
+	return append([]model.BlockedEntry{}, s.blocked...)
+}
 
-var tagsMoved int = 0
+func (s *Stats) IncrementUnchanged() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-var targetGroupsTotal int = 0
+	s.unchanged++
+}
 
-var targetGroupsMoved int = 0
+func (s *Stats) GetUnchanged() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-var targetsTotal int = 0
+	return s.unchanged
+}
 
-var targetsMoved int = 0
+// Connectors
+func (s *Stats) IncrementConnectorsTotal() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-var templatesTotal int = 0
+	s.connectorsTotal++
+}
 
-var templatesMoved int = 0
+func (s *Stats) GetConnectorsTotal() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-var triggersTotal int = 0
+	return s.connectorsTotal
+}
 
-var triggersMoved int = 0
+func (s *Stats) GetConnectorsMoved() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-var userGroupsTotal int = 0
+	return s.connectorsMoved
+}
+func (s *Stats) IncrementConnectorsMoved() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-var userGroupsMoved int = 0
+	s.connectorsMoved++
+}
 
-var usersTotal int = 0
+// Environments
+func (s *Stats) IncrementEnvironmentsTotal() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-var usersMoved int = 0
+	s.environmentsTotal++
+}
 
-var variablesTotal int = 0
+func (s *Stats) GetEnvironmentsTotal() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-var variablesMoved int = 0
+	return s.environmentsTotal
+}
 
-// Define counter functions to increment and get values
+func (s *Stats) IncrementEnvironmentsMoved() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-// API Calls
-func IncrementApiCalls() {
-	apiCalls++
+	s.environmentsMoved++
 }
 
-func GetApiCalls() int {
-	return apiCalls
-}
+func (s *Stats) GetEnvironmentsMoved() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-// Projects
-func IncrementProjects() {
-	projects++
+	return s.environmentsMoved
 }
 
-func GetProjects() int {
-	return projects
-}
+// Environment Groups
+func (s *Stats) IncrementEnvironmentGroupsTotal() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-// Connectors
-func IncrementConnectorsTotal() {
-	connectorsTotal++
+	s.environmentGroupsTotal++
 }
 
-func GetConnectorsTotal() int {
-	return connectorsTotal
-}
+func (s *Stats) GetEnvironmentGroupsTotal() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-func GetConnectorsMoved() int {
-	return connectorsMoved
-}
-func IncrementConnectorsMoved() {
-	connectorsMoved++
+	return s.environmentGroupsTotal
 }
 
-// Environments
-func IncrementEnvironmentsTotal() {
-	environmentsTotal++
-}
+func (s *Stats) IncrementEnvironmentGroupsMoved() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-func GetEnvironmentsTotal() int {
-	return environmentsTotal
+	s.environmentGroupsMoved++
 }
 
-func IncrementEnvironmentsMoved() {
-	environmentsMoved++
-}
+func (s *Stats) GetEnvironmentGroupsMoved() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-func GetEnvironmentsMoved() int {
-	return environmentsMoved
+	return s.environmentGroupsMoved
 }
 
-// Environment Groups
-func IncrementEnvironmentGroupsTotal() {
-	environmentGroupsTotal++
-}
+// Feature Flags
+func (s *Stats) IncrementFeatureFlagsTotal() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-func GetEnvironmentGroupsTotal() int {
-	return environmentGroupsTotal
+	s.featureFlagsTotal++
 }
 
-func IncrementEnvironmentGroupsMoved() {
-	environmentGroupsMoved++
-}
+func (s *Stats) GetFeatureFlagsTotal() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-func GetEnvironmentGroupsMoved() int {
-	return environmentGroupsMoved
+	return s.featureFlagsTotal
 }
 
-// Feature Flags
-func IncrementFeatureFlagsTotal() {
-	featureFlagsTotal++
-}
+func (s *Stats) IncrementFeatureFlagsMoved() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-func GetFeatureFlagsTotal() int {
-	return featureFlagsTotal
+	s.featureFlagsMoved++
 }
 
-func IncrementFeatureFlagsMoved() {
-	featureFlagsMoved++
-}
+func (s *Stats) GetFeatureFlagsMoved() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-func GetFeatureFlagsMoved() int {
-	return featureFlagsMoved
+	return s.featureFlagsMoved
 }
 
 // File Stores
-func IncrementFileStoresTotal() {
-	fileStoresTotal++
+func (s *Stats) IncrementFileStoresTotal() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.fileStoresTotal++
 }
 
-func GetFileStoresTotal() int {
-	return fileStoresTotal
+func (s *Stats) GetFileStoresTotal() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.fileStoresTotal
 }
 
-func IncrementFileStoresMoved() {
-	fileStoresMoved++
+func (s *Stats) IncrementFileStoresMoved() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.fileStoresMoved++
 }
 
-func GetFileStoresMoved() int {
-	return fileStoresMoved
+func (s *Stats) GetFileStoresMoved() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.fileStoresMoved
 }
 
 // Input Sets
-func IncrementInputSetsTotal() {
-	inputSetTotal++
+func (s *Stats) IncrementInputSetsTotal() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.inputSetTotal++
 }
 
-func GetInputSetsTotal() int {
-	return inputSetTotal
+func (s *Stats) GetInputSetsTotal() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.inputSetTotal
 }
 
-func IncrementInputSetsMoved() {
-	inputSetMoved++
+func (s *Stats) IncrementInputSetsMoved() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.inputSetMoved++
 }
 
-func GetInputSetsMoved() int {
-	return inputSetMoved
+func (s *Stats) GetInputSetsMoved() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.inputSetMoved
 }
 
 // Infrastructure
-func IncrementInfrastructureTotal() {
-	infrastructureTotal++
+func (s *Stats) IncrementInfrastructureTotal() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.infrastructureTotal++
 }
 
-func GetInfrastructureTotal() int {
-	return infrastructureTotal
+func (s *Stats) GetInfrastructureTotal() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.infrastructureTotal
 }
 
-func IncrementInfrastructureMoved() {
-	infrastructureMoved++
+func (s *Stats) IncrementInfrastructureMoved() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.infrastructureMoved++
 }
 
-func GetInfrastructureMoved() int {
-	return infrastructureMoved
+func (s *Stats) GetInfrastructureMoved() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.infrastructureMoved
 }
 
 // Overrides
-func IncrementOverridesTotal() {
-	overridesTotal++
+func (s *Stats) IncrementOverridesTotal() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.overridesTotal++
 }
 
-func GetOverridesTotal() int {
-	return overridesTotal
+func (s *Stats) GetOverridesTotal() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.overridesTotal
 }
 
-func IncrementOverridesMoved() {
-	overridesMoved++
+func (s *Stats) IncrementOverridesMoved() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.overridesMoved++
 }
 
-func GetOverridesMoved() int {
-	return overridesMoved
+func (s *Stats) GetOverridesMoved() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.overridesMoved
 }
 
 // Pipelines
-func IncrementPipelinesTotal() {
-	pipelinesTotal++
+func (s *Stats) IncrementPipelinesTotal() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pipelinesTotal++
 }
 
-func GetPipelinesTotal() int {
-	return pipelinesTotal
+func (s *Stats) GetPipelinesTotal() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.pipelinesTotal
 }
 
-func IncrementPipelinesMoved() {
-	pipelinesMoved++
+func (s *Stats) IncrementPipelinesMoved() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pipelinesMoved++
 }
 
-func GetPipelinesMoved() int {
-	return pipelinesMoved
+func (s *Stats) GetPipelinesMoved() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.pipelinesMoved
 }
 
 // Resource Groups
-func IncrementResourceGroupsTotal() {
-	resourceGroupsTotal++
+func (s *Stats) IncrementResourceGroupsTotal() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.resourceGroupsTotal++
 }
 
-func GetResourceGroupsTotal() int {
-	return resourceGroupsTotal
+func (s *Stats) GetResourceGroupsTotal() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.resourceGroupsTotal
 }
 
-func IncrementResourceGroupsMoved() {
-	resourceGroupsMoved++
+func (s *Stats) IncrementResourceGroupsMoved() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.resourceGroupsMoved++
 }
 
-func GetResourceGroupsMoved() int {
-	return resourceGroupsMoved
+func (s *Stats) GetResourceGroupsMoved() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.resourceGroupsMoved
 }
 
 // Role Assignments
-func IncrementRoleAssignmentsTotal() {
-	roleAssignmentsTotal++
+func (s *Stats) IncrementRoleAssignmentsTotal() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.roleAssignmentsTotal++
 }
 
-func GetRoleAssignmentsTotal() int {
-	return roleAssignmentsTotal
+func (s *Stats) GetRoleAssignmentsTotal() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.roleAssignmentsTotal
 }
 
-func IncrementRoleAssignmentsMoved() {
-	roleAssignmentsMoved++
+func (s *Stats) IncrementRoleAssignmentsMoved() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.roleAssignmentsMoved++
 }
 
-func GetRoleAssignmentsMoved() int {
-	return roleAssignmentsMoved
+func (s *Stats) GetRoleAssignmentsMoved() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.roleAssignmentsMoved
 }
 
 // Roles
-func IncrementRolesTotal() {
-	rolesTotal++
+func (s *Stats) IncrementRolesTotal() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rolesTotal++
+}
+
+func (s *Stats) GetRolesTotal() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.rolesTotal
+}
+
+func (s *Stats) IncrementRolesMoved() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rolesMoved++
+}
+
+func (s *Stats) GetRolesMoved() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.rolesMoved
+}
+
+// Secrets
+func (s *Stats) IncrementSecretsTotal() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.secretsTotal++
 }
 
-func GetRolesTotal() int {
-	return rolesTotal
+func (s *Stats) GetSecretsTotal() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.secretsTotal
+}
+
+func (s *Stats) IncrementSecretsMoved() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.secretsMoved++
+}
+
+func (s *Stats) GetSecretsMoved() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.secretsMoved
 }
 
-func IncrementRolesMoved() {
-	rolesMoved++
+func (s *Stats) AddSecretPendingValue(identifier string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.secretsPendingValue = append(s.secretsPendingValue, identifier)
 }
 
-func GetRolesMoved() int {
-	return rolesMoved
+func (s *Stats) GetSecretsPendingValue() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string{}, s.secretsPendingValue...)
 }
 
 // Services
-func IncrementServicesTotal() {
-	servicesTotal++
+func (s *Stats) IncrementServicesTotal() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.servicesTotal++
 }
 
-func GetServicesTotal() int {
-	return servicesTotal
+func (s *Stats) GetServicesTotal() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.servicesTotal
 }
 
-func IncrementServicesMoved() {
-	servicesMoved++
+func (s *Stats) IncrementServicesMoved() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.servicesMoved++
 }
 
-func GetServicesMoved() int {
-	return servicesMoved
+func (s *Stats) GetServicesMoved() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.servicesMoved
 }
 
 // Service Accounts
-func IncrementServiceAccountsTotal() {
-	serviceAccountsTotal++
+func (s *Stats) IncrementServiceAccountsTotal() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.serviceAccountsTotal++
 }
 
-func GetServiceAccountsTotal() int {
-	return serviceAccountsTotal
+func (s *Stats) GetServiceAccountsTotal() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.serviceAccountsTotal
 }
 
-func IncrementServiceAccountsMoved() {
-	serviceAccountsMoved++
+func (s *Stats) IncrementServiceAccountsMoved() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.serviceAccountsMoved++
 }
 
-func GetServiceAccountsMoved() int {
-	return serviceAccountsMoved
+func (s *Stats) GetServiceAccountsMoved() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.serviceAccountsMoved
 }
 
 // Tags
-func IncrementTagsTotal() {
-	tagsTotal++
+func (s *Stats) IncrementTagsTotal() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tagsTotal++
 }
 
-func GetTagsTotal() int {
-	return tagsTotal
+func (s *Stats) GetTagsTotal() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.tagsTotal
 }
 
-func IncrementTagsMoved() {
-	tagsMoved++
+func (s *Stats) IncrementTagsMoved() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tagsMoved++
 }
 
-func GetTagsMoved() int {
-	return tagsMoved
+func (s *Stats) GetTagsMoved() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.tagsMoved
 }
 
 // Target Groups
-func IncrementTargetGroupsTotal() {
-	targetGroupsTotal++
+func (s *Stats) IncrementTargetGroupsTotal() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.targetGroupsTotal++
 }
 
-func GetTargetGroupsTotal() int {
-	return targetGroupsTotal
+func (s *Stats) GetTargetGroupsTotal() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.targetGroupsTotal
 }
 
-func IncrementTargetGroupsMoved() {
-	targetGroupsMoved++
+func (s *Stats) IncrementTargetGroupsMoved() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.targetGroupsMoved++
 }
 
-func GetTargetGroupsMoved() int {
-	return targetGroupsMoved
+func (s *Stats) GetTargetGroupsMoved() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.targetGroupsMoved
 }
 
 // Targets
-func IncrementTargetsTotal() {
-	targetsTotal++
+func (s *Stats) IncrementTargetsTotal() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.targetsTotal++
 }
 
-func GetTargetsTotal() int {
-	return targetsTotal
+func (s *Stats) GetTargetsTotal() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.targetsTotal
 }
 
-func IncrementTargetsMoved() {
-	targetsMoved++
+func (s *Stats) IncrementTargetsMoved() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.targetsMoved++
 }
 
-func GetTargetsMoved() int {
-	return targetsMoved
+func (s *Stats) GetTargetsMoved() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.targetsMoved
 }
 
 // Templates
-func IncrementTemplatesTotal() {
-	templatesTotal++
+func (s *Stats) IncrementTemplatesTotal() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.templatesTotal++
 }
 
-func GetTemplatesTotal() int {
-	return templatesTotal
+func (s *Stats) GetTemplatesTotal() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.templatesTotal
 }
 
-func IncrementTemplatesMoved() {
-	templatesMoved++
+func (s *Stats) IncrementTemplatesMoved() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.templatesMoved++
 }
 
-func GetTemplatesMoved() int {
-	return templatesMoved
+func (s *Stats) GetTemplatesMoved() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.templatesMoved
 }
 
 // Triggers
-func IncrementTriggersTotal() {
-	triggersTotal++
+func (s *Stats) IncrementTriggersTotal() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.triggersTotal++
 }
 
-func GetTriggersTotal() int {
-	return triggersTotal
+func (s *Stats) GetTriggersTotal() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.triggersTotal
 }
 
-func IncrementTriggersMoved() {
-	triggersMoved++
+func (s *Stats) IncrementTriggersMoved() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.triggersMoved++
 }
 
-func GetTriggersMoved() int {
-	return triggersMoved
+func (s *Stats) GetTriggersMoved() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.triggersMoved
 }
 
 // User Groups
-func IncrementUserGroupsTotal() {
-	userGroupsTotal++
+func (s *Stats) IncrementUserGroupsTotal() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.userGroupsTotal++
 }
 
-func GetUserGroupsTotal() int {
-	return userGroupsTotal
+func (s *Stats) GetUserGroupsTotal() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.userGroupsTotal
 }
 
-func IncrementUserGroupsMoved() {
-	userGroupsMoved++
+func (s *Stats) IncrementUserGroupsMoved() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.userGroupsMoved++
 }
 
-func GetUserGroupsMoved() int {
-	return userGroupsMoved
+func (s *Stats) GetUserGroupsMoved() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.userGroupsMoved
 }
 
 // Users
-func IncrementUsersTotal() {
-	usersTotal++
+func (s *Stats) IncrementUsersTotal() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.usersTotal++
 }
 
-func GetUsersTotal() int {
-	return usersTotal
+func (s *Stats) GetUsersTotal() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.usersTotal
 }
 
-func IncrementUsersMoved() {
-	usersMoved++
+func (s *Stats) IncrementUsersMoved() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.usersMoved++
 }
 
-func GetUsersMoved() int {
-	return usersMoved
+func (s *Stats) GetUsersMoved() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.usersMoved
 }
 
 // Variables
-func IncrementVariablesTotal() {
-	variablesTotal++
-}
-
-func GetVariablesTotal() int {
-	return variablesTotal
-}
-
-func IncrementVariablesMoved() {
-	variablesMoved++
-}
-
-func GetVariablesMoved() int {
-	return variablesMoved
-}
-
-// Resets all counters
-
-func ResetAllCounters() {
-	apiCalls = 0
-	projects = 0
-	connectorsTotal = 0
-	connectorsMoved = 0
-	environmentsTotal = 0
-	environmentsMoved = 0
-	environmentGroupsTotal = 0
-	environmentGroupsMoved = 0
-	featureFlagsTotal = 0
-	featureFlagsMoved = 0
-	fileStoresTotal = 0
-	fileStoresMoved = 0
-	infrastructureTotal = 0
-	infrastructureMoved = 0
-	inputSetTotal = 0
-	inputSetMoved = 0
-	overridesTotal = 0
-	overridesMoved = 0
-	pipelinesTotal = 0
-	pipelinesMoved = 0
-	resourceGroupsTotal = 0
-	resourceGroupsMoved = 0
-	roleAssignmentsTotal = 0
-	roleAssignmentsMoved = 0
-	rolesTotal = 0
-	rolesMoved = 0
-	servicesTotal = 0
-	servicesMoved = 0
-	serviceAccountsTotal = 0
-	serviceAccountsMoved = 0
-	tagsTotal = 0
-	tagsMoved = 0
-	targetGroupsTotal = 0
-	targetGroupsMoved = 0
-	targetsTotal = 0
-	targetsMoved = 0
-	templatesTotal = 0
-	templatesMoved = 0
-	triggersTotal = 0
-	triggersMoved = 0
-	userGroupsTotal = 0
-	userGroupsMoved = 0
-	usersTotal = 0
-	usersMoved = 0
-	variablesTotal = 0
-	variablesMoved = 0
+func (s *Stats) IncrementVariablesTotal() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.variablesTotal++
+}
+
+func (s *Stats) GetVariablesTotal() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.variablesTotal
+}
+
+func (s *Stats) IncrementVariablesMoved() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.variablesMoved++
+}
+
+func (s *Stats) GetVariablesMoved() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.variablesMoved
+}
+
+// Bundle
+func (s *Stats) IncrementBundleTotal() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.bundleTotal++
+}
+
+func (s *Stats) GetBundleTotal() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.bundleTotal
+}
+
+func (s *Stats) IncrementBundleMoved() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.bundleMoved++
+}
+
+func (s *Stats) GetBundleMoved() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.bundleMoved
 }
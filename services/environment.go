@@ -2,6 +2,7 @@ package services
 
 import (
 	"encoding/json"
+	"strconv"
 
 	"github.com/schollz/progressbar/v3"
 	"go.uber.org/zap"
@@ -36,7 +37,7 @@ func (c EnvironmentContext) Copy() error {
 		zap.String("project", c.sourceProject),
 	)
 
-	envs, err := c.api.listEnvironments(c.sourceOrg, c.sourceProject, c.logger)
+	envs, err := c.api.source().listEnvironments(c.sourceOrg, c.sourceProject, c.logger)
 	if err != nil {
 		c.logger.Error("Failed to retrive environments for ",
 			zap.String("Project", c.sourceProject),
@@ -45,6 +46,21 @@ func (c EnvironmentContext) Copy() error {
 		return nil
 	}
 
+	existing := map[string]bool{}
+	if c.api.Plan != nil {
+		targetEnvs, err := c.api.listEnvironments(c.targetOrg, c.targetProject, c.logger)
+		if err != nil {
+			c.logger.Error("Failed to list the environments of the target project",
+				zap.String("Project", c.targetProject),
+				zap.Error(err),
+			)
+			return err
+		}
+		for _, env := range targetEnvs {
+			existing[env.Environment.Identifier] = true
+		}
+	}
+
 	var bar *progressbar.ProgressBar
 
 	if c.showPB {
@@ -54,31 +70,73 @@ func (c EnvironmentContext) Copy() error {
 	for _, env := range envs {
 		e := env.Environment
 
-		IncrementEnvironmentsTotal()
+		c.api.Stats.IncrementEnvironmentsTotal()
+
+		if c.api.Checkpoint.Done("Environment", e.Identifier) {
+			c.logger.Info("Skipping environment copied by a previous run",
+				zap.String("identifier", e.Identifier),
+			)
+			c.api.Stats.IncrementEnvironmentsMoved()
+			if c.showPB {
+				bar.Add(1)
+			}
+			continue
+		}
+
+		if c.api.unchanged("Environment", e.Identifier, env.LastModifiedAt, c.logger) {
+			c.api.Stats.IncrementEnvironmentsMoved()
+			if c.showPB {
+				bar.Add(1)
+			}
+			continue
+		}
 
 		c.logger.Info("Processing environments",
 			zap.String("environemnt", e.Name),
 			zap.String("targetProject", c.targetProject),
 		)
 
-		newYaml := updateYaml(e.Yaml, c.targetOrg, c.targetProject)
+		if c.api.Plan != nil {
+			c.api.Plan.Record("Environment", e.Identifier, e.Name, existing[c.api.Rename.Identifier("Environment", e.Identifier)], checkYaml(e.Yaml))
+			if c.showPB {
+				bar.Add(1)
+			}
+			continue
+		}
+
+		if c.api.blocked("Environment", e.Identifier, c.logger) {
+			if c.showPB {
+				bar.Add(1)
+			}
+			continue
+		}
+
+		newYaml := c.api.Rename.Yaml(updateYaml(e.Yaml, c.targetOrg, c.targetProject))
 		req := &model.CreateEnvironmentRequest{
 			OrgIdentifier:     c.targetOrg,
 			ProjectIdentifier: c.targetProject,
-			Identifier:        e.Identifier,
-			Name:              e.Name,
+			Identifier:        c.api.Rename.Identifier("Environment", e.Identifier),
+			Name:              c.api.Rename.Name("Environment", e.Name),
 			Description:       e.Description,
 			Color:             e.Color,
 			Type:              e.Type,
 			Yaml:              newYaml,
 		}
-		if err := c.api.createEnvironment(req, c.logger); err != nil {
+		err := c.api.createEnvironment(req, c.logger)
+		err = c.api.resolveConflict("Environment", e.Identifier, err, func() error {
+			return c.api.updateEnvironment(req, c.logger)
+		}, c.logger)
+		if err != nil {
 			c.logger.Error("Failed to create environment. ",
 				zap.String("environment name", e.Name),
 				zap.Error(err),
 			)
+			c.api.Graph.Failed("Environment", e.Identifier)
 		} else {
-			IncrementEnvironmentsMoved()
+			c.api.Stats.IncrementEnvironmentsMoved()
+			if err := c.api.Checkpoint.Record("Environment", e.Identifier); err != nil {
+				c.logger.Warn("Failed to update checkpoint", zap.Error(err))
+			}
 		}
 		if c.showPB {
 			bar.Add(1)
@@ -98,44 +156,60 @@ func (api *ApiRequest) listEnvironments(org, project string, logger *zap.Logger)
 		zap.String("project", project),
 	)
 
-	IncrementApiCalls()
+	environments := []*model.ListEnvironmentContent{}
 
-	resp, err := api.Client.R().
-		SetHeader("x-api-key", api.Token).
-		SetHeader("Content-Type", "application/json").
-		SetQueryParams(map[string]string{
-			"accountIdentifier": api.Account,
-			"orgIdentifier":     org,
-			"projectIdentifier": project,
-			"size":              "1000",
-		}).
-		Get(api.BaseURL + "/ng/api/environmentsV2")
-	if err != nil {
-		logger.Error("Failed to request to list of environments",
-			zap.Error(err),
-		)
-		return nil, err
-	}
-	if resp.IsError() {
-		logger.Error(
-			"Error response from API when listing environments",
-			zap.String("response",
-				resp.String(),
-			),
-		)
-		return nil, handleErrorResponse(resp)
-	}
+	err := api.paginate(PAGE_SIZE, func(page int) (listPage, error) {
+		api.Stats.IncrementApiCalls()
+
+		resp, err := api.Client.R().
+			SetHeader("x-api-key", api.Token).
+			SetHeader("Content-Type", "application/json").
+			SetQueryParams(map[string]string{
+				"accountIdentifier": api.Account,
+				"orgIdentifier":     org,
+				"projectIdentifier": project,
+				"page":              strconv.Itoa(page),
+				"size":              strconv.Itoa(PAGE_SIZE),
+			}).
+			Get(api.BaseURL + "/ng/api/environmentsV2")
+		if err != nil {
+			logger.Error("Failed to request to list of environments",
+				zap.Error(err),
+			)
+			return listPage{}, err
+		}
+		if resp.IsError() {
+			logger.Error(
+				"Error response from API when listing environments",
+				zap.String("response",
+					resp.String(),
+				),
+			)
+			return listPage{}, handleErrorResponse(resp)
+		}
+
+		result := model.ListEnvironmentResponse{}
+		err = json.Unmarshal(resp.Body(), &result)
+		if err != nil {
+			logger.Error("Failed to parse response from API",
+				zap.Error(err),
+			)
+			return listPage{}, err
+		}
+
+		environments = append(environments, result.Data.Content...)
 
-	result := model.ListEnvironmentResponse{}
-	err = json.Unmarshal(resp.Body(), &result)
+		return listPage{
+			index: int(result.Data.PageIndex),
+			total: int(result.Data.TotalPages),
+			items: len(result.Data.Content),
+		}, nil
+	})
 	if err != nil {
-		logger.Error("Failed to parse response from API",
-			zap.Error(err),
-		)
 		return nil, err
 	}
 
-	return result.Data.Content, nil
+	return environments, nil
 }
 
 func (api *ApiRequest) createEnvironment(env *model.CreateEnvironmentRequest, logger *zap.Logger) error {
@@ -145,7 +219,16 @@ func (api *ApiRequest) createEnvironment(env *model.CreateEnvironmentRequest, lo
 		zap.String("project", env.ProjectIdentifier),
 	)
 
-	IncrementApiCalls()
+	if api.Bundle != nil {
+		return api.Bundle.Add(model.BundleEntry{
+			Entity:      "Environment",
+			Identifier:  env.Identifier,
+			Path:        "/ng/api/environmentsV2",
+			ContentType: "application/json",
+		}, env, nil)
+	}
+
+	api.Stats.IncrementApiCalls()
 
 	resp, err := api.Client.R().
 		SetHeader("x-api-key", api.Token).
@@ -166,11 +249,11 @@ func (api *ApiRequest) createEnvironment(env *model.CreateEnvironmentRequest, lo
 		var errorResponse map[string]interface{}
 		if err := json.Unmarshal(resp.Body(), &errorResponse); err == nil {
 			if code, ok := errorResponse["code"].(string); ok && code == "DUPLICATE_FIELD" {
-				// Log as a warning and skip the error
-				logger.Info("Duplicate environment found, ignoring error",
+				// The conflict strategy decides what happens to the existing entity
+				logger.Info("Duplicate environment found",
 					zap.String("connectorName", env.Name),
 				)
-				return nil
+				return ErrAlreadyExists
 			}
 		} else {
 			logger.Error(
@@ -184,6 +267,13 @@ func (api *ApiRequest) createEnvironment(env *model.CreateEnvironmentRequest, lo
 		return handleErrorResponse(resp)
 	}
 
+	api.recordCreated(model.JournalEntry{
+		Entity:     "Environment",
+		Identifier: env.Identifier,
+		Org:        env.OrgIdentifier,
+		Project:    env.ProjectIdentifier,
+	}, logger)
+
 	return nil
 }
 
@@ -191,3 +281,7 @@ func (api *ApiRequest) createEnvironment(env *model.CreateEnvironmentRequest, lo
 // func sanitizeEnvYaml(yaml string) string {
 // 	return strings.ReplaceAll(yaml, "\"", "")
 // }
+
+func (api *ApiRequest) updateEnvironment(env *model.CreateEnvironmentRequest, logger *zap.Logger) error {
+	return api.updateEntity("/ng/api/environmentsV2", "application/json", env, nil, logger)
+}
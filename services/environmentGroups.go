@@ -2,6 +2,7 @@ package services
 
 import (
 	"encoding/json"
+	"strconv"
 
 	"github.com/schollz/progressbar/v3"
 	"go.uber.org/zap"
@@ -40,7 +41,7 @@ func (c EnvGroupContext) Copy() error {
 	)
 
 	// Leveraging listPipelines func from pipeline.go file
-	envGroups, err := c.api.listEnvGroups(c.sourceOrg, c.sourceProject, c.logger)
+	envGroups, err := c.api.source().listEnvGroups(c.sourceOrg, c.sourceProject, c.logger)
 	if err != nil {
 		c.logger.Error("Failed to retrive environment groups",
 			zap.String("Project", c.sourceProject),
@@ -49,6 +50,21 @@ func (c EnvGroupContext) Copy() error {
 		return err
 	}
 
+	existing := map[string]bool{}
+	if c.api.Plan != nil {
+		targetEnvGroups, err := c.api.listEnvGroups(c.targetOrg, c.targetProject, c.logger)
+		if err != nil {
+			c.logger.Error("Failed to list the environment groups of the target project",
+				zap.String("Project", c.targetProject),
+				zap.Error(err),
+			)
+			return err
+		}
+		for _, eg := range targetEnvGroups {
+			existing[eg.EnvGroup.Identifier] = true
+		}
+	}
+
 	var bar *progressbar.ProgressBar
 
 	if c.showPB {
@@ -57,31 +73,72 @@ func (c EnvGroupContext) Copy() error {
 
 	for _, eg := range envGroups {
 
-		IncrementEnvironmentGroupsTotal()
+		c.api.Stats.IncrementEnvironmentGroupsTotal()
+
+		if c.api.Checkpoint.Done("Environment Group", eg.EnvGroup.Identifier) {
+			c.logger.Info("Skipping environment group copied by a previous run",
+				zap.String("identifier", eg.EnvGroup.Identifier),
+			)
+			c.api.Stats.IncrementEnvironmentGroupsMoved()
+			if c.showPB {
+				bar.Add(1)
+			}
+			continue
+		}
+
+		if c.api.unchanged("Environment Group", eg.EnvGroup.Identifier, eg.LastModifiedAt, c.logger) {
+			c.api.Stats.IncrementEnvironmentGroupsMoved()
+			if c.showPB {
+				bar.Add(1)
+			}
+			continue
+		}
 
 		c.logger.Info("Processing environment group",
 			zap.String("environment group", eg.EnvGroup.Name),
 			zap.String("target project", c.targetProject),
 		)
 
+		if c.api.Plan != nil {
+			c.api.Plan.Record("Environment Group", eg.EnvGroup.Identifier, eg.EnvGroup.Name, existing[c.api.Rename.Identifier("Environment Group", eg.EnvGroup.Identifier)], checkYaml(eg.EnvGroup.YAML))
+			if c.showPB {
+				bar.Add(1)
+			}
+			continue
+		}
+
+		if c.api.blocked("Environment Group", eg.EnvGroup.Identifier, c.logger) {
+			if c.showPB {
+				bar.Add(1)
+			}
+			continue
+		}
+
 		e := model.CreateEnvGroup{}
 
-		newYaml := updateYaml(eg.EnvGroup.YAML, c.targetOrg, c.targetProject)
+		newYaml := c.api.Rename.Yaml(updateYaml(eg.EnvGroup.YAML, c.targetOrg, c.targetProject))
 		e.OrgIdentifier = c.targetOrg
 		e.ProjectIdentifier = c.targetProject
 		e.Color = eg.EnvGroup.Color
-		e.Identifier = eg.EnvGroup.Identifier
+		e.Identifier = c.api.Rename.Identifier("Environment Group", eg.EnvGroup.Identifier)
 		e.YAML = newYaml
 
 		err = c.api.createEnvGroup(e, c.logger)
+		err = c.api.resolveConflict("Environment Group", e.Identifier, err, func() error {
+			return c.api.updateEnvGroup(e, c.logger)
+		}, c.logger)
 
 		if err != nil {
 			c.logger.Error("Failed to create environment group",
 				zap.String("environment group", eg.EnvGroup.Name),
 				zap.Error(err),
 			)
+			c.api.Graph.Failed("Environment Group", eg.EnvGroup.Identifier)
 		} else {
-			IncrementEnvironmentGroupsMoved()
+			c.api.Stats.IncrementEnvironmentGroupsMoved()
+			if err := c.api.Checkpoint.Record("Environment Group", eg.EnvGroup.Identifier); err != nil {
+				c.logger.Warn("Failed to update checkpoint", zap.Error(err))
+			}
 		}
 		if c.showPB {
 			bar.Add(1)
@@ -101,43 +158,60 @@ func (api *ApiRequest) listEnvGroups(org, project string, logger *zap.Logger) ([
 		zap.String("project", project),
 	)
 
-	IncrementApiCalls()
+	envGroups := []model.EnvGroupContent{}
 
-	resp, err := api.Client.R().
-		SetHeader("x-api-key", api.Token).
-		SetHeader("Content-Type", "application/json").
-		SetQueryParams(map[string]string{
-			"accountIdentifier": api.Account,
-			"orgIdentifier":     org,
-			"projectIdentifier": project,
-			"size":              "100",
-		}).
-		Post(api.BaseURL + ENVGROUPLIST)
-	if err != nil {
-		logger.Error("Failed to request to list of environment groups",
-			zap.Error(err),
-		)
-		return nil, err
-	}
-	if resp.IsError() {
-		logger.Error("Error response from API when listing environment groups",
-			zap.String("response",
-				resp.String(),
-			),
-		)
-		return nil, handleErrorResponse(resp)
-	}
+	err := api.paginate(PAGE_SIZE, func(page int) (listPage, error) {
+		api.Stats.IncrementApiCalls()
 
-	result := model.GetEnvGroupResponse{}
-	err = json.Unmarshal(resp.Body(), &result)
+		resp, err := api.Client.R().
+			AddRetryCondition(retryIdempotent).
+			SetHeader("x-api-key", api.Token).
+			SetHeader("Content-Type", "application/json").
+			SetQueryParams(map[string]string{
+				"accountIdentifier": api.Account,
+				"orgIdentifier":     org,
+				"projectIdentifier": project,
+				"page":              strconv.Itoa(page),
+				"size":              strconv.Itoa(PAGE_SIZE),
+			}).
+			Post(api.BaseURL + ENVGROUPLIST)
+		if err != nil {
+			logger.Error("Failed to request to list of environment groups",
+				zap.Error(err),
+			)
+			return listPage{}, err
+		}
+		if resp.IsError() {
+			logger.Error("Error response from API when listing environment groups",
+				zap.String("response",
+					resp.String(),
+				),
+			)
+			return listPage{}, handleErrorResponse(resp)
+		}
+
+		result := model.GetEnvGroupResponse{}
+		err = json.Unmarshal(resp.Body(), &result)
+		if err != nil {
+			logger.Error("Failed to parse response from API",
+				zap.Error(err),
+			)
+			return listPage{}, err
+		}
+
+		envGroups = append(envGroups, result.Data.Content...)
+
+		return listPage{
+			index: int(result.Data.PageIndex),
+			total: int(result.Data.TotalPages),
+			items: len(result.Data.Content),
+		}, nil
+	})
 	if err != nil {
-		logger.Error("Failed to parse response from API",
-			zap.Error(err),
-		)
 		return nil, err
 	}
 
-	return result.Data.Content, nil
+	return envGroups, nil
 }
 
 func (api *ApiRequest) createEnvGroup(envGroup model.CreateEnvGroup, logger *zap.Logger) error {
@@ -147,7 +221,16 @@ func (api *ApiRequest) createEnvGroup(envGroup model.CreateEnvGroup, logger *zap
 		zap.String("project", envGroup.ProjectIdentifier),
 	)
 
-	IncrementApiCalls()
+	if api.Bundle != nil {
+		return api.Bundle.Add(model.BundleEntry{
+			Entity:      "Environment Group",
+			Identifier:  envGroup.Identifier,
+			Path:        ENVGROUP,
+			ContentType: "application/json",
+		}, envGroup, nil)
+	}
+
+	api.Stats.IncrementApiCalls()
 
 	resp, err := api.Client.R().
 		SetHeader("x-api-key", api.Token).
@@ -169,11 +252,11 @@ func (api *ApiRequest) createEnvGroup(envGroup model.CreateEnvGroup, logger *zap
 		var errorResponse map[string]interface{}
 		if err := json.Unmarshal(resp.Body(), &errorResponse); err == nil {
 			if code, ok := errorResponse["code"].(string); ok && code == "DUPLICATE_FIELD" {
-				// Log as a warning and skip the error
-				logger.Info("Duplicate environment group found, ignoring error",
+				// The conflict strategy decides what happens to the existing entity
+				logger.Info("Duplicate environment group found",
 					zap.String("environment group", envGroup.Identifier),
 				)
-				return nil
+				return ErrAlreadyExists
 			}
 		} else {
 			logger.Error(
@@ -187,5 +270,19 @@ func (api *ApiRequest) createEnvGroup(envGroup model.CreateEnvGroup, logger *zap
 		return handleErrorResponse(resp)
 	}
 
+	api.recordCreated(model.JournalEntry{
+		Entity:     "Environment Group",
+		Identifier: envGroup.Identifier,
+		Org:        envGroup.OrgIdentifier,
+		Project:    envGroup.ProjectIdentifier,
+	}, logger)
+
 	return nil
 }
+
+func (api *ApiRequest) updateEnvGroup(envGroup model.CreateEnvGroup, logger *zap.Logger) error {
+	return api.updateEntity(ENVGROUP+"/"+envGroup.Identifier, "application/json", envGroup, map[string]string{
+		"orgIdentifier":     envGroup.OrgIdentifier,
+		"projectIdentifier": envGroup.ProjectIdentifier,
+	}, logger)
+}
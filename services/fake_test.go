@@ -0,0 +1,802 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+	"gopkg.in/yaml.v3"
+	"harness-copy-project/model"
+)
+
+// fakeHarness is an in-memory Harness account the tests copy projects against. It keeps
+// the pipelines, input sets, secrets, template versions and freeze windows of every project, creates, replaces
+// and deletes them the way the API does and records every request it receives. A test
+// handles any other endpoint with handle, the ones nobody handles answer with an empty
+// success.
+type fakeHarness struct {
+	server *httptest.Server
+	custom *http.ServeMux
+	routes *http.ServeMux
+
+	mu        sync.Mutex
+	pipelines map[string][]*fakePipeline
+	inputsets map[string][]*fakeInputset
+	secrets   map[string][]*fakeSecret
+	templates map[string][]*fakeTemplate
+	freezes   map[string]*model.FreezeResponseData
+	requests  []fakeRequest
+
+	// Identifiers whose create is rejected as invalid
+	invalid map[string]bool
+}
+
+// fakeRequest is a request received by the fake, with the status it was answered with
+type fakeRequest struct {
+	Method      string
+	Path        string
+	Query       url.Values
+	Token       string
+	Account     string
+	ContentType string
+	Body        string
+	Status      int
+}
+
+type fakePipeline struct {
+	Identifier   string
+	Name         string
+	Yaml         string
+	Modified     int64
+	StoreType    model.StoreType
+	ConnectorRef *string
+	GitDetails   *model.GitDetails
+	// Flagged by Harness as invalid
+	Invalid bool
+}
+
+type fakeInputset struct {
+	Identifier string
+	Yaml       string
+}
+
+// fakeSecret is a secret with the value it was created with, which is never listed
+type fakeSecret struct {
+	Secret model.Secret
+	Value  string
+}
+
+type fakeTemplate struct {
+	Identifier string
+	Version    string
+	Stable     bool
+	Yaml       string
+}
+
+func newFakeHarness(t *testing.T) *fakeHarness {
+	f := &fakeHarness{
+		custom:    http.NewServeMux(),
+		routes:    http.NewServeMux(),
+		pipelines: map[string][]*fakePipeline{},
+		inputsets: map[string][]*fakeInputset{},
+		secrets:   map[string][]*fakeSecret{},
+		templates: map[string][]*fakeTemplate{},
+		freezes:   map[string]*model.FreezeResponseData{},
+		invalid:   map[string]bool{},
+	}
+	f.routePipelines()
+	f.routeInputsets()
+	f.routeSecrets()
+	f.routeTemplates()
+	f.routeFreezes()
+
+	f.server = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.server.Close)
+
+	return f
+}
+
+// api returns a request to the account of the fake
+func (f *fakeHarness) api() *ApiRequest {
+	return &ApiRequest{
+		Client:  resty.New(),
+		Token:   "token",
+		Account: "account",
+		BaseURL: f.server.URL,
+		Stats:   NewStats(),
+	}
+}
+
+// handle serves an endpoint the fake does not serve, or serves it differently
+func (f *fakeHarness) handle(pattern string, handler http.HandlerFunc) {
+	f.custom.HandleFunc(pattern, handler)
+}
+
+func (f *fakeHarness) serve(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	if _, pattern := f.custom.Handler(r); pattern != "" {
+		f.custom.ServeHTTP(recorder, r)
+	} else if _, pattern := f.routes.Handler(r); pattern != "" {
+		f.routes.ServeHTTP(recorder, r)
+	} else {
+		recorder.Write([]byte(`{"status":"SUCCESS","data":{"content":[],"totalPages":1}}`))
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, fakeRequest{
+		Method:      r.Method,
+		Path:        r.URL.Path,
+		Query:       r.URL.Query(),
+		Token:       r.Header.Get("x-api-key"),
+		Account:     r.URL.Query().Get("accountIdentifier"),
+		ContentType: r.Header.Get("Content-Type"),
+		Body:        string(body),
+		Status:      recorder.status,
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
+
+// sent returns the requests received with the method on the path, in the order they
+// were received. An empty method or path matches every request.
+func (f *fakeHarness) sent(method, path string) []fakeRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	found := []fakeRequest{}
+	for _, r := range f.requests {
+		if (method == "" || r.Method == method) && (path == "" || r.Path == path) {
+			found = append(found, r)
+		}
+	}
+	return found
+}
+
+// changes returns every request that may have changed the account. Lists sent as a POST
+// only read it.
+func (f *fakeHarness) changes() []fakeRequest {
+	found := []fakeRequest{}
+	for _, r := range f.sent("", "") {
+		switch {
+		case r.Method == http.MethodGet:
+		case r.Method == http.MethodPost && (strings.HasSuffix(r.Path, "/list") || strings.HasSuffix(r.Path, "/listV2") || r.Path == LISTUSER):
+		default:
+			found = append(found, r)
+		}
+	}
+	return found
+}
+
+func scopeOf(r *http.Request) string {
+	return r.URL.Query().Get("orgIdentifier") + "/" + r.URL.Query().Get("projectIdentifier")
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"status": "ERROR", "code": code, "message": message})
+}
+
+// addPipeline adds an inline pipeline to a project
+func (f *fakeHarness) addPipeline(org, project, identifier, yaml string) *fakePipeline {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	p := &fakePipeline{Identifier: identifier, Name: identifier, Yaml: yaml, StoreType: model.Inline}
+	f.pipelines[org+"/"+project] = append(f.pipelines[org+"/"+project], p)
+	return p
+}
+
+// pipelineIdentifiers returns the identifiers of the pipelines of a project
+func (f *fakeHarness) pipelineIdentifiers(org, project string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	identifiers := []string{}
+	for _, p := range f.pipelines[org+"/"+project] {
+		identifiers = append(identifiers, p.Identifier)
+	}
+	return identifiers
+}
+
+func (f *fakeHarness) findPipeline(scope, identifier string) (int, *fakePipeline) {
+	for i, p := range f.pipelines[scope] {
+		if p.Identifier == identifier {
+			return i, p
+		}
+	}
+	return -1, nil
+}
+
+func (f *fakeHarness) routePipelines() {
+	f.routes.HandleFunc("POST "+LIST_PIPELINES, func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		size, _ := strconv.Atoi(r.URL.Query().Get("size"))
+		if size == 0 {
+			size = PAGE_SIZE
+		}
+
+		f.mu.Lock()
+		all := f.pipelines[scopeOf(r)]
+		content := []*model.PipelineListContent{}
+		for i := page * size; i < (page+1)*size && i < len(all); i++ {
+			content = append(content, &model.PipelineListContent{
+				Identifier:    all[i].Identifier,
+				Name:          all[i].Name,
+				LastUpdatedAt: all[i].Modified,
+				StoreType:     all[i].StoreType,
+			})
+		}
+		f.mu.Unlock()
+
+		json.NewEncoder(w).Encode(model.PipelineListResult{
+			Status: "SUCCESS",
+			Data: model.PipelineListData{
+				Content:    content,
+				TotalPages: int64((len(all) + size - 1) / size),
+				Number:     int64(page),
+				Size:       int64(size),
+			},
+		})
+	})
+
+	f.routes.HandleFunc("GET /pipeline/api/pipelines/{identifier}", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		_, p := f.findPipeline(scopeOf(r), r.PathValue("identifier"))
+		f.mu.Unlock()
+
+		if p == nil {
+			writeError(w, http.StatusNotFound, "ENTITY_NOT_FOUND", "pipeline not found")
+			return
+		}
+		validity := model.EntityValidityDetails{Valid: true}
+		if p.Invalid {
+			reason := "invalid yaml"
+			validity = model.EntityValidityDetails{InvalidYAML: &reason}
+		}
+		json.NewEncoder(w).Encode(model.PipelineGetResult{
+			Status: "SUCCESS",
+			Data: &model.PipelineGetData{
+				YAMLPipeline:          p.Yaml,
+				EntityValidityDetails: validity,
+				StoreType:             p.StoreType,
+				ConnectorRef:          p.ConnectorRef,
+				GitDetails:            p.GitDetails,
+			},
+		})
+	})
+
+	f.routes.HandleFunc("DELETE /pipeline/api/pipelines/{identifier}", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		scope := scopeOf(r)
+		i, p := f.findPipeline(scope, r.PathValue("identifier"))
+		if p == nil {
+			writeError(w, http.StatusNotFound, "ENTITY_NOT_FOUND", "pipeline not found")
+			return
+		}
+		f.pipelines[scope] = append(f.pipelines[scope][:i], f.pipelines[scope][i+1:]...)
+		w.Write([]byte(`{"status":"SUCCESS"}`))
+	})
+
+	f.routes.HandleFunc("POST "+CREATE_PIPELINE, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		identifier := yamlIdentifier(string(body))
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		scope := scopeOf(r)
+		if f.invalid[identifier] {
+			writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid yaml")
+			return
+		}
+		if _, p := f.findPipeline(scope, identifier); p != nil {
+			writeError(w, http.StatusBadRequest, "DUPLICATE_FIELD", fmt.Sprintf("Pipeline [%s] already exists", identifier))
+			return
+		}
+		f.pipelines[scope] = append(f.pipelines[scope], &fakePipeline{
+			Identifier: identifier,
+			Name:       identifier,
+			Yaml:       string(body),
+			StoreType:  model.Inline,
+		})
+		w.Write([]byte(`{"status":"SUCCESS"}`))
+	})
+
+	f.routes.HandleFunc("PUT "+CREATE_PIPELINE+"/{identifier}", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		_, p := f.findPipeline(scopeOf(r), r.PathValue("identifier"))
+		if p == nil {
+			writeError(w, http.StatusNotFound, "ENTITY_NOT_FOUND", "pipeline not found")
+			return
+		}
+		p.Yaml = string(body)
+		w.Write([]byte(`{"status":"SUCCESS"}`))
+	})
+}
+
+// addInputset adds an inline input set to a pipeline of a project
+func (f *fakeHarness) addInputset(org, project, pipeline, identifier string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := org + "/" + project + "/" + pipeline
+	f.inputsets[key] = append(f.inputsets[key], &fakeInputset{
+		Identifier: identifier,
+		Yaml:       fmt.Sprintf("inputSet:\n  identifier: %s\n  pipeline:\n    identifier: %s\n  orgIdentifier: %s\n  projectIdentifier: %s\n", identifier, pipeline, org, project),
+	})
+}
+
+// inputsetIdentifiers returns the identifiers of the input sets of a pipeline
+func (f *fakeHarness) inputsetIdentifiers(org, project, pipeline string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	identifiers := []string{}
+	for _, i := range f.inputsets[org+"/"+project+"/"+pipeline] {
+		identifiers = append(identifiers, i.Identifier)
+	}
+	return identifiers
+}
+
+func (f *fakeHarness) findInputset(key, identifier string) *fakeInputset {
+	for _, i := range f.inputsets[key] {
+		if i.Identifier == identifier {
+			return i
+		}
+	}
+	return nil
+}
+
+func (f *fakeHarness) routeInputsets() {
+	pipelineOf := func(r *http.Request) string {
+		return scopeOf(r) + "/" + r.URL.Query().Get("pipelineIdentifier")
+	}
+
+	f.routes.HandleFunc("GET /pipeline/api/inputSets", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		content := []*model.ListInputsetContent{}
+		for _, i := range f.inputsets[pipelineOf(r)] {
+			content = append(content, &model.ListInputsetContent{
+				Identifier:            i.Identifier,
+				Name:                  i.Identifier,
+				PipelineIdentifier:    r.URL.Query().Get("pipelineIdentifier"),
+				EntityValidityDetails: model.EntityValidityDetails{Valid: true},
+			})
+		}
+		f.mu.Unlock()
+
+		json.NewEncoder(w).Encode(model.ListInputsetResponse{
+			Status: "SUCCESS",
+			Data:   model.ListInputsetData{Content: content, TotalPages: 1},
+		})
+	})
+
+	f.routes.HandleFunc("GET /pipeline/api/inputSets/{identifier}", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		i := f.findInputset(pipelineOf(r), r.PathValue("identifier"))
+		f.mu.Unlock()
+
+		if i == nil {
+			writeError(w, http.StatusNotFound, "ENTITY_NOT_FOUND", "input set not found")
+			return
+		}
+		json.NewEncoder(w).Encode(model.GetInputsetResponse{
+			Status: "SUCCESS",
+			Data: &model.GetInputsetData{
+				Identifier:         i.Identifier,
+				Name:               i.Identifier,
+				PipelineIdentifier: r.URL.Query().Get("pipelineIdentifier"),
+				Yaml:               i.Yaml,
+				StoreType:          model.Inline,
+			},
+		})
+	})
+
+	f.routes.HandleFunc("POST /pipeline/api/inputSets", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		identifier := yamlIdentifier(string(body))
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		key := pipelineOf(r)
+		if f.findInputset(key, identifier) != nil {
+			writeError(w, http.StatusBadRequest, "DUPLICATE_FIELD", fmt.Sprintf("Input set [%s] already exists", identifier))
+			return
+		}
+		f.inputsets[key] = append(f.inputsets[key], &fakeInputset{Identifier: identifier, Yaml: string(body)})
+		w.Write([]byte(`{"status":"SUCCESS"}`))
+	})
+
+	f.routes.HandleFunc("PUT /pipeline/api/inputSets/{identifier}", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		i := f.findInputset(pipelineOf(r), r.PathValue("identifier"))
+		if i == nil {
+			writeError(w, http.StatusNotFound, "ENTITY_NOT_FOUND", "input set not found")
+			return
+		}
+		i.Yaml = string(body)
+		w.Write([]byte(`{"status":"SUCCESS"}`))
+	})
+
+	f.routes.HandleFunc("DELETE /pipeline/api/inputSets/{identifier}", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		key := pipelineOf(r)
+		for j, i := range f.inputsets[key] {
+			if i.Identifier == r.PathValue("identifier") {
+				f.inputsets[key] = append(f.inputsets[key][:j], f.inputsets[key][j+1:]...)
+				w.Write([]byte(`{"status":"SUCCESS"}`))
+				return
+			}
+		}
+		writeError(w, http.StatusNotFound, "ENTITY_NOT_FOUND", "input set not found")
+	})
+}
+
+// addSecret adds a secret with its value to a project
+func (f *fakeHarness) addSecret(org, project string, secret model.Secret, value string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if secret.Spec == nil {
+		secret.Spec = map[string]interface{}{}
+	}
+	f.secrets[org+"/"+project] = append(f.secrets[org+"/"+project], &fakeSecret{Secret: secret, Value: value})
+}
+
+// secret returns a secret of a project, nil when the project does not have it
+func (f *fakeHarness) secret(org, project, identifier string) *fakeSecret {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.findSecret(org+"/"+project, identifier)
+}
+
+func (f *fakeHarness) findSecret(scope, identifier string) *fakeSecret {
+	for _, s := range f.secrets[scope] {
+		if s.Secret.Identifier == identifier {
+			return s
+		}
+	}
+	return nil
+}
+
+// decodeSecret reads the secret and its value sent as JSON or as a multipart form with
+// the content of a file secret
+func decodeSecret(contentType string, body []byte) (*model.Secret, string) {
+	request := model.CreateSecretRequest{}
+
+	mediaType, params, _ := mime.ParseMediaType(contentType)
+	if mediaType != "multipart/form-data" {
+		json.Unmarshal(body, &request)
+		if request.Secret == nil {
+			return nil, ""
+		}
+		value, _ := request.Secret.Spec["value"].(string)
+		return request.Secret, value
+	}
+
+	form, err := multipart.NewReader(bytes.NewReader(body), params["boundary"]).ReadForm(1 << 20)
+	if err != nil || len(form.Value["spec"]) == 0 {
+		return nil, ""
+	}
+	json.Unmarshal([]byte(form.Value["spec"][0]), &request)
+	if request.Secret == nil || len(form.File["file"]) == 0 {
+		return request.Secret, ""
+	}
+	file, err := form.File["file"][0].Open()
+	if err != nil {
+		return request.Secret, ""
+	}
+	defer file.Close()
+	content, _ := io.ReadAll(file)
+	return request.Secret, string(content)
+}
+
+func (f *fakeHarness) routeSecrets() {
+	f.routes.HandleFunc("GET "+SECRETS, func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		content := []*model.SecretContent{}
+		for _, s := range f.secrets[scopeOf(r)] {
+			content = append(content, &model.SecretContent{Secret: s.Secret})
+		}
+		f.mu.Unlock()
+
+		json.NewEncoder(w).Encode(model.SecretListResponse{
+			Status: "SUCCESS",
+			Data:   model.SecretListData{Content: content, TotalPages: 1},
+		})
+	})
+
+	create := func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		secret, value := decodeSecret(r.Header.Get("Content-Type"), body)
+		if secret == nil {
+			writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "secret is missing")
+			return
+		}
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		scope := scopeOf(r)
+		if f.findSecret(scope, secret.Identifier) != nil {
+			writeError(w, http.StatusBadRequest, "DUPLICATE_FIELD", fmt.Sprintf("Secret [%s] already exists", secret.Identifier))
+			return
+		}
+		f.secrets[scope] = append(f.secrets[scope], &fakeSecret{Secret: *secret, Value: value})
+		w.Write([]byte(`{"status":"SUCCESS"}`))
+	}
+	f.routes.HandleFunc("POST "+SECRETS, create)
+	f.routes.HandleFunc("POST "+SECRETFILES, create)
+
+	update := func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		secret, value := decodeSecret(r.Header.Get("Content-Type"), body)
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		existing := f.findSecret(scopeOf(r), r.PathValue("identifier"))
+		if existing == nil || secret == nil {
+			writeError(w, http.StatusNotFound, "ENTITY_NOT_FOUND", "secret not found")
+			return
+		}
+		existing.Secret = *secret
+		existing.Value = value
+		w.Write([]byte(`{"status":"SUCCESS"}`))
+	}
+	f.routes.HandleFunc("PUT "+SECRETS+"/{identifier}", update)
+	f.routes.HandleFunc("PUT "+SECRETFILES+"/{identifier}", update)
+}
+
+// addTemplate adds an inline version of a template to a project
+func (f *fakeHarness) addTemplate(org, project, identifier, version string, stable bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.templates[org+"/"+project] = append(f.templates[org+"/"+project], &fakeTemplate{
+		Identifier: identifier,
+		Version:    version,
+		Stable:     stable,
+		Yaml:       fmt.Sprintf("template:\n  identifier: %s\n  versionLabel: %s\n  orgIdentifier: %s\n  projectIdentifier: %s\n", identifier, version, org, project),
+	})
+}
+
+func (f *fakeHarness) findTemplate(scope, identifier, version string) *fakeTemplate {
+	for _, t := range f.templates[scope] {
+		if t.Identifier == identifier && t.Version == version {
+			return t
+		}
+	}
+	return nil
+}
+
+func (f *fakeHarness) routeTemplates() {
+	f.routes.HandleFunc("GET /v1/orgs/{org}/projects/{project}/templates", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		result := model.TemplateListResult{}
+		for _, t := range f.templates[r.PathValue("org")+"/"+r.PathValue("project")] {
+			result = append(result, model.TemplateListResultElement{
+				Identifier:     t.Identifier,
+				Name:           t.Identifier,
+				VersionLabel:   t.Version,
+				StableTemplate: t.Stable,
+			})
+		}
+		f.mu.Unlock()
+
+		w.Header().Set("X-Total-Elements", strconv.Itoa(len(result)))
+		json.NewEncoder(w).Encode(result)
+	})
+
+	f.routes.HandleFunc("GET /template/api/templates/{identifier}", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		t := f.findTemplate(scopeOf(r), r.PathValue("identifier"), r.URL.Query().Get("versionLabel"))
+		f.mu.Unlock()
+
+		if t == nil {
+			writeError(w, http.StatusNotFound, "TEMPLATE_NOT_FOUND", "template not found")
+			return
+		}
+		json.NewEncoder(w).Encode(model.TemplateGetResult{
+			Status: "SUCCESS",
+			Data: &model.TemplateGetData{
+				Identifier:   t.Identifier,
+				VersionLabel: t.Version,
+				Yaml:         t.Yaml,
+			},
+		})
+	})
+
+	f.routes.HandleFunc("POST "+CREATE_TEMPLATE_ENDPOINT, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		doc := map[string]map[string]interface{}{}
+		yaml.Unmarshal(body, &doc)
+		identifier := fmt.Sprint(doc["template"]["identifier"])
+		version := fmt.Sprint(doc["template"]["versionLabel"])
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		scope := scopeOf(r)
+		if f.findTemplate(scope, identifier, version) != nil {
+			writeError(w, http.StatusBadRequest, "DUPLICATE_FIELD", "template already exists")
+			return
+		}
+		f.templates[scope] = append(f.templates[scope], &fakeTemplate{
+			Identifier: identifier,
+			Version:    version,
+			Yaml:       string(body),
+		})
+		w.Write([]byte(`{"status":"SUCCESS"}`))
+	})
+
+	f.routes.HandleFunc("PUT "+CREATE_TEMPLATE_ENDPOINT+"/update/{identifier}/{version}", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		t := f.findTemplate(scopeOf(r), r.PathValue("identifier"), r.PathValue("version"))
+		if t == nil {
+			writeError(w, http.StatusNotFound, "TEMPLATE_NOT_FOUND", "template not found")
+			return
+		}
+		t.Yaml = string(body)
+		w.Write([]byte(`{"status":"SUCCESS"}`))
+	})
+
+	f.routes.HandleFunc("PUT "+CREATE_TEMPLATE_ENDPOINT+"/updateStableTemplate/{identifier}/{version}", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		scope := scopeOf(r)
+		if f.findTemplate(scope, r.PathValue("identifier"), r.PathValue("version")) == nil {
+			writeError(w, http.StatusNotFound, "TEMPLATE_NOT_FOUND", "template not found")
+			return
+		}
+		for _, t := range f.templates[scope] {
+			if t.Identifier == r.PathValue("identifier") {
+				t.Stable = t.Version == r.PathValue("version")
+			}
+		}
+		w.Write([]byte(`{"status":"SUCCESS"}`))
+	})
+}
+
+// setFreeze replaces the freeze window of the tool in a project, nil removes it
+func (f *fakeHarness) setFreeze(org, project string, freeze *model.FreezeResponseData) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.freezes[org+"/"+project] = freeze
+}
+
+// freeze returns the freeze window of the tool in a project
+func (f *fakeHarness) freeze(org, project string) *model.FreezeResponseData {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.freezes[org+"/"+project]
+}
+
+func (f *fakeHarness) routeFreezes() {
+	save := func(body []byte) *model.FreezeResponseData {
+		request := model.FreezeRequest{}
+		yaml.Unmarshal(body, &request)
+		return &model.FreezeResponseData{
+			Identifier:  request.Freeze.Identifier,
+			Name:        request.Freeze.Name,
+			Description: request.Freeze.Description,
+			Status:      request.Freeze.Status,
+			Yaml:        string(body),
+		}
+	}
+
+	f.routes.HandleFunc("POST "+FREEZEPROJECT, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		scope := scopeOf(r)
+		if f.freezes[scope] != nil {
+			writeError(w, http.StatusBadRequest, "RESOURCE_ALREADY_EXISTS", "already exists")
+			return
+		}
+		f.freezes[scope] = save(body)
+		json.NewEncoder(w).Encode(model.FreezeResponse{Status: "SUCCESS", Data: f.freezes[scope]})
+	})
+
+	f.routes.HandleFunc(FREEZEPROJECT+"/"+FREEZE_IDENTIFIER, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		scope := scopeOf(r)
+		if f.freezes[scope] == nil {
+			writeError(w, http.StatusBadRequest, "ENTITY_NOT_FOUND", "Freeze Config doesn't exist")
+			return
+		}
+		switch r.Method {
+		case http.MethodPut:
+			status := f.freezes[scope].Status
+			f.freezes[scope] = save(body)
+			f.freezes[scope].Status = status
+		case http.MethodDelete:
+			delete(f.freezes, scope)
+			w.Write([]byte(`{"status":"SUCCESS"}`))
+			return
+		}
+		json.NewEncoder(w).Encode(model.FreezeResponse{Status: "SUCCESS", Data: f.freezes[scope]})
+	})
+
+	f.routes.HandleFunc("POST "+FREEZEPROJECTSTATUS, func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		if freeze := f.freezes[scopeOf(r)]; freeze != nil {
+			freeze.Status = r.URL.Query().Get("status")
+		}
+		w.Write([]byte(`{"status":"SUCCESS"}`))
+	})
+}
+
+// pathsAfter returns the paths of the requests with the prefix cut off, in order
+func pathsAfter(requests []fakeRequest, prefix string) []string {
+	paths := []string{}
+	for _, r := range requests {
+		if strings.HasPrefix(r.Path, prefix) {
+			paths = append(paths, strings.TrimPrefix(r.Path, prefix))
+		}
+	}
+	return paths
+}
+
+// inProject keeps the requests sent to a project
+func inProject(requests []fakeRequest, project string) []fakeRequest {
+	kept := []fakeRequest{}
+	for _, r := range requests {
+		if r.Query.Get("projectIdentifier") == project {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
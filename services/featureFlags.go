@@ -3,6 +3,7 @@ package services
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
 
 	"github.com/schollz/progressbar/v3"
 	"go.uber.org/zap"
@@ -39,7 +40,7 @@ func (c FeatureContext) Copy() error {
 		zap.String("project", c.sourceProject),
 	)
 
-	featureFlags, err := c.api.listFeatureFlags(c.sourceOrg, c.sourceProject, c.logger)
+	featureFlags, err := c.api.source().listFeatureFlags(c.sourceOrg, c.sourceProject, c.logger)
 	if err != nil {
 		c.logger.Error("Failed to retrive feature flags",
 			zap.String("Project", c.sourceProject),
@@ -48,6 +49,21 @@ func (c FeatureContext) Copy() error {
 		return err
 	}
 
+	existing := map[string]bool{}
+	if c.api.Plan != nil {
+		targetFlags, err := c.api.listFeatureFlags(c.targetOrg, c.targetProject, c.logger)
+		if err != nil {
+			c.logger.Error("Failed to list the feature flags of the target project",
+				zap.String("Project", c.targetProject),
+				zap.Error(err),
+			)
+			return err
+		}
+		for _, f := range targetFlags {
+			existing[f.Identifier] = true
+		}
+	}
+
 	var bar *progressbar.ProgressBar
 
 	if c.showPB {
@@ -59,14 +75,41 @@ func (c FeatureContext) Copy() error {
 			f.Tags = []string{}
 		}
 
-		IncrementFeatureFlagsTotal()
+		c.api.Stats.IncrementFeatureFlagsTotal()
+
+		if c.api.Checkpoint.Done("Feature Flag", f.Identifier) {
+			c.logger.Info("Skipping feature flag copied by a previous run",
+				zap.String("identifier", f.Identifier),
+			)
+			c.api.Stats.IncrementFeatureFlagsMoved()
+			if c.showPB {
+				bar.Add(1)
+			}
+			continue
+		}
+
+		if c.api.unchanged("Feature Flag", f.Identifier, f.ModifiedAt, c.logger) {
+			c.api.Stats.IncrementFeatureFlagsMoved()
+			if c.showPB {
+				bar.Add(1)
+			}
+			continue
+		}
 
 		c.logger.Info("Processing feature flag",
 			zap.String("feature flag", f.Name),
 			zap.String("targetProject", c.targetProject),
 		)
 
-		err = c.api.createFeatureFlags(&model.CreateFeatureFlag{
+		if c.api.Plan != nil {
+			c.api.Plan.Record("Feature Flag", f.Identifier, f.Name, existing[c.api.Rename.Identifier("Feature Flag", f.Identifier)], nil)
+			if c.showPB {
+				bar.Add(1)
+			}
+			continue
+		}
+
+		flag := &model.CreateFeatureFlag{
 			OrgIdentifier:       c.targetOrg,
 			ProjectIdentifier:   c.targetProject,
 			Archived:            f.Archived,
@@ -76,10 +119,10 @@ func (c FeatureContext) Copy() error {
 			Description:         f.Description,
 			EnvProperties:       f.EnvProperties,
 			Evaluation:          f.Evaluation,
-			Identifier:          f.Identifier,
+			Identifier:          c.api.Rename.Identifier("Feature Flag", f.Identifier),
 			Kind:                f.Kind,
 			ModifiedAt:          f.ModifiedAt,
-			Name:                f.Name,
+			Name:                c.api.Rename.Name("Feature Flag", f.Name),
 			Owner:               fmt.Sprint(f.Owner),
 			Permanent:           f.Permanent,
 			Prerequisites:       f.Prerequisites,
@@ -87,6 +130,10 @@ func (c FeatureContext) Copy() error {
 			Services:            f.Services,
 			Tags:                f.Tags,
 			Variations:          f.Variations,
+		}
+		err = c.api.createFeatureFlags(flag, c.logger)
+		err = c.api.resolveConflict("Feature Flag", f.Identifier, err, func() error {
+			return c.api.updateFeatureFlag(flag, c.logger)
 		}, c.logger)
 
 		if err != nil {
@@ -96,7 +143,10 @@ func (c FeatureContext) Copy() error {
 			)
 			return err
 		} else {
-			IncrementFeatureFlagsMoved()
+			c.api.Stats.IncrementFeatureFlagsMoved()
+			if err := c.api.Checkpoint.Record("Feature Flag", f.Identifier); err != nil {
+				c.logger.Warn("Failed to update checkpoint", zap.Error(err))
+			}
 		}
 		if c.showPB {
 			bar.Add(1)
@@ -116,47 +166,61 @@ func (api *ApiRequest) listFeatureFlags(org, project string, logger *zap.Logger)
 		zap.String("project", project),
 	)
 
-	IncrementApiCalls()
+	featureFlags := []*model.FeatureFlag{}
 
-	resp, err := api.Client.R().
-		SetHeader("x-api-key", api.Token).
-		SetHeader("Content-Type", "application/json").
-		SetQueryParams(map[string]string{
-			"accountIdentifier": api.Account,
-			"orgIdentifier":     org,
-			"projectIdentifier": project,
-		}).
-		Get(api.BaseURL + FEATFLAGS)
-	if err != nil {
-		logger.Error("Failed to request to list of feature flags",
-			zap.Error(err),
-		)
-		return nil, err
-	}
-	if resp.IsError() {
-		logger.Error("Error response from API when listing feature flags",
-			zap.String("response",
-				resp.String(),
-			),
-		)
-		return nil, handleErrorResponse(resp)
-	}
+	err := api.paginate(PAGE_SIZE, func(page int) (listPage, error) {
+		api.Stats.IncrementApiCalls()
+
+		resp, err := api.Client.R().
+			SetHeader("x-api-key", api.Token).
+			SetHeader("Content-Type", "application/json").
+			SetQueryParams(map[string]string{
+				"accountIdentifier": api.Account,
+				"orgIdentifier":     org,
+				"projectIdentifier": project,
+				"pageNumber":        strconv.Itoa(page),
+				"pageSize":          strconv.Itoa(PAGE_SIZE),
+			}).
+			Get(api.BaseURL + FEATFLAGS)
+		if err != nil {
+			logger.Error("Failed to request to list of feature flags",
+				zap.Error(err),
+			)
+			return listPage{}, err
+		}
+		if resp.IsError() {
+			logger.Error("Error response from API when listing feature flags",
+				zap.String("response",
+					resp.String(),
+				),
+			)
+			return listPage{}, handleErrorResponse(resp)
+		}
 
-	result := model.FeatureFlagListResult{}
-	err = json.Unmarshal(resp.Body(), &result)
+		result := model.FeatureFlagListResult{}
+		err = json.Unmarshal(resp.Body(), &result)
+		if err != nil {
+			logger.Error("Failed to parse response from API",
+				zap.Error(err),
+			)
+			return listPage{}, err
+		}
+
+		for _, c := range result.Features {
+			feature := c
+			featureFlags = append(featureFlags, &feature)
+		}
+
+		return listPage{
+			index: int(result.PageIndex),
+			total: int(result.PageCount),
+			items: len(result.Features),
+		}, nil
+	})
 	if err != nil {
-		logger.Error("Failed to parse response from API",
-			zap.Error(err),
-		)
 		return nil, err
 	}
 
-	featureFlags := []*model.FeatureFlag{}
-	for _, c := range result.Features {
-		feature := c
-		featureFlags = append(featureFlags, &feature)
-	}
-
 	return featureFlags, nil
 }
 
@@ -167,7 +231,20 @@ func (api *ApiRequest) createFeatureFlags(featureFlag *model.CreateFeatureFlag,
 		zap.String("project", featureFlag.ProjectIdentifier),
 	)
 
-	IncrementApiCalls()
+	if api.Bundle != nil {
+		return api.Bundle.Add(model.BundleEntry{
+			Entity:      "Feature Flag",
+			Identifier:  featureFlag.Identifier,
+			Path:        FEATFLAGS,
+			ContentType: "application/json",
+			Params: map[string]string{
+				"orgIdentifier":     featureFlag.OrgIdentifier,
+				"projectIdentifier": featureFlag.ProjectIdentifier,
+			},
+		}, featureFlag, nil)
+	}
+
+	api.Stats.IncrementApiCalls()
 
 	resp, err := api.Client.R().
 		SetHeader("x-api-key", api.Token).
@@ -191,11 +268,11 @@ func (api *ApiRequest) createFeatureFlags(featureFlag *model.CreateFeatureFlag,
 		var errorResponse map[string]interface{}
 		if err := json.Unmarshal(resp.Body(), &errorResponse); err == nil {
 			if code, ok := errorResponse["code"].(string); ok && code == "409" {
-				// Log as a warning and skip the error
-				logger.Info("Duplicate feature flag found, ignoring error",
+				// The conflict strategy decides what happens to the existing entity
+				logger.Info("Duplicate feature flag found",
 					zap.String("feature flag", featureFlag.Name),
 				)
-				return nil
+				return ErrAlreadyExists
 			}
 		} else {
 			logger.Error(
@@ -209,5 +286,19 @@ func (api *ApiRequest) createFeatureFlags(featureFlag *model.CreateFeatureFlag,
 		return handleErrorResponse(resp)
 	}
 
+	api.recordCreated(model.JournalEntry{
+		Entity:     "Feature Flag",
+		Identifier: featureFlag.Identifier,
+		Org:        featureFlag.OrgIdentifier,
+		Project:    featureFlag.ProjectIdentifier,
+	}, logger)
+
 	return nil
 }
+
+func (api *ApiRequest) updateFeatureFlag(featureFlag *model.CreateFeatureFlag, logger *zap.Logger) error {
+	return api.updateEntity(FEATFLAGS+"/"+featureFlag.Identifier, "application/json", featureFlag, map[string]string{
+		"orgIdentifier":     featureFlag.OrgIdentifier,
+		"projectIdentifier": featureFlag.ProjectIdentifier,
+	}, logger)
+}
@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"sync"
 
+	"github.com/go-resty/resty/v2"
 	"github.com/schollz/progressbar/v3"
 	"go.uber.org/zap"
 	"harness-copy-project/model"
@@ -40,32 +42,42 @@ func (c FileStoreContext) Copy() error {
 		zap.String("project", c.sourceProject),
 	)
 
-	nodes, err := c.listNodes("Root", "Root", nil, c.logger)
+	nodes, err := c.api.source().listNodes(c.sourceOrg, c.sourceProject, "Root", "Root", nil, c.logger)
 	if err != nil {
 		c.logger.Error("Failed to list file store nodes", zap.Error(err))
 		return err
 	}
 
+	existing := map[string]bool{}
+	if c.api.Plan != nil {
+		c.api.collectNodes(c.targetOrg, c.targetProject, "Root", "Root", nil, existing, c.logger)
+	}
+
 	if c.showPB {
 		bar = progressbar.Default(int64(len(nodes)), "File Store")
 	}
 
 	var failures []string
+	var failuresMu sync.Mutex
 
-	for _, n := range nodes {
+	// FOLDERS ARE COPIED WITH THEIR CHILD NODES, SO ONLY THE TOP LEVEL NODES RUN AT THE SAME TIME
+	c.api.forEach(len(nodes), func(i int) {
+		n := nodes[i]
 
-		IncrementFileStoresTotal()
+		c.api.Stats.IncrementFileStoresTotal()
 
-		if err := c.handleNode(n, failures, c.logger, c.showPB); err != nil {
+		if err := c.handleNode(n, nil, existing, c.logger, c.showPB); err != nil {
 			c.logger.Error("Failed to handle file", zap.Error(err))
+			failuresMu.Lock()
 			failures = handeNodeFailure(n, failures, err)
+			failuresMu.Unlock()
 		} else {
-			IncrementFileStoresMoved()
+			c.api.Stats.IncrementFileStoresMoved()
 		}
 		if c.showPB {
 			bar.Add(1)
 		}
-	}
+	})
 	if c.showPB {
 		bar.Finish()
 	}
@@ -77,12 +89,22 @@ func handeNodeFailure(node *model.FileStoreNode, failures []string, err error) [
 	return append(failures, fmt.Sprintf("%s (%s) - %s", node.Name, node.Path, err.Error()))
 }
 
-func (c FileStoreContext) handleNode(n *model.FileStoreNode, failures []string, logger *zap.Logger, showPB bool) error {
+func (c FileStoreContext) handleNode(n *model.FileStoreNode, failures []string, existing map[string]bool, logger *zap.Logger, showPB bool) error {
 
 	// CREATE FOLDER OR FILE
-	if err := c.createNode(n, c.logger); err != nil {
+	if c.api.unchanged("File Store", n.Path, n.LastModifiedAt, logger) {
+		// THE CHILD NODES OF AN UNCHANGED FOLDER MAY STILL HAVE CHANGED
+	} else if c.api.Plan != nil {
+		c.api.Plan.Record("File Store", n.Path, n.Name, existing[c.api.Rename.Identifier("File Store", n.Identifier)], nil)
+	} else if c.api.Checkpoint.Done("File Store", n.Path) {
+		logger.Info("Skipping file store node copied by a previous run",
+			zap.String("identifier", n.Path),
+		)
+	} else if err := c.createNode(n, c.logger); err != nil {
 		logger.Error("Failed to create or folder", zap.Error(err))
 		return err
+	} else if err := c.api.Checkpoint.Record("File Store", n.Path); err != nil {
+		logger.Warn("Failed to update checkpoint", zap.Error(err))
 	}
 
 	// A FILE DON'T HAVE CHILD NODES
@@ -92,19 +114,19 @@ func (c FileStoreContext) handleNode(n *model.FileStoreNode, failures []string,
 	}
 
 	// SEARCH FOR CHILD NODES
-	nodes, err := c.listNodes(n.Identifier, n.Name, &n.ParentIdentifier, c.logger)
+	nodes, err := c.api.source().listNodes(c.sourceOrg, c.sourceProject, n.Identifier, n.Name, &n.ParentIdentifier, c.logger)
 	if err != nil {
 		logger.Error("Failed identify child nodes", zap.Error(err))
 		return err
 	}
 
 	if showPB {
-		bar.ChangeMax(bar.GetMax() + len(nodes))
+		growBar(bar, len(nodes))
 	}
 
 	// FOR EACH NODE MAKE A RECURSIVE CALL
 	for _, n := range nodes {
-		if err := c.handleNode(n, failures, c.logger, c.showPB); err != nil {
+		if err := c.handleNode(n, failures, existing, c.logger, c.showPB); err != nil {
 			logger.Error("Error creating file or directory", zap.Error(err))
 			failures = handeNodeFailure(n, failures, err)
 		}
@@ -118,38 +140,52 @@ func (c FileStoreContext) handleNode(n *model.FileStoreNode, failures []string,
 
 func (c FileStoreContext) createNode(n *model.FileStoreNode, logger *zap.Logger) error {
 
+	// THE NODE IS CREATED WITH ITS RENAMED IDENTIFIER UNDER ITS RENAMED PARENT. NAMES ARE KEPT
+	// SINCE THE PATHS OF THE NODES ARE MADE OF THEM.
+	target := *n
+	target.Identifier = c.api.Rename.Identifier("File Store", n.Identifier)
+	if n.ParentIdentifier != "Root" {
+		target.ParentIdentifier = c.api.Rename.Identifier("File Store", n.ParentIdentifier)
+	}
+
 	switch n.Type {
 	case model.Folder:
-		return c.createFolder(n, c.logger)
+		err := c.createFolder(&target, c.logger)
+		return c.api.resolveConflict("File Store", n.Path, err, func() error {
+			return c.updateFolder(&target, c.logger)
+		}, logger)
 	case model.File:
 		// DOWNLOAD FILE
-		b, err := c.downloadFile(n, c.logger)
+		b, err := c.api.source().downloadFile(c.sourceOrg, c.sourceProject, n, c.logger)
 		if err != nil {
 			logger.Error("Failed download file", zap.Error(err))
 			return err
 		}
 
 		// CREATE/UPLOAD FILE
-		return c.createFile(n, b, c.logger)
+		err = c.createFile(&target, b, c.logger)
+		return c.api.resolveConflict("File Store", n.Path, err, func() error {
+			return c.updateFile(&target, b, c.logger)
+		}, logger)
 
 	default:
 		return fmt.Errorf("unsupported file store node type %s", n.Type)
 	}
 }
 
-func (c FileStoreContext) downloadFile(n *model.FileStoreNode, logger *zap.Logger) ([]byte, error) {
+func (api *ApiRequest) downloadFile(org, project string, n *model.FileStoreNode, logger *zap.Logger) ([]byte, error) {
 
-	IncrementApiCalls()
+	api.Stats.IncrementApiCalls()
 
-	resp, err := c.api.Client.R().
-		SetHeader("x-api-key", c.api.Token).
+	resp, err := api.Client.R().
+		SetHeader("x-api-key", api.Token).
 		SetPathParam("identifier", n.Identifier).
 		SetQueryParams(map[string]string{
-			"accountIdentifier": c.api.Account,
-			"orgIdentifier":     c.sourceOrg,
-			"projectIdentifier": c.sourceProject,
+			"accountIdentifier": api.Account,
+			"orgIdentifier":     org,
+			"projectIdentifier": project,
 		}).
-		Get(c.api.BaseURL + "/ng/api/file-store/files/{identifier}/download")
+		Get(api.BaseURL + "/ng/api/file-store/files/{identifier}/download")
 	if err != nil {
 		logger.Error("Failed to request download file",
 			zap.Error(err),
@@ -164,14 +200,47 @@ func (c FileStoreContext) downloadFile(n *model.FileStoreNode, logger *zap.Logge
 }
 
 func (c FileStoreContext) createFile(n *model.FileStoreNode, b []byte, logger *zap.Logger) error {
+	return c.uploadFile(n, b, resty.MethodPost, "/ng/api/file-store", logger)
+}
 
-	IncrementApiCalls()
+func (c FileStoreContext) updateFile(n *model.FileStoreNode, b []byte, logger *zap.Logger) error {
+	return c.uploadFile(n, b, resty.MethodPut, "/ng/api/file-store/"+n.Identifier, logger)
+}
+
+func (c FileStoreContext) uploadFile(n *model.FileStoreNode, b []byte, method, path string, logger *zap.Logger) error {
 
 	// ENSURE ONLY FILES CAN BE UPLOADED
 	if n.Type != model.File {
 		return fmt.Errorf("node %s is not a folder", n.Name)
 	}
 
+	if c.api.Bundle != nil {
+		return c.api.Bundle.Add(model.BundleEntry{
+			Entity:      "File Store",
+			Identifier:  n.Path,
+			Method:      method,
+			Path:        path,
+			ContentType: "multipart/form-data",
+			Params: map[string]string{
+				"orgIdentifier":     c.targetOrg,
+				"projectIdentifier": c.targetProject,
+			},
+			Form: map[string]string{
+				"identifier":       n.Identifier,
+				"name":             n.Name,
+				"type":             string(n.Type),
+				"parentIdentifier": n.ParentIdentifier,
+				"description":      n.Description,
+				"path":             n.Path,
+				"fileUsage":        n.FileUsage,
+				"mimeType":         *n.MimeType,
+			},
+			ContentField: "content",
+		}, nil, b)
+	}
+
+	c.api.Stats.IncrementApiCalls()
+
 	reader := bytes.NewReader(b)
 
 	resp, err := c.api.Client.R().
@@ -193,7 +262,7 @@ func (c FileStoreContext) createFile(n *model.FileStoreNode, b []byte, logger *z
 			"orgIdentifier":     c.targetOrg,
 			"projectIdentifier": c.targetProject,
 		}).
-		Post(c.api.BaseURL + "/ng/api/file-store")
+		Execute(method, c.api.BaseURL+path)
 	if err != nil {
 		logger.Error("Failed to upload file",
 			zap.Error(err),
@@ -204,18 +273,58 @@ func (c FileStoreContext) createFile(n *model.FileStoreNode, b []byte, logger *z
 		return handleErrorResponse(resp)
 	}
 
+	// AN UPDATED FILE ALREADY EXISTED IN THE TARGET AND IS NOT JOURNALED
+	if method == resty.MethodPost {
+		c.api.recordCreated(model.JournalEntry{
+			Entity:     "File Store",
+			Identifier: n.Identifier,
+			Org:        c.targetOrg,
+			Project:    c.targetProject,
+		}, logger)
+	}
+
 	return nil
 }
 
 func (c FileStoreContext) createFolder(n *model.FileStoreNode, logger *zap.Logger) error {
+	return c.saveFolder(n, resty.MethodPost, "/ng/api/file-store", logger)
+}
+
+func (c FileStoreContext) updateFolder(n *model.FileStoreNode, logger *zap.Logger) error {
+	return c.saveFolder(n, resty.MethodPut, "/ng/api/file-store/"+n.Identifier, logger)
+}
 
-	IncrementApiCalls()
+func (c FileStoreContext) saveFolder(n *model.FileStoreNode, method, path string, logger *zap.Logger) error {
 
 	// ENSURE ONLY FOLDERS CAN BE CREATED
 	if n.Type != model.Folder {
 		return fmt.Errorf("node %s is not a folder", n.Name)
 	}
 
+	if c.api.Bundle != nil {
+		return c.api.Bundle.Add(model.BundleEntry{
+			Entity:      "File Store",
+			Identifier:  n.Path,
+			Method:      method,
+			Path:        path,
+			ContentType: "multipart/form-data",
+			Params: map[string]string{
+				"orgIdentifier":     c.targetOrg,
+				"projectIdentifier": c.targetProject,
+			},
+			Form: map[string]string{
+				"identifier":       n.Identifier,
+				"name":             n.Name,
+				"type":             string(n.Type),
+				"parentIdentifier": n.ParentIdentifier,
+				"description":      n.Description,
+				"path":             n.Path,
+			},
+		}, nil, nil)
+	}
+
+	c.api.Stats.IncrementApiCalls()
+
 	resp, err := c.api.Client.R().
 		SetHeader("x-api-key", c.api.Token).
 		SetHeader("Content-Type", "multipart/form-data").
@@ -232,7 +341,7 @@ func (c FileStoreContext) createFolder(n *model.FileStoreNode, logger *zap.Logge
 			"orgIdentifier":     c.targetOrg,
 			"projectIdentifier": c.targetProject,
 		}).
-		Post(c.api.BaseURL + "/ng/api/file-store")
+		Execute(method, c.api.BaseURL+path)
 	if err != nil {
 		logger.Error("Failed to create folder",
 			zap.Error(err),
@@ -243,12 +352,36 @@ func (c FileStoreContext) createFolder(n *model.FileStoreNode, logger *zap.Logge
 		return handleErrorResponse(resp)
 	}
 
+	// AN UPDATED FOLDER ALREADY EXISTED IN THE TARGET AND IS NOT JOURNALED
+	if method == resty.MethodPost {
+		c.api.recordCreated(model.JournalEntry{
+			Entity:     "File Store",
+			Identifier: n.Identifier,
+			Org:        c.targetOrg,
+			Project:    c.targetProject,
+		}, logger)
+	}
+
 	return nil
 }
 
-func (c FileStoreContext) listNodes(identifier, name string, parentIdentifier *string, logger *zap.Logger) ([]*model.FileStoreNode, error) {
+// collectNodes walks the file store of a project and records every node identifier
+func (api *ApiRequest) collectNodes(org, project, identifier, name string, parentIdentifier *string, found map[string]bool, logger *zap.Logger) {
+	nodes, err := api.listNodes(org, project, identifier, name, parentIdentifier, logger)
+	if err != nil {
+		return
+	}
+	for _, n := range nodes {
+		found[n.Identifier] = true
+		if n.Type == model.Folder {
+			api.collectNodes(org, project, n.Identifier, n.Name, &n.ParentIdentifier, found, logger)
+		}
+	}
+}
+
+func (api *ApiRequest) listNodes(org, project, identifier, name string, parentIdentifier *string, logger *zap.Logger) ([]*model.FileStoreNode, error) {
 
-	IncrementApiCalls()
+	api.Stats.IncrementApiCalls()
 
 	req := model.GetFolderNodesRequest{
 		Identifier:       identifier,
@@ -257,16 +390,16 @@ func (c FileStoreContext) listNodes(identifier, name string, parentIdentifier *s
 		Type:             "FOLDER",
 	}
 
-	resp, err := c.api.Client.R().
-		SetHeader("x-api-key", c.api.Token).
+	resp, err := api.Client.R().
+		SetHeader("x-api-key", api.Token).
 		SetHeader("Content-Type", "application/json").
 		SetBody(req).
 		SetQueryParams(map[string]string{
-			"accountIdentifier": c.api.Account,
-			"orgIdentifier":     c.sourceOrg,
-			"projectIdentifier": c.sourceProject,
+			"accountIdentifier": api.Account,
+			"orgIdentifier":     org,
+			"projectIdentifier": project,
 		}).
-		Post(c.api.BaseURL + "/ng/api/file-store/folder")
+		Post(api.BaseURL + "/ng/api/file-store/folder")
 	if err != nil {
 		logger.Error("Failed to retrieve list of nodes",
 			zap.Error(err),
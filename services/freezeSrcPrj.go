@@ -2,7 +2,11 @@ package services
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
@@ -13,26 +17,56 @@ import (
 const FREEZEPROJECT = "/ng/api/freeze"
 const FREEZEPROJECTSTATUS = "/ng/api/freeze/updateFreezeStatus"
 
+// The freeze window of the tool, used when the run does not set its own
+const (
+	FREEZE_IDENTIFIER = "hrns_copy_prj_freeze"
+	FREEZE_NAME       = "Harness Copy Project Freeze"
+	FREEZE_TIME_ZONE  = "America/Los_Angeles"
+	FREEZE_DURATION   = "365d"
+
+	// The description points the users of the source project to the target project
+	FREEZE_DESCRIPTION = "This project was moved to the project '{targetProject}' of the org '{targetOrg}'."
+)
+
+// The status of the freeze window of the tool in a project
+const (
+	FREEZE_ENABLED  = "Enabled"
+	FREEZE_DISABLED = "Disabled"
+	FREEZE_NONE     = "None"
+)
+
+// The start time of a freeze window as it is set and as Harness expects it
+const FREEZE_START_TIME_LAYOUT = "2006-01-02 15:04"
+const FREEZE_WINDOW_TIME_LAYOUT = "2006-01-02 03:04 PM"
+
+var freezeDurationPattern = regexp.MustCompile(`^([0-9]+[wdhm])+$`)
+
 type FreezeSourceProjectContext struct {
 	api           *ApiRequest
 	sourceOrg     string
 	sourceProject string
+	window        model.FreezeWindow
 	logger        *zap.Logger
 }
 
-func FreezeSourceProjectOperation(api *ApiRequest, sourceOrg, sourceProject string, logger *zap.Logger) FreezeSourceProjectContext {
+func FreezeSourceProjectOperation(api *ApiRequest, sourceOrg, sourceProject string, window model.FreezeWindow, logger *zap.Logger) FreezeSourceProjectContext {
 	return FreezeSourceProjectContext{
 		api:           api,
 		sourceOrg:     sourceOrg,
 		sourceProject: sourceProject,
+		window:        window,
 		logger:        logger,
 	}
 }
 
 func (c FreezeSourceProjectContext) Copy() error {
+	_, err := c.Freeze()
+	return err
+}
 
-	timeZone, _ := time.LoadLocation("America/Los_Angeles")
-	currentTime := time.Now().In(timeZone)
+// Freeze enables the freeze window of the tool in the source project. It reports whether
+// the window was already enabled before, so a caller only lifts a freeze it enabled.
+func (c FreezeSourceProjectContext) Freeze() (bool, error) {
 
 	fmt.Printf("Freezing source project: '%s'. \n", c.sourceProject)
 
@@ -40,82 +74,138 @@ func (c FreezeSourceProjectContext) Copy() error {
 		zap.String("project", c.sourceProject),
 	)
 
-	freeze := model.FreezeRequest{
-		Freeze: model.Freeze{
-			Name:       "Harness Copy Project Freeze",
-			Identifier: "hrns_copy_prj_freeze",
-			EntityConfigs: []model.EntityConfig{
-				{
-					Name: "hrns_copy_prj_freeze",
-					Entities: []model.Entity{
-						{Type: "Service", FilterType: "All"},
-						{Type: "EnvType", FilterType: "All"},
-					},
-				},
-			},
-			Status:            "Disabled",
-			OrgIdentifier:     c.sourceOrg,
-			ProjectIdentifier: c.sourceProject,
-			Windows: []model.Window{
-				{
-					TimeZone:  "America/Los_Angeles",
-					StartTime: currentTime.Format("2006-01-02 03:04 PM"),
-					Duration:  "365d",
-				},
-			},
-		},
+	freeze, err := freezeRequest(c.window, c.sourceOrg, c.sourceProject, time.Now())
+	if err != nil {
+		c.logger.Error("Invalid freeze window",
+			zap.String("project", c.sourceProject),
+			zap.Error(err),
+		)
+		return false, err
 	}
 
 	freezeYaml, err := yaml.Marshal(&freeze)
-	freezeRequest := string(freezeYaml)
-
 	if err != nil {
 		c.logger.Error("Failed to marshal freeze request",
 			zap.String("project", c.sourceProject),
 			zap.Error(err),
 		)
+		return false, err
 	}
+	freezeRequest := string(freezeYaml)
 
-	freezeId, err := c.api.createProjectFreeze(&freezeRequest, c.sourceOrg, c.sourceProject, c.logger)
-
-	if *freezeId == "duplicate" {
-		c.logger.Info("Duplicate project freeze",
+	// A FREEZE LEFT BY A PREVIOUS RUN IS UPDATED IN PLACE
+	existing, err := c.api.getProjectFreeze(c.sourceOrg, c.sourceProject, c.logger)
+	if err != nil {
+		c.logger.Error("Failed to fetch project freeze",
 			zap.String("project", c.sourceProject),
+			zap.Error(err),
 		)
-		fmt.Printf("Project '%s' is already frozen. \n", c.sourceProject)
-		return nil
+		return false, err
 	}
-	if freezeId == nil {
-		return fmt.Errorf("failed to create freeze for project %s", c.sourceProject)
+	wasEnabled := existing != nil && existing.Status == FREEZE_ENABLED
+	if existing == nil {
+		err = c.api.createProjectFreeze(&freezeRequest, c.sourceOrg, c.sourceProject, c.logger)
+	}
+	if existing != nil || errors.Is(err, ErrAlreadyExists) {
+		fmt.Printf("Updating the existing freeze of project '%s'. \n", c.sourceProject)
+		err = c.api.updateProjectFreeze(&freezeRequest, c.sourceOrg, c.sourceProject, c.logger)
 	}
-	freezeSlc := []string{*freezeId}
-
 	if err != nil {
 		c.logger.Error("Failed to create project freeze",
-			zap.String("project", c.sourceOrg),
+			zap.String("project", c.sourceProject),
 			zap.Error(err),
 		)
-	} else {
-		err := c.api.enableProjectFreeze(&freezeSlc, c.sourceOrg, c.sourceProject, c.logger)
+		return false, err
+	}
 
+	if err := c.api.updateProjectFreezeStatus(c.sourceOrg, c.sourceProject, FREEZE_ENABLED, c.logger); err != nil {
+		c.logger.Error("Failed to enable project freeze ",
+			zap.String("project", c.sourceProject),
+			zap.Error(err),
+		)
+		return false, err
+	}
+
+	return wasEnabled, nil
+}
+
+// ValidateFreezeWindow checks the freeze window of a run before any project is copied
+func ValidateFreezeWindow(window model.FreezeWindow) error {
+	_, err := freezeRequest(window, "", "", time.Now())
+	return err
+}
+
+// freezeRequest creates the freeze window of a project, filling the fields the window
+// does not set with the defaults of the tool
+func freezeRequest(window model.FreezeWindow, org, project string, now time.Time) (model.FreezeRequest, error) {
+	if window.Name == "" {
+		window.Name = FREEZE_NAME
+	}
+	if window.TimeZone == "" {
+		window.TimeZone = FREEZE_TIME_ZONE
+	}
+	if window.Duration == "" {
+		window.Duration = FREEZE_DURATION
+	}
+
+	timeZone, err := time.LoadLocation(window.TimeZone)
+	if err != nil {
+		return model.FreezeRequest{}, fmt.Errorf("invalid freeze time zone '%v': %v", window.TimeZone, err)
+	}
+	start := now.In(timeZone)
+	if window.StartTime != "" {
+		start, err = time.ParseInLocation(FREEZE_START_TIME_LAYOUT, window.StartTime, timeZone)
 		if err != nil {
-			c.logger.Error("Failed to enable project freeze ",
-				zap.String("project", c.sourceOrg),
-				zap.Error(err),
-			)
+			return model.FreezeRequest{}, fmt.Errorf("invalid freeze start time '%v', expected '%v'", window.StartTime, FREEZE_START_TIME_LAYOUT)
 		}
 	}
-	return nil
+	if !freezeDurationPattern.MatchString(window.Duration) {
+		return model.FreezeRequest{}, fmt.Errorf("invalid freeze duration '%v', expected a duration such as '30m', '12h' or '365d'", window.Duration)
+	}
+
+	// WITHOUT A LIST EVERY SERVICE AND ENVIRONMENT IS FROZEN
+	entities := []model.Entity{{Type: "Service", FilterType: "All"}}
+	if len(window.Services) > 0 {
+		entities[0] = model.Entity{Type: "Service", FilterType: "Equals", EntityRefs: window.Services}
+	}
+	entities = append(entities, model.Entity{Type: "EnvType", FilterType: "All"})
+	if len(window.Environments) > 0 {
+		entities = append(entities, model.Entity{Type: "Environment", FilterType: "Equals", EntityRefs: window.Environments})
+	}
+
+	return model.FreezeRequest{
+		Freeze: model.Freeze{
+			Name:        window.Name,
+			Identifier:  FREEZE_IDENTIFIER,
+			Description: window.Description,
+			EntityConfigs: []model.EntityConfig{
+				{
+					Name:     FREEZE_IDENTIFIER,
+					Entities: entities,
+				},
+			},
+			Status:            FREEZE_DISABLED,
+			OrgIdentifier:     org,
+			ProjectIdentifier: project,
+			Windows: []model.Window{
+				{
+					TimeZone:  window.TimeZone,
+					StartTime: start.Format(FREEZE_WINDOW_TIME_LAYOUT),
+					Duration:  window.Duration,
+				},
+			},
+		},
+	}, nil
 }
 
-func (api *ApiRequest) createProjectFreeze(freeze *string, org string, project string, logger *zap.Logger) (*string, error) {
+func (api *ApiRequest) createProjectFreeze(freeze *string, org string, project string, logger *zap.Logger) error {
 
 	logger.Info("Creating project freeze",
 		zap.String("org", org),
 		zap.String("project", project),
 	)
 
-	IncrementApiCalls()
+	api.Stats.IncrementApiCalls()
 
 	resp, err := api.Client.R().
 		SetHeader("x-api-key", api.Token).
@@ -131,66 +221,128 @@ func (api *ApiRequest) createProjectFreeze(freeze *string, org string, project s
 		logger.Error("Failed to request project freeze creation",
 			zap.Error(err),
 		)
-		return nil, err
+		return err
 	}
 	if resp.IsError() {
 		var errorResponse map[string]interface{}
 		if err := json.Unmarshal(resp.Body(), &errorResponse); err == nil {
 			if code, ok := errorResponse["code"].(string); ok && code == "RESOURCE_ALREADY_EXISTS" {
-				// Log as a warning and skip the error
 				logger.Info("Duplicate project freeze.",
 					zap.String("project", project),
 				)
-				freezeId := "duplicate"
-				return &freezeId, nil
+				return ErrAlreadyExists
 			}
 		} else {
 			logger.Error(
-				"Error response from API when setting project freeze status ",
+				"Error response from API when creating project freeze ",
 				zap.String("project", project),
 				zap.String("response",
 					resp.String(),
 				),
 			)
 		}
-		return nil, handleErrorResponse(resp)
+		return handleErrorResponse(resp)
 	}
 
 	result := model.FreezeResponse{}
-	err = json.Unmarshal(resp.Body(), &result)
+	if err := json.Unmarshal(resp.Body(), &result); err != nil {
+		logger.Error("Failed to parse response from API",
+			zap.Error(err),
+		)
+		return err
+	}
 	if result.Status != "SUCCESS" {
+		return fmt.Errorf("failed to create freeze for project %s: %s", project, result.Message)
+	}
+
+	return nil
+}
+
+func (api *ApiRequest) updateProjectFreeze(freeze *string, org string, project string, logger *zap.Logger) error {
+	return api.updateEntity(FREEZEPROJECT+"/"+FREEZE_IDENTIFIER, "application/yaml", *freeze, map[string]string{
+		"orgIdentifier":     org,
+		"projectIdentifier": project,
+	}, logger)
+}
+
+// getProjectFreeze returns the freeze window of the tool in a project, nil when the
+// project has none
+func (api *ApiRequest) getProjectFreeze(org string, project string, logger *zap.Logger) (*model.FreezeResponseData, error) {
+
+	logger.Info("Fetching project freeze",
+		zap.String("org", org),
+		zap.String("project", project),
+	)
+
+	api.Stats.IncrementApiCalls()
+
+	resp, err := api.Client.R().
+		SetHeader("x-api-key", api.Token).
+		SetQueryParams(map[string]string{
+			"accountIdentifier": api.Account,
+			"orgIdentifier":     org,
+			"projectIdentifier": project,
+		}).
+		Get(api.BaseURL + FREEZEPROJECT + "/" + FREEZE_IDENTIFIER)
+	if err != nil {
+		logger.Error("Failed to request project freeze",
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	if resp.IsError() {
+		result := model.ErrorResponse{}
+		if resp.StatusCode() == http.StatusNotFound {
+			return nil, nil
+		}
+		if err := json.Unmarshal(resp.Body(), &result); err == nil && (result.Code == "ENTITY_NOT_FOUND" || strings.Contains(result.Message, "doesn't exist")) {
+			return nil, nil
+		}
+		logger.Error("Error response from API when fetching project freeze ",
+			zap.String("project", project),
+			zap.String("response",
+				resp.String(),
+			),
+		)
+		return nil, handleErrorResponse(resp)
+	}
+
+	result := model.FreezeResponse{}
+	if err := json.Unmarshal(resp.Body(), &result); err != nil {
 		logger.Error("Failed to parse response from API",
 			zap.Error(err),
 		)
 		return nil, err
 	}
 
-	return &result.Data.Identifier, nil
+	return result.Data, nil
 }
 
-func (api *ApiRequest) enableProjectFreeze(freezeId *[]string, org string, project string, logger *zap.Logger) error {
+func (api *ApiRequest) updateProjectFreezeStatus(org string, project string, status string, logger *zap.Logger) error {
 
-	logger.Info("Enabling project freeze",
+	logger.Info("Updating project freeze status",
 		zap.String("org", org),
 		zap.String("project", project),
+		zap.String("status", status),
 	)
 
-	IncrementApiCalls()
+	api.Stats.IncrementApiCalls()
 
 	resp, err := api.Client.R().
+		AddRetryCondition(retryIdempotent).
 		SetHeader("x-api-key", api.Token).
 		SetHeader("Content-Type", "application/json").
-		SetBody(freezeId).
+		SetBody([]string{FREEZE_IDENTIFIER}).
 		SetQueryParams(map[string]string{
 			"accountIdentifier": api.Account,
 			"orgIdentifier":     org,
 			"projectIdentifier": project,
-			"status":            "Enabled",
+			"status":            status,
 		}).
 		Post(api.BaseURL + FREEZEPROJECTSTATUS)
 
 	if err != nil {
-		logger.Error("Failed to send request to create ",
+		logger.Error("Failed to send request to update freeze status ",
 			zap.String("Project", project),
 			zap.Error(err),
 		)
@@ -209,3 +361,57 @@ func (api *ApiRequest) enableProjectFreeze(freezeId *[]string, org string, proje
 
 	return nil
 }
+
+// ProjectFreezeStatus returns the freeze window of the tool in a project
+func (api *ApiRequest) ProjectFreezeStatus(org, project string, logger *zap.Logger) (model.FreezeStatus, error) {
+	status := model.FreezeStatus{
+		Org:     org,
+		Project: project,
+		Status:  FREEZE_NONE,
+	}
+
+	freeze, err := api.getProjectFreeze(org, project, logger)
+	if err != nil || freeze == nil {
+		return status, err
+	}
+
+	status.Status = freeze.Status
+	status.Name = freeze.Name
+	status.Description = freeze.Description
+
+	request := model.FreezeRequest{}
+	if err := yaml.Unmarshal([]byte(freeze.Yaml), &request); err == nil && len(request.Freeze.Windows) > 0 {
+		status.TimeZone = request.Freeze.Windows[0].TimeZone
+		status.StartTime = request.Freeze.Windows[0].StartTime
+		status.Duration = request.Freeze.Windows[0].Duration
+	}
+
+	return status, nil
+}
+
+// UnfreezeProject disables the freeze window of the tool in a project, or deletes it.
+// It returns the status the project is left with.
+func (api *ApiRequest) UnfreezeProject(org, project string, remove bool, logger *zap.Logger) (string, error) {
+	freeze, err := api.getProjectFreeze(org, project, logger)
+	if err != nil || freeze == nil {
+		return FREEZE_NONE, err
+	}
+
+	if remove {
+		if err := api.deleteEntity(FREEZEPROJECT+"/"+FREEZE_IDENTIFIER, map[string]string{
+			"orgIdentifier":     org,
+			"projectIdentifier": project,
+		}, logger); err != nil {
+			return freeze.Status, err
+		}
+		return FREEZE_NONE, nil
+	}
+
+	if freeze.Status == FREEZE_DISABLED {
+		return FREEZE_DISABLED, nil
+	}
+	if err := api.updateProjectFreezeStatus(org, project, FREEZE_DISABLED, logger); err != nil {
+		return freeze.Status, err
+	}
+	return FREEZE_DISABLED, nil
+}
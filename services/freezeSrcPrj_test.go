@@ -0,0 +1,164 @@
+package services
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"harness-copy-project/model"
+)
+
+func TestFreezeRequest_Defaults(t *testing.T) {
+	now := time.Date(2024, 3, 1, 17, 30, 0, 0, time.UTC)
+
+	freeze, err := freezeRequest(model.FreezeWindow{}, "org", "project", now)
+	assert.NoError(t, err)
+
+	assert.Equal(t, FREEZE_IDENTIFIER, freeze.Freeze.Identifier)
+	assert.Equal(t, FREEZE_NAME, freeze.Freeze.Name)
+	assert.Equal(t, "org", freeze.Freeze.OrgIdentifier)
+	assert.Equal(t, "project", freeze.Freeze.ProjectIdentifier)
+	assert.Equal(t, []model.Window{{
+		TimeZone:  FREEZE_TIME_ZONE,
+		StartTime: "2024-03-01 09:30 AM",
+		Duration:  FREEZE_DURATION,
+	}}, freeze.Freeze.Windows)
+	assert.Equal(t, []model.Entity{
+		{Type: "Service", FilterType: "All"},
+		{Type: "EnvType", FilterType: "All"},
+	}, freeze.Freeze.EntityConfigs[0].Entities)
+}
+
+func TestFreezeRequest_Window(t *testing.T) {
+	freeze, err := freezeRequest(model.FreezeWindow{
+		Name:         "Cutover",
+		Description:  "Moved to newOrg/newProject",
+		TimeZone:     "Europe/Berlin",
+		StartTime:    "2024-03-04 18:00",
+		Duration:     "2d",
+		Services:     []string{"api", "web"},
+		Environments: []string{"prod"},
+	}, "org", "project", time.Now())
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Cutover", freeze.Freeze.Name)
+	assert.Equal(t, "Moved to newOrg/newProject", freeze.Freeze.Description)
+	assert.Equal(t, []model.Window{{
+		TimeZone:  "Europe/Berlin",
+		StartTime: "2024-03-04 06:00 PM",
+		Duration:  "2d",
+	}}, freeze.Freeze.Windows)
+	assert.Equal(t, []model.Entity{
+		{Type: "Service", FilterType: "Equals", EntityRefs: []string{"api", "web"}},
+		{Type: "EnvType", FilterType: "All"},
+		{Type: "Environment", FilterType: "Equals", EntityRefs: []string{"prod"}},
+	}, freeze.Freeze.EntityConfigs[0].Entities)
+}
+
+func TestValidateFreezeWindow(t *testing.T) {
+	assert.NoError(t, ValidateFreezeWindow(model.FreezeWindow{Duration: "1w2d12h30m"}))
+	assert.ErrorContains(t, ValidateFreezeWindow(model.FreezeWindow{TimeZone: "Europe/Nowhere"}), "invalid freeze time zone")
+	assert.ErrorContains(t, ValidateFreezeWindow(model.FreezeWindow{StartTime: "tomorrow"}), "invalid freeze start time")
+	assert.ErrorContains(t, ValidateFreezeWindow(model.FreezeWindow{Duration: "1 year"}), "invalid freeze duration")
+}
+
+// freezeCalls lists the changes sent to the freeze window of the tool, in order
+func freezeCalls(fake *fakeHarness) []string {
+	calls := []string{}
+	for _, r := range fake.sent("", "") {
+		switch {
+		case r.Method == http.MethodPost && r.Path == FREEZEPROJECT:
+			calls = append(calls, "create")
+		case r.Method == http.MethodPut && r.Path == FREEZEPROJECT+"/"+FREEZE_IDENTIFIER && r.Status == http.StatusOK:
+			calls = append(calls, "update")
+		case r.Method == http.MethodDelete && r.Path == FREEZEPROJECT+"/"+FREEZE_IDENTIFIER && r.Status == http.StatusOK:
+			calls = append(calls, "delete")
+		case r.Path == FREEZEPROJECTSTATUS:
+			calls = append(calls, r.Query.Get("status"))
+		}
+	}
+	return calls
+}
+
+func TestFreezeSourceProject_CreatesFreeze(t *testing.T) {
+	fake := newFakeHarness(t)
+	api := fake.api()
+
+	wasEnabled, err := FreezeSourceProjectOperation(api, "org", "project", model.FreezeWindow{Name: "Cutover"}, zap.NewNop()).Freeze()
+	assert.NoError(t, err)
+	assert.False(t, wasEnabled)
+
+	assert.Equal(t, []string{"create", FREEZE_ENABLED}, freezeCalls(fake))
+	freeze := fake.freeze("org", "project")
+	assert.Equal(t, "Cutover", freeze.Name)
+	assert.Equal(t, FREEZE_ENABLED, freeze.Status)
+}
+
+func TestFreezeSourceProject_UpdatesExistingFreeze(t *testing.T) {
+	fake := newFakeHarness(t)
+	fake.setFreeze("org", "project", &model.FreezeResponseData{
+		Identifier: FREEZE_IDENTIFIER,
+		Name:       FREEZE_NAME,
+		Status:     FREEZE_DISABLED,
+	})
+	api := fake.api()
+
+	wasEnabled, err := FreezeSourceProjectOperation(api, "org", "project", model.FreezeWindow{Name: "Cutover", Duration: "2d"}, zap.NewNop()).Freeze()
+	assert.NoError(t, err)
+	assert.False(t, wasEnabled)
+
+	// THE FREEZE OF A PREVIOUS RUN IS UPDATED IN PLACE, NOT CREATED AGAIN
+	assert.Equal(t, []string{"update", FREEZE_ENABLED}, freezeCalls(fake))
+
+	status, err := api.ProjectFreezeStatus("org", "project", zap.NewNop())
+	assert.NoError(t, err)
+	assert.Equal(t, "Cutover", status.Name)
+	assert.Equal(t, FREEZE_ENABLED, status.Status)
+	assert.Equal(t, "2d", status.Duration)
+	assert.Equal(t, FREEZE_TIME_ZONE, status.TimeZone)
+}
+
+func TestFreezeSourceProject_ReportsEnabledFreeze(t *testing.T) {
+	fake := newFakeHarness(t)
+	fake.setFreeze("org", "project", &model.FreezeResponseData{
+		Identifier: FREEZE_IDENTIFIER,
+		Name:       FREEZE_NAME,
+		Status:     FREEZE_ENABLED,
+	})
+	api := fake.api()
+
+	// A FREEZE THE USER ENABLED BEFORE THE RUN IS NOT LIFTED WHEN THE COPY FAILS
+	wasEnabled, err := FreezeSourceProjectOperation(api, "org", "project", model.FreezeWindow{}, zap.NewNop()).Freeze()
+	assert.NoError(t, err)
+	assert.True(t, wasEnabled)
+}
+
+func TestUnfreezeProject(t *testing.T) {
+	fake := newFakeHarness(t)
+	fake.setFreeze("org", "project", &model.FreezeResponseData{
+		Identifier: FREEZE_IDENTIFIER,
+		Status:     FREEZE_ENABLED,
+	})
+	api := fake.api()
+
+	status, err := api.UnfreezeProject("org", "project", false, zap.NewNop())
+	assert.NoError(t, err)
+	assert.Equal(t, FREEZE_DISABLED, status)
+
+	// DISABLING TWICE DOES NOTHING
+	status, err = api.UnfreezeProject("org", "project", false, zap.NewNop())
+	assert.NoError(t, err)
+	assert.Equal(t, FREEZE_DISABLED, status)
+
+	status, err = api.UnfreezeProject("org", "project", true, zap.NewNop())
+	assert.NoError(t, err)
+	assert.Equal(t, FREEZE_NONE, status)
+
+	status, err = api.UnfreezeProject("org", "project", true, zap.NewNop())
+	assert.NoError(t, err)
+	assert.Equal(t, FREEZE_NONE, status)
+
+	assert.Equal(t, []string{FREEZE_DISABLED, "delete"}, freezeCalls(fake))
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+	"harness-copy-project/model"
+)
+
+const IMPORT_PIPELINE = "/pipeline/api/pipelines/import"
+const IMPORT_TEMPLATE = "/template/api/templates/import/%s"
+const IMPORT_INPUTSET = "/pipeline/api/inputSets/import/%s"
+
+// Returned instead of replacing an entity of the target that is stored in git with an
+// inline copy
+var ErrRemoteInTarget = errors.New("the existing entity is stored in git and is not replaced with an inline copy")
+
+// gitLocation returns the file a remote entity of the source is stored in
+func gitLocation(connectorRef *string, details *model.GitDetails) (model.GitLocation, error) {
+	if details == nil || details.RepoName == "" || details.FilePath == "" {
+		return model.GitLocation{}, fmt.Errorf("remote entity without a repository and file path")
+	}
+	location := model.GitLocation{
+		RepoName: details.RepoName,
+		Branch:   details.Branch,
+		FilePath: details.FilePath,
+	}
+	if connectorRef != nil {
+		location.ConnectorRef = *connectorRef
+	}
+	return location, nil
+}
+
+// importRemote creates an entity of the target from the file it is stored in. The body
+// holds the name and description of the entity, anything else is read from git. With
+// force the entity that already exists in the target is imported again from the file.
+func (api *ApiRequest) importRemote(path string, params map[string]string, location model.GitLocation, body interface{}, journal model.JournalEntry, bundleIdentifier string, force bool, logger *zap.Logger) error {
+
+	logger.Info("Importing remote entity",
+		zap.String("entity", journal.Entity),
+		zap.String("identifier", journal.Identifier),
+		zap.String("repoName", location.RepoName),
+		zap.String("branch", location.Branch),
+		zap.String("filePath", location.FilePath),
+	)
+
+	query := map[string]string{
+		"orgIdentifier":     journal.Org,
+		"projectIdentifier": journal.Project,
+		"connectorRef":      location.ConnectorRef,
+		"repoName":          location.RepoName,
+		"branch":            location.Branch,
+		"filePath":          location.FilePath,
+		"isForceImport":     fmt.Sprint(force),
+	}
+	for k, v := range params {
+		query[k] = v
+	}
+
+	if api.Bundle != nil {
+		return api.Bundle.Add(model.BundleEntry{
+			Entity:      journal.Entity,
+			Identifier:  bundleIdentifier,
+			Path:        path,
+			ContentType: "application/json",
+			Params:      query,
+		}, body, nil)
+	}
+
+	api.Stats.IncrementApiCalls()
+
+	query["accountIdentifier"] = api.Account
+	resp, err := api.Client.R().
+		SetHeader("x-api-key", api.Token).
+		SetHeader("Content-Type", "application/json").
+		SetBody(body).
+		SetQueryParams(query).
+		Post(api.BaseURL + path)
+	if err != nil {
+		logger.Error("Failed to send request to import ",
+			zap.String("identifier", journal.Identifier),
+			zap.Error(err),
+		)
+		return err
+	}
+	if resp.IsError() {
+		var errorResponse map[string]interface{}
+		if err := json.Unmarshal(resp.Body(), &errorResponse); err == nil {
+			if code, ok := errorResponse["code"].(string); ok && code == "DUPLICATE_FIELD" {
+				// The conflict strategy decides what happens to the existing entity
+				logger.Info("Duplicate remote entity found",
+					zap.String("entity", journal.Entity),
+					zap.String("identifier", journal.Identifier),
+				)
+				return ErrAlreadyExists
+			}
+		}
+		logger.Error("Error response from API when importing ",
+			zap.String("identifier", journal.Identifier),
+			zap.String("response",
+				resp.String(),
+			),
+		)
+		return handleErrorResponse(resp)
+	}
+
+	// A FORCED IMPORT REPLACED AN ENTITY THE TARGET ALREADY HAD AND IS NOT JOURNALED
+	if !force {
+		api.recordCreated(journal, logger)
+	}
+
+	return nil
+}
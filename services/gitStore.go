@@ -0,0 +1,202 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"harness-copy-project/model"
+)
+
+const GIT_DEFAULT_REMOTE = "origin"
+
+// The entities that can be written to git, with the path used when the layout has none
+var gitStoreLayouts = map[string]string{
+	"Pipeline":  ".harness/{org}/{project}/pipelines/{identifier}.yaml",
+	"Template":  ".harness/{org}/{project}/templates/{identifier}_{version}.yaml",
+	"Input Set": ".harness/{org}/{project}/pipelines/{pipeline}/input_sets/{identifier}.yaml",
+}
+
+// GitStore writes the YAML of inline entities to a git repository, so they are created
+// as remote entities in the target. Files are committed and pushed one at a time, an
+// entity can only be imported once its file is on the remote branch.
+type GitStore struct {
+	config   model.GitStore
+	entities map[string]bool
+	layouts  map[string]string
+	mu       sync.Mutex
+}
+
+// gitFile fills the placeholders of the layout
+type gitFile struct {
+	org        string
+	project    string
+	identifier string
+	pipeline   string
+	version    string
+}
+
+// NewGitStore checks the git store of the command. Without a work tree nothing is
+// written to git.
+func NewGitStore(config model.GitStore) (*GitStore, error) {
+	if config.WorkTree == "" {
+		return nil, nil
+	}
+
+	var missing []string
+	if config.ConnectorRef == "" {
+		missing = append(missing, "connectorRef")
+	}
+	if config.RepoName == "" {
+		missing = append(missing, "repoName")
+	}
+	if config.Branch == "" {
+		missing = append(missing, "branch")
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("git store without %v", strings.Join(missing, ", "))
+	}
+	if config.Remote == "" {
+		config.Remote = GIT_DEFAULT_REMOTE
+	}
+
+	store := &GitStore{
+		config:   config,
+		entities: map[string]bool{},
+		layouts: map[string]string{
+			"Pipeline":  config.Layout.Pipelines,
+			"Template":  config.Layout.Templates,
+			"Input Set": config.Layout.InputSets,
+		},
+	}
+	for entity, layout := range store.layouts {
+		if layout == "" {
+			store.layouts[entity] = gitStoreLayouts[entity]
+		}
+	}
+
+	entities := config.Entities
+	if len(entities) == 0 {
+		for entity := range gitStoreLayouts {
+			entities = append(entities, entity)
+		}
+	}
+	for _, entity := range entities {
+		if _, ok := gitStoreLayouts[entity]; !ok {
+			return nil, fmt.Errorf("entity '%v' can not be stored in git", entity)
+		}
+		store.entities[entity] = true
+	}
+
+	if _, err := store.git("rev-parse", "--is-inside-work-tree"); err != nil {
+		return nil, fmt.Errorf("'%v' is not a git work tree: %v", config.WorkTree, err)
+	}
+
+	// THE COMMITS OF THE WORK TREE ARE PUSHED TO THE BRANCH, SO IT MUST HAVE IT CHECKED OUT
+	head, err := store.git("symbolic-ref", "--quiet", "--short", "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("'%v' has no branch checked out, check out '%v' first", config.WorkTree, config.Branch)
+	}
+	if head = strings.TrimSpace(head); head != config.Branch {
+		return nil, fmt.Errorf("'%v' has branch '%v' checked out instead of '%v'", config.WorkTree, head, config.Branch)
+	}
+
+	return store, nil
+}
+
+// Stores tells whether the inline entities of a type are written to git
+func (g *GitStore) Stores(entity string) bool {
+	return g != nil && g.entities[entity]
+}
+
+// Write commits the YAML of an entity to the file of the layout and pushes it, and
+// returns the location the target imports the entity from
+func (g *GitStore) Write(entity string, file gitFile, content string, logger *zap.Logger) (model.GitLocation, error) {
+	path := strings.NewReplacer(
+		"{org}", file.org,
+		"{project}", file.project,
+		"{identifier}", file.identifier,
+		"{pipeline}", file.pipeline,
+		"{version}", file.version,
+	).Replace(g.layouts[entity])
+
+	location := model.GitLocation{
+		ConnectorRef: g.config.ConnectorRef,
+		RepoName:     g.config.RepoName,
+		Branch:       g.config.Branch,
+		FilePath:     path,
+	}
+
+	logger.Info("Writing entity to git",
+		zap.String("entity", entity),
+		zap.String("identifier", file.identifier),
+		zap.String("filePath", path),
+	)
+
+	// THE WORK TREE IS SHARED BY THE ENTITIES COPIED AT THE SAME TIME
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	full := filepath.Join(g.config.WorkTree, filepath.FromSlash(path))
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		return location, err
+	}
+	if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+		return location, err
+	}
+	if _, err := g.git("add", "--", path); err != nil {
+		return location, err
+	}
+
+	// A FILE WRITTEN BY A PREVIOUS RUN IS NOT COMMITTED AGAIN
+	if _, err := g.git("diff", "--cached", "--quiet", "--", path); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return location, err
+		}
+		if _, err := g.git("commit", "-m", fmt.Sprintf("Add %v %v of %v/%v", entity, file.identifier, file.org, file.project), "--", path); err != nil {
+			return location, err
+		}
+	}
+
+	if err := g.push(); err != nil {
+		logger.Error("Failed to push entity to git",
+			zap.String("entity", entity),
+			zap.String("identifier", file.identifier),
+			zap.Error(err),
+		)
+		return location, err
+	}
+
+	return location, nil
+}
+
+// push pushes the commits of the work tree to the branch, rebasing them once when the
+// branch moved on since
+func (g *GitStore) push() error {
+	ref := "HEAD:refs/heads/" + g.config.Branch
+	if _, err := g.git("push", g.config.Remote, ref); err == nil {
+		return nil
+	}
+	if _, err := g.git("pull", "--rebase", g.config.Remote, g.config.Branch); err != nil {
+		// A WORK TREE LEFT IN THE MIDDLE OF A REBASE WOULD FAIL EVERY LATER WRITE. THERE IS
+		// NOTHING TO ABORT WHEN THE PULL FAILED BEFORE THE REBASE STARTED.
+		g.git("rebase", "--abort")
+		return err
+	}
+	_, err := g.git("push", g.config.Remote, ref)
+	return err
+}
+
+func (g *GitStore) git(args ...string) (string, error) {
+	out, err := exec.Command("git", append([]string{"-C", g.config.WorkTree}, args...)...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %v: %w: %s", args[0], err, strings.TrimSpace(string(out)))
+	}
+	return string(out), nil
+}
@@ -0,0 +1,301 @@
+package services
+
+import (
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+	"harness-copy-project/model"
+)
+
+// git runs a git command in dir and fails the test when it fails
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return string(out)
+}
+
+// newBareRepo creates a bare repository holding a pipeline file on the 'main' and
+// 'release' branches
+func newBareRepo(t *testing.T) string {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	bare := filepath.Join(dir, "repo.git")
+	work := filepath.Join(dir, "work")
+
+	git(t, dir, "init", "--bare", bare)
+	git(t, dir, "init", work)
+	assert.NoError(t, os.MkdirAll(filepath.Join(work, ".harness"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(work, ".harness", "deploy.yaml"), []byte("pipeline:\n  identifier: deploy\n  name: Deploy\n"), 0644))
+	git(t, work, "add", "-A")
+	git(t, work, "commit", "-m", "Add deploy pipeline")
+	git(t, work, "push", bare, "HEAD:refs/heads/main")
+	git(t, work, "push", bare, "HEAD:refs/heads/release")
+
+	return bare
+}
+
+// newGitHarness serves a remote pipeline stored in a bare repository and an inline
+// pipeline the target already has stored in git. Imports are read from the bare
+// repository like Harness reads them from git, the remote pipelines named existing are
+// only imported again when forced.
+func newGitHarness(t *testing.T, bare string, existing ...string) *fakeHarness {
+	fake := newFakeHarness(t)
+
+	connectorRef := "github"
+	deploy := fake.addPipeline("src", "src", "deploy", "pipeline:\n  identifier: deploy\n  name: Deploy\n")
+	deploy.Name = "Deploy"
+	deploy.StoreType = model.Remote
+	deploy.ConnectorRef = &connectorRef
+	deploy.GitDetails = &model.GitDetails{
+		RepoName: "harness-config",
+		Branch:   "main",
+		FilePath: ".harness/deploy.yaml",
+	}
+	fake.addPipeline("src", "src", "build", "pipeline:\n  identifier: build\n  name: Build\n").Name = "Build"
+	fake.addPipeline("target", "target", "build", "pipeline:\n  identifier: build\n  name: Build\n").StoreType = model.Remote
+
+	fake.handle(IMPORT_PIPELINE, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if slices.Contains(existing, query.Get("pipelineIdentifier")) && query.Get("isForceImport") != "true" {
+			writeError(w, http.StatusBadRequest, "DUPLICATE_FIELD", "already exists")
+			return
+		}
+
+		out, err := exec.Command("git", "--git-dir", bare, "show", query.Get("branch")+":"+query.Get("filePath")).CombinedOutput()
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "file not found in git")
+			return
+		}
+		pipeline := map[string]map[string]interface{}{}
+		yaml.Unmarshal(out, &pipeline)
+		if pipeline["pipeline"]["identifier"] != query.Get("pipelineIdentifier") {
+			writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "identifier mismatch")
+			return
+		}
+		w.Write([]byte(`{"status":"SUCCESS"}`))
+	})
+
+	fake.handle("PUT "+CREATE_PIPELINE+"/{identifier}", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("the remote pipeline of the target was replaced with an inline copy")
+	})
+
+	return fake
+}
+
+// createdPipelines returns the identifiers of the pipelines sent to be created inline
+func createdPipelines(fake *fakeHarness) []string {
+	created := []string{}
+	for _, r := range fake.sent(http.MethodPost, CREATE_PIPELINE) {
+		created = append(created, yamlIdentifier(r.Body))
+	}
+	return created
+}
+
+func TestPipelineCopy_RemoteIsImportedFromGit(t *testing.T) {
+	bare := newBareRepo(t)
+	fake := newGitHarness(t, bare)
+
+	rename, err := NewRenamer(model.RenameRules{}, model.ReferenceRemap{
+		Connectors:   map[string]string{"github": "org.github"},
+		Repositories: map[string]string{"harness-config": "platform-config"},
+		Branches:     map[string]string{"main": "release"},
+	})
+	assert.NoError(t, err)
+
+	api := fake.api()
+	api.Conflict = ConflictOverwrite
+	api.Rename = rename
+
+	err = NewPipelineOperation(api, "src", "src", "target", "target", zap.NewNop(), false).Copy()
+	assert.NoError(t, err)
+
+	// THE REMOTE PIPELINE IS IMPORTED FROM THE REMAPPED LOCATION, NEVER CREATED INLINE
+	imports := fake.sent("", IMPORT_PIPELINE)
+	if assert.Len(t, imports, 1) {
+		assert.Equal(t, "deploy", imports[0].Query.Get("pipelineIdentifier"))
+		assert.Equal(t, "target", imports[0].Query.Get("projectIdentifier"))
+		assert.Equal(t, "org.github", imports[0].Query.Get("connectorRef"))
+		assert.Equal(t, "platform-config", imports[0].Query.Get("repoName"))
+		assert.Equal(t, "release", imports[0].Query.Get("branch"))
+		assert.Equal(t, ".harness/deploy.yaml", imports[0].Query.Get("filePath"))
+	}
+	assert.Equal(t, []string{"build"}, createdPipelines(fake))
+	assert.Equal(t, 1, api.Stats.GetPipelinesMoved())
+
+	// THE INLINE PIPELINE DOES NOT REPLACE THE REMOTE ONE OF THE TARGET
+	conflicts := api.Stats.GetConflicts()
+	if assert.Len(t, conflicts, 1) {
+		assert.Equal(t, "build", conflicts[0].Identifier)
+		assert.Equal(t, ErrRemoteInTarget.Error(), conflicts[0].Reason)
+	}
+}
+
+func TestPipelineCopy_ExistingRemoteIsImportedAgain(t *testing.T) {
+	fake := newGitHarness(t, newBareRepo(t), "deploy")
+
+	api := fake.api()
+	api.Conflict = ConflictOverwrite
+
+	err := NewPipelineOperation(api, "src", "src", "target", "target", zap.NewNop(), false).Copy()
+	assert.NoError(t, err)
+
+	// THE PIPELINE THE TARGET ALREADY HAS IS IMPORTED AGAIN FROM ITS FILE
+	imports := fake.sent("", IMPORT_PIPELINE)
+	if assert.Len(t, imports, 2) {
+		assert.Equal(t, "false", imports[0].Query.Get("isForceImport"))
+		assert.Equal(t, "true", imports[1].Query.Get("isForceImport"))
+	}
+	assert.Contains(t, api.Stats.GetConflicts(), model.ConflictEntry{
+		Entity:     "Pipeline",
+		Identifier: "deploy",
+		Strategy:   ConflictOverwrite,
+	})
+
+	// IT EXISTED BEFORE THE COPY, SO A ROLLBACK MUST NOT DELETE IT
+	assert.Empty(t, api.Stats.GetCreated())
+}
+
+func TestPipelineCopy_RemoteMissingFromGitFails(t *testing.T) {
+	bare := newBareRepo(t)
+	fake := newGitHarness(t, bare)
+
+	rename, err := NewRenamer(model.RenameRules{}, model.ReferenceRemap{
+		Branches: map[string]string{"main": "unknown"},
+	})
+	assert.NoError(t, err)
+
+	api := fake.api()
+	api.Rename = rename
+
+	err = NewPipelineOperation(api, "src", "src", "target", "target", zap.NewNop(), false).Copy()
+	assert.NoError(t, err)
+
+	// THE FAILED IMPORT IS NOT REPLACED WITH AN INLINE COPY
+	assert.Len(t, fake.sent("", IMPORT_PIPELINE), 1)
+	assert.Equal(t, []string{"build"}, createdPipelines(fake))
+	assert.Equal(t, 1, api.Stats.GetPipelinesMoved())
+}
+
+func TestPipelineCopy_InlineIsWrittenToGit(t *testing.T) {
+	bare := newBareRepo(t)
+	fake := newGitHarness(t, bare)
+
+	work := filepath.Join(t.TempDir(), "clone")
+	git(t, ".", "clone", "--branch", "main", bare, work)
+	git(t, work, "config", "user.name", "test")
+	git(t, work, "config", "user.email", "test@example.com")
+
+	store, err := NewGitStore(model.GitStore{
+		WorkTree:     work,
+		ConnectorRef: "org.github",
+		RepoName:     "harness-config",
+		Branch:       "main",
+		Entities:     []string{"Pipeline"},
+		Layout: model.GitLayout{
+			Pipelines: "{org}/{project}/{identifier}.yaml",
+		},
+	})
+	assert.NoError(t, err)
+
+	api := fake.api()
+	api.Git = store
+
+	err = NewPipelineOperation(api, "src", "src", "target", "target", zap.NewNop(), false).Copy()
+	assert.NoError(t, err)
+
+	// THE INLINE PIPELINE IS PUSHED AND IMPORTED FROM ITS FILE INSTEAD OF CREATED
+	assert.Empty(t, createdPipelines(fake))
+	assert.Equal(t, 2, api.Stats.GetPipelinesMoved())
+	imports := fake.sent("", IMPORT_PIPELINE)
+	if assert.Len(t, imports, 2) {
+		sort.Slice(imports, func(i, j int) bool {
+			return imports[i].Query.Get("pipelineIdentifier") < imports[j].Query.Get("pipelineIdentifier")
+		})
+		assert.Equal(t, "build", imports[0].Query.Get("pipelineIdentifier"))
+		assert.Equal(t, "org.github", imports[0].Query.Get("connectorRef"))
+		assert.Equal(t, "main", imports[0].Query.Get("branch"))
+		assert.Equal(t, "target/target/build.yaml", imports[0].Query.Get("filePath"))
+	}
+	assert.Contains(t, git(t, ".", "--git-dir", bare, "log", "main", "--format=%s"), "Add Pipeline build of target/target")
+}
+
+func TestNewGitStore(t *testing.T) {
+	store, err := NewGitStore(model.GitStore{})
+	assert.NoError(t, err)
+	assert.Nil(t, store)
+	assert.False(t, store.Stores("Pipeline"))
+
+	_, err = NewGitStore(model.GitStore{WorkTree: t.TempDir(), RepoName: "repo"})
+	assert.ErrorContains(t, err, "connectorRef, branch")
+
+	_, err = NewGitStore(model.GitStore{WorkTree: t.TempDir(), ConnectorRef: "github", RepoName: "repo", Branch: "main", Entities: []string{"Service"}})
+	assert.ErrorContains(t, err, "'Service' can not be stored in git")
+}
+
+func TestGitStore_ConflictingPushAbortsRebase(t *testing.T) {
+	bare := newBareRepo(t)
+
+	work := filepath.Join(t.TempDir(), "clone")
+	git(t, ".", "clone", "--branch", "main", bare, work)
+	git(t, work, "config", "user.name", "test")
+	git(t, work, "config", "user.email", "test@example.com")
+
+	store, err := NewGitStore(model.GitStore{
+		WorkTree:     work,
+		ConnectorRef: "org.github",
+		RepoName:     "harness-config",
+		Branch:       "main",
+	})
+	assert.NoError(t, err)
+
+	// SOMEONE ELSE PUSHES ANOTHER VERSION OF THE SAME FILE IN THE MEANTIME
+	other := filepath.Join(t.TempDir(), "other")
+	git(t, ".", "clone", "--branch", "main", bare, other)
+	path := filepath.Join(other, ".harness", "target", "target", "pipelines", "build.yaml")
+	assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	assert.NoError(t, os.WriteFile(path, []byte("pipeline:\n  identifier: other\n"), 0644))
+	git(t, other, "add", "-A")
+	git(t, other, "commit", "-m", "Add other build pipeline")
+	git(t, other, "push", "origin", "main")
+
+	file := gitFile{org: "target", project: "target", identifier: "build"}
+	_, err = store.Write("Pipeline", file, "pipeline:\n  identifier: build\n", zap.NewNop())
+	assert.Error(t, err)
+
+	// THE WORK TREE IS NOT LEFT IN THE MIDDLE OF THE REBASE
+	assert.Empty(t, strings.TrimSpace(git(t, work, "status", "--porcelain")))
+	assert.NoDirExists(t, filepath.Join(work, ".git", "rebase-merge"))
+	assert.NoDirExists(t, filepath.Join(work, ".git", "rebase-apply"))
+}
+
+func TestNewGitStore_BranchMustBeCheckedOut(t *testing.T) {
+	bare := newBareRepo(t)
+
+	work := filepath.Join(t.TempDir(), "clone")
+	git(t, ".", "clone", "--branch", "release", bare, work)
+
+	_, err := NewGitStore(model.GitStore{
+		WorkTree:     work,
+		ConnectorRef: "org.github",
+		RepoName:     "harness-config",
+		Branch:       "main",
+	})
+	assert.ErrorContains(t, err, "has branch 'release' checked out instead of 'main'")
+}
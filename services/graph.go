@@ -0,0 +1,437 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"harness-copy-project/model"
+)
+
+// referencingEntities are the entity types whose YAML or JSON references other entities
+var referencingEntities = map[string]bool{
+	"Secret":            true,
+	"Connector":         true,
+	"Environment":       true,
+	"Environment Group": true,
+	"Variable":          true,
+	"Infrastructure":    true,
+	"Service":           true,
+	"Service Override":  true,
+	"Template":          true,
+	"Pipeline":          true,
+	"Input Set":         true,
+	"Trigger":           true,
+}
+
+// GraphNode is an entity of the source project. Entities of an environment or a pipeline
+// are keyed by scope/identifier, templates by identifier so every version is one node.
+type GraphNode struct {
+	Entity string
+	Key    string
+}
+
+func (n GraphNode) String() string {
+	return fmt.Sprintf("%s '%s'", n.Entity, n.Key)
+}
+
+// Graph holds the dependencies between the entities of the source project, found by
+// parsing the references of every entity. It orders the operations and the entities of
+// an operation so every entity is created after the entities it references, and tracks
+// the entities that failed so their dependents are skipped.
+// A nil Graph keeps the default order and never blocks an entity.
+type Graph struct {
+	nodes []GraphNode
+	deps  map[GraphNode][]GraphNode
+
+	// Every entity listed to build the graph, every template version included, normalized
+	entities []interface{}
+
+	mu     sync.Mutex
+	failed map[GraphNode]bool
+}
+
+func NewGraph() *Graph {
+	return &Graph{
+		deps:   map[GraphNode][]GraphNode{},
+		failed: map[GraphNode]bool{},
+	}
+}
+
+// BuildGraph lists every entity of the project and the references between them
+func (api *ApiRequest) BuildGraph(org, project string, copyCD, copyFF bool, logger *zap.Logger) (*Graph, error) {
+
+	logger.Info("Building dependency graph",
+		zap.String("project", project),
+	)
+
+	g := NewGraph()
+	entities := map[GraphNode]interface{}{}
+	for _, l := range api.verifyListers(copyCD, copyFF, logger) {
+		if !referencingEntities[l.entity] {
+			continue
+		}
+		found, err := l.list(org, project)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", l.entity, err)
+		}
+		for key, entity := range found {
+			normalized := normalizeEntity(entity)
+			g.entities = append(g.entities, normalized)
+			// EVERY VERSION OF A TEMPLATE IS THE SAME NODE
+			if l.entity == "Template" {
+				key, _, _ = strings.Cut(key, "/")
+			}
+			entities[GraphNode{Entity: l.entity, Key: key}] = normalized
+		}
+	}
+
+	for node := range entities {
+		g.nodes = append(g.nodes, node)
+	}
+	sort.Slice(g.nodes, func(i, j int) bool {
+		if g.nodes[i].Entity != g.nodes[j].Entity {
+			return g.nodes[i].Entity < g.nodes[j].Entity
+		}
+		return g.nodes[i].Key < g.nodes[j].Key
+	})
+
+	for _, node := range g.nodes {
+		refs := collectProjectReferences(node, entities[node])
+		seen := map[GraphNode]bool{}
+		for _, ref := range refs {
+			if _, ok := entities[ref]; !ok || ref == node || seen[ref] {
+				continue
+			}
+			seen[ref] = true
+			g.deps[node] = append(g.deps[node], ref)
+		}
+		sort.Slice(g.deps[node], func(i, j int) bool {
+			return g.deps[node][i].String() < g.deps[node][j].String()
+		})
+	}
+
+	return g, nil
+}
+
+// Nodes returns every entity of the graph
+func (g *Graph) Nodes() []GraphNode {
+	if g == nil {
+		return nil
+	}
+	return append([]GraphNode{}, g.nodes...)
+}
+
+// scanned returns every entity the graph was built from, false without a graph
+func (g *Graph) scanned() ([]interface{}, bool) {
+	if g == nil {
+		return nil, false
+	}
+	return g.entities, true
+}
+
+// Dependencies returns the entities an entity references
+func (g *Graph) Dependencies(node GraphNode) []GraphNode {
+	if g == nil {
+		return nil
+	}
+	return append([]GraphNode{}, g.deps[node]...)
+}
+
+// Order returns the indexes of the entity types of the operations, ordered so every type
+// comes after the types it depends on. The given order is kept between independent types
+// and breaks the cycles between types, such as connectors that use secrets stored in
+// another connector.
+func (g *Graph) Order(entities []string) []int {
+	after := make([]map[int]bool, len(entities))
+	for i := range entities {
+		after[i] = map[int]bool{}
+	}
+	if g != nil {
+		for node, deps := range g.deps {
+			for _, dep := range deps {
+				if dep.Entity == node.Entity {
+					continue
+				}
+				for i, entity := range entities {
+					if entity != node.Entity {
+						continue
+					}
+					for j, other := range entities {
+						if other == dep.Entity {
+							after[i][j] = true
+						}
+					}
+				}
+			}
+		}
+	}
+	return orderIndexes(after)
+}
+
+// Levels groups the indexes of the entities of one type so every entity is in a later
+// level than the entities of the same type it depends on. The entities of a level do not
+// depend on each other and can be created at the same time.
+func (g *Graph) Levels(entity string, keys []string) [][]int {
+	if g == nil || len(keys) == 0 {
+		all := make([]int, len(keys))
+		for i := range keys {
+			all[i] = i
+		}
+		return [][]int{all}
+	}
+
+	index := map[string][]int{}
+	for i, key := range keys {
+		index[key] = append(index[key], i)
+	}
+
+	// THE DEPTH OF A KEY IS COMPUTED ONCE, HOWEVER MANY ENTITIES DEPEND ON IT. A DEPTH
+	// CUT SHORT BY A CYCLE DEPENDS ON WHERE THE CYCLE WAS ENTERED AND IS NOT KEPT.
+	level := make([]int, len(keys))
+	depths := map[string]int{}
+	var depth func(key string, visiting map[string]bool) (int, bool)
+	depth = func(key string, visiting map[string]bool) (int, bool) {
+		if d, ok := depths[key]; ok {
+			return d, false
+		}
+		if visiting[key] {
+			return 0, true
+		}
+		visiting[key] = true
+		defer delete(visiting, key)
+
+		d, cut := 0, false
+		for _, dep := range g.deps[GraphNode{Entity: entity, Key: key}] {
+			if dep.Entity != entity || len(index[dep.Key]) == 0 {
+				continue
+			}
+			l, c := depth(dep.Key, visiting)
+			if l+1 > d {
+				d = l + 1
+			}
+			cut = cut || c
+		}
+		if !cut {
+			depths[key] = d
+		}
+		return d, cut
+	}
+
+	levels := [][]int{}
+	for i, key := range keys {
+		level[i], _ = depth(key, map[string]bool{})
+		for len(levels) <= level[i] {
+			levels = append(levels, []int{})
+		}
+		levels[level[i]] = append(levels[level[i]], i)
+	}
+	return levels
+}
+
+// Blocked returns the dependency an entity is blocked by when one of them failed, or an
+// empty string. A blocked entity counts as failed for its own dependents.
+func (g *Graph) Blocked(entity, key string) string {
+	if g == nil {
+		return ""
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	node := GraphNode{Entity: entity, Key: key}
+	for _, dep := range g.deps[node] {
+		if g.failed[dep] {
+			g.failed[node] = true
+			return dep.String()
+		}
+	}
+	return ""
+}
+
+// Failed records an entity that could not be created
+func (g *Graph) Failed(entity, key string) {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.failed[GraphNode{Entity: entity, Key: key}] = true
+}
+
+// blocked reports whether an entity is skipped because one of its dependencies failed
+func (api *ApiRequest) blocked(entity, key string, logger *zap.Logger) bool {
+	by := api.Graph.Blocked(entity, key)
+	if by == "" {
+		return false
+	}
+
+	logger.Warn("Skipping entity, a dependency failed",
+		zap.String("entity", entity),
+		zap.String("identifier", key),
+		zap.String("blockedBy", by),
+	)
+	api.Stats.AddBlocked(model.BlockedEntry{
+		Entity:     entity,
+		Identifier: key,
+		BlockedBy:  by,
+	})
+	return true
+}
+
+// orderIndexes sorts indexes topologically, after[i] are the indexes i comes after. The
+// indexes keep their order unless one must be pulled forward because an earlier index
+// depends on it, and a cycle is broken at its lowest index.
+func orderIndexes(after []map[int]bool) []int {
+	done := make([]bool, len(after))
+	order := []int{}
+
+	ready := func(i int) bool {
+		for j := range after[i] {
+			if !done[j] && j != i {
+				return false
+			}
+		}
+		return true
+	}
+
+	for len(order) < len(after) {
+		first := 0
+		for done[first] {
+			first++
+		}
+
+		// THE LOWEST READY INDEX THE FIRST ONE WAITS FOR, DIRECTLY OR NOT
+		next := first
+		if !ready(first) {
+			seen := map[int]bool{first: true}
+			pending := []int{first}
+			for len(pending) > 0 {
+				i := pending[0]
+				pending = pending[1:]
+				for j := range after[i] {
+					if done[j] || seen[j] {
+						continue
+					}
+					seen[j] = true
+					if ready(j) {
+						if next == first || j < next {
+							next = j
+						}
+						continue
+					}
+					pending = append(pending, j)
+				}
+			}
+		}
+
+		done[next] = true
+		order = append(order, next)
+	}
+
+	return order
+}
+
+// collectProjectReferences walks a normalized entity and returns the entities of the same
+// project it references
+func collectProjectReferences(node GraphNode, value interface{}) []GraphNode {
+	found := []GraphNode{}
+
+	scope, _, scoped := strings.Cut(node.Key, "/")
+	switch node.Entity {
+	case "Infrastructure":
+		found = append(found, GraphNode{Entity: "Environment", Key: scope})
+	case "Service Override":
+		_, service, _ := strings.Cut(node.Key, "/")
+		found = append(found, GraphNode{Entity: "Environment", Key: scope}, GraphNode{Entity: "Service", Key: service})
+	case "Input Set", "Trigger":
+		if scoped {
+			found = append(found, GraphNode{Entity: "Pipeline", Key: scope})
+		}
+	}
+
+	// INPUT SETS ARE REFERENCED WITHIN THEIR PIPELINE
+	pipeline := ""
+	switch node.Entity {
+	case "Pipeline":
+		pipeline = node.Key
+	case "Input Set", "Trigger":
+		pipeline = scope
+	}
+
+	var walk func(value interface{})
+	walk = func(value interface{}) {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			environment, _ := v["environmentRef"].(string)
+			// THE INPUT SETS OF A PIPELINE STAGE ARE THOSE OF THE CHILD PIPELINE
+			scope := pipeline
+			if child, ok := v["pipeline"].(string); ok && projectReference(child) {
+				scope = child
+			}
+			for key, field := range v {
+				if entity, ok := renameReferences[key]; ok {
+					refs := []interface{}{field}
+					if list, ok := field.([]interface{}); ok {
+						refs = list
+					}
+					for _, ref := range refs {
+						s, ok := ref.(string)
+						if !ok || !projectReference(s) {
+							continue
+						}
+						switch entity {
+						case "Input Set":
+							found = append(found, GraphNode{Entity: entity, Key: scope + "/" + s})
+						default:
+							found = append(found, GraphNode{Entity: entity, Key: s})
+						}
+					}
+					continue
+				}
+				switch key {
+				case "pipeline":
+					// A PIPELINE STAGE REFERENCES THE CHILD PIPELINE BY ITS IDENTIFIER
+					if s, ok := field.(string); ok && projectReference(s) {
+						found = append(found, GraphNode{Entity: "Pipeline", Key: s})
+						continue
+					}
+				case "infrastructureDefinitions":
+					if list, ok := field.([]interface{}); ok && projectReference(environment) {
+						for _, item := range list {
+							if infra, ok := item.(map[string]interface{}); ok {
+								if id, ok := infra["identifier"].(string); ok && projectReference(id) {
+									found = append(found, GraphNode{Entity: "Infrastructure", Key: environment + "/" + id})
+								}
+							}
+						}
+					}
+				}
+				walk(field)
+			}
+		case []interface{}:
+			for _, item := range v {
+				walk(item)
+			}
+		case string:
+			for _, match := range secretExpression.FindAllStringSubmatch(v, -1) {
+				if projectReference(match[2]) {
+					found = append(found, GraphNode{Entity: "Secret", Key: match[2]})
+				}
+			}
+		}
+	}
+	walk(value)
+
+	return found
+}
+
+// projectReference reports whether a reference is to an entity of the project itself
+func projectReference(value string) bool {
+	if value == "" || value == HARNESS_SECRET_MANAGER || strings.Contains(value, "<+") {
+		return false
+	}
+	return !strings.HasPrefix(value, "org.") && !strings.HasPrefix(value, "account.")
+}
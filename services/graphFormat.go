@@ -0,0 +1,175 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"harness-copy-project/model"
+)
+
+const (
+	GraphFormatDOT     = "dot"
+	GraphFormatMermaid = "mermaid"
+	GraphFormatJSON    = "json"
+)
+
+// GraphFormats maps every output format of the dependency graph to its file extension
+var GraphFormats = map[string]string{
+	GraphFormatDOT:     ".dot",
+	GraphFormatMermaid: ".mmd",
+	GraphFormatJSON:    ".json",
+}
+
+// GraphFormatNames lists the supported output formats
+func GraphFormatNames() []string {
+	names := []string{}
+	for name := range GraphFormats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Closure returns the part of the graph around one entity: the entities it references
+// and the entities that reference it, directly or not
+func (g *Graph) Closure(node GraphNode) (*Graph, error) {
+	if g == nil || !g.has(node) {
+		return nil, fmt.Errorf("%s not found in the project", node)
+	}
+
+	dependents := map[GraphNode][]GraphNode{}
+	for from, deps := range g.deps {
+		for _, to := range deps {
+			dependents[to] = append(dependents[to], from)
+		}
+	}
+
+	keep := map[GraphNode]bool{node: true}
+	for _, edges := range []map[GraphNode][]GraphNode{g.deps, dependents} {
+		pending := []GraphNode{node}
+		for len(pending) > 0 {
+			n := pending[0]
+			pending = pending[1:]
+			for _, next := range edges[n] {
+				if !keep[next] {
+					keep[next] = true
+					pending = append(pending, next)
+				}
+			}
+		}
+	}
+
+	closure := NewGraph()
+	for _, n := range g.nodes {
+		if !keep[n] {
+			continue
+		}
+		closure.nodes = append(closure.nodes, n)
+		for _, dep := range g.deps[n] {
+			if keep[dep] {
+				closure.deps[n] = append(closure.deps[n], dep)
+			}
+		}
+	}
+	return closure, nil
+}
+
+func (g *Graph) has(node GraphNode) bool {
+	for _, n := range g.nodes {
+		if n == node {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteGraph writes the graph of a project in one of the GraphFormats
+func (g *Graph) WriteGraph(w io.Writer, format, org, project string) error {
+	switch format {
+	case GraphFormatDOT:
+		return g.writeDOT(w, project)
+	case GraphFormatMermaid:
+		return g.writeMermaid(w)
+	case GraphFormatJSON:
+		return g.writeJSON(w, org, project)
+	}
+	return fmt.Errorf("unsupported graph format '%s'", format)
+}
+
+// graphID identifies a node in every output format
+func graphID(n GraphNode) string {
+	return n.Entity + ":" + n.Key
+}
+
+func (g *Graph) writeDOT(w io.Writer, project string) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "digraph %q {\n", project)
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box];\n")
+	for _, n := range g.nodes {
+		fmt.Fprintf(&b, "  %q [label=%q];\n", graphID(n), n.Entity+"\n"+n.Key)
+	}
+	for _, n := range g.nodes {
+		for _, dep := range g.deps[n] {
+			fmt.Fprintf(&b, "  %q -> %q;\n", graphID(n), graphID(dep))
+		}
+	}
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (g *Graph) writeMermaid(w io.Writer) error {
+	var b strings.Builder
+
+	// MERMAID NODE IDS CAN NOT HOLD SPACES OR SLASHES, THE ENTITY IS IN THE LABEL
+	ids := map[GraphNode]string{}
+	b.WriteString("graph LR\n")
+	for i, n := range g.nodes {
+		ids[n] = fmt.Sprintf("n%d", i)
+		label := strings.ReplaceAll(n.Entity+": "+n.Key, `"`, "#quot;")
+		fmt.Fprintf(&b, "  %s[\"%s\"]\n", ids[n], label)
+	}
+	for _, n := range g.nodes {
+		for _, dep := range g.deps[n] {
+			fmt.Fprintf(&b, "  %s --> %s\n", ids[n], ids[dep])
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (g *Graph) writeJSON(w io.Writer, org, project string) error {
+	graph := model.DependencyGraph{
+		Org:     org,
+		Project: project,
+		Nodes:   []model.DependencyNode{},
+		Edges:   []model.DependencyEdge{},
+	}
+	for _, n := range g.nodes {
+		graph.Nodes = append(graph.Nodes, model.DependencyNode{
+			ID:         graphID(n),
+			Entity:     n.Entity,
+			Identifier: n.Key,
+		})
+		for _, dep := range g.deps[n] {
+			graph.Edges = append(graph.Edges, model.DependencyEdge{
+				From: graphID(n),
+				To:   graphID(dep),
+			})
+		}
+	}
+
+	data, err := json.MarshalIndent(graph, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
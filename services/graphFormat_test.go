@@ -0,0 +1,77 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"harness-copy-project/model"
+)
+
+// newTestGraph builds a graph of a pipeline run by a trigger, using a template and a
+// service, next to an unrelated connector
+func newTestGraph() *Graph {
+	g := NewGraph()
+	g.nodes = []GraphNode{
+		{Entity: "Connector", Key: "github"},
+		{Entity: "Connector", Key: "unused"},
+		{Entity: "Pipeline", Key: "deploy"},
+		{Entity: "Service", Key: "api"},
+		{Entity: "Template", Key: "stage"},
+		{Entity: "Trigger", Key: "deploy/nightly"},
+	}
+	g.deps[GraphNode{Entity: "Pipeline", Key: "deploy"}] = []GraphNode{{Entity: "Service", Key: "api"}, {Entity: "Template", Key: "stage"}}
+	g.deps[GraphNode{Entity: "Service", Key: "api"}] = []GraphNode{{Entity: "Connector", Key: "github"}}
+	g.deps[GraphNode{Entity: "Trigger", Key: "deploy/nightly"}] = []GraphNode{{Entity: "Pipeline", Key: "deploy"}}
+	return g
+}
+
+func TestGraph_Closure(t *testing.T) {
+	g := newTestGraph()
+
+	closure, err := g.Closure(GraphNode{Entity: "Service", Key: "api"})
+	assert.NoError(t, err)
+	// THE PIPELINE AND ITS TRIGGER USE THE SERVICE, THE TEMPLATE OF THE PIPELINE DOES NOT
+	assert.Equal(t, []GraphNode{
+		{Entity: "Connector", Key: "github"},
+		{Entity: "Pipeline", Key: "deploy"},
+		{Entity: "Service", Key: "api"},
+		{Entity: "Trigger", Key: "deploy/nightly"},
+	}, closure.Nodes())
+	assert.Equal(t, []GraphNode{{Entity: "Service", Key: "api"}}, closure.Dependencies(GraphNode{Entity: "Pipeline", Key: "deploy"}))
+
+	_, err = g.Closure(GraphNode{Entity: "Pipeline", Key: "missing"})
+	assert.Error(t, err)
+}
+
+func TestGraph_WriteGraph(t *testing.T) {
+	g := newTestGraph()
+
+	var dot bytes.Buffer
+	assert.NoError(t, g.WriteGraph(&dot, GraphFormatDOT, "org", "app"))
+	assert.Contains(t, dot.String(), "digraph \"app\" {\n")
+	assert.Contains(t, dot.String(), "  \"Trigger:deploy/nightly\" [label=\"Trigger\\ndeploy/nightly\"];\n")
+	assert.Contains(t, dot.String(), "  \"Pipeline:deploy\" -> \"Template:stage\";\n")
+
+	var mermaid bytes.Buffer
+	assert.NoError(t, g.WriteGraph(&mermaid, GraphFormatMermaid, "org", "app"))
+	assert.Contains(t, mermaid.String(), "graph LR\n")
+	assert.Contains(t, mermaid.String(), "  n2[\"Pipeline: deploy\"]\n")
+	assert.Contains(t, mermaid.String(), "  n5 --> n2\n")
+
+	var out bytes.Buffer
+	assert.NoError(t, g.WriteGraph(&out, GraphFormatJSON, "org", "app"))
+	graph := model.DependencyGraph{}
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &graph))
+	assert.Equal(t, "app", graph.Project)
+	assert.Len(t, graph.Nodes, 6)
+	assert.Equal(t, []model.DependencyEdge{
+		{From: "Pipeline:deploy", To: "Service:api"},
+		{From: "Pipeline:deploy", To: "Template:stage"},
+		{From: "Service:api", To: "Connector:github"},
+		{From: "Trigger:deploy/nightly", To: "Pipeline:deploy"},
+	}, graph.Edges)
+
+	assert.Error(t, g.WriteGraph(&out, "svg", "org", "app"))
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"harness-copy-project/model"
+)
+
+func TestCollectProjectReferences(t *testing.T) {
+	pipeline := normalizeEntity(map[string]interface{}{
+		"yaml": `pipeline:
+  identifier: deploy
+  stages:
+    - stage:
+        template:
+          templateRef: deployStage
+          versionLabel: v1
+    - stage:
+        spec:
+          pipeline: child
+          inputSetReferences:
+            - defaults
+    - stage:
+        spec:
+          service:
+            serviceRef: api
+          environment:
+            environmentRef: prod
+            infrastructureDefinitions:
+              - identifier: k8s
+          execution:
+            steps:
+              - step:
+                  spec:
+                    connectorRef: org.github
+                    command: echo <+secrets.getValue("token")> <+secrets.getValue("account.token")>
+`,
+	})
+
+	assert.ElementsMatch(t, []GraphNode{
+		{Entity: "Template", Key: "deployStage"},
+		{Entity: "Pipeline", Key: "child"},
+		{Entity: "Input Set", Key: "child/defaults"},
+		{Entity: "Service", Key: "api"},
+		{Entity: "Environment", Key: "prod"},
+		{Entity: "Infrastructure", Key: "prod/k8s"},
+		{Entity: "Secret", Key: "token"},
+	}, collectProjectReferences(GraphNode{Entity: "Pipeline", Key: "deploy"}, pipeline))
+
+	// ENTITIES OF A SCOPE DEPEND ON IT
+	assert.ElementsMatch(t, []GraphNode{
+		{Entity: "Environment", Key: "prod"},
+		{Entity: "Service", Key: "api"},
+	}, collectProjectReferences(GraphNode{Entity: "Service Override", Key: "prod/api"}, nil))
+	assert.ElementsMatch(t, []GraphNode{
+		{Entity: "Pipeline", Key: "deploy"},
+	}, collectProjectReferences(GraphNode{Entity: "Trigger", Key: "deploy/nightly"}, nil))
+}
+
+func TestGraph_Order(t *testing.T) {
+	g := NewGraph()
+	g.deps[GraphNode{Entity: "Connector", Key: "vault"}] = []GraphNode{{Entity: "Secret", Key: "token"}}
+	g.deps[GraphNode{Entity: "Secret", Key: "stored"}] = []GraphNode{{Entity: "Connector", Key: "vault"}}
+	g.deps[GraphNode{Entity: "Pipeline", Key: "deploy"}] = []GraphNode{{Entity: "Input Set", Key: "deploy/defaults"}}
+
+	// THE CYCLE BETWEEN SECRETS AND CONNECTORS KEEPS THE DEFAULT ORDER, PIPELINES ARE
+	// MOVED AFTER THE INPUT SETS THEY REFERENCE
+	entities := []string{"Secret", "Connector", "Secret", "Pipeline", "Input Set", "Tag"}
+	assert.Equal(t, []int{0, 1, 2, 4, 3, 5}, g.Order(entities))
+
+	var nilGraph *Graph
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5}, nilGraph.Order(entities))
+}
+
+func TestGraph_Levels(t *testing.T) {
+	g := NewGraph()
+	g.deps[GraphNode{Entity: "Template", Key: "pipeline"}] = []GraphNode{{Entity: "Template", Key: "stage"}}
+	g.deps[GraphNode{Entity: "Template", Key: "stage"}] = []GraphNode{{Entity: "Template", Key: "step"}, {Entity: "Secret", Key: "token"}}
+	// A CYCLE DOES NOT LOOP FOREVER, ITS ENTITIES END UP IN THE SAME LEVEL
+	g.deps[GraphNode{Entity: "Template", Key: "a"}] = []GraphNode{{Entity: "Template", Key: "b"}}
+	g.deps[GraphNode{Entity: "Template", Key: "b"}] = []GraphNode{{Entity: "Template", Key: "a"}}
+
+	assert.Equal(t, [][]int{{1}, {2}, {0, 3, 4}}, g.Levels("Template", []string{"pipeline", "step", "stage", "a", "b"}))
+
+	var nilGraph *Graph
+	assert.Equal(t, [][]int{{0, 1, 2}}, nilGraph.Levels("Template", []string{"pipeline", "step", "stage"}))
+}
+
+func TestGraph_LevelsOfSharedDependencies(t *testing.T) {
+	// EVERY TEMPLATE USES THE TWO BEFORE IT, WALKING EVERY PATH WOULD NEVER FINISH
+	g := NewGraph()
+	keys := []string{}
+	for i := 0; i < 80; i++ {
+		key := fmt.Sprintf("t%d", i)
+		keys = append(keys, key)
+		for _, dep := range []int{i - 1, i - 2} {
+			if dep >= 0 {
+				g.deps[GraphNode{Entity: "Template", Key: key}] = append(g.deps[GraphNode{Entity: "Template", Key: key}], GraphNode{Entity: "Template", Key: fmt.Sprintf("t%d", dep)})
+			}
+		}
+	}
+
+	levels := g.Levels("Template", keys)
+	assert.Len(t, levels, 80)
+	assert.Equal(t, []int{79}, levels[79])
+}
+
+func TestGraph_BlockedByFailedDependency(t *testing.T) {
+	g := NewGraph()
+	g.deps[GraphNode{Entity: "Input Set", Key: "deploy/defaults"}] = []GraphNode{{Entity: "Pipeline", Key: "deploy"}}
+	g.deps[GraphNode{Entity: "Trigger", Key: "deploy/nightly"}] = []GraphNode{{Entity: "Input Set", Key: "deploy/defaults"}, {Entity: "Pipeline", Key: "deploy"}}
+
+	assert.Empty(t, g.Blocked("Input Set", "deploy/defaults"))
+
+	g.Failed("Pipeline", "deploy")
+	assert.Equal(t, "Pipeline 'deploy'", g.Blocked("Input Set", "deploy/defaults"))
+	// A BLOCKED ENTITY BLOCKS ITS OWN DEPENDENTS
+	assert.Equal(t, "Input Set 'deploy/defaults'", g.Blocked("Trigger", "deploy/nightly"))
+}
+
+func TestPipelineCopy_BlockedByFailedChildPipeline(t *testing.T) {
+	// THE PARENT RUNS THE CHILD, THE CHILD CANNOT BE CREATED
+	fake := newFakeHarness(t)
+	fake.addPipeline("src", "src", "parent", "pipeline:\n  identifier: parent\n  stages:\n    - stage:\n        spec:\n          pipeline: child\n").Name = "Parent"
+	fake.addPipeline("src", "src", "child", "pipeline:\n  identifier: child\n").Name = "Child"
+	fake.invalid["child"] = true
+
+	api := fake.api()
+
+	api.Graph = NewGraph()
+	api.Graph.deps[GraphNode{Entity: "Pipeline", Key: "parent"}] = collectProjectReferences(GraphNode{Entity: "Pipeline", Key: "parent"}, normalizeEntity(map[string]interface{}{
+		"yamlPipeline": "pipeline:\n  identifier: parent\n  stages:\n    - stage:\n        spec:\n          pipeline: child\n",
+	}))
+
+	err := NewPipelineOperation(api, "src", "src", "target", "target", zap.NewNop(), false).Copy()
+	assert.NoError(t, err)
+
+	// THE CHILD IS CREATED FIRST, THE PARENT IS NOT SENT ONCE THE CHILD FAILED
+	assert.Len(t, fake.sent(http.MethodPost, CREATE_PIPELINE), 1)
+	assert.Equal(t, []model.BlockedEntry{
+		{Entity: "Pipeline", Identifier: "parent", BlockedBy: "Pipeline 'child'"},
+	}, api.Stats.GetBlocked())
+}
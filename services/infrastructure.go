@@ -2,6 +2,7 @@ package services
 
 import (
 	"encoding/json"
+	"strconv"
 
 	"github.com/schollz/progressbar/v3"
 	"go.uber.org/zap"
@@ -38,7 +39,7 @@ func (c InfrastructureContext) Copy() error {
 		zap.String("project", c.sourceProject),
 	)
 
-	envs, err := c.api.listEnvironments(c.sourceOrg, c.sourceProject, c.logger)
+	envs, err := c.api.source().listEnvironments(c.sourceOrg, c.sourceProject, c.logger)
 	if err != nil {
 		c.logger.Error("Failed to retrive environments",
 			zap.String("Project", c.sourceProject),
@@ -55,7 +56,7 @@ func (c InfrastructureContext) Copy() error {
 
 	for _, env := range envs {
 		e := env.Environment
-		infras, err := c.api.listInfraDef(c.sourceOrg, c.sourceProject, e.Identifier, c.logger)
+		infras, err := c.api.source().listInfraDef(c.sourceOrg, c.sourceProject, e.Identifier, c.logger)
 		if err != nil {
 			c.logger.Error("Failed to retrive infrastructure",
 				zap.String("Project", c.sourceProject),
@@ -68,35 +69,102 @@ func (c InfrastructureContext) Copy() error {
 			bar.ChangeMax(bar.GetMax() + len(infras))
 		}
 
+		// WHEN THE TARGET CANNOT BE LISTED IT IS UNKNOWN WHETHER THE ENTITIES EXIST, SO THEIR
+		// PLAN FAILS
+		existing := map[string]bool{}
+		var listErr error
+		if c.api.Plan != nil {
+			targetInfras, err := c.api.listInfraDef(c.targetOrg, c.targetProject, c.api.Rename.Identifier("Environment", e.Identifier), c.logger)
+			if err != nil {
+				c.logger.Error("Failed to list the infrastructure of the target project",
+					zap.String("Project", c.targetProject),
+					zap.Error(err),
+				)
+				listErr = err
+			}
+			for _, ti := range targetInfras {
+				existing[ti.Infrastructure.Identifier] = true
+			}
+		}
+
 		for _, infra := range infras {
 			i := infra.Infrastructure
 
-			IncrementInfrastructureTotal()
+			c.api.Stats.IncrementInfrastructureTotal()
+
+			if c.api.Checkpoint.Done("Infrastructure", e.Identifier+"/"+i.Identifier) {
+				c.logger.Info("Skipping infrastructure copied by a previous run",
+					zap.String("identifier", e.Identifier+"/"+i.Identifier),
+				)
+				c.api.Stats.IncrementInfrastructureMoved()
+				if c.showPB {
+					bar.Add(1)
+				}
+				continue
+			}
+
+			if c.api.unchanged("Infrastructure", e.Identifier+"/"+i.Identifier, infra.LastModifiedAt, c.logger) {
+				c.api.Stats.IncrementInfrastructureMoved()
+				if c.showPB {
+					bar.Add(1)
+				}
+				continue
+			}
 
 			c.logger.Info("Processing infrastructure",
 				zap.String("infrastructure", i.Name),
 				zap.String("targetProject", c.targetProject),
 			)
-			newYaml := updateYaml(i.Yaml, c.targetOrg, c.targetProject)
 
-			err := c.api.createInfrastructure(&model.CreateInfrastructureRequest{
-				Name:              i.Name,
-				Identifier:        i.Identifier,
+			if c.api.Plan != nil {
+				err := listErr
+				if err == nil {
+					err = checkValidity(infra.EntityValidityDetails)
+				}
+				if err == nil {
+					err = checkYaml(i.Yaml)
+				}
+				c.api.Plan.Record("Infrastructure", e.Identifier+"/"+i.Identifier, i.Name, existing[c.api.Rename.Identifier("Infrastructure", i.Identifier)], err)
+				if c.showPB {
+					bar.Add(1)
+				}
+				continue
+			}
+
+			if c.api.blocked("Infrastructure", e.Identifier+"/"+i.Identifier, c.logger) {
+				if c.showPB {
+					bar.Add(1)
+				}
+				continue
+			}
+			newYaml := c.api.Rename.Yaml(updateYaml(i.Yaml, c.targetOrg, c.targetProject))
+
+			req := &model.CreateInfrastructureRequest{
+				Name:              c.api.Rename.Name("Infrastructure", i.Name),
+				Identifier:        c.api.Rename.Identifier("Infrastructure", i.Identifier),
 				OrgIdentifier:     c.targetOrg,
 				ProjectIdentifier: c.targetProject,
 				Description:       i.Description,
-				EnvironmentRef:    e.Identifier,
+				EnvironmentRef:    c.api.Rename.Identifier("Environment", e.Identifier),
 				DeploymentType:    i.DeploymentType,
 				Type:              i.Type,
 				Yaml:              newYaml,
+			}
+			err := c.api.createInfrastructure(req, c.logger)
+			err = c.api.resolveConflict("Infrastructure", e.Identifier+"/"+i.Identifier, err, func() error {
+				return c.api.updateInfrastructure(req, c.logger)
 			}, c.logger)
 			if err != nil {
 				c.logger.Error("Failed to create infrastructure",
 					zap.String("infrastructure", i.Name),
 					zap.Error(err),
 				)
+				c.api.Graph.Failed("Infrastructure", e.Identifier+"/"+i.Identifier)
 			} else {
-				IncrementInfrastructureMoved()
+				c.api.Stats.IncrementInfrastructureMoved()
+				if err := c.api.Checkpoint.Record("Infrastructure", e.Identifier+"/"+i.Identifier); err != nil {
+					c.logger.Warn("Failed to update checkpoint", zap.Error(err))
+				}
 			}
 
 			if c.showPB {
@@ -122,44 +190,60 @@ func (api *ApiRequest) listInfraDef(org, project, envId string, logger *zap.Logg
 		zap.String("environment", envId),
 	)
 
-	IncrementApiCalls()
+	infras := []*model.InfraDefListContent{}
 
-	resp, err := api.Client.R().
-		SetHeader("x-api-key", api.Token).
-		SetHeader("Content-Type", "application/json").
-		SetQueryParams(map[string]string{
-			"accountIdentifier":     api.Account,
-			"orgIdentifier":         org,
-			"projectIdentifier":     project,
-			"environmentIdentifier": envId,
-			"size":                  "1000",
-		}).
-		Get(api.BaseURL + INFRASTRUCTURE)
-	if err != nil {
-		logger.Error("Failed to request to list of infrastructure",
-			zap.Error(err),
-		)
-		return nil, err
-	}
-	if resp.IsError() {
-		logger.Error("Error response from API when listing infrastructure",
-			zap.String("response",
-				resp.String(),
-			),
-		)
-		return nil, handleErrorResponse(resp)
-	}
+	err := api.paginate(PAGE_SIZE, func(page int) (listPage, error) {
+		api.Stats.IncrementApiCalls()
+
+		resp, err := api.Client.R().
+			SetHeader("x-api-key", api.Token).
+			SetHeader("Content-Type", "application/json").
+			SetQueryParams(map[string]string{
+				"accountIdentifier":     api.Account,
+				"orgIdentifier":         org,
+				"projectIdentifier":     project,
+				"environmentIdentifier": envId,
+				"page":                  strconv.Itoa(page),
+				"size":                  strconv.Itoa(PAGE_SIZE),
+			}).
+			Get(api.BaseURL + INFRASTRUCTURE)
+		if err != nil {
+			logger.Error("Failed to request to list of infrastructure",
+				zap.Error(err),
+			)
+			return listPage{}, err
+		}
+		if resp.IsError() {
+			logger.Error("Error response from API when listing infrastructure",
+				zap.String("response",
+					resp.String(),
+				),
+			)
+			return listPage{}, handleErrorResponse(resp)
+		}
 
-	result := model.InfraDefListResponse{}
-	err = json.Unmarshal(resp.Body(), &result)
+		result := model.InfraDefListResponse{}
+		err = json.Unmarshal(resp.Body(), &result)
+		if err != nil {
+			logger.Error("Failed to parse response from API",
+				zap.Error(err),
+			)
+			return listPage{}, err
+		}
+
+		infras = append(infras, result.Data.Content...)
+
+		return listPage{
+			index: int(result.Data.PageIndex),
+			total: int(result.Data.TotalPages),
+			items: len(result.Data.Content),
+		}, nil
+	})
 	if err != nil {
-		logger.Error("Failed to parse response from API",
-			zap.Error(err),
-		)
 		return nil, err
 	}
 
-	return result.Data.Content, nil
+	return infras, nil
 }
 
 func (api *ApiRequest) createInfrastructure(infra *model.CreateInfrastructureRequest, logger *zap.Logger) error {
@@ -168,7 +252,16 @@ func (api *ApiRequest) createInfrastructure(infra *model.CreateInfrastructureReq
 		zap.String("project", infra.ProjectIdentifier),
 	)
 
-	IncrementApiCalls()
+	if api.Bundle != nil {
+		return api.Bundle.Add(model.BundleEntry{
+			Entity:      "Infrastructure",
+			Identifier:  infra.EnvironmentRef + "/" + infra.Identifier,
+			Path:        INFRASTRUCTURE,
+			ContentType: "application/json",
+		}, infra, nil)
+	}
+
+	api.Stats.IncrementApiCalls()
 
 	resp, err := api.Client.R().
 		SetHeader("x-api-key", api.Token).
@@ -189,11 +282,11 @@ func (api *ApiRequest) createInfrastructure(infra *model.CreateInfrastructureReq
 		var errorResponse map[string]interface{}
 		if err := json.Unmarshal(resp.Body(), &errorResponse); err == nil {
 			if code, ok := errorResponse["code"].(string); ok && code == "DUPLICATE_FIELD" {
-				// Log as a warning and skip the error
-				logger.Info("Duplicate infrastructure found, ignoring error",
+				// The conflict strategy decides what happens to the existing entity
+				logger.Info("Duplicate infrastructure found",
 					zap.String("infrastructure", infra.Name),
 				)
-				return nil
+				return ErrAlreadyExists
 			}
 		} else {
 			logger.Error(
@@ -207,5 +300,17 @@ func (api *ApiRequest) createInfrastructure(infra *model.CreateInfrastructureReq
 		return handleErrorResponse(resp)
 	}
 
+	api.recordCreated(model.JournalEntry{
+		Entity:      "Infrastructure",
+		Identifier:  infra.Identifier,
+		Org:         infra.OrgIdentifier,
+		Project:     infra.ProjectIdentifier,
+		Environment: infra.EnvironmentRef,
+	}, logger)
+
 	return nil
 }
+
+func (api *ApiRequest) updateInfrastructure(infra *model.CreateInfrastructureRequest, logger *zap.Logger) error {
+	return api.updateEntity(INFRASTRUCTURE, "application/json", infra, nil, logger)
+}
@@ -2,6 +2,8 @@ package services
 
 import (
 	"encoding/json"
+	"fmt"
+	"strconv"
 
 	"github.com/schollz/progressbar/v3"
 	"go.uber.org/zap"
@@ -35,7 +37,7 @@ func (c InputsetContext) Copy() error {
 		zap.String("project", c.sourceProject),
 	)
 
-	pipelines, err := c.api.listPipelines(c.sourceOrg, c.sourceProject, c.logger)
+	pipelines, err := c.api.source().listPipelines(c.sourceOrg, c.sourceProject, c.logger)
 	if err != nil {
 		c.logger.Error("Failed to retrive pipelines",
 			zap.String("Project", c.sourceProject),
@@ -50,41 +52,154 @@ func (c InputsetContext) Copy() error {
 		bar = progressbar.Default(int64(len(pipelines)), "Inputsets")
 	}
 
-	for _, pipeline := range pipelines {
-		inputsets, err := c.api.listInputsets(c.sourceOrg, c.sourceProject, pipeline.Identifier, c.logger)
+	c.api.forEach(len(pipelines), func(i int) {
+		pipeline := pipelines[i]
+
+		inputsets, err := c.api.source().listInputsets(c.sourceOrg, c.sourceProject, pipeline.Identifier, c.logger)
 		if err != nil {
 			c.logger.Error("Failed to retrive inputsets",
 				zap.String("Project", c.sourceProject),
 				zap.Error(err),
 			)
-			continue
+			return
 		}
 
 		if c.showPB {
-			bar.ChangeMax(bar.GetMax() + len(inputsets))
+			growBar(bar, len(inputsets))
+		}
+
+		// WHEN THE TARGET CANNOT BE LISTED IT IS UNKNOWN WHETHER THE ENTITIES EXIST, SO THEIR
+		// PLAN FAILS
+		existing := map[string]bool{}
+		var listErr error
+		if c.api.Plan != nil {
+			targetInputsets, err := c.api.listInputsets(c.targetOrg, c.targetProject, c.api.Rename.Identifier("Pipeline", pipeline.Identifier), c.logger)
+			if err != nil {
+				c.logger.Error("Failed to list the input sets of the target project",
+					zap.String("Project", c.targetProject),
+					zap.Error(err),
+				)
+				listErr = err
+			}
+			for _, ti := range targetInputsets {
+				existing[ti.Identifier] = true
+			}
+		}
+
+		// OVERLAY INPUT SETS ARE CREATED AFTER THE INPUT SETS THEY COMBINE
+		keys := make([]string, len(inputsets))
+		for j, inputset := range inputsets {
+			keys[j] = pipeline.Identifier + "/" + inputset.Identifier
+		}
+		ordered := []*model.ListInputsetContent{}
+		for _, level := range c.api.Graph.Levels("Input Set", keys) {
+			for _, j := range level {
+				ordered = append(ordered, inputsets[j])
+			}
 		}
 
-		for _, inputset := range inputsets {
+		for _, inputset := range ordered {
 
-			IncrementInputSetsTotal()
+			c.api.Stats.IncrementInputSetsTotal()
+
+			if c.api.Checkpoint.Done("Input Set", pipeline.Identifier+"/"+inputset.Identifier) {
+				c.logger.Info("Skipping input set copied by a previous run",
+					zap.String("identifier", pipeline.Identifier+"/"+inputset.Identifier),
+				)
+				c.api.Stats.IncrementInputSetsMoved()
+				if c.showPB {
+					bar.Add(1)
+				}
+				continue
+			}
+
+			if c.api.unchanged("Input Set", pipeline.Identifier+"/"+inputset.Identifier, inputset.LastUpdatedAt, c.logger) {
+				c.api.Stats.IncrementInputSetsMoved()
+				if c.showPB {
+					bar.Add(1)
+				}
+				continue
+			}
 
 			c.logger.Info("Processing Inputset",
 				zap.String("inputset", inputset.Name),
 				zap.String("targetProject", c.targetProject),
 				zap.String("pipeline", pipeline.Name),
 			)
-			is, err := c.api.getInputset(c.sourceOrg, c.sourceProject, pipeline.Identifier, inputset.Identifier, c.logger)
-			if err == nil {
-				newYaml := updateYaml(is.Yaml, c.targetOrg, c.targetProject)
-				err = c.api.createInputset(c.targetOrg, c.targetProject, pipeline.Identifier, newYaml, c.logger)
+			is, err := c.api.source().getInputset(c.sourceOrg, c.sourceProject, pipeline.Identifier, inputset.Identifier, c.logger)
+			if c.api.Plan != nil {
+				if err == nil {
+					err = listErr
+				}
+				if err == nil {
+					err = checkValidity(inputset.EntityValidityDetails)
+				}
+				if err == nil {
+					err = checkYaml(is.Yaml)
+				}
+				if err == nil && is.StoreType == model.Remote {
+					_, err = gitLocation(is.ConnectorRef, is.GitDetails)
+				}
+				c.api.Plan.Record("Input Set", pipeline.Identifier+"/"+inputset.Identifier, inputset.Name, existing[c.api.Rename.Identifier("Input Set", inputset.Identifier)], err)
+				if c.showPB {
+					bar.Add(1)
+				}
+				continue
+			}
+			if c.api.blocked("Input Set", pipeline.Identifier+"/"+inputset.Identifier, c.logger) {
+				if c.showPB {
+					bar.Add(1)
+				}
+				continue
+			}
+			if err == nil && is.StoreType == model.Remote {
+				// A REMOTE INPUT SET IS IMPORTED FROM ITS FILE, AN INLINE COPY NEVER REPLACES IT
+				var location model.GitLocation
+				if location, err = gitLocation(is.ConnectorRef, is.GitDetails); err == nil {
+					location = c.api.Rename.Git(location)
+					err = c.importInputset(pipeline.Identifier, is, location, false)
+				}
+				err = c.api.resolveConflict("Input Set", pipeline.Identifier+"/"+inputset.Identifier, err, func() error {
+					return c.importInputset(pipeline.Identifier, is, location, true)
+				}, c.logger)
+			} else if err == nil && c.api.Git.Stores("Input Set") {
+				// THE INLINE INPUT SET IS WRITTEN TO GIT AND IMPORTED FROM THERE
+				newYaml := c.api.Rename.Yaml(updateYaml(is.Yaml, c.targetOrg, c.targetProject))
+				var location model.GitLocation
+				if location, err = c.api.Git.Write("Input Set", gitFile{
+					org:        c.targetOrg,
+					project:    c.targetProject,
+					identifier: c.api.Rename.Identifier("Input Set", inputset.Identifier),
+					pipeline:   c.api.Rename.Identifier("Pipeline", pipeline.Identifier),
+				}, newYaml, c.logger); err == nil {
+					err = c.importInputset(pipeline.Identifier, is, location, false)
+				}
+				err = c.api.resolveConflict("Input Set", pipeline.Identifier+"/"+inputset.Identifier, err, func() error {
+					return c.importInputset(pipeline.Identifier, is, location, true)
+				}, c.logger)
+			} else if err == nil {
+				newYaml := c.api.Rename.Yaml(updateYaml(is.Yaml, c.targetOrg, c.targetProject))
+				pipelineIdentifier := c.api.Rename.Identifier("Pipeline", pipeline.Identifier)
+				identifier := c.api.Rename.Identifier("Input Set", inputset.Identifier)
+				err = c.api.createInputset(c.targetOrg, c.targetProject, pipelineIdentifier, newYaml, c.logger)
+				err = c.api.resolveConflict("Input Set", pipeline.Identifier+"/"+inputset.Identifier, err, func() error {
+					if existing, err := c.api.getInputset(c.targetOrg, c.targetProject, pipelineIdentifier, identifier, c.logger); err == nil && existing.StoreType == model.Remote {
+						return ErrRemoteInTarget
+					}
+					return c.api.updateInputset(c.targetOrg, c.targetProject, pipelineIdentifier, identifier, newYaml, c.logger)
+				}, c.logger)
 			}
 			if err != nil {
 				c.logger.Error("Failed to create input set",
 					zap.String("input set", inputset.Name),
 					zap.Error(err),
 				)
+				c.api.Graph.Failed("Input Set", pipeline.Identifier+"/"+inputset.Identifier)
 			} else {
-				IncrementInputSetsMoved()
+				c.api.Stats.IncrementInputSetsMoved()
+				if err := c.api.Checkpoint.Record("Input Set", pipeline.Identifier+"/"+inputset.Identifier); err != nil {
+					c.logger.Warn("Failed to update checkpoint", zap.Error(err))
+				}
 			}
 			if c.showPB {
 				bar.Add(1)
@@ -93,7 +208,7 @@ func (c InputsetContext) Copy() error {
 		if c.showPB {
 			bar.Add(1)
 		}
-	}
+	})
 	if c.showPB {
 		bar.Finish()
 	}
@@ -101,6 +216,25 @@ func (c InputsetContext) Copy() error {
 	return nil
 }
 
+// importInputset imports a remote input set into the target from the file it is stored in
+func (c InputsetContext) importInputset(pipeline string, is *model.GetInputsetData, location model.GitLocation, force bool) error {
+	pipelineIdentifier := c.api.Rename.Identifier("Pipeline", pipeline)
+	identifier := c.api.Rename.Identifier("Input Set", is.Identifier)
+	body := model.InputSetImportRequest{
+		InputSetName: c.api.Rename.Name("Input Set", is.Name),
+	}
+
+	return c.api.importRemote(fmt.Sprintf(IMPORT_INPUTSET, identifier), map[string]string{
+		"pipelineIdentifier": pipelineIdentifier,
+	}, location, body, model.JournalEntry{
+		Entity:     "Input Set",
+		Identifier: identifier,
+		Org:        c.targetOrg,
+		Project:    c.targetProject,
+		Pipeline:   pipelineIdentifier,
+	}, pipelineIdentifier+"/"+identifier, force, c.logger)
+}
+
 func (api *ApiRequest) listInputsets(org, project, pipelineIdentifier string, logger *zap.Logger) ([]*model.ListInputsetContent, error) {
 
 	logger.Info("Fetching inputsets",
@@ -108,45 +242,61 @@ func (api *ApiRequest) listInputsets(org, project, pipelineIdentifier string, lo
 		zap.String("project", project),
 	)
 
-	IncrementApiCalls()
+	inputsets := []*model.ListInputsetContent{}
 
-	resp, err := api.Client.R().
-		SetHeader("x-api-key", api.Token).
-		SetHeader("Content-Type", "application/json").
-		SetQueryParams(map[string]string{
-			"accountIdentifier":  api.Account,
-			"orgIdentifier":      org,
-			"projectIdentifier":  project,
-			"pipelineIdentifier": pipelineIdentifier,
-			"inputSetType":       "ALL",
-			"size":               "1000",
-		}).
-		Get(api.BaseURL + "/pipeline/api/inputSets")
-	if err != nil {
-		logger.Error("Failed to request to list of inputsets",
-			zap.Error(err),
-		)
-		return nil, err
-	}
-	if resp.IsError() {
-		logger.Error("Error response from API when listing input sets",
-			zap.String("response",
-				resp.String(),
-			),
-		)
-		return nil, handleErrorResponse(resp)
-	}
+	err := api.paginate(PAGE_SIZE, func(page int) (listPage, error) {
+		api.Stats.IncrementApiCalls()
+
+		resp, err := api.Client.R().
+			SetHeader("x-api-key", api.Token).
+			SetHeader("Content-Type", "application/json").
+			SetQueryParams(map[string]string{
+				"accountIdentifier":  api.Account,
+				"orgIdentifier":      org,
+				"projectIdentifier":  project,
+				"pipelineIdentifier": pipelineIdentifier,
+				"inputSetType":       "ALL",
+				"pageIndex":          strconv.Itoa(page),
+				"pageSize":           strconv.Itoa(PAGE_SIZE),
+			}).
+			Get(api.BaseURL + "/pipeline/api/inputSets")
+		if err != nil {
+			logger.Error("Failed to request to list of inputsets",
+				zap.Error(err),
+			)
+			return listPage{}, err
+		}
+		if resp.IsError() {
+			logger.Error("Error response from API when listing input sets",
+				zap.String("response",
+					resp.String(),
+				),
+			)
+			return listPage{}, handleErrorResponse(resp)
+		}
+
+		result := &model.ListInputsetResponse{}
+		err = json.Unmarshal(resp.Body(), &result)
+		if err != nil {
+			logger.Error("Failed to parse response from API",
+				zap.Error(err),
+			)
+			return listPage{}, err
+		}
+
+		inputsets = append(inputsets, result.Data.Content...)
 
-	result := &model.ListInputsetResponse{}
-	err = json.Unmarshal(resp.Body(), &result)
+		return listPage{
+			index: int(result.Data.PageIndex),
+			total: int(result.Data.TotalPages),
+			items: len(result.Data.Content),
+		}, nil
+	})
 	if err != nil {
-		logger.Error("Failed to parse response from API",
-			zap.Error(err),
-		)
 		return nil, err
 	}
 
-	return result.Data.Content, nil
+	return inputsets, nil
 }
 
 func (api *ApiRequest) getInputset(org, project, pipelineIdentifier, isIdentifier string, logger *zap.Logger) (*model.GetInputsetData, error) {
@@ -156,7 +306,7 @@ func (api *ApiRequest) getInputset(org, project, pipelineIdentifier, isIdentifie
 		zap.String("project", project),
 	)
 
-	IncrementApiCalls()
+	api.Stats.IncrementApiCalls()
 
 	resp, err := api.Client.R().
 		SetHeader("x-api-key", api.Token).
@@ -203,7 +353,21 @@ func (api *ApiRequest) createInputset(org, project, pipelineIdentifier, yaml str
 		zap.String("project", project),
 	)
 
-	IncrementApiCalls()
+	if api.Bundle != nil {
+		return api.Bundle.Add(model.BundleEntry{
+			Entity:      "Input Set",
+			Identifier:  pipelineIdentifier + "/" + yamlIdentifier(yaml),
+			Path:        "/pipeline/api/inputSets",
+			ContentType: "application/yaml",
+			Params: map[string]string{
+				"orgIdentifier":      org,
+				"projectIdentifier":  project,
+				"pipelineIdentifier": pipelineIdentifier,
+			},
+		}, yaml, nil)
+	}
+
+	api.Stats.IncrementApiCalls()
 
 	resp, err := api.Client.R().
 		SetHeader("x-api-key", api.Token).
@@ -223,11 +387,11 @@ func (api *ApiRequest) createInputset(org, project, pipelineIdentifier, yaml str
 		var errorResponse map[string]interface{}
 		if err := json.Unmarshal(resp.Body(), &errorResponse); err == nil {
 			if code, ok := errorResponse["code"].(string); ok && code == "DUPLICATE_FIELD" {
-				// Log as a warning and skip the error
-				logger.Info("Duplicate pipeline input set found, ignoring error",
+				// The conflict strategy decides what happens to the existing entity
+				logger.Info("Duplicate pipeline input set found",
 					zap.String("pipeline", pipelineIdentifier),
 				)
-				return nil
+				return ErrAlreadyExists
 			}
 		} else {
 			logger.Error(
@@ -241,5 +405,21 @@ func (api *ApiRequest) createInputset(org, project, pipelineIdentifier, yaml str
 		return handleErrorResponse(resp)
 	}
 
+	api.recordCreated(model.JournalEntry{
+		Entity:     "Input Set",
+		Identifier: yamlIdentifier(yaml),
+		Org:        org,
+		Project:    project,
+		Pipeline:   pipelineIdentifier,
+	}, logger)
+
 	return nil
 }
+
+func (api *ApiRequest) updateInputset(org, project, pipelineIdentifier, identifier, yaml string, logger *zap.Logger) error {
+	return api.updateEntity("/pipeline/api/inputSets/"+identifier, "application/yaml", yaml, map[string]string{
+		"orgIdentifier":      org,
+		"projectIdentifier":  project,
+		"pipelineIdentifier": pipelineIdentifier,
+	}, logger)
+}
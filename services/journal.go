@@ -0,0 +1,168 @@
+package services
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+	"harness-copy-project/model"
+)
+
+const (
+	JOURNAL_CREATE = "create"
+	JOURNAL_DELETE = "delete"
+)
+
+// Journal is an append-only record of every entity this tool created in a target
+// account, one JSON line per create call. Entities that already existed in the target,
+// including the ones replaced with --conflictStrategy overwrite, are never recorded, so
+// a rollback only ever deletes what a copy added. Deletes made by a rollback are
+// appended as well, so the same entity is never deleted twice.
+// A nil Journal records nothing.
+type Journal struct {
+	path string
+	mu   sync.Mutex
+}
+
+func OpenJournal(path string) *Journal {
+	return &Journal{
+		path: path,
+	}
+}
+
+// Record appends the entry to the journal file
+func (j *Journal) Record(entry model.JournalEntry) error {
+	if j == nil {
+		return nil
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if entry.Time == 0 {
+		entry.Time = time.Now().Unix()
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("error encoding journal entry: %v", err)
+	}
+
+	file, err := os.OpenFile(j.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("error opening journal file: %v", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("error writing journal file: %v", err)
+	}
+
+	return file.Sync()
+}
+
+// Created returns the entities created in the target project that were not deleted by a
+// previous rollback, in the order they were created
+func (j *Journal) Created(account, org, project string) ([]model.JournalEntry, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	file, err := os.Open(j.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error opening journal file: %v", err)
+	}
+	defer file.Close()
+
+	created := []model.JournalEntry{}
+	deleted := map[string]bool{}
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		entry := model.JournalEntry{}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("error reading journal file at line %d: %v", line, err)
+		}
+		if entry.Account != account || entry.Org != org {
+			continue
+		}
+		// THE PROJECT ITSELF IS RECORDED WITHOUT A PROJECT SCOPE
+		if entry.Entity == "Project" {
+			if entry.Identifier != project {
+				continue
+			}
+		} else if entry.Project != project {
+			continue
+		}
+
+		switch entry.Action {
+		case JOURNAL_CREATE:
+			// AN ENTITY CREATED AGAIN AFTER A ROLLBACK IS PENDING AGAIN
+			delete(deleted, journalKey(entry))
+			created = append(created, entry)
+		case JOURNAL_DELETE:
+			deleted[journalKey(entry)] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading journal file: %v", err)
+	}
+
+	// ONLY THE LAST CREATE OF AN ENTITY IS KEPT, AND ONLY WHEN IT WAS NOT DELETED SINCE
+	pending := []model.JournalEntry{}
+	seen := map[string]bool{}
+	for i := len(created) - 1; i >= 0; i-- {
+		key := journalKey(created[i])
+		if seen[key] || deleted[key] {
+			continue
+		}
+		seen[key] = true
+		pending = append([]model.JournalEntry{created[i]}, pending...)
+	}
+
+	return pending, nil
+}
+
+func journalKey(entry model.JournalEntry) string {
+	return fmt.Sprintf("%s/%s/%s/%s/%s", entry.Entity, entry.Environment, entry.Pipeline, entry.Identifier, entry.Version)
+}
+
+// recordCreated journals an entity created by the request. A failure to write the journal
+// does not fail the copy, the entity was created either way.
+func (api *ApiRequest) recordCreated(entry model.JournalEntry, logger *zap.Logger) {
+	entry.Action = JOURNAL_CREATE
+	entry.Account = api.Account
+	api.Stats.AddCreated(entry)
+
+	if api.Journal == nil {
+		return
+	}
+	if err := api.Journal.Record(entry); err != nil {
+		logger.Warn("Failed to update journal", zap.Error(err))
+	}
+}
+
+// yamlValue returns the value of a key of the top level entity of an entity YAML
+func yamlValue(input, key string) string {
+	doc := map[string]map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(input), &doc); err != nil {
+		return ""
+	}
+	for _, entity := range doc {
+		if value, ok := entity[key]; ok {
+			return fmt.Sprintf("%v", value)
+		}
+	}
+	return ""
+}
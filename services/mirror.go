@@ -0,0 +1,124 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"go.uber.org/zap"
+	"harness-copy-project/model"
+)
+
+const (
+	MIRROR_CREATE = "create"
+	MIRROR_UPDATE = "update"
+	MIRROR_DELETE = "delete"
+)
+
+// The entities a mirror never deletes from the target. Access to the target project is
+// managed in the target, and file store nodes and tags are not keyed by identifier.
+var mirrorKeptEntities = map[string]bool{
+	"User":            true,
+	"Role Assignment": true,
+	"Service Account": true,
+	"File Store":      true,
+	"Tag":             true,
+}
+
+// MirrorChanges lists the entities a copy created or replaced in the target project.
+// An entity that failed to be replaced, or could not be, is listed with the reason.
+func MirrorChanges(stats *Stats) []model.MirrorChange {
+	changes := []model.MirrorChange{}
+
+	for _, entry := range stats.GetCreated() {
+		changes = append(changes, model.MirrorChange{
+			Action:     MIRROR_CREATE,
+			Entity:     entry.Entity,
+			Identifier: journalName(entry),
+		})
+	}
+
+	for _, c := range stats.GetConflicts() {
+		if c.Strategy != ConflictOverwrite {
+			continue
+		}
+		changes = append(changes, model.MirrorChange{
+			Action:     MIRROR_UPDATE,
+			Entity:     c.Entity,
+			Identifier: c.Identifier,
+			Error:      c.Reason,
+		})
+	}
+
+	for _, c := range NotReplaced(stats) {
+		changes = append(changes, model.MirrorChange{
+			Action:     MIRROR_UPDATE,
+			Entity:     c.Entity,
+			Identifier: c.Identifier,
+			Error:      c.Reason,
+		})
+	}
+
+	return changes
+}
+
+// MirrorDeletes deletes the entities of the target project the source project does not
+// have, found the same way Verify finds extra entities. Entities are deleted in the
+// reverse order they are copied so every entity is deleted before the entities it
+// depends on. An entity type that fails to be listed on either side is left as it is.
+func (api *ApiRequest) MirrorDeletes(sourceOrg, sourceProject, targetOrg, targetProject string, copyCD, copyFF bool, logger *zap.Logger) ([]model.MirrorChange, []string) {
+
+	verification := api.Verify(sourceOrg, sourceProject, targetOrg, targetProject, copyCD, copyFF, logger)
+
+	changes := []model.MirrorChange{}
+
+	for i := len(verification.Entries) - 1; i >= 0; i-- {
+		e := verification.Entries[i]
+		if e.Status != VerifyExtra || mirrorKeptEntities[e.Entity] {
+			continue
+		}
+
+		change := model.MirrorChange{
+			Action:     MIRROR_DELETE,
+			Entity:     e.Entity,
+			Identifier: e.Identifier,
+		}
+
+		err := api.deleteCreated(mirrorEntry(targetOrg, targetProject, e.Entity, e.Identifier), logger)
+		if errors.Is(err, errRollbackUnsupported) {
+			continue
+		}
+		if err != nil {
+			logger.Error("Failed to delete entity",
+				zap.String("entity", e.Entity),
+				zap.String("identifier", e.Identifier),
+				zap.Error(err),
+			)
+			change.Error = removeNewLine(err.Error())
+		}
+
+		changes = append(changes, change)
+	}
+
+	return changes, verification.Errors
+}
+
+// mirrorEntry turns the key Verify reports an entity with into the entry it is deleted by
+func mirrorEntry(org, project, entity, key string) model.JournalEntry {
+	entry := model.JournalEntry{
+		Entity:     entity,
+		Identifier: key,
+		Org:        org,
+		Project:    project,
+	}
+
+	switch entity {
+	case "Infrastructure", "Service Override", "Target", "Target Group":
+		entry.Environment, entry.Identifier, _ = strings.Cut(key, "/")
+	case "Input Set", "Trigger":
+		entry.Pipeline, entry.Identifier, _ = strings.Cut(key, "/")
+	case "Template":
+		entry.Identifier, entry.Version, _ = strings.Cut(key, "/")
+	}
+
+	return entry
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"net/http"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"harness-copy-project/model"
+)
+
+func TestMirror_CreatesUpdatesAndDeletes(t *testing.T) {
+	fake := newFakeHarness(t)
+	for _, p := range []struct{ project, identifier string }{
+		{"src", "kept"}, {"src", "added"}, {"target", "kept"}, {"target", "removed"},
+	} {
+		fake.addPipeline("org", p.project, p.identifier, "pipeline:\n  identifier: "+p.identifier+"\n")
+	}
+
+	api := fake.api()
+	api.Conflict = ConflictOverwrite
+
+	err := NewPipelineOperation(api, "org", "src", "org", "target", zap.NewNop(), false).Copy()
+	assert.NoError(t, err)
+
+	// THE NEW PIPELINE IS CREATED AND THE ONE THE TARGET ALREADY HAS IS REPLACED
+	assert.Equal(t, []model.MirrorChange{
+		{Action: MIRROR_CREATE, Entity: "Pipeline", Identifier: "added"},
+		{Action: MIRROR_UPDATE, Entity: "Pipeline", Identifier: "kept"},
+	}, MirrorChanges(api.Stats))
+	assert.Len(t, fake.sent(http.MethodPut, CREATE_PIPELINE+"/kept"), 1)
+	assert.Len(t, fake.sent(http.MethodPut, ""), 1)
+
+	// ONLY THE PIPELINE THE SOURCE DOES NOT HAVE IS DELETED
+	deletes, errs := api.MirrorDeletes("org", "src", "org", "target", true, false, zap.NewNop())
+	assert.Equal(t, []model.MirrorChange{
+		{Action: MIRROR_DELETE, Entity: "Pipeline", Identifier: "removed"},
+	}, deletes)
+	assert.Len(t, fake.sent(http.MethodDelete, "/pipeline/api/pipelines/removed"), 1)
+	assert.Len(t, fake.sent(http.MethodDelete, ""), 1)
+	assert.NotEmpty(t, errs)
+
+	target := fake.pipelineIdentifiers("org", "target")
+	sort.Strings(target)
+	assert.Equal(t, []string{"added", "kept"}, target)
+}
+
+func TestMirrorChanges_ReportsEntitiesNotReplaced(t *testing.T) {
+	api := &ApiRequest{
+		Stats:    NewStats(),
+		Conflict: ConflictOverwrite,
+	}
+
+	api.resolveConflict("Connector", "git", ErrAlreadyExists, nil, zap.NewNop())
+	api.resolveConflict("User", "user@example.com", ErrAlreadyExists, keepExisting, zap.NewNop())
+
+	// THE CONNECTOR STILL HOLDS WHAT THE TARGET HAD, THE USER ALREADY IS THE COPY
+	assert.Equal(t, []model.MirrorChange{
+		{Action: MIRROR_UPDATE, Entity: "Connector", Identifier: "git", Error: reasonNotReplaceable},
+	}, MirrorChanges(api.Stats))
+	assert.Len(t, NotReplaced(api.Stats), 1)
+}
+
+func TestMirrorChanges_KeptPlaceholderSecretIsUnchanged(t *testing.T) {
+	state, err := LoadSyncState(filepath.Join(t.TempDir(), "sync.json"))
+	assert.NoError(t, err)
+	assert.NoError(t, state.Project("src", "src", "target", "target").Complete(time.Now().Add(-time.Hour)))
+
+	fake := newFakeHarness(t)
+	fake.addSecret("src", "src", textSecret("token"), "")
+	fake.addSecret("target", "target", textSecret("token"), "real value")
+
+	api := fake.api()
+	api.Conflict = ConflictOverwrite
+	api.Sync = state.Project("src", "src", "target", "target")
+
+	assert.NoError(t, NewSecretOperation(api, "src", "src", "target", "target", nil, zap.NewNop(), false).Copy())
+
+	// A CYCLE WITHOUT A VALUE FOR THE SECRET LEAVES IT AS IT IS AND REPORTS NO CHANGE
+	assert.Empty(t, MirrorChanges(api.Stats))
+	assert.Empty(t, NotReplaced(api.Stats))
+	assert.Equal(t, 1, api.Stats.GetUnchanged())
+}
+
+func TestMirrorEntry(t *testing.T) {
+	tests := []struct {
+		entity string
+		key    string
+		want   model.JournalEntry
+	}{
+		{"Pipeline", "deploy", model.JournalEntry{Entity: "Pipeline", Identifier: "deploy", Org: "org", Project: "project"}},
+		{"Infrastructure", "dev/k8s", model.JournalEntry{Entity: "Infrastructure", Identifier: "k8s", Environment: "dev", Org: "org", Project: "project"}},
+		{"Input Set", "deploy/inputs", model.JournalEntry{Entity: "Input Set", Identifier: "inputs", Pipeline: "deploy", Org: "org", Project: "project"}},
+		{"Template", "stage/v1", model.JournalEntry{Entity: "Template", Identifier: "stage", Version: "v1", Org: "org", Project: "project"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.entity, func(t *testing.T) {
+			assert.Equal(t, tt.want, mirrorEntry("org", "project", tt.entity, tt.key))
+		})
+	}
+}
@@ -0,0 +1,421 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/schollz/progressbar/v3"
+	"go.uber.org/zap"
+	"harness-copy-project/model"
+)
+
+const (
+	OrgDependencyCopied   = "copied"
+	OrgDependencyExisting = "existing"
+	OrgDependencyMissing  = "missing"
+	OrgDependencyFailed   = "failed"
+)
+
+var orgVariableExpression = regexp.MustCompile(`<\+variable\.org\.([A-Za-z0-9_]+)`)
+
+type OrgDependencyContext struct {
+	api           *ApiRequest
+	sourceOrg     string
+	sourceProject string
+	targetOrg     string
+	targetProject string
+	values        map[string]string
+	copyCD        bool
+	copyFF        bool
+	logger        *zap.Logger
+	showPB        bool
+}
+
+// Copies the connectors, secrets, templates and variables of the source org that the
+// project references into the target org. Must run before the project-level operations
+// so the org references of the copied entities resolve.
+func NewOrgDependencyOperation(api *ApiRequest, sourceOrg, sourceProject, targetOrg, targetProject string, values map[string]string, copyCD, copyFF bool, logger *zap.Logger, showPB bool) OrgDependencyContext {
+	return OrgDependencyContext{
+		api:           api,
+		sourceOrg:     sourceOrg,
+		sourceProject: sourceProject,
+		targetOrg:     targetOrg,
+		targetProject: targetProject,
+		values:        values,
+		copyCD:        copyCD,
+		copyFF:        copyFF,
+		logger:        logger,
+		showPB:        showPB,
+	}
+}
+
+// orgDependency is an entity of the org referenced by the project or by another org
+// dependency. Templates are referenced by version, an empty version is the stable one.
+type orgDependency struct {
+	entity     string
+	identifier string
+	version    string
+}
+
+func (d orgDependency) name() string {
+	if d.version != "" {
+		return "org." + d.identifier + "/" + d.version
+	}
+	return "org." + d.identifier
+}
+
+// resolvedDependency is an org dependency as found in the source org, entity is nil when
+// it does not exist there
+type resolvedDependency struct {
+	orgDependency
+	entity interface{}
+	err    error
+}
+
+func (c OrgDependencyContext) Copy() error {
+
+	c.logger.Info("Copying org dependencies",
+		zap.String("project", c.sourceProject),
+	)
+
+	source := c.api.source()
+	if c.sourceOrg == c.targetOrg && source.Account == c.api.Account && source.BaseURL == c.api.BaseURL {
+		c.logger.Info("Skipping org dependencies, the project stays in the same org")
+		return nil
+	}
+
+	// EVERY ORG REFERENCE OF THE PROJECT, FROM THE ENTITIES THE DEPENDENCY GRAPH ALREADY
+	// FETCHED WHEN THERE IS ONE
+	scanned, ok := c.api.Graph.scanned()
+	if !ok {
+		for _, l := range source.verifyListers(c.copyCD, c.copyFF, c.logger) {
+			if !referencingEntities[l.entity] {
+				continue
+			}
+			entities, err := l.list(c.sourceOrg, c.sourceProject)
+			if err != nil {
+				c.logger.Error("Failed to scan project for org dependencies",
+					zap.String("entity", l.entity),
+					zap.Error(err),
+				)
+				return err
+			}
+			for _, e := range entities {
+				scanned = append(scanned, normalizeEntity(e))
+			}
+		}
+	}
+
+	pending := []orgDependency{}
+	for _, e := range scanned {
+		pending = append(pending, collectOrgReferences(e, false)...)
+	}
+
+	dependencies, err := c.resolve(pending)
+	if err != nil {
+		return err
+	}
+
+	existing := map[orgDependency]bool{}
+	if c.api.Plan != nil {
+		if existing, err = c.existingInTarget(dependencies); err != nil {
+			c.logger.Error("Failed to list the org entities of the target org",
+				zap.String("Org", c.targetOrg),
+				zap.Error(err),
+			)
+			return err
+		}
+	}
+
+	var bar *progressbar.ProgressBar
+
+	if c.showPB {
+		bar = progressbar.Default(int64(len(dependencies)), "Org Dependencies")
+	}
+
+	// DEPENDENCIES ARE FOUND AFTER THE ENTITIES THAT REFERENCE THEM, SO THEY ARE CREATED
+	// IN REVERSE ORDER
+	for i := len(dependencies) - 1; i >= 0; i-- {
+		d := dependencies[i]
+
+		entry := model.OrgDependencyEntry{
+			Entity:     d.orgDependency.entity,
+			Identifier: d.name(),
+			Status:     OrgDependencyCopied,
+		}
+
+		switch {
+		case d.err != nil:
+			entry.Status = OrgDependencyFailed
+			entry.Reason = d.err.Error()
+		case d.entity == nil:
+			entry.Status = OrgDependencyMissing
+			entry.Reason = fmt.Sprintf("not found in source org %s", c.sourceOrg)
+		}
+
+		if c.api.Plan != nil {
+			var err error
+			if entry.Status != OrgDependencyCopied {
+				err = errors.New(entry.Reason)
+			}
+			c.api.Plan.Record("Org "+entry.Entity, entry.Identifier, entry.Identifier, existing[d.orgDependency], err)
+			if c.showPB {
+				bar.Add(1)
+			}
+			continue
+		}
+
+		if entry.Status == OrgDependencyCopied {
+			err := c.create(d)
+			if errors.Is(err, ErrAlreadyExists) {
+				entry.Status = OrgDependencyExisting
+			} else if err != nil {
+				c.logger.Error("Failed to create org dependency",
+					zap.String("entity", entry.Entity),
+					zap.String("identifier", entry.Identifier),
+					zap.Error(err),
+				)
+				entry.Status = OrgDependencyFailed
+				entry.Reason = err.Error()
+			}
+		}
+
+		c.api.Stats.AddOrgDependency(entry)
+		if c.showPB {
+			bar.Add(1)
+		}
+	}
+	if c.showPB {
+		bar.Finish()
+	}
+
+	return nil
+}
+
+// resolve looks up the org dependencies in the source org, and the org dependencies of
+// those, breadth first
+func (c OrgDependencyContext) resolve(pending []orgDependency) ([]resolvedDependency, error) {
+	source := c.api.source()
+
+	connectors, err := source.listConnectors(c.sourceOrg, "", c.logger)
+	if err != nil {
+		return nil, err
+	}
+	secrets, err := source.listSecrets(c.sourceOrg, "", c.logger)
+	if err != nil {
+		return nil, err
+	}
+	variables, err := source.listVariables(c.sourceOrg, "", c.logger)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := []resolvedDependency{}
+	seen := map[orgDependency]bool{}
+
+	for len(pending) > 0 {
+		d := pending[0]
+		pending = pending[1:]
+
+		// REMAPPED REFERENCES POINT TO AN ENTITY THAT IS NOT COPIED
+		if seen[d] || c.api.Rename.remapped(d.entity, "org."+d.identifier) {
+			continue
+		}
+		seen[d] = true
+
+		r := resolvedDependency{orgDependency: d}
+		switch d.entity {
+		case "Connector":
+			for _, cn := range connectors {
+				if cn.Connector.Identifier == d.identifier {
+					r.entity = cn
+				}
+			}
+		case "Secret":
+			for _, s := range secrets {
+				if s.Identifier == d.identifier {
+					r.entity = s
+				}
+			}
+		case "Variable":
+			for _, v := range variables {
+				if v.Identifier == d.identifier {
+					r.entity = v
+				}
+			}
+		case "Template":
+			t, err := source.getTemplate(c.sourceOrg, "", d.identifier, d.version, c.logger)
+			if err != nil {
+				r.err = err
+			} else if t != nil {
+				r.entity = t
+			}
+		}
+
+		resolved = append(resolved, r)
+		if r.entity != nil {
+			pending = append(pending, collectOrgReferences(normalizeEntity(r.entity), true)...)
+		}
+	}
+
+	return resolved, nil
+}
+
+// existingInTarget lists the org dependencies that already exist in the target org
+func (c OrgDependencyContext) existingInTarget(dependencies []resolvedDependency) (map[orgDependency]bool, error) {
+	existing := map[orgDependency]bool{}
+
+	connectors, err := c.api.listConnectors(c.targetOrg, "", c.logger)
+	if err != nil {
+		return nil, err
+	}
+	for _, cn := range connectors {
+		existing[orgDependency{entity: "Connector", identifier: cn.Connector.Identifier}] = true
+	}
+	secrets, err := c.api.listSecrets(c.targetOrg, "", c.logger)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range secrets {
+		existing[orgDependency{entity: "Secret", identifier: s.Identifier}] = true
+	}
+	variables, err := c.api.listVariables(c.targetOrg, "", c.logger)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range variables {
+		existing[orgDependency{entity: "Variable", identifier: v.Identifier}] = true
+	}
+	for _, d := range dependencies {
+		if d.orgDependency.entity != "Template" {
+			continue
+		}
+		if t, err := c.api.getTemplate(c.targetOrg, "", d.identifier, d.version, c.logger); err == nil && t != nil {
+			existing[d.orgDependency] = true
+		}
+	}
+
+	return existing, nil
+}
+
+// create creates an org dependency in the target org. Entities that already exist there
+// are kept as they are, they may be used by other projects of the org.
+func (c OrgDependencyContext) create(d resolvedDependency) error {
+	switch e := d.entity.(type) {
+	case *model.ConnectorContent:
+		cn := *e
+		cn.Connector.OrgIdentifier = c.targetOrg
+		cn.Connector.ProjectIdentifier = ""
+		return c.api.addConnector(&cn, c.logger)
+	case *model.Secret:
+		s := *e
+		s.OrgIdentifier = c.targetOrg
+		s.ProjectIdentifier = ""
+		// SECRET VALUES OF THE ORG ARE SUPPLIED WITH THE 'org.' PREFIX
+		secrets := SecretContext{
+			api:    c.api,
+			values: map[string]string{},
+			logger: c.logger,
+		}
+		if value, ok := c.values["org."+s.Identifier]; ok {
+			secrets.values[s.Identifier] = value
+		}
+		value, placeholder, err := secrets.secretValue(&s)
+		if err != nil {
+			return err
+		}
+		if _, err := secrets.create(&s, value); err != nil {
+			return err
+		}
+		if placeholder {
+			c.api.Stats.AddSecretPendingValue("org." + s.Identifier)
+		}
+		return nil
+	case *model.Variable:
+		v := *e
+		v.OrgIdentifier = c.targetOrg
+		v.ProjectIdentifier = ""
+		return c.api.createVariable(&model.CreateVariableRequest{Variable: &v}, c.logger)
+	case *model.TemplateGetData:
+		templates := TemplateContext{
+			api:    c.api,
+			logger: c.logger,
+		}
+		return templates.createTemplate(c.targetOrg, "", updateYaml(e.Yaml, c.targetOrg, ""), c.logger)
+	}
+	return fmt.Errorf("unsupported org dependency %s", d.orgDependency.entity)
+}
+
+// collectOrgReferences walks a normalized entity and returns the connectors, secrets,
+// templates and variables of the org it references. References of an entity of the org
+// itself without a scope are to the org as well.
+func collectOrgReferences(value interface{}, orgScoped bool) []orgDependency {
+	found := []orgDependency{}
+
+	var walk func(value interface{})
+	walk = func(value interface{}) {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			for key, field := range v {
+				entity := renameReferences[key]
+				if entity != "Connector" && entity != "Secret" && entity != "Template" {
+					walk(field)
+					continue
+				}
+				refs := []interface{}{field}
+				if list, ok := field.([]interface{}); ok {
+					refs = list
+				}
+				for _, ref := range refs {
+					s, ok := ref.(string)
+					if !ok {
+						continue
+					}
+					identifier, ok := orgReference(s, orgScoped)
+					if !ok {
+						walk(s)
+						continue
+					}
+					d := orgDependency{entity: entity, identifier: identifier}
+					if entity == "Template" {
+						d.version, _ = v["versionLabel"].(string)
+					}
+					found = append(found, d)
+				}
+			}
+		case []interface{}:
+			for _, item := range v {
+				walk(item)
+			}
+		case string:
+			// EXPRESSIONS ARE RESOLVED IN THE SCOPE THEY RUN IN, ONLY 'org.' IS THE ORG
+			for _, match := range secretExpression.FindAllStringSubmatch(v, -1) {
+				if identifier, ok := orgReference(match[2], false); ok {
+					found = append(found, orgDependency{entity: "Secret", identifier: identifier})
+				}
+			}
+			for _, match := range orgVariableExpression.FindAllStringSubmatch(v, -1) {
+				found = append(found, orgDependency{entity: "Variable", identifier: match[1]})
+			}
+		}
+	}
+	walk(value)
+
+	return found
+}
+
+// orgReference returns the identifier of a reference to an entity of the org
+func orgReference(value string, orgScoped bool) (string, bool) {
+	if value == "" || strings.Contains(value, "<+") || strings.HasPrefix(value, "account.") {
+		return "", false
+	}
+	if strings.HasPrefix(value, "org.") {
+		return strings.TrimPrefix(value, "org."), true
+	}
+	if orgScoped && value != HARNESS_SECRET_MANAGER {
+		return value, true
+	}
+	return "", false
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"harness-copy-project/model"
+)
+
+// newOrgHarness serves a project whose secrets and connectors reference entities of its
+// org. The secret 'dockerToken' already exists in every org.
+func newOrgHarness(t *testing.T) *fakeHarness {
+	fake := newFakeHarness(t)
+
+	fake.handle("POST "+CONNECTORLOOKUP, func(w http.ResponseWriter, r *http.Request) {
+		token := func(ref string) *string { return &ref }
+		content := []model.ConnectorContent{}
+		switch scopeOf(r) {
+		case "src/app":
+			content = append(content, model.ConnectorContent{Connector: model.Connector{
+				Identifier: "docker",
+				Spec:       model.ConnectorSpec{AuthToken: token("org.dockerToken")},
+			}})
+		case "src/":
+			// A SECRET OF THE ORG IS REFERENCED WITHOUT A SCOPE BY A CONNECTOR OF THE ORG
+			content = append(content, model.ConnectorContent{Connector: model.Connector{
+				Identifier: "vault",
+				Spec:       model.ConnectorSpec{AuthToken: token("vaultToken")},
+			}})
+		case "dst/":
+			content = append(content, model.ConnectorContent{Connector: model.Connector{
+				Identifier: "unused",
+			}})
+		}
+		json.NewEncoder(w).Encode(model.ConnectorListResult{
+			Status: "SUCCESS",
+			Data: model.GetConnectorData{
+				Content:    content,
+				TotalPages: 1,
+			},
+		})
+	})
+
+	fake.handle("POST "+SECRETS, func(w http.ResponseWriter, r *http.Request) {
+		secret := model.CreateSecretRequest{}
+		json.NewDecoder(r.Body).Decode(&secret)
+		if secret.Secret.Identifier == "dockerToken" {
+			writeError(w, http.StatusBadRequest, "DUPLICATE_FIELD", "already exists")
+			return
+		}
+		w.Write([]byte(`{"status":"SUCCESS"}`))
+	})
+
+	fake.handle("GET "+SECRETS, func(w http.ResponseWriter, r *http.Request) {
+		content := []*model.SecretContent{}
+		switch scopeOf(r) {
+		case "src/app":
+			content = append(content, &model.SecretContent{Secret: model.Secret{
+				Identifier: "apiKey",
+				Type:       string(model.SecretText),
+				Spec:       map[string]interface{}{"secretManagerIdentifier": "org.vault"},
+			}})
+		case "src/":
+			for _, id := range []string{"dockerToken", "vaultToken"} {
+				content = append(content, &model.SecretContent{Secret: model.Secret{
+					Identifier: id,
+					Type:       string(model.SecretText),
+					Spec:       map[string]interface{}{"secretManagerIdentifier": HARNESS_SECRET_MANAGER},
+				}})
+			}
+		}
+		json.NewEncoder(w).Encode(model.SecretListResponse{
+			Status: "SUCCESS",
+			Data: model.SecretListData{
+				Content:    content,
+				TotalPages: 1,
+			},
+		})
+	})
+
+	return fake
+}
+
+// orgCreated lists the secrets and connectors sent to be created, in order
+func orgCreated(fake *fakeHarness) []string {
+	created := []string{}
+	for _, r := range fake.sent(http.MethodPost, "") {
+		switch r.Path {
+		case CONNECTORCREATE:
+			connector := model.ConnectorContent{}
+			json.Unmarshal([]byte(r.Body), &connector)
+			created = append(created, "Connector "+connector.Connector.OrgIdentifier+"/"+connector.Connector.ProjectIdentifier+"/"+connector.Connector.Identifier)
+		case SECRETS:
+			secret := model.CreateSecretRequest{}
+			json.Unmarshal([]byte(r.Body), &secret)
+			created = append(created, "Secret "+secret.Secret.OrgIdentifier+"/"+secret.Secret.ProjectIdentifier+"/"+secret.Secret.Identifier)
+		}
+	}
+	return created
+}
+
+func TestOrgDependencies_CopiedBeforeTheirDependants(t *testing.T) {
+	fake := newOrgHarness(t)
+	api := fake.api()
+
+	err := NewOrgDependencyOperation(api, "src", "app", "dst", "app", map[string]string{"org.vaultToken": "s3cr3t"}, false, true, zap.NewNop(), false).Copy()
+	assert.NoError(t, err)
+
+	// ONLY THE ORG ENTITIES ARE CREATED, IN THE TARGET ORG AND EACH ONE AFTER WHAT IT NEEDS
+	assert.Equal(t, []string{
+		"Secret dst//vaultToken",
+		"Secret dst//dockerToken",
+		"Connector dst//vault",
+	}, orgCreated(fake))
+
+	assert.Equal(t, []model.OrgDependencyEntry{
+		{Entity: "Secret", Identifier: "org.vaultToken", Status: OrgDependencyCopied},
+		{Entity: "Secret", Identifier: "org.dockerToken", Status: OrgDependencyExisting},
+		{Entity: "Connector", Identifier: "org.vault", Status: OrgDependencyCopied},
+	}, api.Stats.GetOrgDependencies())
+	// THE SUPPLIED VALUE IS USED, NO PLACEHOLDER IS LEFT BEHIND
+	assert.Empty(t, api.Stats.GetSecretsPendingValue())
+}
+
+func TestOrgDependencies_ReuseTheEntitiesOfTheGraph(t *testing.T) {
+	fake := newOrgHarness(t)
+	api := fake.api()
+
+	graph, err := api.BuildGraph("src", "app", false, true, zap.NewNop())
+	assert.NoError(t, err)
+	api.Graph = graph
+	scanned := len(inProject(fake.sent("", ""), "app"))
+
+	err = NewOrgDependencyOperation(api, "src", "app", "dst", "app", map[string]string{"org.vaultToken": "s3cr3t"}, false, true, zap.NewNop(), false).Copy()
+	assert.NoError(t, err)
+
+	// THE PROJECT IS NOT LISTED AGAIN, ITS ORG REFERENCES ARE STILL FOUND
+	assert.Equal(t, scanned, len(inProject(fake.sent("", ""), "app")))
+	assert.Len(t, api.Stats.GetOrgDependencies(), 3)
+}
+
+func TestOrgDependencies_SkippedWithinTheSameOrg(t *testing.T) {
+	fake := newOrgHarness(t)
+	api := fake.api()
+
+	err := NewOrgDependencyOperation(api, "src", "app", "src", "copy", nil, false, true, zap.NewNop(), false).Copy()
+	assert.NoError(t, err)
+	assert.Empty(t, orgCreated(fake))
+	assert.Empty(t, api.Stats.GetOrgDependencies())
+}
+
+func TestCollectOrgReferences(t *testing.T) {
+	pipeline := normalizeEntity(map[string]interface{}{
+		"yaml": `pipeline:
+  identifier: build
+  stages:
+    - stage:
+        template:
+          templateRef: org.deploy
+          versionLabel: v2
+    - stage:
+        spec:
+          connectorRef: account.github
+          command: echo <+secrets.getValue("org.token")> <+variable.org.region> <+secrets.getValue("local")>
+          secretRef: project_secret
+`,
+	})
+
+	assert.ElementsMatch(t, []orgDependency{
+		{entity: "Template", identifier: "deploy", version: "v2"},
+		{entity: "Secret", identifier: "token"},
+		{entity: "Variable", identifier: "region"},
+	}, collectOrgReferences(pipeline, false))
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// Number of items requested per page by the list calls
+const PAGE_SIZE = 100
+
+// Upper bound on the pages requested by a single list call, in case an API ignores the page parameter
+const MAX_PAGES = 10000
+
+// listPage describes a page returned by a list call
+type listPage struct {
+	// Index of the page returned by the API
+	index int
+	// Number of pages, 0 when the API does not return it
+	total int
+	// Number of items on the page
+	items int
+	// Key of the first item on the page, empty when the caller does not set it
+	first string
+}
+
+// paginate calls fetch for every page of a list call, starting with page 0. The next page
+// follows the TotalPages/PageIndex of the response. APIs that do not return the number of
+// pages, like the v1 endpoints that use limit/page, are read until a page is not full.
+// An API that ignores the page parameter fails the list instead of returning the same
+// items over and over.
+func (api *ApiRequest) paginate(pageSize int, fetch func(page int) (listPage, error)) error {
+	seen := map[string]bool{}
+	for page := 0; page < MAX_PAGES; page++ {
+		p, err := fetch(page)
+		if err != nil {
+			return err
+		}
+		if p.items == 0 {
+			return nil
+		}
+		if p.index != page {
+			return fmt.Errorf("page %d was requested but page %d was returned", page, p.index)
+		}
+		if p.first != "" {
+			if seen[p.first] {
+				return fmt.Errorf("page %d repeats the items of a previous page", page)
+			}
+			seen[p.first] = true
+		}
+		if p.total > 0 {
+			if p.index+1 >= p.total {
+				return nil
+			}
+		} else if p.items < pageSize {
+			return nil
+		}
+	}
+	return fmt.Errorf("more than %d pages", MAX_PAGES)
+}
+
+// v1Page builds the page of a v1 list call from the X-Total-Elements header. The v1
+// endpoints do not return the page index, so the first item tells the pages apart.
+func v1Page(resp *resty.Response, page, pageSize, items int, first string) listPage {
+	p := listPage{
+		index: page,
+		items: items,
+		first: first,
+	}
+	if total, err := strconv.Atoi(resp.Header().Get("X-Total-Elements")); err == nil && total > 0 {
+		p.total = (total + pageSize - 1) / pageSize
+	}
+	return p
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestPipelineCopy_AllPages(t *testing.T) {
+	fake := newFakeHarness(t)
+	total := PAGE_SIZE*2 + 5
+	for i := 0; i < total; i++ {
+		identifier := fmt.Sprintf("pipeline_%d", i)
+		fake.addPipeline("src", "src", identifier, fmt.Sprintf("pipeline:\n  identifier: %s\n  orgIdentifier: src\n  projectIdentifier: src\n", identifier))
+	}
+
+	api := fake.api()
+
+	err := NewPipelineOperation(api, "src", "src", "target", "target", zap.NewNop(), false).Copy()
+
+	assert.Nil(t, err)
+	for _, list := range fake.sent(http.MethodPost, LIST_PIPELINES) {
+		assert.Equal(t, strconv.Itoa(PAGE_SIZE), list.Query.Get("size"))
+	}
+	creates := fake.sent(http.MethodPost, CREATE_PIPELINE)
+	for _, create := range creates {
+		assert.Contains(t, create.Body, "projectIdentifier: target")
+	}
+	assert.Equal(t, total, len(creates), "Pipelines beyond the first page were not copied")
+	assert.Equal(t, total, api.Stats.GetPipelinesTotal())
+	assert.Equal(t, total, api.Stats.GetPipelinesMoved())
+}
+
+func TestPaginate_StopsOnPartialPage(t *testing.T) {
+	api := &ApiRequest{}
+	pages := []int{}
+
+	err := api.paginate(10, func(page int) (listPage, error) {
+		pages = append(pages, page)
+		items := 10
+		if page == 2 {
+			items = 3
+		}
+		return listPage{index: page, items: items}, nil
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []int{0, 1, 2}, pages)
+}
+
+func TestPaginate_FailsWhenPageIsIgnored(t *testing.T) {
+	api := &ApiRequest{}
+	calls := 0
+
+	// THE API ALWAYS RETURNS THE FIRST PAGE OF TWO
+	err := api.paginate(10, func(page int) (listPage, error) {
+		calls++
+		return listPage{index: 0, total: 2, items: 10}, nil
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, 2, calls)
+}
+
+func TestPaginate_FailsWhenPageRepeats(t *testing.T) {
+	api := &ApiRequest{}
+	calls := 0
+
+	// A V1 API WITHOUT A PAGE INDEX THAT RETURNS THE SAME FULL PAGE
+	err := api.paginate(10, func(page int) (listPage, error) {
+		calls++
+		return listPage{index: page, items: 10, first: "template/v1"}, nil
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, 2, calls)
+}
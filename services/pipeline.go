@@ -3,6 +3,7 @@ package services
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
 
 	"github.com/schollz/progressbar/v3"
 	"go.uber.org/zap"
@@ -41,7 +42,7 @@ func (c PipelineContext) Copy() error {
 		zap.String("project", c.sourceProject),
 	)
 
-	pipelines, err := c.api.listPipelines(c.sourceOrg, c.sourceProject, c.logger)
+	pipelines, err := c.api.source().listPipelines(c.sourceOrg, c.sourceProject, c.logger)
 	if err != nil {
 		c.logger.Error("Failed to retrive pipelines",
 			zap.String("Project", c.sourceProject),
@@ -50,33 +51,132 @@ func (c PipelineContext) Copy() error {
 		return err
 	}
 
+	existing := map[string]bool{}
+	if c.api.Plan != nil {
+		targetPipelines, err := c.api.listPipelines(c.targetOrg, c.targetProject, c.logger)
+		if err != nil {
+			c.logger.Error("Failed to list the pipelines of the target project",
+				zap.String("Project", c.targetProject),
+				zap.Error(err),
+			)
+			return err
+		}
+		for _, p := range targetPipelines {
+			existing[p.Identifier] = true
+		}
+	}
+
 	var bar *progressbar.ProgressBar
 
 	if c.showPB {
 		bar = progressbar.Default(int64(len(pipelines)), "Pipelines   ")
 	}
 
-	for _, pipe := range pipelines {
+	// CHILD PIPELINES ARE CREATED BEFORE THE PIPELINES THAT RUN THEM
+	keys := make([]string, len(pipelines))
+	for i, pipe := range pipelines {
+		keys[i] = pipe.Identifier
+	}
+
+	c.api.forEachLevel("Pipeline", keys, func(i int) {
+		pipe := pipelines[i]
+
+		c.api.Stats.IncrementPipelinesTotal()
 
-		IncrementPipelinesTotal()
+		if c.api.Checkpoint.Done("Pipeline", pipe.Identifier) {
+			c.logger.Info("Skipping pipeline copied by a previous run",
+				zap.String("identifier", pipe.Identifier),
+			)
+			c.api.Stats.IncrementPipelinesMoved()
+			if c.showPB {
+				bar.Add(1)
+			}
+			return
+		}
+
+		if c.api.unchanged("Pipeline", pipe.Identifier, pipe.LastUpdatedAt, c.logger) {
+			c.api.Stats.IncrementPipelinesMoved()
+			if c.showPB {
+				bar.Add(1)
+			}
+			return
+		}
 
-		pipeData, err := c.api.getPipeline(c.sourceOrg, c.sourceProject, pipe.Identifier, c.logger)
-		if err == nil {
-			newYaml := updateYaml(pipeData.YAMLPipeline, c.targetOrg, c.targetProject)
+		pipeData, err := c.api.source().getPipeline(c.sourceOrg, c.sourceProject, pipe.Identifier, c.logger)
+		if c.api.Plan != nil {
+			if err == nil {
+				err = checkValidity(pipeData.EntityValidityDetails)
+			}
+			if err == nil {
+				err = checkYaml(pipeData.YAMLPipeline)
+			}
+			if err == nil && pipeData.StoreType == model.Remote {
+				_, err = pipelineLocation(pipe, pipeData)
+			}
+			c.api.Plan.Record("Pipeline", pipe.Identifier, pipe.Name, existing[c.api.Rename.Identifier("Pipeline", pipe.Identifier)], err)
+			if c.showPB {
+				bar.Add(1)
+			}
+			return
+		}
+		if c.api.blocked("Pipeline", pipe.Identifier, c.logger) {
+			if c.showPB {
+				bar.Add(1)
+			}
+			return
+		}
+		if err == nil && pipeData.StoreType == model.Remote {
+			// A REMOTE PIPELINE IS IMPORTED FROM ITS FILE, AN INLINE COPY NEVER REPLACES IT
+			var location model.GitLocation
+			if location, err = pipelineLocation(pipe, pipeData); err == nil {
+				location = c.api.Rename.Git(location)
+				err = c.importPipeline(pipe, location, false)
+			}
+			err = c.api.resolveConflict("Pipeline", pipe.Identifier, err, func() error {
+				return c.importPipeline(pipe, location, true)
+			}, c.logger)
+		} else if err == nil && c.api.Git.Stores("Pipeline") {
+			// THE INLINE PIPELINE IS WRITTEN TO GIT AND IMPORTED FROM THERE
+			identifier := c.api.Rename.Identifier("Pipeline", pipe.Identifier)
+			newYaml := c.api.Rename.Yaml(updateYaml(pipeData.YAMLPipeline, c.targetOrg, c.targetProject))
+			var location model.GitLocation
+			if location, err = c.api.Git.Write("Pipeline", gitFile{
+				org:        c.targetOrg,
+				project:    c.targetProject,
+				identifier: identifier,
+			}, newYaml, c.logger); err == nil {
+				err = c.importPipeline(pipe, location, false)
+			}
+			err = c.api.resolveConflict("Pipeline", pipe.Identifier, err, func() error {
+				return c.importPipeline(pipe, location, true)
+			}, c.logger)
+		} else if err == nil {
+			identifier := c.api.Rename.Identifier("Pipeline", pipe.Identifier)
+			newYaml := c.api.Rename.Yaml(updateYaml(pipeData.YAMLPipeline, c.targetOrg, c.targetProject))
 			err = c.api.createPipeline(c.targetOrg, c.targetProject, newYaml, c.logger)
+			err = c.api.resolveConflict("Pipeline", pipe.Identifier, err, func() error {
+				if existing, err := c.api.getPipeline(c.targetOrg, c.targetProject, identifier, c.logger); err == nil && existing.StoreType == model.Remote {
+					return ErrRemoteInTarget
+				}
+				return c.api.updatePipeline(c.targetOrg, c.targetProject, identifier, newYaml, c.logger)
+			}, c.logger)
 		}
 		if err != nil {
 			c.logger.Error("Failed to create pipeline",
 				zap.String("pipeline", pipe.Name),
 				zap.Error(err),
 			)
+			c.api.Graph.Failed("Pipeline", pipe.Identifier)
 		} else {
-			IncrementPipelinesMoved()
+			c.api.Stats.IncrementPipelinesMoved()
+			if err := c.api.Checkpoint.Record("Pipeline", pipe.Identifier); err != nil {
+				c.logger.Warn("Failed to update checkpoint", zap.Error(err))
+			}
 		}
 		if c.showPB {
 			bar.Add(1)
 		}
-	}
+	})
 	if c.showPB {
 		bar.Finish()
 	}
@@ -84,6 +184,39 @@ func (c PipelineContext) Copy() error {
 	return nil
 }
 
+// importPipeline imports a remote pipeline into the target from the file it is stored in
+func (c PipelineContext) importPipeline(pipe *model.PipelineListContent, location model.GitLocation, force bool) error {
+	identifier := c.api.Rename.Identifier("Pipeline", pipe.Identifier)
+	body := model.PipelineImportRequest{
+		PipelineName: c.api.Rename.Name("Pipeline", pipe.Name),
+	}
+	if pipe.Description != nil {
+		body.PipelineDescription = *pipe.Description
+	}
+
+	return c.api.importRemote(IMPORT_PIPELINE, map[string]string{
+		"pipelineIdentifier": identifier,
+	}, location, body, model.JournalEntry{
+		Entity:     "Pipeline",
+		Identifier: identifier,
+		Org:        c.targetOrg,
+		Project:    c.targetProject,
+	}, identifier, force, c.logger)
+}
+
+// pipelineLocation returns the file a remote pipeline is stored in, the list holds the
+// git details when the pipeline itself does not
+func pipelineLocation(pipe *model.PipelineListContent, data *model.PipelineGetData) (model.GitLocation, error) {
+	connectorRef, details := data.ConnectorRef, data.GitDetails
+	if connectorRef == nil {
+		connectorRef = pipe.ConnectorRef
+	}
+	if details == nil {
+		details = pipe.GitDetails
+	}
+	return gitLocation(connectorRef, details)
+}
+
 func (api *ApiRequest) listPipelines(org, project string, logger *zap.Logger) ([]*model.PipelineListContent, error) {
 
 	logger.Info("Fetching pipelines",
@@ -91,44 +224,61 @@ func (api *ApiRequest) listPipelines(org, project string, logger *zap.Logger) ([
 		zap.String("project", project),
 	)
 
-	IncrementApiCalls()
+	pipelines := []*model.PipelineListContent{}
 
-	resp, err := api.Client.R().
-		SetHeader("x-api-key", api.Token).
-		SetHeader("Content-Type", "application/json").
-		SetBody(`{"filterType": "PipelineSetup"}`).
-		SetQueryParams(map[string]string{
-			"accountIdentifier": api.Account,
-			"orgIdentifier":     org,
-			"projectIdentifier": project,
-			"size":              "1000",
-		}).
-		Post(api.BaseURL + LIST_PIPELINES)
-	if err != nil {
-		logger.Error("Failed to request to list of pipelines",
-			zap.Error(err),
-		)
-		return nil, err
-	}
-	if resp.IsError() {
-		logger.Error("Error response from API when listing pipelines",
-			zap.String("response",
-				resp.String(),
-			),
-		)
-		return nil, handleErrorResponse(resp)
-	}
+	err := api.paginate(PAGE_SIZE, func(page int) (listPage, error) {
+		api.Stats.IncrementApiCalls()
 
-	result := model.PipelineListResult{}
-	err = json.Unmarshal(resp.Body(), &result)
+		resp, err := api.Client.R().
+			AddRetryCondition(retryIdempotent).
+			SetHeader("x-api-key", api.Token).
+			SetHeader("Content-Type", "application/json").
+			SetBody(`{"filterType": "PipelineSetup"}`).
+			SetQueryParams(map[string]string{
+				"accountIdentifier": api.Account,
+				"orgIdentifier":     org,
+				"projectIdentifier": project,
+				"page":              strconv.Itoa(page),
+				"size":              strconv.Itoa(PAGE_SIZE),
+			}).
+			Post(api.BaseURL + LIST_PIPELINES)
+		if err != nil {
+			logger.Error("Failed to request to list of pipelines",
+				zap.Error(err),
+			)
+			return listPage{}, err
+		}
+		if resp.IsError() {
+			logger.Error("Error response from API when listing pipelines",
+				zap.String("response",
+					resp.String(),
+				),
+			)
+			return listPage{}, handleErrorResponse(resp)
+		}
+
+		result := model.PipelineListResult{}
+		err = json.Unmarshal(resp.Body(), &result)
+		if err != nil {
+			logger.Error("Failed to parse response from API",
+				zap.Error(err),
+			)
+			return listPage{}, err
+		}
+
+		pipelines = append(pipelines, result.Data.Content...)
+
+		return listPage{
+			index: int(result.Data.Number),
+			total: int(result.Data.TotalPages),
+			items: len(result.Data.Content),
+		}, nil
+	})
 	if err != nil {
-		logger.Error("Failed to parse response from API",
-			zap.Error(err),
-		)
 		return nil, err
 	}
 
-	return result.Data.Content, nil
+	return pipelines, nil
 }
 
 func (api *ApiRequest) getPipeline(org, project, pipeIdentifier string, logger *zap.Logger) (*model.PipelineGetData, error) {
@@ -138,7 +288,7 @@ func (api *ApiRequest) getPipeline(org, project, pipeIdentifier string, logger *
 		zap.String("pipeline", pipeIdentifier),
 	)
 
-	IncrementApiCalls()
+	api.Stats.IncrementApiCalls()
 
 	resp, err := api.Client.R().
 		SetHeader("x-api-key", api.Token).
@@ -183,7 +333,20 @@ func (api *ApiRequest) createPipeline(org, project, yaml string, logger *zap.Log
 		zap.String("project", project),
 	)
 
-	IncrementApiCalls()
+	if api.Bundle != nil {
+		return api.Bundle.Add(model.BundleEntry{
+			Entity:      "Pipeline",
+			Identifier:  yamlIdentifier(yaml),
+			Path:        CREATE_PIPELINE,
+			ContentType: "application/yaml",
+			Params: map[string]string{
+				"orgIdentifier":     org,
+				"projectIdentifier": project,
+			},
+		}, yaml, nil)
+	}
+
+	api.Stats.IncrementApiCalls()
 
 	resp, err := api.Client.R().
 		SetHeader("x-api-key", api.Token).
@@ -205,9 +368,9 @@ func (api *ApiRequest) createPipeline(org, project, yaml string, logger *zap.Log
 		var errorResponse map[string]interface{}
 		if err := json.Unmarshal(resp.Body(), &errorResponse); err == nil {
 			if code, ok := errorResponse["code"].(string); ok && code == "DUPLICATE_FIELD" {
-				// Log as a warning and skip the error
-				logger.Info("Duplicate pipeline found, ignoring error")
-				return nil
+				// The conflict strategy decides what happens to the existing entity
+				logger.Info("Duplicate pipeline found")
+				return ErrAlreadyExists
 			}
 		} else {
 			logger.Error(
@@ -220,5 +383,19 @@ func (api *ApiRequest) createPipeline(org, project, yaml string, logger *zap.Log
 		return handleErrorResponse(resp)
 	}
 
+	api.recordCreated(model.JournalEntry{
+		Entity:     "Pipeline",
+		Identifier: yamlIdentifier(yaml),
+		Org:        org,
+		Project:    project,
+	}, logger)
+
 	return nil
 }
+
+func (api *ApiRequest) updatePipeline(org, project, identifier, yaml string, logger *zap.Logger) error {
+	return api.updateEntity(CREATE_PIPELINE+"/"+identifier, "application/yaml", yaml, map[string]string{
+		"orgIdentifier":     org,
+		"projectIdentifier": project,
+	}, logger)
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+	"harness-copy-project/model"
+)
+
+const (
+	PlanCreate         = "create"
+	PlanAlreadyExists  = "already-exists"
+	PlanFailValidation = "will-fail-validation"
+)
+
+// Plan collects what a copy would do without calling any create endpoint.
+// When ApiRequest.Plan is set, every operation lists and fetches as usual and
+// records an entry per entity instead of creating it.
+type Plan struct {
+	mu      sync.Mutex
+	Entries []model.PlanEntry
+}
+
+func NewPlan() *Plan {
+	return &Plan{
+		Entries: []model.PlanEntry{},
+	}
+}
+
+// Record adds an entity to the plan. An error means the entity would fail
+// validation, otherwise it is either created or already present in the target.
+func (p *Plan) Record(entity, identifier, name string, exists bool, err error) {
+	entry := model.PlanEntry{
+		Entity:     entity,
+		Identifier: identifier,
+		Name:       name,
+	}
+
+	switch {
+	case err != nil:
+		entry.Action = PlanFailValidation
+		entry.Reason = removeNewLine(err.Error())
+	case exists:
+		entry.Action = PlanAlreadyExists
+	default:
+		entry.Action = PlanCreate
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.Entries = append(p.Entries, entry)
+}
+
+// checkYaml confirms the YAML can be parsed before it is sent to the target
+func checkYaml(input string) error {
+	if len(input) == 0 {
+		return fmt.Errorf("YAML is empty")
+	}
+	var data yaml.Node
+	if err := yaml.Unmarshal([]byte(input), &data); err != nil {
+		return fmt.Errorf("failed to parse YAML: %v", err)
+	}
+	return nil
+}
+
+// checkValidity returns an error when Harness has flagged the source entity as invalid
+func checkValidity(details model.EntityValidityDetails) error {
+	if !details.Valid && details.InvalidYAML != nil {
+		return fmt.Errorf("entity is marked as invalid in the source project")
+	}
+	return nil
+}
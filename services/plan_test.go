@@ -0,0 +1,136 @@
+package services
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"harness-copy-project/model"
+)
+
+func TestPlan_Record(t *testing.T) {
+	plan := NewPlan()
+
+	plan.Record("Pipeline", "build", "Build", false, nil)
+	plan.Record("Pipeline", "deploy", "Deploy", true, nil)
+	// A FAILED VALIDATION WINS OVER AN ENTITY THE TARGET ALREADY HAS
+	plan.Record("Pipeline", "broken", "Broken", true, checkYaml(""))
+
+	assert.Equal(t, []model.PlanEntry{
+		{Entity: "Pipeline", Identifier: "build", Name: "Build", Action: PlanCreate},
+		{Entity: "Pipeline", Identifier: "deploy", Name: "Deploy", Action: PlanAlreadyExists},
+		{Entity: "Pipeline", Identifier: "broken", Name: "Broken", Action: PlanFailValidation, Reason: "YAML is empty"},
+	}, plan.Entries)
+}
+
+func TestCheckYaml(t *testing.T) {
+	assert.NoError(t, checkYaml("pipeline:\n  identifier: build\n"))
+	assert.ErrorContains(t, checkYaml(""), "YAML is empty")
+	assert.ErrorContains(t, checkYaml("pipeline:\n  identifier: [build\n"), "failed to parse YAML")
+}
+
+func TestCheckValidity(t *testing.T) {
+	reason := "invalid yaml"
+
+	assert.NoError(t, checkValidity(model.EntityValidityDetails{Valid: true}))
+	// AN ENTITY WITHOUT THE DETAILS OF WHAT IS INVALID IS NOT REJECTED
+	assert.NoError(t, checkValidity(model.EntityValidityDetails{}))
+	assert.Error(t, checkValidity(model.EntityValidityDetails{InvalidYAML: &reason}))
+}
+
+func TestPlan_NothingIsSent(t *testing.T) {
+	fake := newFakeHarness(t)
+	fake.addPipeline("src", "src", "build", "pipeline:\n  identifier: build\n")
+	fake.addPipeline("src", "src", "deploy", "pipeline:\n  identifier: deploy\n")
+	fake.addPipeline("src", "src", "invalid", "pipeline:\n  identifier: invalid\n").Invalid = true
+	fake.addPipeline("src", "src", "broken", "pipeline:\n  identifier: [broken\n")
+	// A REMOTE PIPELINE WITHOUT THE FILE IT IS STORED IN CANNOT BE IMPORTED
+	fake.addPipeline("src", "src", "remote", "pipeline:\n  identifier: remote\n").StoreType = model.Remote
+	fake.addPipeline("target", "target", "deploy", "pipeline:\n  identifier: deploy\n")
+
+	fake.addInputset("src", "src", "build", "defaults")
+	fake.addInputset("src", "src", "deploy", "prod")
+	fake.addInputset("target", "target", "deploy", "prod")
+
+	fake.addTemplate("src", "src", "stage", "v1", true)
+	fake.addTemplate("src", "src", "stage", "v2", false)
+	fake.addTemplate("target", "target", "stage", "v1", true)
+
+	api := fake.api()
+	api.Plan = NewPlan()
+
+	assert.NoError(t, NewTemplateOperation(api, "src", "src", "target", "target", zap.NewNop(), false).Copy())
+	assert.NoError(t, NewPipelineOperation(api, "src", "src", "target", "target", zap.NewNop(), false).Copy())
+	assert.NoError(t, NewInputsetOperation(api, "src", "src", "target", "target", zap.NewNop(), false).Copy())
+
+	// THE SOURCE AND THE TARGET ARE ONLY READ
+	assert.Empty(t, fake.changes())
+	assert.Equal(t, []string{"deploy"}, fake.pipelineIdentifiers("target", "target"))
+
+	assert.Len(t, api.Plan.Entries, 9)
+	actions := map[string]string{}
+	for _, e := range api.Plan.Entries {
+		actions[e.Entity+" "+e.Identifier] = e.Action
+		if e.Action == PlanFailValidation {
+			assert.NotEmpty(t, e.Reason, e.Identifier)
+		}
+	}
+	assert.Equal(t, map[string]string{
+		"Template stage/v1":        PlanAlreadyExists,
+		"Template stage/v2":        PlanCreate,
+		"Pipeline build":           PlanCreate,
+		"Pipeline deploy":          PlanAlreadyExists,
+		"Pipeline invalid":         PlanFailValidation,
+		"Pipeline broken":          PlanFailValidation,
+		"Pipeline remote":          PlanFailValidation,
+		"Input Set build/defaults": PlanCreate,
+		"Input Set deploy/prod":    PlanAlreadyExists,
+	}, actions)
+	assert.Empty(t, api.Stats.GetCreated())
+}
+
+// failTargetList fails the list of the target project on the path, the source is still
+// listed by the fake
+func failTargetList(fake *fakeHarness, pattern string) {
+	fake.handle(pattern, func(w http.ResponseWriter, r *http.Request) {
+		if scopeOf(r) == "target/target" {
+			writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "list failed")
+			return
+		}
+		fake.routes.ServeHTTP(w, r)
+	})
+}
+
+func TestPlan_TargetListFails(t *testing.T) {
+	fake := newFakeHarness(t)
+	fake.addPipeline("src", "src", "build", "pipeline:\n  identifier: build\n")
+	failTargetList(fake, "POST "+LIST_PIPELINES)
+
+	api := fake.api()
+	api.Plan = NewPlan()
+
+	// A PLAN THAT CANNOT TELL WHAT THE TARGET HAS IS NOT REPORTED AS A CREATE
+	assert.Error(t, NewPipelineOperation(api, "src", "src", "target", "target", zap.NewNop(), false).Copy())
+	assert.Empty(t, api.Plan.Entries)
+	assert.Empty(t, fake.changes())
+}
+
+func TestPlan_TargetListOfParentFails(t *testing.T) {
+	fake := newFakeHarness(t)
+	fake.addPipeline("src", "src", "build", "pipeline:\n  identifier: build\n")
+	fake.addInputset("src", "src", "build", "defaults")
+	failTargetList(fake, "GET /pipeline/api/inputSets")
+
+	api := fake.api()
+	api.Plan = NewPlan()
+
+	// THE INPUT SETS OF THE PIPELINE FAIL THE PLAN, THE OTHER ENTITIES ARE STILL PLANNED
+	assert.NoError(t, NewInputsetOperation(api, "src", "src", "target", "target", zap.NewNop(), false).Copy())
+	if assert.Len(t, api.Plan.Entries, 1) {
+		assert.Equal(t, "build/defaults", api.Plan.Entries[0].Identifier)
+		assert.Equal(t, PlanFailValidation, api.Plan.Entries[0].Action)
+		assert.NotEmpty(t, api.Plan.Entries[0].Reason)
+	}
+	assert.Empty(t, fake.changes())
+}
@@ -17,7 +17,7 @@ func (api *ApiRequest) ValidateProject(org, project string, logger *zap.Logger)
 		zap.String("project", project),
 	)
 
-	IncrementApiCalls()
+	api.Stats.IncrementApiCalls()
 
 	resp, err := api.Client.R().
 		SetHeader("x-api-key", api.Token).
@@ -88,7 +88,7 @@ func (c ProjectContext) Copy() error {
 		zap.String("project", c.sourceProject),
 	)
 
-	sourceProject, err := c.api.getProject(c.sourceOrg, c.sourceProject, c.logger)
+	sourceProject, err := c.api.source().getProject(c.sourceOrg, c.sourceProject, c.logger)
 	if err != nil {
 		c.logger.Error("Failed to retrive source project",
 			zap.String("Project", c.sourceProject),
@@ -97,6 +97,11 @@ func (c ProjectContext) Copy() error {
 		return err
 	}
 
+	if c.api.Plan != nil {
+		c.api.Plan.Record("Project", c.targetProject, sourceProject.Name, false, nil)
+		return nil
+	}
+
 	newProject := &model.Project{
 		Identifier:    c.targetProject,
 		Name:          sourceProject.Name,
@@ -125,7 +130,7 @@ func (api *ApiRequest) getProject(org, project string, logger *zap.Logger) (mode
 		zap.String("project", project),
 	)
 
-	IncrementApiCalls()
+	api.Stats.IncrementApiCalls()
 
 	resp, err := api.Client.R().
 		SetHeader("x-api-key", api.Token).
@@ -173,7 +178,21 @@ func (api *ApiRequest) CreateProject(project *model.Project, logger *zap.Logger)
 		zap.String("project", project.Name),
 	)
 
-	IncrementApiCalls()
+	if api.Bundle != nil {
+		return api.Bundle.Add(model.BundleEntry{
+			Entity:      "Project",
+			Identifier:  project.Identifier,
+			Path:        NEW_PROJECT,
+			ContentType: "application/json",
+			Params: map[string]string{
+				"orgIdentifier": project.OrgIdentifier,
+			},
+		}, model.ProjectWrapper{
+			Project: project,
+		}, nil)
+	}
+
+	api.Stats.IncrementApiCalls()
 
 	wrappedProject := model.ProjectWrapper{
 		Project: project,
@@ -214,7 +233,7 @@ func (api *ApiRequest) CreateProject(project *model.Project, logger *zap.Logger)
 				),
 			)
 		}
-		return handleErrorResponse(resp)
+		return ignoreAlreadyExists(handleErrorResponse(resp))
 	}
 	result := model.GetProjectResponse{}
 	err = json.Unmarshal(resp.Body(), &result)
@@ -230,5 +249,12 @@ func (api *ApiRequest) CreateProject(project *model.Project, logger *zap.Logger)
 			zap.Error(err),
 		)
 	}
+
+	api.recordCreated(model.JournalEntry{
+		Entity:     "Project",
+		Identifier: project.Identifier,
+		Org:        project.OrgIdentifier,
+	}, logger)
+
 	return nil
 }
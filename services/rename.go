@@ -0,0 +1,468 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"harness-copy-project/model"
+)
+
+// renameEntities are the keys that hold an entity, its identifier and name are renamed
+// with the rules of that entity type. At the root of a YAML document they are the entity
+// itself, anywhere else they are an inline reference to it.
+var renameEntities = map[string]string{
+	"pipeline":                 "Pipeline",
+	"template":                 "Template",
+	"inputSet":                 "Input Set",
+	"overlayInputSet":          "Input Set",
+	"trigger":                  "Trigger",
+	"service":                  "Service",
+	"environment":              "Environment",
+	"environmentGroup":         "Environment Group",
+	"infrastructureDefinition": "Infrastructure",
+	"connector":                "Connector",
+	"secret":                   "Secret",
+	"variable":                 "Variable",
+	"resourceGroup":            "Resource Group",
+}
+
+// renameReferences are the keys whose value is the identifier of another entity, or a
+// list of identifiers
+var renameReferences = map[string]string{
+	"connectorRef":            "Connector",
+	"connectorIdentifier":     "Connector",
+	"secretManagerIdentifier": "Connector",
+	"serviceRef":              "Service",
+	"serviceIdentifier":       "Service",
+	"environmentRef":          "Environment",
+	"envRef":                  "Environment",
+	"environmentIdentifier":   "Environment",
+	"envIdentifiers":          "Environment",
+	"environmentGroupRef":     "Environment Group",
+	"envGroupRef":             "Environment Group",
+	"templateRef":             "Template",
+	"pipelineIdentifier":      "Pipeline",
+	"inputSetReferences":      "Input Set",
+	"inputSetRefs":            "Input Set",
+	"passwordRef":             "Secret",
+	"usernameRef":             "Secret",
+	"tokenRef":                "Secret",
+	"authTokenRef":            "Secret",
+	"secretKeyRef":            "Secret",
+	"accessKeyRef":            "Secret",
+	"apiKeyRef":               "Secret",
+	"sshKeyRef":               "Secret",
+	"keyRef":                  "Secret",
+	"secretRef":               "Secret",
+	"certificateRef":          "Secret",
+	"ngCertificateRef":        "Secret",
+}
+
+// renameLists are the keys that hold a list of entities referenced by their identifier
+var renameLists = map[string]string{
+	"infrastructureDefinitions": "Infrastructure",
+}
+
+var secretExpression = regexp.MustCompile(`<\+secrets\.get(Value|FileContent|FileContentAsBase64)\(\s*"([^"]+)"\s*\)>`)
+
+// Renamer changes the identifiers and names of the entities created in the target, and
+// every reference to them. The first rule that matches an entity type and value applies.
+// References remapped explicitly are replaced as they are, whatever their scope.
+// A nil Renamer keeps every identifier, name and reference.
+type Renamer struct {
+	identifiers []renameRule
+	names       []renameRule
+	remap       map[string]map[string]string
+
+	// stats of the project copy the remapped references are reported to, if any
+	stats *Stats
+}
+
+type renameRule struct {
+	entity  string
+	from    string
+	pattern *regexp.Regexp
+	to      string
+}
+
+func NewRenamer(rules model.RenameRules, remap model.ReferenceRemap) (*Renamer, error) {
+	references := map[string]map[string]string{}
+	for entity, values := range map[string]map[string]string{
+		"Connector":  remap.Connectors,
+		"Secret":     remap.Secrets,
+		"Template":   remap.Templates,
+		"Repository": remap.Repositories,
+		"Branch":     remap.Branches,
+	} {
+		if len(values) > 0 {
+			references[entity] = values
+		}
+	}
+
+	if len(rules.Identifiers) == 0 && len(rules.Names) == 0 && len(references) == 0 {
+		return nil, nil
+	}
+
+	r := &Renamer{
+		remap: references,
+	}
+	var err error
+	if r.identifiers, err = compileRenameRules("identifiers", rules.Identifiers); err != nil {
+		return nil, err
+	}
+	if r.names, err = compileRenameRules("names", rules.Names); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func compileRenameRules(field string, rules []model.RenameRule) ([]renameRule, error) {
+	compiled := []renameRule{}
+	for i, rule := range rules {
+		if (rule.From == "") == (rule.Pattern == "") {
+			return nil, fmt.Errorf("%s rule %d must have either 'from' or 'pattern'", field, i+1)
+		}
+		c := renameRule{
+			entity: rule.Entity,
+			from:   rule.From,
+			to:     rule.To,
+		}
+		if rule.Pattern != "" {
+			pattern, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("%s rule %d has an invalid pattern: %v", field, i+1, err)
+			}
+			c.pattern = pattern
+		}
+		compiled = append(compiled, c)
+	}
+	return compiled, nil
+}
+
+func applyRenameRules(rules []renameRule, entity, value string) string {
+	if value == "" {
+		return value
+	}
+	for _, rule := range rules {
+		if rule.entity != "" && rule.entity != entity {
+			continue
+		}
+		if rule.pattern == nil {
+			if rule.from == value {
+				return rule.to
+			}
+			continue
+		}
+		if rule.pattern.MatchString(value) {
+			return rule.pattern.ReplaceAllString(value, rule.to)
+		}
+	}
+	return value
+}
+
+// Identifier returns the identifier an entity is created with in the target
+func (r *Renamer) Identifier(entity, identifier string) string {
+	if r == nil {
+		return identifier
+	}
+	return applyRenameRules(r.identifiers, entity, identifier)
+}
+
+// Name returns the name an entity is created with in the target
+func (r *Renamer) Name(entity, name string) string {
+	if r == nil {
+		return name
+	}
+	return applyRenameRules(r.names, entity, name)
+}
+
+// Entity renames the identifier and the name of an entity in place
+func (r *Renamer) Entity(entity string, identifier, name *string) {
+	if identifier != nil {
+		*identifier = r.Identifier(entity, *identifier)
+	}
+	if name != nil {
+		*name = r.Name(entity, *name)
+	}
+}
+
+// Project returns the renamer of a single project copy, the references it remaps are
+// reported in the stats of that copy
+func (r *Renamer) Project(stats *Stats) *Renamer {
+	if r == nil {
+		return nil
+	}
+	project := *r
+	project.stats = stats
+	return &project
+}
+
+// remapped reports whether a reference is replaced by the reference remap
+func (r *Renamer) remapped(entity, value string) bool {
+	if r == nil {
+		return false
+	}
+	_, ok := r.remap[entity][value]
+	return ok
+}
+
+// remapValue returns the value a reference is remapped to, and reports the remap
+func (r *Renamer) remapValue(entity, value string) (string, bool) {
+	to, ok := r.remap[entity][value]
+	if ok && r.stats != nil {
+		r.stats.AddRemap(entity, value, to)
+	}
+	return to, ok
+}
+
+// Git returns the location a remote entity is imported from in the target. The connector
+// is renamed or remapped like any other reference, the repository and the branch only
+// with the reference remap, and the file path is kept.
+func (r *Renamer) Git(location model.GitLocation) model.GitLocation {
+	if r == nil {
+		return location
+	}
+	location.ConnectorRef = r.reference("Connector", location.ConnectorRef)
+	if to, ok := r.remapValue("Repository", location.RepoName); ok {
+		location.RepoName = to
+	}
+	if to, ok := r.remapValue("Branch", location.Branch); ok {
+		location.Branch = to
+	}
+	return location
+}
+
+// reference renames the identifier of a referenced entity. References to an entity of
+// the org or the account, and runtime expressions, are kept unless they are remapped.
+func (r *Renamer) reference(entity, value string) string {
+	if to, ok := r.remapValue(entity, value); ok {
+		return to
+	}
+	if strings.HasPrefix(value, "org.") || strings.HasPrefix(value, "account.") || strings.Contains(value, "<+") {
+		return value
+	}
+	// THE BUILT-IN SECRET MANAGER IS NOT PART OF THE PROJECT
+	if value == HARNESS_SECRET_MANAGER {
+		return value
+	}
+	return r.Identifier(entity, value)
+}
+
+// expressions renames the secrets referenced by the expressions of a string value
+func (r *Renamer) expressions(value string) string {
+	if !strings.Contains(value, "<+secrets.") {
+		return value
+	}
+	return secretExpression.ReplaceAllStringFunc(value, func(match string) string {
+		parts := secretExpression.FindStringSubmatch(match)
+		return strings.Replace(match, `"`+parts[2]+`"`, `"`+r.reference("Secret", parts[2])+`"`, 1)
+	})
+}
+
+// Yaml renames the entity of a YAML document and every reference to a renamed entity in it
+func (r *Renamer) Yaml(input string) string {
+	if r == nil {
+		return input
+	}
+
+	var data yaml.Node
+	if err := yaml.Unmarshal([]byte(input), &data); err != nil || data.Kind != yaml.DocumentNode {
+		return input
+	}
+	if len(data.Content) == 0 || data.Content[0].Kind != yaml.MappingNode {
+		return input
+	}
+	for _, content := range data.Content {
+		r.renameNode(content, "")
+	}
+
+	var output strings.Builder
+	encoder := yaml.NewEncoder(&output)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&data); err != nil {
+		return input
+	}
+	encoder.Close()
+
+	return output.String()
+}
+
+// renameNode walks a YAML node. self is the entity type the identifier and name keys of a
+// mapping belong to, if any.
+func (r *Renamer) renameNode(node *yaml.Node, self string) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i < len(node.Content)-1; i += 2 {
+			key := node.Content[i].Value
+			value := node.Content[i+1]
+
+			if value.Kind == yaml.ScalarNode {
+				switch {
+				case self != "" && key == "identifier":
+					value.Value = r.Identifier(self, value.Value)
+				case self != "" && key == "name":
+					value.Value = r.Name(self, value.Value)
+				case renameReferences[key] != "":
+					value.Value = r.reference(renameReferences[key], value.Value)
+				case key == "inputYaml":
+					// TRIGGERS HOLD THE PIPELINE INPUTS AS AN EMBEDDED YAML DOCUMENT
+					value.Value = r.Yaml(value.Value)
+				default:
+					value.Value = r.expressions(value.Value)
+				}
+				continue
+			}
+
+			if entity, ok := renameReferences[key]; ok && value.Kind == yaml.SequenceNode {
+				for _, item := range value.Content {
+					if item.Kind == yaml.ScalarNode {
+						item.Value = r.reference(entity, item.Value)
+					}
+				}
+				continue
+			}
+			if entity, ok := renameLists[key]; ok && value.Kind == yaml.SequenceNode {
+				for _, item := range value.Content {
+					r.renameNode(item, entity)
+				}
+				continue
+			}
+			r.renameNode(value, renameEntities[key])
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			r.renameNode(item, "")
+		}
+	case yaml.ScalarNode:
+		node.Value = r.expressions(node.Value)
+	}
+}
+
+// Object renames an entity sent as JSON and every reference to a renamed entity in it. The
+// entity is encoded, renamed and decoded back in place.
+func (r *Renamer) Object(entity string, object interface{}) error {
+	if r == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(object)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return err
+	}
+
+	data, err = json.Marshal(r.Value(entity, value))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, object)
+}
+
+// Value renames a decoded JSON value, self is the entity type of the value itself
+func (r *Renamer) Value(self string, value interface{}) interface{} {
+	if r == nil {
+		return value
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			switch f := field.(type) {
+			case string:
+				switch {
+				case self != "" && key == "identifier":
+					v[key] = r.Identifier(self, f)
+				case self != "" && key == "name":
+					v[key] = r.Name(self, f)
+				case renameReferences[key] != "":
+					v[key] = r.reference(renameReferences[key], f)
+				case strings.Contains(f, "\n"):
+					// ENTITIES SENT AS JSON HOLD THEIR DEFINITION AS AN EMBEDDED YAML DOCUMENT
+					v[key] = r.Yaml(f)
+				default:
+					v[key] = r.expressions(f)
+				}
+			case []interface{}:
+				if entity, ok := renameReferences[key]; ok {
+					for i, item := range f {
+						if s, ok := item.(string); ok {
+							f[i] = r.reference(entity, s)
+						}
+					}
+				} else if entity, ok := renameLists[key]; ok {
+					for i, item := range f {
+						f[i] = r.Value(entity, item)
+					}
+				} else {
+					v[key] = r.Value("", f)
+				}
+			default:
+				v[key] = r.Value(renameEntities[key], f)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = r.Value("", item)
+		}
+	case string:
+		return r.expressions(v)
+	}
+	return value
+}
+
+// Source moves the source entities of one type listed by the verification to the
+// identifiers and names they were created with in the target
+func (r *Renamer) Source(entity string, source map[string]interface{}) map[string]interface{} {
+	if r == nil {
+		return source
+	}
+
+	// THE REFERENCES WERE ALREADY REPORTED WHEN THE ENTITIES WERE CREATED
+	quiet := *r
+	quiet.stats = nil
+
+	renamed := map[string]interface{}{}
+	for key, value := range source {
+		renamed[r.sourceKey(entity, key)] = quiet.Value(entity, normalizeEntity(value))
+	}
+	return renamed
+}
+
+// sourceKey renames a verification key. Entities of an environment or a pipeline are keyed
+// by scope/identifier, templates by identifier/version and file store nodes by their path.
+func (r *Renamer) sourceKey(entity, key string) string {
+	scope, identifier, scoped := strings.Cut(key, "/")
+
+	switch entity {
+	case "File Store", "User":
+		return key
+	case "Template":
+		if scoped {
+			return r.Identifier(entity, scope) + "/" + identifier
+		}
+	case "Infrastructure", "Target", "Target Group":
+		if scoped {
+			return r.Identifier("Environment", scope) + "/" + r.Identifier(entity, identifier)
+		}
+	case "Service Override":
+		if scoped {
+			return r.Identifier("Environment", scope) + "/" + r.Identifier("Service", identifier)
+		}
+	case "Input Set", "Trigger":
+		if scoped {
+			return r.Identifier("Pipeline", scope) + "/" + r.Identifier(entity, identifier)
+		}
+	}
+
+	return r.Identifier(entity, key)
+}
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+	"harness-copy-project/model"
+)
+
+func newTestRenamer(t *testing.T) *Renamer {
+	r, err := NewRenamer(model.RenameRules{
+		Identifiers: []model.RenameRule{
+			{Entity: "Connector", From: "github_old", To: "github"},
+			{Pattern: "^legacy_(.*)$", To: "$1"},
+		},
+		Names: []model.RenameRule{
+			{Entity: "Pipeline", Pattern: "^Legacy (.*)$", To: "$1"},
+		},
+	}, model.ReferenceRemap{})
+	assert.NoError(t, err)
+	return r
+}
+
+func TestNewRenamer_InvalidRules(t *testing.T) {
+	r, err := NewRenamer(model.RenameRules{}, model.ReferenceRemap{})
+	assert.NoError(t, err)
+	assert.Nil(t, r)
+
+	_, err = NewRenamer(model.RenameRules{Identifiers: []model.RenameRule{{To: "x"}}}, model.ReferenceRemap{})
+	assert.Error(t, err)
+
+	_, err = NewRenamer(model.RenameRules{Names: []model.RenameRule{{Pattern: "(", To: "x"}}}, model.ReferenceRemap{})
+	assert.Error(t, err)
+}
+
+func TestRenamer_ExplicitAndPatternRules(t *testing.T) {
+	r := newTestRenamer(t)
+
+	assert.Equal(t, "github", r.Identifier("Connector", "github_old"))
+	// AN EXPLICIT RULE ONLY APPLIES TO ITS ENTITY TYPE
+	assert.Equal(t, "github_old", r.Identifier("Secret", "github_old"))
+	assert.Equal(t, "build", r.Identifier("Pipeline", "legacy_build"))
+	assert.Equal(t, "Build", r.Name("Pipeline", "Legacy Build"))
+	assert.Equal(t, "Legacy Build", r.Name("Service", "Legacy Build"))
+
+	var nilRenamer *Renamer
+	assert.Equal(t, "legacy_build", nilRenamer.Identifier("Pipeline", "legacy_build"))
+}
+
+func TestRenamer_YamlRewritesReferences(t *testing.T) {
+	r := newTestRenamer(t)
+
+	input := `pipeline:
+  identifier: legacy_build
+  name: Legacy Build
+  stages:
+    - stage:
+        identifier: legacy_stage
+        name: Legacy Stage
+        spec:
+          service:
+            serviceRef: legacy_api
+          environment:
+            environmentRef: legacy_prod
+            infrastructureDefinitions:
+              - identifier: legacy_k8s
+          execution:
+            steps:
+              - step:
+                  identifier: clone
+                  spec:
+                    connectorRef: github_old
+                    orgConnector: org.github_old
+                    command: echo <+secrets.getValue("legacy_token")> <+secrets.getValue("org.legacy_token")>
+`
+
+	output := map[string]interface{}{}
+	assert.NoError(t, yaml.Unmarshal([]byte(r.Yaml(input)), &output))
+
+	pipeline := output["pipeline"].(map[string]interface{})
+	assert.Equal(t, "build", pipeline["identifier"])
+	assert.Equal(t, "Build", pipeline["name"])
+
+	stage := pipeline["stages"].([]interface{})[0].(map[string]interface{})["stage"].(map[string]interface{})
+	// STAGES AND STEPS ARE NOT ENTITIES, THEIR IDENTIFIERS ARE KEPT
+	assert.Equal(t, "legacy_stage", stage["identifier"])
+
+	spec := stage["spec"].(map[string]interface{})
+	assert.Equal(t, "api", spec["service"].(map[string]interface{})["serviceRef"])
+	environment := spec["environment"].(map[string]interface{})
+	assert.Equal(t, "prod", environment["environmentRef"])
+	assert.Equal(t, "k8s", environment["infrastructureDefinitions"].([]interface{})[0].(map[string]interface{})["identifier"])
+
+	step := spec["execution"].(map[string]interface{})["steps"].([]interface{})[0].(map[string]interface{})["step"].(map[string]interface{})["spec"].(map[string]interface{})
+	assert.Equal(t, "github", step["connectorRef"])
+	assert.Equal(t, "org.github_old", step["orgConnector"])
+	assert.Equal(t, `echo <+secrets.getValue("token")> <+secrets.getValue("org.legacy_token")>`, step["command"])
+}
+
+func TestPipelineCopy_RenameRules(t *testing.T) {
+	fake := newFakeHarness(t)
+	fake.addPipeline("src", "src", "legacy_build", "pipeline:\n  identifier: legacy_build\n  name: Legacy Build\n  orgIdentifier: src\n  projectIdentifier: src\n  properties:\n    ci:\n      codebase:\n        connectorRef: github_old\n").Name = "Legacy Build"
+
+	checkpoint, err := LoadCheckpoint(filepath.Join(t.TempDir(), "checkpoint.json"), false)
+	assert.NoError(t, err)
+
+	api := fake.api()
+	api.Checkpoint = checkpoint.Project("src", "src", "target", "target")
+	api.Rename = newTestRenamer(t)
+
+	err = NewPipelineOperation(api, "src", "src", "target", "target", zap.NewNop(), false).Copy()
+	assert.Nil(t, err)
+
+	creates := fake.sent(http.MethodPost, CREATE_PIPELINE)
+	assert.Len(t, creates, 1)
+	created := map[string]map[string]interface{}{}
+	assert.NoError(t, yaml.Unmarshal([]byte(creates[0].Body), &created))
+	assert.Equal(t, "build", created["pipeline"]["identifier"])
+	assert.Equal(t, "Build", created["pipeline"]["name"])
+	assert.Equal(t, "target", created["pipeline"]["projectIdentifier"])
+	assert.Contains(t, creates[0].Body, "connectorRef: github\n")
+
+	// THE CHECKPOINT KEEPS THE SOURCE IDENTIFIER SO A RESUMED COPY SKIPS IT
+	assert.True(t, api.Checkpoint.Done("Pipeline", "legacy_build"))
+}
+
+func TestRenamer_ReferenceRemapIsReported(t *testing.T) {
+	r, err := NewRenamer(model.RenameRules{}, model.ReferenceRemap{
+		Connectors: map[string]string{"org.gitConnector": "org.github"},
+		Secrets:    map[string]string{"account.token": "org.token"},
+		Templates:  map[string]string{"account.deployTemplate": "org.deploy"},
+	})
+	assert.NoError(t, err)
+
+	stats := NewStats()
+	r = r.Project(stats)
+
+	output := r.Yaml(`pipeline:
+  identifier: build
+  properties:
+    ci:
+      codebase:
+        connectorRef: org.gitConnector
+  stages:
+    - stage:
+        identifier: deploy
+        template:
+          templateRef: account.deployTemplate
+          versionLabel: v1
+    - stage:
+        identifier: notify
+        spec:
+          command: curl -H 'token <+secrets.getValue("account.token")>'
+          connectorRef: org.gitConnector
+`)
+	assert.Contains(t, output, "connectorRef: org.github\n")
+	assert.Contains(t, output, "templateRef: org.deploy\n")
+	assert.Contains(t, output, `<+secrets.getValue("org.token")>`)
+	assert.NotContains(t, output, "gitConnector")
+
+	token := "account.token"
+	connector := &model.ConnectorContent{
+		Connector: model.Connector{
+			Identifier: "docker",
+			Spec: model.ConnectorSpec{
+				AuthToken: &token,
+			},
+		},
+	}
+	assert.NoError(t, r.Object("Connector", connector))
+	assert.Equal(t, "org.token", *connector.Connector.Spec.AuthToken)
+	assert.Equal(t, "docker", connector.Connector.Identifier)
+
+	assert.Equal(t, []model.RemapEntry{
+		{Entity: "Connector", From: "org.gitConnector", To: "org.github", Count: 2},
+		{Entity: "Template", From: "account.deployTemplate", To: "org.deploy", Count: 1},
+		{Entity: "Secret", From: "account.token", To: "org.token", Count: 2},
+	}, stats.GetRemaps())
+}
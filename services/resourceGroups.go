@@ -2,6 +2,7 @@ package services
 
 import (
 	"encoding/json"
+	"strconv"
 
 	"github.com/schollz/progressbar/v3"
 	"go.uber.org/zap"
@@ -34,7 +35,7 @@ func NewResourceGroupOperation(api *ApiRequest, sourceOrg, sourceProject, target
 
 func (c ResourceGroupContext) Copy() error {
 
-	resourceGroups, err := c.api.listResourceGroups(c.sourceOrg, c.sourceProject, c.logger)
+	resourceGroups, err := c.api.source().listResourceGroups(c.sourceOrg, c.sourceProject, c.logger)
 	if err != nil {
 		c.logger.Error("Failed to retrive resource groups",
 			zap.String("Project", c.sourceProject),
@@ -43,6 +44,21 @@ func (c ResourceGroupContext) Copy() error {
 		return err
 	}
 
+	existing := map[string]bool{}
+	if c.api.Plan != nil {
+		targetResourceGroups, err := c.api.listResourceGroups(c.targetOrg, c.targetProject, c.logger)
+		if err != nil {
+			c.logger.Error("Failed to list the resource groups of the target project",
+				zap.String("Project", c.targetProject),
+				zap.Error(err),
+			)
+			return err
+		}
+		for _, rg := range targetResourceGroups {
+			existing[rg.Identifier] = true
+		}
+	}
+
 	var bar *progressbar.ProgressBar
 
 	if c.showPB {
@@ -51,13 +67,35 @@ func (c ResourceGroupContext) Copy() error {
 
 	for _, rg := range resourceGroups {
 
-		IncrementResourceGroupsTotal()
+		c.api.Stats.IncrementResourceGroupsTotal()
+
+		if c.api.Checkpoint.Done("Resource Group", rg.Identifier) {
+			c.logger.Info("Skipping resource group copied by a previous run",
+				zap.String("identifier", rg.Identifier),
+			)
+			c.api.Stats.IncrementResourceGroupsMoved()
+			if c.showPB {
+				bar.Add(1)
+			}
+			continue
+		}
 
 		c.logger.Info("Processing resource group",
 			zap.String("resource group", rg.Name),
 			zap.String("targetProject", c.targetProject),
 		)
 
+		if c.api.Plan != nil {
+			c.api.Plan.Record("Resource Group", rg.Identifier, rg.Name, existing[c.api.Rename.Identifier("Resource Group", rg.Identifier)], nil)
+			if c.showPB {
+				bar.Add(1)
+			}
+			continue
+		}
+
+		identifier := rg.Identifier
+		c.api.Rename.Entity("Resource Group", &rg.Identifier, &rg.Name)
+
 		rg.OrgIdentifier = c.targetOrg
 		rg.ProjectIdentifier = c.targetProject
 
@@ -71,6 +109,9 @@ func (c ResourceGroupContext) Copy() error {
 		}
 
 		err = c.api.createResourceGroup(newResourceGroup, c.logger)
+		err = c.api.resolveConflict("Resource Group", identifier, err, func() error {
+			return c.api.updateResourceGroup(newResourceGroup, c.logger)
+		}, c.logger)
 
 		if err != nil {
 			c.logger.Error("Failed to create resource group",
@@ -78,7 +119,10 @@ func (c ResourceGroupContext) Copy() error {
 				zap.Error(err),
 			)
 		} else {
-			IncrementResourceGroupsMoved()
+			c.api.Stats.IncrementResourceGroupsMoved()
+			if err := c.api.Checkpoint.Record("Resource Group", identifier); err != nil {
+				c.logger.Warn("Failed to update checkpoint", zap.Error(err))
+			}
 		}
 		if c.showPB {
 			bar.Add(1)
@@ -98,49 +142,62 @@ func (api *ApiRequest) listResourceGroups(org, project string, logger *zap.Logge
 		zap.String("project", project),
 	)
 
-	IncrementApiCalls()
+	resourceGroups := []*model.ResourceGroup{}
 
-	resp, err := api.Client.R().
-		SetHeader("x-api-key", api.Token).
-		SetHeader("Content-Type", "application/json").
-		SetQueryParams(map[string]string{
-			"accountIdentifier": api.Account,
-			"orgIdentifier":     org,
-			"projectIdentifier": project,
-			"pageSize":          "100",
-		}).
-		Get(api.BaseURL + RESOURCEGROUP)
-	if err != nil {
-		logger.Error("Failed to request to list of resource groups",
-			zap.Error(err),
-		)
-		return nil, err
-	}
-	if resp.IsError() {
-		logger.Error("Error response from API when listing resource groups",
-			zap.String("response",
-				resp.String(),
-			),
-		)
-		return nil, handleErrorResponse(resp)
-	}
+	err := api.paginate(PAGE_SIZE, func(page int) (listPage, error) {
+		api.Stats.IncrementApiCalls()
 
-	result := model.GetResourceGroupResponse{}
-	err = json.Unmarshal(resp.Body(), &result)
-	if err != nil {
-		logger.Error("Failed to parse response from API",
-			zap.Error(err),
-		)
-		return nil, err
-	}
+		resp, err := api.Client.R().
+			SetHeader("x-api-key", api.Token).
+			SetHeader("Content-Type", "application/json").
+			SetQueryParams(map[string]string{
+				"accountIdentifier": api.Account,
+				"orgIdentifier":     org,
+				"projectIdentifier": project,
+				"pageIndex":         strconv.Itoa(page),
+				"pageSize":          strconv.Itoa(PAGE_SIZE),
+			}).
+			Get(api.BaseURL + RESOURCEGROUP)
+		if err != nil {
+			logger.Error("Failed to request to list of resource groups",
+				zap.Error(err),
+			)
+			return listPage{}, err
+		}
+		if resp.IsError() {
+			logger.Error("Error response from API when listing resource groups",
+				zap.String("response",
+					resp.String(),
+				),
+			)
+			return listPage{}, handleErrorResponse(resp)
+		}
 
-	resourceGroups := []*model.ResourceGroup{}
-	for _, c := range result.Data.Content {
-		if !c.HarnessManaged {
-			// Only add non-Harness managed Resoure Groups
-			newResourceGroup := c.ResourceGroup
-			resourceGroups = append(resourceGroups, &newResourceGroup)
+		result := model.GetResourceGroupResponse{}
+		err = json.Unmarshal(resp.Body(), &result)
+		if err != nil {
+			logger.Error("Failed to parse response from API",
+				zap.Error(err),
+			)
+			return listPage{}, err
 		}
+
+		for _, c := range result.Data.Content {
+			if !c.HarnessManaged {
+				// Only add non-Harness managed Resoure Groups
+				newResourceGroup := c.ResourceGroup
+				resourceGroups = append(resourceGroups, &newResourceGroup)
+			}
+		}
+
+		return listPage{
+			index: int(result.Data.PageIndex),
+			total: int(result.Data.TotalPages),
+			items: len(result.Data.Content),
+		}, nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return resourceGroups, nil
@@ -153,7 +210,20 @@ func (api *ApiRequest) createResourceGroup(rg *model.NewResourceGroupContent, lo
 		zap.String("project", rg.ResourceGroup.ProjectIdentifier),
 	)
 
-	IncrementApiCalls()
+	if api.Bundle != nil {
+		return api.Bundle.Add(model.BundleEntry{
+			Entity:      "Resource Group",
+			Identifier:  rg.ResourceGroup.Identifier,
+			Path:        RESOURCEGROUP,
+			ContentType: "application/json",
+			Params: map[string]string{
+				"orgIdentifier":     rg.ResourceGroup.OrgIdentifier,
+				"projectIdentifier": rg.ResourceGroup.ProjectIdentifier,
+			},
+		}, rg, nil)
+	}
+
+	api.Stats.IncrementApiCalls()
 
 	resp, err := api.Client.R().
 		SetHeader("x-api-key", api.Token).
@@ -177,11 +247,11 @@ func (api *ApiRequest) createResourceGroup(rg *model.NewResourceGroupContent, lo
 		var errorResponse map[string]interface{}
 		if err := json.Unmarshal(resp.Body(), &errorResponse); err == nil {
 			if code, ok := errorResponse["code"].(string); ok && code == "DUPLICATE_FIELD" {
-				// Log as a warning and skip the error
-				logger.Info("Duplicate resource group found, ignoring error",
+				// The conflict strategy decides what happens to the existing entity
+				logger.Info("Duplicate resource group found",
 					zap.String("resource group", rg.ResourceGroup.Name),
 				)
-				return nil
+				return ErrAlreadyExists
 			}
 		} else {
 			logger.Error(
@@ -195,5 +265,19 @@ func (api *ApiRequest) createResourceGroup(rg *model.NewResourceGroupContent, lo
 		return handleErrorResponse(resp)
 	}
 
+	api.recordCreated(model.JournalEntry{
+		Entity:     "Resource Group",
+		Identifier: rg.ResourceGroup.Identifier,
+		Org:        rg.ResourceGroup.OrgIdentifier,
+		Project:    rg.ResourceGroup.ProjectIdentifier,
+	}, logger)
+
 	return nil
 }
+
+func (api *ApiRequest) updateResourceGroup(rg *model.NewResourceGroupContent, logger *zap.Logger) error {
+	return api.updateEntity(RESOURCEGROUP+"/"+rg.ResourceGroup.Identifier, "application/json", rg, map[string]string{
+		"orgIdentifier":     rg.ResourceGroup.OrgIdentifier,
+		"projectIdentifier": rg.ResourceGroup.ProjectIdentifier,
+	}, logger)
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"encoding/json"
+	"strconv"
 
 	"github.com/schollz/progressbar/v3"
 	"go.uber.org/zap"
@@ -38,7 +39,7 @@ func (c RoleAssignmentContext) Copy() error {
 		zap.String("project", c.sourceProject),
 	)
 
-	roleAssignments, err := c.api.listRoleAssignments(c.sourceOrg, c.sourceProject, c.logger)
+	roleAssignments, err := c.api.source().listRoleAssignments(c.sourceOrg, c.sourceProject, c.logger)
 	if err != nil {
 		c.logger.Error("Failed to retrive role assignments",
 			zap.String("Project", c.sourceProject),
@@ -47,6 +48,21 @@ func (c RoleAssignmentContext) Copy() error {
 		return err
 	}
 
+	existing := map[string]bool{}
+	if c.api.Plan != nil {
+		targetRoleAssignments, err := c.api.listRoleAssignments(c.targetOrg, c.targetProject, c.logger)
+		if err != nil {
+			c.logger.Error("Failed to list the role assignments of the target project",
+				zap.String("Project", c.targetProject),
+				zap.Error(err),
+			)
+			return err
+		}
+		for _, r := range targetRoleAssignments {
+			existing[r.Identifier] = true
+		}
+	}
+
 	var bar *progressbar.ProgressBar
 
 	if c.showPB {
@@ -55,23 +71,51 @@ func (c RoleAssignmentContext) Copy() error {
 
 	for _, r := range roleAssignments {
 
-		IncrementRoleAssignmentsTotal()
+		c.api.Stats.IncrementRoleAssignmentsTotal()
+
+		if c.api.Checkpoint.Done("Role Assignment", r.Identifier) {
+			c.logger.Info("Skipping role assignment copied by a previous run",
+				zap.String("identifier", r.Identifier),
+			)
+			c.api.Stats.IncrementRoleAssignmentsMoved()
+			if c.showPB {
+				bar.Add(1)
+			}
+			continue
+		}
 
 		c.logger.Info("Processing role assignment",
 			zap.String("role assignment", r.RoleIdentifier),
 			zap.String("targetProject", c.targetProject),
 		)
 
+		if c.api.Plan != nil {
+			c.api.Plan.Record("Role Assignment", r.Identifier, r.RoleIdentifier, existing[c.api.Rename.Identifier("Role Assignment", r.Identifier)], nil)
+			if c.showPB {
+				bar.Add(1)
+			}
+			continue
+		}
+
 		role := &model.NewRoleAssignment{
-			Identifier:              r.Identifier,
-			ResourceGroupIdentifier: r.ResourceGroupIdentifier,
-			RoleIdentifier:          r.RoleIdentifier,
+			Identifier:              c.api.Rename.Identifier("Role Assignment", r.Identifier),
+			ResourceGroupIdentifier: c.api.Rename.Identifier("Resource Group", r.ResourceGroupIdentifier),
+			RoleIdentifier:          c.api.Rename.Identifier("Role", r.RoleIdentifier),
 			Principal:               r.Principal,
 			OrgIdentifier:           c.targetOrg,
 			ProjectIdentifier:       c.targetProject,
 		}
 
+		// USER GROUPS AND SERVICE ACCOUNTS OF THE PROJECT ARE COPIED AND MAY BE RENAMED
+		switch role.Principal.Type {
+		case "USER_GROUP":
+			role.Principal.Identifier = c.api.Rename.Identifier("User Group", role.Principal.Identifier)
+		case "SERVICE_ACCOUNT":
+			role.Principal.Identifier = c.api.Rename.Identifier("Service Account", role.Principal.Identifier)
+		}
+
 		err = c.api.createRoleAssignment(role, c.logger)
+		err = c.api.resolveConflict("Role Assignment", r.Identifier, err, keepExisting, c.logger)
 
 		if err != nil {
 			c.logger.Error("Failed to create role assignment",
@@ -79,7 +123,10 @@ func (c RoleAssignmentContext) Copy() error {
 				zap.Error(err),
 			)
 		} else {
-			IncrementRoleAssignmentsMoved()
+			c.api.Stats.IncrementRoleAssignmentsMoved()
+			if err := c.api.Checkpoint.Record("Role Assignment", r.Identifier); err != nil {
+				c.logger.Warn("Failed to update checkpoint", zap.Error(err))
+			}
 		}
 		if c.showPB {
 			bar.Add(1)
@@ -99,48 +146,61 @@ func (api *ApiRequest) listRoleAssignments(org, project string, logger *zap.Logg
 		zap.String("project", project),
 	)
 
-	IncrementApiCalls()
+	roleAssignments := []*model.ExistingRoleAssignment{}
 
-	resp, err := api.Client.R().
-		SetHeader("x-api-key", api.Token).
-		SetHeader("Content-Type", "application/json").
-		SetQueryParams(map[string]string{
-			"accountIdentifier": api.Account,
-			"orgIdentifier":     org,
-			"projectIdentifier": project,
-			"pageSize":          "100",
-		}).
-		Get(api.BaseURL + ROLEASSIGNMENT)
-	if err != nil {
-		logger.Error("Failed to request to list of role assignments",
-			zap.Error(err),
-		)
-		return nil, err
-	}
-	if resp.IsError() {
-		logger.Error("Error response from API when listing role assignments",
-			zap.String("response",
-				resp.String(),
-			),
-		)
-		return nil, handleErrorResponse(resp)
-	}
+	err := api.paginate(PAGE_SIZE, func(page int) (listPage, error) {
+		api.Stats.IncrementApiCalls()
+
+		resp, err := api.Client.R().
+			SetHeader("x-api-key", api.Token).
+			SetHeader("Content-Type", "application/json").
+			SetQueryParams(map[string]string{
+				"accountIdentifier": api.Account,
+				"orgIdentifier":     org,
+				"projectIdentifier": project,
+				"pageIndex":         strconv.Itoa(page),
+				"pageSize":          strconv.Itoa(PAGE_SIZE),
+			}).
+			Get(api.BaseURL + ROLEASSIGNMENT)
+		if err != nil {
+			logger.Error("Failed to request to list of role assignments",
+				zap.Error(err),
+			)
+			return listPage{}, err
+		}
+		if resp.IsError() {
+			logger.Error("Error response from API when listing role assignments",
+				zap.String("response",
+					resp.String(),
+				),
+			)
+			return listPage{}, handleErrorResponse(resp)
+		}
+
+		result := model.GetRoleAssignmentResponse{}
+		err = json.Unmarshal(resp.Body(), &result)
+		if err != nil {
+			logger.Error("Failed to parse response from API",
+				zap.Error(err),
+			)
+			return listPage{}, err
+		}
 
-	result := model.GetRoleAssignmentResponse{}
-	err = json.Unmarshal(resp.Body(), &result)
+		for _, c := range result.Data.Content {
+			newRoleAssignment := c.RoleAssignment
+			roleAssignments = append(roleAssignments, &newRoleAssignment)
+		}
+
+		return listPage{
+			index: int(result.Data.PageIndex),
+			total: int(result.Data.TotalPages),
+			items: len(result.Data.Content),
+		}, nil
+	})
 	if err != nil {
-		logger.Error("Failed to parse response from API",
-			zap.Error(err),
-		)
 		return nil, err
 	}
 
-	roleAssignments := []*model.ExistingRoleAssignment{}
-	for _, c := range result.Data.Content {
-		newRoleAssignment := c.RoleAssignment
-		roleAssignments = append(roleAssignments, &newRoleAssignment)
-	}
-
 	return roleAssignments, nil
 }
 
@@ -151,7 +211,20 @@ func (api *ApiRequest) createRoleAssignment(role *model.NewRoleAssignment, logge
 		zap.String("project", role.ProjectIdentifier),
 	)
 
-	IncrementApiCalls()
+	if api.Bundle != nil {
+		return api.Bundle.Add(model.BundleEntry{
+			Entity:      "Role Assignment",
+			Identifier:  role.Identifier,
+			Path:        ROLEASSIGNMENT,
+			ContentType: "application/json",
+			Params: map[string]string{
+				"orgIdentifier":     role.OrgIdentifier,
+				"projectIdentifier": role.ProjectIdentifier,
+			},
+		}, role, nil)
+	}
+
+	api.Stats.IncrementApiCalls()
 
 	resp, err := api.Client.R().
 		SetHeader("x-api-key", api.Token).
@@ -175,11 +248,11 @@ func (api *ApiRequest) createRoleAssignment(role *model.NewRoleAssignment, logge
 		var errorResponse map[string]interface{}
 		if err := json.Unmarshal(resp.Body(), &errorResponse); err == nil {
 			if code, ok := errorResponse["code"].(string); ok && code == "DUPLICATE_FIELD" {
-				// Log as a warning and skip the error
-				logger.Info("Duplicate role assignment found, ignoring error",
+				// The conflict strategy decides what happens to the existing entity
+				logger.Info("Duplicate role assignment found",
 					zap.String("role assignment", role.Identifier),
 				)
-				return nil
+				return ErrAlreadyExists
 			}
 		} else {
 			logger.Error(
@@ -193,5 +266,12 @@ func (api *ApiRequest) createRoleAssignment(role *model.NewRoleAssignment, logge
 		return handleErrorResponse(resp)
 	}
 
+	api.recordCreated(model.JournalEntry{
+		Entity:     "Role Assignment",
+		Identifier: role.Identifier,
+		Org:        role.OrgIdentifier,
+		Project:    role.ProjectIdentifier,
+	}, logger)
+
 	return nil
 }
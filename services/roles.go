@@ -2,6 +2,7 @@ package services
 
 import (
 	"encoding/json"
+	"strconv"
 
 	"github.com/schollz/progressbar/v3"
 	"go.uber.org/zap"
@@ -38,7 +39,7 @@ func (c RoleContext) Copy() error {
 		zap.String("project", c.sourceProject),
 	)
 
-	roles, err := c.api.listRoles(c.sourceOrg, c.sourceProject, c.logger)
+	roles, err := c.api.source().listRoles(c.sourceOrg, c.sourceProject, c.logger)
 	if err != nil {
 		c.logger.Error("Failed to retrive roles",
 			zap.String("Project", c.sourceProject),
@@ -47,6 +48,21 @@ func (c RoleContext) Copy() error {
 		return err
 	}
 
+	existing := map[string]bool{}
+	if c.api.Plan != nil {
+		targetRoles, err := c.api.listRoles(c.targetOrg, c.targetProject, c.logger)
+		if err != nil {
+			c.logger.Error("Failed to list the roles of the target project",
+				zap.String("Project", c.targetProject),
+				zap.Error(err),
+			)
+			return err
+		}
+		for _, r := range targetRoles {
+			existing[r.Identifier] = true
+		}
+	}
+
 	var bar *progressbar.ProgressBar
 
 	if c.showPB {
@@ -55,16 +71,35 @@ func (c RoleContext) Copy() error {
 
 	for _, r := range roles {
 
-		IncrementRolesTotal()
+		c.api.Stats.IncrementRolesTotal()
+
+		if c.api.Checkpoint.Done("Role", r.Identifier) {
+			c.logger.Info("Skipping role copied by a previous run",
+				zap.String("identifier", r.Identifier),
+			)
+			c.api.Stats.IncrementRolesMoved()
+			if c.showPB {
+				bar.Add(1)
+			}
+			continue
+		}
 
 		c.logger.Info("Processing role",
 			zap.String("role", r.Name),
 			zap.String("targetProject", c.targetProject),
 		)
 
+		if c.api.Plan != nil {
+			c.api.Plan.Record("Role", r.Identifier, r.Name, existing[c.api.Rename.Identifier("Role", r.Identifier)], nil)
+			if c.showPB {
+				bar.Add(1)
+			}
+			continue
+		}
+
 		role := &model.NewRole{
-			Identifier:         r.Identifier,
-			Name:               r.Name,
+			Identifier:         c.api.Rename.Identifier("Role", r.Identifier),
+			Name:               c.api.Rename.Name("Role", r.Name),
 			Description:        r.Description,
 			Tags:               r.Tags,
 			Permissions:        r.Permissions,
@@ -74,6 +109,9 @@ func (c RoleContext) Copy() error {
 		}
 
 		err = c.api.createRole(role, c.logger)
+		err = c.api.resolveConflict("Role", r.Identifier, err, func() error {
+			return c.api.updateRole(role, c.logger)
+		}, c.logger)
 
 		if err != nil {
 			c.logger.Error("Failed to create role",
@@ -81,7 +119,10 @@ func (c RoleContext) Copy() error {
 				zap.Error(err),
 			)
 		} else {
-			IncrementRolesMoved()
+			c.api.Stats.IncrementRolesMoved()
+			if err := c.api.Checkpoint.Record("Role", r.Identifier); err != nil {
+				c.logger.Warn("Failed to update checkpoint", zap.Error(err))
+			}
 		}
 		if c.showPB {
 			bar.Add(1)
@@ -101,54 +142,67 @@ func (api *ApiRequest) listRoles(org, project string, logger *zap.Logger) ([]*mo
 		zap.String("project", project),
 	)
 
-	IncrementApiCalls()
+	roles := []*model.ExistingRoles{}
 
-	resp, err := api.Client.R().
-		SetHeader("x-api-key", api.Token).
-		SetHeader("Content-Type", "application/json").
-		SetQueryParams(map[string]string{
-			"accountIdentifier": api.Account,
-			"orgIdentifier":     org,
-			"projectIdentifier": project,
-			"limit":             "100",
-		}).
-		Get(api.BaseURL + ROLE)
-	if err != nil {
-		logger.Error("Failed to request to list of roles",
-			zap.Error(err),
-		)
-		return nil, err
-	}
-	if resp.IsError() {
-		logger.Error("Error response from API when listing roles",
-			zap.String("response",
-				resp.String(),
-			),
-		)
-		return nil, handleErrorResponse(resp)
-	}
+	err := api.paginate(PAGE_SIZE, func(page int) (listPage, error) {
+		api.Stats.IncrementApiCalls()
 
-	result := model.GetRolesResponse{}
-	err = json.Unmarshal(resp.Body(), &result)
-	if err != nil {
-		logger.Error("Failed to parse response from API",
-			zap.Error(err),
-		)
-		return nil, err
-	}
+		resp, err := api.Client.R().
+			SetHeader("x-api-key", api.Token).
+			SetHeader("Content-Type", "application/json").
+			SetQueryParams(map[string]string{
+				"accountIdentifier": api.Account,
+				"orgIdentifier":     org,
+				"projectIdentifier": project,
+				"pageIndex":         strconv.Itoa(page),
+				"pageSize":          strconv.Itoa(PAGE_SIZE),
+			}).
+			Get(api.BaseURL + ROLE)
+		if err != nil {
+			logger.Error("Failed to request to list of roles",
+				zap.Error(err),
+			)
+			return listPage{}, err
+		}
+		if resp.IsError() {
+			logger.Error("Error response from API when listing roles",
+				zap.String("response",
+					resp.String(),
+				),
+			)
+			return listPage{}, handleErrorResponse(resp)
+		}
 
-	roles := []*model.ExistingRoles{}
-	for _, c := range result.Data.Content {
-		if !c.HarnessManaged {
-			// Only add non-Harness managed roles
-			newRole := c.Role
-			roles = append(roles, &newRole)
-		} else {
-			logger.Warn("Skipping role because it is harness managed",
-				zap.String("role", c.Role.Name),
-				zap.Bool("harnessManaged", c.HarnessManaged),
+		result := model.GetRolesResponse{}
+		err = json.Unmarshal(resp.Body(), &result)
+		if err != nil {
+			logger.Error("Failed to parse response from API",
+				zap.Error(err),
 			)
+			return listPage{}, err
+		}
+
+		for _, c := range result.Data.Content {
+			if !c.HarnessManaged {
+				// Only add non-Harness managed roles
+				newRole := c.Role
+				roles = append(roles, &newRole)
+			} else {
+				logger.Warn("Skipping role because it is harness managed",
+					zap.String("role", c.Role.Name),
+					zap.Bool("harnessManaged", c.HarnessManaged),
+				)
+			}
 		}
+
+		return listPage{
+			index: int(result.Data.PageIndex),
+			total: int(result.Data.TotalPages),
+			items: len(result.Data.Content),
+		}, nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return roles, nil
@@ -161,7 +215,20 @@ func (api *ApiRequest) createRole(role *model.NewRole, logger *zap.Logger) error
 		zap.String("project", role.ProjectIdentifier),
 	)
 
-	IncrementApiCalls()
+	if api.Bundle != nil {
+		return api.Bundle.Add(model.BundleEntry{
+			Entity:      "Role",
+			Identifier:  role.Identifier,
+			Path:        ROLE,
+			ContentType: "application/json",
+			Params: map[string]string{
+				"orgIdentifier":     role.OrgIdentifier,
+				"projectIdentifier": role.ProjectIdentifier,
+			},
+		}, role, nil)
+	}
+
+	api.Stats.IncrementApiCalls()
 
 	resp, err := api.Client.R().
 		SetHeader("x-api-key", api.Token).
@@ -185,11 +252,11 @@ func (api *ApiRequest) createRole(role *model.NewRole, logger *zap.Logger) error
 		var errorResponse map[string]interface{}
 		if err := json.Unmarshal(resp.Body(), &errorResponse); err == nil {
 			if code, ok := errorResponse["code"].(string); ok && code == "DUPLICATE_FIELD" {
-				// Log as a warning and skip the error
-				logger.Info("Duplicate role found, ignoring error",
+				// The conflict strategy decides what happens to the existing entity
+				logger.Info("Duplicate role found",
 					zap.String("role", role.Name),
 				)
-				return nil
+				return ErrAlreadyExists
 			}
 		} else {
 			logger.Error(
@@ -203,5 +270,19 @@ func (api *ApiRequest) createRole(role *model.NewRole, logger *zap.Logger) error
 		return handleErrorResponse(resp)
 	}
 
+	api.recordCreated(model.JournalEntry{
+		Entity:     "Role",
+		Identifier: role.Identifier,
+		Org:        role.OrgIdentifier,
+		Project:    role.ProjectIdentifier,
+	}, logger)
+
 	return nil
 }
+
+func (api *ApiRequest) updateRole(role *model.NewRole, logger *zap.Logger) error {
+	return api.updateEntity(ROLE+"/"+role.Identifier, "application/json", role, map[string]string{
+		"orgIdentifier":     role.OrgIdentifier,
+		"projectIdentifier": role.ProjectIdentifier,
+	}, logger)
+}
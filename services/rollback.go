@@ -0,0 +1,220 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+	"harness-copy-project/model"
+)
+
+// errRollbackUnsupported is returned for the entities the API has no delete endpoint for
+var errRollbackUnsupported = errors.New("can not be deleted through the API, delete it manually")
+
+const (
+	RollbackDeleted     = "deleted"
+	RollbackFailed      = "failed"
+	RollbackUnsupported = "unsupported"
+)
+
+// Rollback deletes the entities the journal records as created in the target project,
+// in the reverse order they were created so every entity is deleted before the entities
+// it depends on. Nothing that is not in the journal is ever deleted. Entities that can
+// not be deleted through the API are reported as unsupported.
+func (api *ApiRequest) Rollback(org, project string, logger *zap.Logger) ([]model.RollbackEntry, error) {
+
+	logger.Info("Rolling back project",
+		zap.String("org", org),
+		zap.String("project", project),
+	)
+
+	if api.Journal == nil {
+		return nil, fmt.Errorf("no journal to roll back from")
+	}
+
+	created, err := api.Journal.Created(api.Account, org, project)
+	if err != nil {
+		return nil, err
+	}
+
+	report := []model.RollbackEntry{}
+
+	for i := len(created) - 1; i >= 0; i-- {
+		entry := created[i]
+
+		result := model.RollbackEntry{
+			Entity:     entry.Entity,
+			Identifier: journalName(entry),
+			Status:     RollbackDeleted,
+		}
+
+		err := api.deleteCreated(entry, logger)
+		if errors.Is(err, errRollbackUnsupported) {
+			result.Status = RollbackUnsupported
+			result.Reason = err.Error()
+		} else if err != nil {
+			logger.Error("Failed to delete entity",
+				zap.String("entity", entry.Entity),
+				zap.String("identifier", result.Identifier),
+				zap.Error(err),
+			)
+			result.Status = RollbackFailed
+			result.Reason = err.Error()
+		} else {
+			entry.Action = JOURNAL_DELETE
+			entry.Time = 0
+			if err := api.Journal.Record(entry); err != nil {
+				logger.Warn("Failed to update journal", zap.Error(err))
+			}
+		}
+
+		report = append(report, result)
+	}
+
+	return report, nil
+}
+
+// journalName is the identifier of an entity with the scope it was created in
+func journalName(entry model.JournalEntry) string {
+	parts := []string{}
+	for _, part := range []string{entry.Environment, entry.Pipeline, entry.Identifier, entry.Version} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// deleteCreated deletes a single journaled entity. An entity that is already gone is
+// not an error.
+func (api *ApiRequest) deleteCreated(entry model.JournalEntry, logger *zap.Logger) error {
+
+	scope := map[string]string{
+		"orgIdentifier":     entry.Org,
+		"projectIdentifier": entry.Project,
+	}
+
+	var path string
+	switch entry.Entity {
+	case "Project":
+		path = NEW_PROJECT + "/" + entry.Identifier
+		delete(scope, "projectIdentifier")
+	case "Connector":
+		path = CONNECTORCREATE + "/" + entry.Identifier
+	case "Environment":
+		path = "/ng/api/environmentsV2/" + entry.Identifier
+	case "Environment Group":
+		path = ENVGROUP + "/" + entry.Identifier
+	case "Feature Flag":
+		path = FEATFLAGS + "/" + entry.Identifier
+	case "File Store":
+		path = "/ng/api/file-store/" + entry.Identifier
+	case "Infrastructure":
+		path = INFRASTRUCTURE + "/" + entry.Identifier
+		scope["environmentIdentifier"] = entry.Environment
+	case "Input Set":
+		path = "/pipeline/api/inputSets/" + entry.Identifier
+		scope["pipelineIdentifier"] = entry.Pipeline
+	case "Pipeline":
+		path = "/pipeline/api/pipelines/" + entry.Identifier
+	case "Resource Group":
+		path = RESOURCEGROUP + "/" + entry.Identifier
+	case "Role Assignment":
+		path = ROLEASSIGNMENT + "/" + entry.Identifier
+	case "Role":
+		path = ROLE + "/" + entry.Identifier
+	case "Secret":
+		path = SECRETS + "/" + entry.Identifier
+	case "Service":
+		path = CREATE_SERVICES + "/" + entry.Identifier
+	case "Service Account":
+		path = SERVICEACCOUNTS + "/" + entry.Identifier
+	case "Service Override":
+		path = "/ng/api/environmentsV2/serviceOverrides"
+		scope["environmentIdentifier"] = entry.Environment
+		scope["serviceIdentifier"] = entry.Identifier
+	case "Target Group":
+		path = TARGETGROUPS + "/" + entry.Identifier
+		scope["environmentIdentifier"] = entry.Environment
+	case "Target":
+		path = TARGETS + "/" + entry.Identifier
+		scope["environmentIdentifier"] = entry.Environment
+	case "Template":
+		path = CREATE_TEMPLATE_ENDPOINT + "/" + entry.Identifier + "/" + entry.Version
+	case "Trigger":
+		path = TRIGGER + "/" + entry.Identifier
+		scope["targetIdentifier"] = entry.Pipeline
+	case "User Group":
+		path = USERGROUP + "/" + entry.Identifier
+	case "Variable":
+		path = "/ng/api/variables/" + entry.Identifier
+	case "User":
+		return api.deleteCreatedUser(entry, logger)
+	default:
+		return errRollbackUnsupported
+	}
+
+	return api.deleteEntity(path, scope, logger)
+}
+
+// deleteCreatedUser removes a user added to the project, users are journaled by email
+func (api *ApiRequest) deleteCreatedUser(entry model.JournalEntry, logger *zap.Logger) error {
+	users, err := api.listUsers(entry.Org, entry.Project, logger)
+	if err != nil {
+		return err
+	}
+	for _, u := range users {
+		if strings.EqualFold(u.Email, entry.Identifier) {
+			return api.RemoveCurrentUserAccess(*u, entry.Org, entry.Project, logger)
+		}
+	}
+	return nil
+}
+
+func (api *ApiRequest) deleteEntity(path string, params map[string]string, logger *zap.Logger) error {
+
+	logger.Info("Deleting entity",
+		zap.String("path", path),
+	)
+
+	api.Stats.IncrementApiCalls()
+
+	query := map[string]string{
+		"accountIdentifier": api.Account,
+	}
+	for k, v := range params {
+		query[k] = v
+	}
+
+	resp, err := api.Client.R().
+		SetHeader("x-api-key", api.Token).
+		SetHeader("Content-Type", "application/json").
+		SetQueryParams(query).
+		Delete(api.BaseURL + path)
+	if err != nil {
+		logger.Error("Failed to send request to delete ",
+			zap.String("path", path),
+			zap.Error(err),
+		)
+		return err
+	}
+	if resp.StatusCode() == http.StatusNotFound {
+		logger.Info("Entity already deleted",
+			zap.String("path", path),
+		)
+		return nil
+	}
+	if resp.IsError() {
+		logger.Error("Error response from API when deleting ",
+			zap.String("path", path),
+			zap.String("response",
+				resp.String(),
+			),
+		)
+		return handleErrorResponse(resp)
+	}
+
+	return nil
+}
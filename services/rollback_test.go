@@ -0,0 +1,73 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"harness-copy-project/model"
+)
+
+// deletedOf lists the entities deleted, in the order they were deleted
+func deletedOf(fake *fakeHarness) []string {
+	deleted := []string{}
+	for _, r := range fake.sent(http.MethodDelete, "") {
+		deleted = append(deleted, r.Path+"?"+r.Query.Get("pipelineIdentifier"))
+	}
+	return deleted
+}
+
+func TestRollback_DeletesOnlyJournaledEntitiesInReverseOrder(t *testing.T) {
+	// EVERY ENTITY IS CREATED EXCEPT THE CONNECTOR 'existing'
+	fake := newFakeHarness(t)
+	fake.handle("POST "+CONNECTORCREATE, func(w http.ResponseWriter, r *http.Request) {
+		connector := model.ConnectorContent{}
+		if json.NewDecoder(r.Body).Decode(&connector) == nil && connector.Connector.Identifier == "existing" {
+			writeError(w, http.StatusBadRequest, "DUPLICATE_FIELD", "already exists")
+			return
+		}
+		w.Write([]byte(`{"status":"SUCCESS"}`))
+	})
+
+	logger := zap.NewNop()
+	api := fake.api()
+	api.Journal = OpenJournal(filepath.Join(t.TempDir(), "journal.jsonl"))
+
+	connector := func(identifier string) *model.ConnectorContent {
+		return &model.ConnectorContent{
+			Connector: model.Connector{
+				Identifier:        identifier,
+				OrgIdentifier:     "org",
+				ProjectIdentifier: "dst",
+			},
+		}
+	}
+
+	assert.NoError(t, api.addConnector(connector("git"), logger))
+	assert.ErrorIs(t, api.addConnector(connector("existing"), logger), ErrAlreadyExists)
+	assert.NoError(t, api.createPipeline("org", "dst", "pipeline:\n  identifier: build\n", logger))
+	assert.NoError(t, api.createInputset("org", "dst", "build", "inputSet:\n  identifier: prod\n", logger))
+	// ANOTHER PROJECT OF THE SAME JOURNAL IS NOT ROLLED BACK
+	assert.NoError(t, api.createPipeline("org", "other", "pipeline:\n  identifier: deploy\n", logger))
+
+	report, err := api.Rollback("org", "dst", logger)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"/pipeline/api/inputSets/prod?build",
+		"/pipeline/api/pipelines/build?",
+		CONNECTORCREATE + "/git?",
+	}, deletedOf(fake))
+	assert.Len(t, report, 3)
+	for _, entry := range report {
+		assert.Equal(t, RollbackDeleted, entry.Status)
+	}
+
+	// A SECOND ROLLBACK HAS NOTHING LEFT TO DELETE
+	report, err = api.Rollback("org", "dst", logger)
+	assert.NoError(t, err)
+	assert.Empty(t, report)
+	assert.Len(t, deletedOf(fake), 3)
+}
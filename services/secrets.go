@@ -0,0 +1,574 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/schollz/progressbar/v3"
+	"go.uber.org/zap"
+	"harness-copy-project/model"
+)
+
+const SECRETS = "/ng/api/v2/secrets"
+const SECRETFILES = "/ng/api/v2/secrets/files"
+
+// Value used for secrets when the API does not return the value and none was supplied
+const SECRET_PLACEHOLDER = "harness-copy-project-placeholder"
+
+// Identifier of the built-in secret manager that exists at every scope
+const HARNESS_SECRET_MANAGER = "harnessSecretManager"
+
+type SecretContext struct {
+	api             *ApiRequest
+	sourceOrg       string
+	sourceProject   string
+	targetOrg       string
+	targetProject   string
+	values          map[string]string
+	projectManagers bool
+	logger          *zap.Logger
+	showPB          bool
+}
+
+// Copies the secrets stored in the built-in, org or account secret managers. These
+// must exist before the connectors are copied so their secret refs resolve.
+func NewSecretOperation(api *ApiRequest, sourceOrg, sourceProject, targetOrg, targetProject string, values map[string]string, logger *zap.Logger, showPB bool) SecretContext {
+	return SecretContext{
+		api:             api,
+		sourceOrg:       sourceOrg,
+		sourceProject:   sourceProject,
+		targetOrg:       targetOrg,
+		targetProject:   targetProject,
+		values:          values,
+		projectManagers: false,
+		logger:          logger,
+		showPB:          showPB,
+	}
+}
+
+// Copies the secrets stored in a secret manager connector of the project itself.
+// Must run after the connectors have been copied.
+func NewProjectManagerSecretOperation(api *ApiRequest, sourceOrg, sourceProject, targetOrg, targetProject string, values map[string]string, logger *zap.Logger, showPB bool) SecretContext {
+	return SecretContext{
+		api:             api,
+		sourceOrg:       sourceOrg,
+		sourceProject:   sourceProject,
+		targetOrg:       targetOrg,
+		targetProject:   targetProject,
+		values:          values,
+		projectManagers: true,
+		logger:          logger,
+		showPB:          showPB,
+	}
+}
+
+func (c SecretContext) Copy() error {
+
+	c.logger.Info("Copying secrets",
+		zap.String("project", c.sourceProject),
+		zap.Bool("projectSecretManagers", c.projectManagers),
+	)
+
+	allSecrets, err := c.api.source().listSecrets(c.sourceOrg, c.sourceProject, c.logger)
+	if err != nil {
+		c.logger.Error("Failed to retrive secrets",
+			zap.String("Project", c.sourceProject),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	secrets := []*model.Secret{}
+	for _, s := range allSecrets {
+		if isProjectSecretManager(s) == c.projectManagers {
+			secrets = append(secrets, s)
+		}
+	}
+
+	// SSH AND WINRM CREDENTIALS REFERENCE OTHER SECRETS SO THEY ARE CREATED LAST
+	sort.SliceStable(secrets, func(i, j int) bool {
+		return secretOrder(secrets[i].Type) < secretOrder(secrets[j].Type)
+	})
+
+	existing := map[string]bool{}
+	if c.api.Plan != nil {
+		targetSecrets, err := c.api.listSecrets(c.targetOrg, c.targetProject, c.logger)
+		if err != nil {
+			c.logger.Error("Failed to list the secrets of the target project",
+				zap.String("Project", c.targetProject),
+				zap.Error(err),
+			)
+			return err
+		}
+		for _, s := range targetSecrets {
+			existing[s.Identifier] = true
+		}
+	}
+
+	var bar *progressbar.ProgressBar
+
+	if c.showPB {
+		bar = progressbar.Default(int64(len(secrets)), "Secrets    ")
+	}
+
+	for _, s := range secrets {
+
+		c.api.Stats.IncrementSecretsTotal()
+
+		if c.api.Checkpoint.Done("Secret", s.Identifier) {
+			c.logger.Info("Skipping secret copied by a previous run",
+				zap.String("identifier", s.Identifier),
+			)
+			c.api.Stats.IncrementSecretsMoved()
+			if c.showPB {
+				bar.Add(1)
+			}
+			continue
+		}
+
+		c.logger.Info("Processing secret",
+			zap.String("secret", s.Name),
+			zap.String("type", s.Type),
+			zap.String("targetProject", c.targetProject),
+		)
+
+		value, placeholder, err := c.secretValue(s)
+
+		if c.api.Plan != nil {
+			c.api.Plan.Record("Secret", s.Identifier, s.Name, existing[c.api.Rename.Identifier("Secret", s.Identifier)], err)
+			if c.showPB {
+				bar.Add(1)
+			}
+			continue
+		}
+
+		if c.api.blocked("Secret", s.Identifier, c.logger) {
+			if c.showPB {
+				bar.Add(1)
+			}
+			continue
+		}
+
+		identifier := s.Identifier
+		if err := c.api.Rename.Object("Secret", s); err != nil {
+			c.logger.Warn("Failed to rename secret", zap.Error(err))
+		}
+
+		s.OrgIdentifier = c.targetOrg
+		s.ProjectIdentifier = c.targetProject
+
+		if err == nil {
+			var overwrite func() error
+			overwrite, err = c.create(s, value)
+			// Never replace a real value in the target with a placeholder, the existing
+			// secret is kept as it is
+			kept := errors.Is(err, ErrAlreadyExists) && placeholder
+			if kept {
+				overwrite = keepExisting
+				placeholder = false
+			}
+			err = c.api.resolveConflict("Secret", identifier, err, overwrite, c.logger)
+			// A SECRET HAS NO MODIFIED TIME, SO A SYNC REPORTS THE ONE IT KEPT AS UNCHANGED
+			if kept && err == nil && !c.api.Sync.Since().IsZero() {
+				c.api.Stats.IncrementUnchanged()
+			}
+		}
+
+		if err != nil {
+			c.logger.Error("Failed to create secret",
+				zap.String("secret", s.Name),
+				zap.Error(err),
+			)
+			c.api.Graph.Failed("Secret", identifier)
+		} else {
+			c.api.Stats.IncrementSecretsMoved()
+			if err := c.api.Checkpoint.Record("Secret", identifier); err != nil {
+				c.logger.Warn("Failed to update checkpoint", zap.Error(err))
+			}
+			if placeholder {
+				c.api.Stats.AddSecretPendingValue(s.Identifier)
+				c.logger.Warn("Secret was created with a placeholder value and must be re-entered in the target project",
+					zap.String("secret", s.Identifier),
+					zap.String("targetProject", c.targetProject),
+				)
+			}
+		}
+		if c.showPB {
+			bar.Add(1)
+		}
+	}
+	if c.showPB {
+		bar.Finish()
+	}
+
+	return nil
+}
+
+// create creates a secret with its value and returns the function that replaces it when
+// it already exists
+func (c SecretContext) create(s *model.Secret, value string) (func() error, error) {
+	switch model.SecretType(s.Type) {
+	case model.SecretText:
+		s.Spec["value"] = value
+		return func() error {
+			return c.api.updateSecret(s, c.logger)
+		}, c.api.createSecret(s, c.logger)
+	case model.SecretFile:
+		return func() error {
+			return c.api.updateSecretFile(s, []byte(value), c.logger)
+		}, c.api.createSecretFile(s, []byte(value), c.logger)
+	default:
+		return func() error {
+			return c.api.updateSecret(s, c.logger)
+		}, c.api.createSecret(s, c.logger)
+	}
+}
+
+// secretValue resolves the value to create a secret with. Values are taken from the
+// source when the API returns them, then from the supplied values and otherwise a
+// placeholder is used. For file secrets the supplied value is the path of the file.
+func (c SecretContext) secretValue(s *model.Secret) (string, bool, error) {
+	switch model.SecretType(s.Type) {
+	case model.SecretText:
+		if valueType, _ := s.Spec["valueType"].(string); valueType == "Reference" {
+			if value, ok := s.Spec["value"].(string); ok && value != "" {
+				return value, false, nil
+			}
+		}
+		if value, ok := c.lookupValue(s.Identifier); ok {
+			return value, false, nil
+		}
+		return SECRET_PLACEHOLDER, true, nil
+	case model.SecretFile:
+		if path, ok := c.lookupValue(s.Identifier); ok {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return "", false, fmt.Errorf("error reading value file for secret %s: %v", s.Identifier, err)
+			}
+			return string(content), false, nil
+		}
+		return SECRET_PLACEHOLDER, true, nil
+	case model.SSHKey, model.WinRmCredentials:
+		return "", false, nil
+	default:
+		return "", false, fmt.Errorf("unsupported secret type %s", s.Type)
+	}
+}
+
+// Values can be scoped to a single source project with the 'project/identifier' key
+func (c SecretContext) lookupValue(identifier string) (string, bool) {
+	if value, ok := c.values[c.sourceProject+"/"+identifier]; ok {
+		return value, true
+	}
+	value, ok := c.values[identifier]
+	return value, ok
+}
+
+func isProjectSecretManager(s *model.Secret) bool {
+	manager, ok := s.Spec["secretManagerIdentifier"].(string)
+	if !ok || manager == "" || manager == HARNESS_SECRET_MANAGER {
+		return false
+	}
+	return !strings.HasPrefix(manager, "org.") && !strings.HasPrefix(manager, "account.")
+}
+
+func secretOrder(secretType string) int {
+	switch model.SecretType(secretType) {
+	case model.SecretText, model.SecretFile:
+		return 0
+	default:
+		return 1
+	}
+}
+
+func (api *ApiRequest) listSecrets(org, project string, logger *zap.Logger) ([]*model.Secret, error) {
+
+	logger.Info("Fetching secrets",
+		zap.String("org", org),
+		zap.String("project", project),
+	)
+
+	secrets := []*model.Secret{}
+
+	err := api.paginate(PAGE_SIZE, func(page int) (listPage, error) {
+		api.Stats.IncrementApiCalls()
+
+		resp, err := api.Client.R().
+			SetHeader("x-api-key", api.Token).
+			SetHeader("Content-Type", "application/json").
+			SetQueryParams(map[string]string{
+				"accountIdentifier": api.Account,
+				"orgIdentifier":     org,
+				"projectIdentifier": project,
+				"pageIndex":         strconv.Itoa(page),
+				"pageSize":          strconv.Itoa(PAGE_SIZE),
+			}).
+			Get(api.BaseURL + SECRETS)
+		if err != nil {
+			logger.Error("Failed to request to list of secrets",
+				zap.Error(err),
+			)
+			return listPage{}, err
+		}
+		if resp.IsError() {
+			logger.Error("Error response from API when listing secrets",
+				zap.String("response",
+					resp.String(),
+				),
+			)
+			return listPage{}, handleErrorResponse(resp)
+		}
+
+		result := model.SecretListResponse{}
+		err = json.Unmarshal(resp.Body(), &result)
+		if err != nil {
+			logger.Error("Failed to parse response from API",
+				zap.Error(err),
+			)
+			return listPage{}, err
+		}
+
+		for _, c := range result.Data.Content {
+			secret := c.Secret
+			if secret.Spec == nil {
+				secret.Spec = map[string]interface{}{}
+			}
+			secrets = append(secrets, &secret)
+		}
+
+		return listPage{
+			index: int(result.Data.PageIndex),
+			total: int(result.Data.TotalPages),
+			items: len(result.Data.Content),
+		}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return secrets, nil
+}
+
+func (api *ApiRequest) createSecret(secret *model.Secret, logger *zap.Logger) error {
+
+	logger.Info("Creating secret",
+		zap.String("secret", secret.Name),
+		zap.String("project", secret.ProjectIdentifier),
+	)
+
+	if api.Bundle != nil {
+		return api.Bundle.Add(model.BundleEntry{
+			Entity:      "Secret",
+			Identifier:  secret.Identifier,
+			Path:        SECRETS,
+			ContentType: "application/json",
+			Params: map[string]string{
+				"orgIdentifier":     secret.OrgIdentifier,
+				"projectIdentifier": secret.ProjectIdentifier,
+			},
+		}, &model.CreateSecretRequest{
+			Secret: secret,
+		}, nil)
+	}
+
+	api.Stats.IncrementApiCalls()
+
+	resp, err := api.Client.R().
+		SetHeader("x-api-key", api.Token).
+		SetHeader("Content-Type", "application/json").
+		SetBody(&model.CreateSecretRequest{
+			Secret: secret,
+		}).
+		SetQueryParams(map[string]string{
+			"accountIdentifier": api.Account,
+			"orgIdentifier":     secret.OrgIdentifier,
+			"projectIdentifier": secret.ProjectIdentifier,
+		}).
+		Post(api.BaseURL + SECRETS)
+	if err != nil {
+		logger.Error("Failed to send request to create ",
+			zap.String("secret", secret.Name),
+			zap.Error(err),
+		)
+		return err
+	}
+	if resp.IsError() {
+		var errorResponse map[string]interface{}
+		if err := json.Unmarshal(resp.Body(), &errorResponse); err == nil {
+			if code, ok := errorResponse["code"].(string); ok && code == "DUPLICATE_FIELD" {
+				// The conflict strategy decides what happens to the existing entity
+				logger.Info("Duplicate secret found",
+					zap.String("secret", secret.Name),
+				)
+				return ErrAlreadyExists
+			}
+		} else {
+			logger.Error(
+				"Error response from API when creating ",
+				zap.String("secret", secret.Name),
+				zap.String("response",
+					resp.String(),
+				),
+			)
+		}
+		return handleErrorResponse(resp)
+	}
+
+	api.recordCreated(model.JournalEntry{
+		Entity:     "Secret",
+		Identifier: secret.Identifier,
+		Org:        secret.OrgIdentifier,
+		Project:    secret.ProjectIdentifier,
+	}, logger)
+
+	return nil
+}
+
+func (api *ApiRequest) createSecretFile(secret *model.Secret, content []byte, logger *zap.Logger) error {
+
+	logger.Info("Creating secret file",
+		zap.String("secret", secret.Name),
+		zap.String("project", secret.ProjectIdentifier),
+	)
+
+	spec, err := json.Marshal(&model.CreateSecretRequest{
+		Secret: secret,
+	})
+	if err != nil {
+		return err
+	}
+
+	if api.Bundle != nil {
+		return api.Bundle.Add(model.BundleEntry{
+			Entity:      "Secret",
+			Identifier:  secret.Identifier,
+			Path:        SECRETFILES,
+			ContentType: "multipart/form-data",
+			Params: map[string]string{
+				"orgIdentifier":     secret.OrgIdentifier,
+				"projectIdentifier": secret.ProjectIdentifier,
+			},
+			Form: map[string]string{
+				"spec": string(spec),
+			},
+			ContentField: "file",
+		}, nil, content)
+	}
+
+	api.Stats.IncrementApiCalls()
+
+	resp, err := api.Client.R().
+		SetHeader("x-api-key", api.Token).
+		SetHeader("Content-Type", "multipart/form-data").
+		SetMultipartFormData(map[string]string{
+			"spec": string(spec),
+		}).
+		SetMultipartField("file", secret.Identifier, "application/octet-stream", bytes.NewReader(content)).
+		SetQueryParams(map[string]string{
+			"accountIdentifier": api.Account,
+			"orgIdentifier":     secret.OrgIdentifier,
+			"projectIdentifier": secret.ProjectIdentifier,
+		}).
+		Post(api.BaseURL + SECRETFILES)
+	if err != nil {
+		logger.Error("Failed to send request to create ",
+			zap.String("secret", secret.Name),
+			zap.Error(err),
+		)
+		return err
+	}
+	if resp.IsError() {
+		var errorResponse map[string]interface{}
+		if err := json.Unmarshal(resp.Body(), &errorResponse); err == nil {
+			if code, ok := errorResponse["code"].(string); ok && code == "DUPLICATE_FIELD" {
+				// The conflict strategy decides what happens to the existing entity
+				logger.Info("Duplicate secret file found",
+					zap.String("secret", secret.Name),
+				)
+				return ErrAlreadyExists
+			}
+		} else {
+			logger.Error(
+				"Error response from API when creating ",
+				zap.String("secret", secret.Name),
+				zap.String("response",
+					resp.String(),
+				),
+			)
+		}
+		return handleErrorResponse(resp)
+	}
+
+	api.recordCreated(model.JournalEntry{
+		Entity:     "Secret",
+		Identifier: secret.Identifier,
+		Org:        secret.OrgIdentifier,
+		Project:    secret.ProjectIdentifier,
+	}, logger)
+
+	return nil
+}
+
+func (api *ApiRequest) updateSecret(secret *model.Secret, logger *zap.Logger) error {
+	return api.updateEntity(SECRETS+"/"+secret.Identifier, "application/json", &model.CreateSecretRequest{
+		Secret: secret,
+	}, map[string]string{
+		"orgIdentifier":     secret.OrgIdentifier,
+		"projectIdentifier": secret.ProjectIdentifier,
+	}, logger)
+}
+
+func (api *ApiRequest) updateSecretFile(secret *model.Secret, content []byte, logger *zap.Logger) error {
+
+	logger.Info("Updating secret file",
+		zap.String("secret", secret.Name),
+		zap.String("project", secret.ProjectIdentifier),
+	)
+
+	api.Stats.IncrementApiCalls()
+
+	spec, err := json.Marshal(&model.CreateSecretRequest{
+		Secret: secret,
+	})
+	if err != nil {
+		return err
+	}
+
+	resp, err := api.Client.R().
+		SetHeader("x-api-key", api.Token).
+		SetHeader("Content-Type", "multipart/form-data").
+		SetMultipartFormData(map[string]string{
+			"spec": string(spec),
+		}).
+		SetMultipartField("file", secret.Identifier, "application/octet-stream", bytes.NewReader(content)).
+		SetQueryParams(map[string]string{
+			"accountIdentifier": api.Account,
+			"orgIdentifier":     secret.OrgIdentifier,
+			"projectIdentifier": secret.ProjectIdentifier,
+		}).
+		Put(api.BaseURL + SECRETFILES + "/" + secret.Identifier)
+	if err != nil {
+		logger.Error("Failed to send request to update ",
+			zap.String("secret", secret.Name),
+			zap.Error(err),
+		)
+		return err
+	}
+	if resp.IsError() {
+		logger.Error("Error response from API when updating ",
+			zap.String("secret", secret.Name),
+			zap.String("response",
+				resp.String(),
+			),
+		)
+		return handleErrorResponse(resp)
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"harness-copy-project/model"
+)
+
+func textSecret(identifier string) model.Secret {
+	return model.Secret{
+		Type:       string(model.SecretText),
+		Name:       identifier,
+		Identifier: identifier,
+		Spec:       map[string]interface{}{"secretManagerIdentifier": HARNESS_SECRET_MANAGER},
+	}
+}
+
+func TestSecretValue_Lookup(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "cert.pem")
+	assert.NoError(t, os.WriteFile(file, []byte("certificate"), 0600))
+
+	c := SecretContext{
+		sourceProject: "app",
+		values: map[string]string{
+			"app/token":   "project value",
+			"token":       "shared value",
+			"other/key":   "value of another project",
+			"cert":        file,
+			"missingFile": filepath.Join(t.TempDir(), "missing.pem"),
+		},
+	}
+
+	tests := []struct {
+		name        string
+		secret      model.Secret
+		value       string
+		placeholder bool
+		err         string
+	}{
+		{"ProjectKeyFirst", textSecret("token"), "project value", false, ""},
+		{"OtherProjectIgnored", textSecret("key"), SECRET_PLACEHOLDER, true, ""},
+		{"Reference", model.Secret{Type: string(model.SecretText), Identifier: "vault", Spec: map[string]interface{}{"valueType": "Reference", "value": "path/to/secret"}}, "path/to/secret", false, ""},
+		{"File", model.Secret{Type: string(model.SecretFile), Identifier: "cert"}, "certificate", false, ""},
+		{"FilePlaceholder", model.Secret{Type: string(model.SecretFile), Identifier: "key"}, SECRET_PLACEHOLDER, true, ""},
+		{"FileMissing", model.Secret{Type: string(model.SecretFile), Identifier: "missingFile"}, "", false, "error reading value file"},
+		{"SSHKey", model.Secret{Type: string(model.SSHKey), Identifier: "ssh"}, "", false, ""},
+		{"Unsupported", model.Secret{Type: "Unknown", Identifier: "unknown"}, "", false, "unsupported secret type"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, placeholder, err := c.secretValue(&tt.secret)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.value, value)
+			assert.Equal(t, tt.placeholder, placeholder)
+		})
+	}
+
+	// WITHOUT A KEY OF THE PROJECT THE SHARED VALUE IS USED
+	c.sourceProject = "web"
+	value, placeholder, err := c.secretValue(&model.Secret{Type: string(model.SecretText), Identifier: "token"})
+	assert.NoError(t, err)
+	assert.False(t, placeholder)
+	assert.Equal(t, "shared value", value)
+}
+
+func TestSecretCopy_ValuesAndPlaceholders(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "cert.pem")
+	assert.NoError(t, os.WriteFile(file, []byte("certificate"), 0600))
+
+	fake := newFakeHarness(t)
+	// THE CREDENTIALS ARE LISTED FIRST BUT REFERENCE THE SECRETS AFTER THEM
+	fake.addSecret("src", "src", model.Secret{Type: string(model.SSHKey), Name: "ssh", Identifier: "ssh", Spec: map[string]interface{}{"auth": map[string]interface{}{"spec": map[string]interface{}{"key": "sshKey"}}}}, "")
+	fake.addSecret("src", "src", model.Secret{Type: string(model.WinRmCredentials), Name: "winrm", Identifier: "winrm", Spec: map[string]interface{}{"auth": map[string]interface{}{"spec": map[string]interface{}{"password": "token"}}}}, "")
+	fake.addSecret("src", "src", textSecret("token"), "")
+	fake.addSecret("src", "src", textSecret("sshKey"), "")
+	fake.addSecret("src", "src", model.Secret{Type: string(model.SecretFile), Name: "cert", Identifier: "cert", Spec: map[string]interface{}{"secretManagerIdentifier": HARNESS_SECRET_MANAGER}}, "")
+	fake.addSecret("src", "src", textSecret("existing"), "")
+	// A SECRET OF A SECRET MANAGER OF THE PROJECT IS COPIED AFTER THE CONNECTORS
+	fake.addSecret("src", "src", model.Secret{Type: string(model.SecretText), Name: "vaulted", Identifier: "vaulted", Spec: map[string]interface{}{"secretManagerIdentifier": "vault"}}, "")
+	fake.addSecret("target", "target", textSecret("existing"), "real value")
+
+	api := fake.api()
+	api.Conflict = ConflictOverwrite
+
+	values := map[string]string{
+		"src/token": "s3cr3t",
+		"cert":      file,
+	}
+	assert.NoError(t, NewSecretOperation(api, "src", "src", "target", "target", values, zap.NewNop(), false).Copy())
+
+	created := []string{}
+	for _, r := range fake.sent(http.MethodPost, "") {
+		if r.Path == SECRETS || r.Path == SECRETFILES {
+			secret, _ := decodeSecret(r.ContentType, []byte(r.Body))
+			created = append(created, secret.Identifier)
+		}
+	}
+	assert.Equal(t, []string{"token", "sshKey", "cert", "existing", "ssh", "winrm"}, created)
+
+	assert.Equal(t, "s3cr3t", fake.secret("target", "target", "token").Value)
+	assert.Equal(t, SECRET_PLACEHOLDER, fake.secret("target", "target", "sshKey").Value)
+	assert.Equal(t, "certificate", fake.secret("target", "target", "cert").Value)
+	assert.Nil(t, fake.secret("target", "target", "vaulted"))
+
+	// A PLACEHOLDER NEVER REPLACES THE VALUE THE TARGET ALREADY HAS, EVEN WHEN OVERWRITING
+	assert.Equal(t, "real value", fake.secret("target", "target", "existing").Value)
+	assert.Empty(t, fake.sent(http.MethodPut, ""))
+	assert.Equal(t, []model.ConflictEntry{
+		{Entity: "Secret", Identifier: "existing", Strategy: ConflictSkip, Reason: reasonSameAsCopy},
+	}, api.Stats.GetConflicts())
+
+	// ONLY THE SECRETS CREATED WITH A PLACEHOLDER ARE REPORTED
+	assert.Equal(t, []string{"sshKey"}, api.Stats.GetSecretsPendingValue())
+	assert.Equal(t, 6, api.Stats.GetSecretsTotal())
+	assert.Equal(t, 6, api.Stats.GetSecretsMoved())
+}
+
+func TestSecretCopy_ProjectSecretManagers(t *testing.T) {
+	fake := newFakeHarness(t)
+	fake.addSecret("src", "src", textSecret("token"), "")
+	fake.addSecret("src", "src", model.Secret{Type: string(model.SecretText), Name: "vaulted", Identifier: "vaulted", Spec: map[string]interface{}{"secretManagerIdentifier": "vault"}}, "")
+
+	api := fake.api()
+
+	assert.NoError(t, NewProjectManagerSecretOperation(api, "src", "src", "target", "target", nil, zap.NewNop(), false).Copy())
+
+	assert.NotNil(t, fake.secret("target", "target", "vaulted"))
+	assert.Nil(t, fake.secret("target", "target", "token"))
+	assert.Equal(t, []string{"vaulted"}, api.Stats.GetSecretsPendingValue())
+}
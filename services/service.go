@@ -2,6 +2,7 @@ package services
 
 import (
 	"encoding/json"
+	"strconv"
 
 	"github.com/schollz/progressbar/v3"
 	"go.uber.org/zap"
@@ -39,7 +40,7 @@ func (c ServiceContext) Copy() error {
 		zap.String("project", c.sourceProject),
 	)
 
-	services, err := c.listServices(c.sourceOrg, c.sourceProject, c.logger)
+	services, err := c.api.source().listServices(c.sourceOrg, c.sourceProject, c.logger)
 	if err != nil {
 		c.logger.Error("Failed to retrive serivces",
 			zap.String("Project", c.sourceProject),
@@ -48,6 +49,21 @@ func (c ServiceContext) Copy() error {
 		return err
 	}
 
+	existing := map[string]bool{}
+	if c.api.Plan != nil {
+		targetServices, err := c.api.listServices(c.targetOrg, c.targetProject, c.logger)
+		if err != nil {
+			c.logger.Error("Failed to list the services of the target project",
+				zap.String("Project", c.targetProject),
+				zap.Error(err),
+			)
+			return err
+		}
+		for _, s := range targetServices {
+			existing[s.Service.Identifier] = true
+		}
+	}
+
 	var bar *progressbar.ProgressBar
 
 	if c.showPB {
@@ -56,29 +72,75 @@ func (c ServiceContext) Copy() error {
 
 	for _, s := range services {
 
-		IncrementServicesTotal()
+		c.api.Stats.IncrementServicesTotal()
+
+		if c.api.Checkpoint.Done("Service", s.Service.Identifier) {
+			c.logger.Info("Skipping service copied by a previous run",
+				zap.String("identifier", s.Service.Identifier),
+			)
+			c.api.Stats.IncrementServicesMoved()
+			if c.showPB {
+				bar.Add(1)
+			}
+			continue
+		}
+
+		if c.api.unchanged("Service", s.Service.Identifier, s.LastModifiedAt, c.logger) {
+			c.api.Stats.IncrementServicesMoved()
+			if c.showPB {
+				bar.Add(1)
+			}
+			continue
+		}
 
 		c.logger.Info("Processing service",
 			zap.String("service", s.Service.Name),
 			zap.String("targetProject", c.targetProject),
 		)
-		newYaml := updateYaml(s.Service.Yaml, c.targetOrg, c.targetProject)
+
+		if c.api.Plan != nil {
+			err := checkValidity(s.EntityValidityDetails)
+			if err == nil {
+				err = checkYaml(s.Service.Yaml)
+			}
+			c.api.Plan.Record("Service", s.Service.Identifier, s.Service.Name, existing[c.api.Rename.Identifier("Service", s.Service.Identifier)], err)
+			if c.showPB {
+				bar.Add(1)
+			}
+			continue
+		}
+
+		if c.api.blocked("Service", s.Service.Identifier, c.logger) {
+			if c.showPB {
+				bar.Add(1)
+			}
+			continue
+		}
+		newYaml := c.api.Rename.Yaml(updateYaml(s.Service.Yaml, c.targetOrg, c.targetProject))
 		service := &model.CreateServiceRequest{
 			OrgIdentifier:     c.targetOrg,
 			ProjectIdentifier: c.targetProject,
-			Identifier:        s.Service.Identifier,
-			Name:              s.Service.Name,
+			Identifier:        c.api.Rename.Identifier("Service", s.Service.Identifier),
+			Name:              c.api.Rename.Name("Service", s.Service.Name),
 			Description:       s.Service.Description,
 			Tags:              s.Service.Tags,
 			Yaml:              newYaml,
 		}
-		if err := c.createService(service, c.logger); err != nil {
+		err := c.createService(service, c.logger)
+		err = c.api.resolveConflict("Service", s.Service.Identifier, err, func() error {
+			return c.api.updateService(service, c.logger)
+		}, c.logger)
+		if err != nil {
 			c.logger.Error("Failed to create service",
 				zap.String("service", s.Service.Name),
 				zap.Error(err),
 			)
+			c.api.Graph.Failed("Service", s.Service.Identifier)
 		} else {
-			IncrementServicesMoved()
+			c.api.Stats.IncrementServicesMoved()
+			if err := c.api.Checkpoint.Record("Service", s.Service.Identifier); err != nil {
+				c.logger.Warn("Failed to update checkpoint", zap.Error(err))
+			}
 		}
 		if c.showPB {
 			bar.Add(1)
@@ -91,51 +153,66 @@ func (c ServiceContext) Copy() error {
 	return nil
 }
 
-func (c ServiceContext) listServices(org, project string, logger *zap.Logger) ([]*model.ServiceListContent, error) {
+func (api *ApiRequest) listServices(org, project string, logger *zap.Logger) ([]*model.ServiceListContent, error) {
 
 	logger.Info("Fetching service overrides",
 		zap.String("org", org),
 		zap.String("project", project),
 	)
 
-	IncrementApiCalls()
+	services := []*model.ServiceListContent{}
 
-	api := c.api
-	resp, err := api.Client.R().
-		SetHeader("x-api-key", api.Token).
-		SetHeader("Content-Type", "application/json").
-		SetQueryParams(map[string]string{
-			"accountIdentifier": api.Account,
-			"orgIdentifier":     org,
-			"projectIdentifier": project,
-			"size":              "1000",
-		}).
-		Get(api.BaseURL + LIST_SERVICES)
-	if err != nil {
-		logger.Error("Failed to request to list of services",
-			zap.Error(err),
-		)
-		return nil, err
-	}
-	if resp.IsError() {
-		logger.Error("Error response from API when listing services",
-			zap.String("response",
-				resp.String(),
-			),
-		)
-		return nil, handleErrorResponse(resp)
-	}
+	err := api.paginate(PAGE_SIZE, func(page int) (listPage, error) {
+		api.Stats.IncrementApiCalls()
+
+		resp, err := api.Client.R().
+			SetHeader("x-api-key", api.Token).
+			SetHeader("Content-Type", "application/json").
+			SetQueryParams(map[string]string{
+				"accountIdentifier": api.Account,
+				"orgIdentifier":     org,
+				"projectIdentifier": project,
+				"page":              strconv.Itoa(page),
+				"size":              strconv.Itoa(PAGE_SIZE),
+			}).
+			Get(api.BaseURL + LIST_SERVICES)
+		if err != nil {
+			logger.Error("Failed to request to list of services",
+				zap.Error(err),
+			)
+			return listPage{}, err
+		}
+		if resp.IsError() {
+			logger.Error("Error response from API when listing services",
+				zap.String("response",
+					resp.String(),
+				),
+			)
+			return listPage{}, handleErrorResponse(resp)
+		}
+
+		result := model.ServiceListResult{}
+		err = json.Unmarshal(resp.Body(), &result)
+		if err != nil {
+			logger.Error("Failed to parse response from API",
+				zap.Error(err),
+			)
+			return listPage{}, err
+		}
+
+		services = append(services, result.Data.Content...)
 
-	result := model.ServiceListResult{}
-	err = json.Unmarshal(resp.Body(), &result)
+		return listPage{
+			index: int(result.Data.PageIndex),
+			total: int(result.Data.TotalPages),
+			items: len(result.Data.Content),
+		}, nil
+	})
 	if err != nil {
-		logger.Error("Failed to parse response from API",
-			zap.Error(err),
-		)
 		return nil, err
 	}
 
-	return result.Data.Content, nil
+	return services, nil
 }
 
 func (c ServiceContext) createService(service *model.CreateServiceRequest, logger *zap.Logger) error {
@@ -145,7 +222,16 @@ func (c ServiceContext) createService(service *model.CreateServiceRequest, logge
 		zap.String("project", service.ProjectIdentifier),
 	)
 
-	IncrementApiCalls()
+	if c.api.Bundle != nil {
+		return c.api.Bundle.Add(model.BundleEntry{
+			Entity:      "Service",
+			Identifier:  service.Identifier,
+			Path:        CREATE_SERVICES,
+			ContentType: "application/json",
+		}, service, nil)
+	}
+
+	c.api.Stats.IncrementApiCalls()
 
 	api := c.api
 	resp, err := api.Client.R().
@@ -167,11 +253,11 @@ func (c ServiceContext) createService(service *model.CreateServiceRequest, logge
 		var errorResponse map[string]interface{}
 		if err := json.Unmarshal(resp.Body(), &errorResponse); err == nil {
 			if code, ok := errorResponse["code"].(string); ok && code == "DUPLICATE_FIELD" {
-				// Log as a warning and skip the error
-				logger.Info("Duplicate service found, ignoring error",
+				// The conflict strategy decides what happens to the existing entity
+				logger.Info("Duplicate service found",
 					zap.String("service", service.Name),
 				)
-				return nil
+				return ErrAlreadyExists
 			}
 		} else {
 			logger.Error(
@@ -185,5 +271,16 @@ func (c ServiceContext) createService(service *model.CreateServiceRequest, logge
 		return handleErrorResponse(resp)
 	}
 
+	c.api.recordCreated(model.JournalEntry{
+		Entity:     "Service",
+		Identifier: service.Identifier,
+		Org:        service.OrgIdentifier,
+		Project:    service.ProjectIdentifier,
+	}, logger)
+
 	return nil
 }
+
+func (api *ApiRequest) updateService(service *model.CreateServiceRequest, logger *zap.Logger) error {
+	return api.updateEntity(CREATE_SERVICES, "application/json", service, nil, logger)
+}
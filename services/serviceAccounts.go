@@ -38,7 +38,7 @@ func (c ServiceAccountContext) Copy() error {
 		zap.String("project", c.sourceProject),
 	)
 
-	serviceAccounts, err := c.api.listServiceAccounts(c.sourceOrg, c.sourceProject, c.logger)
+	serviceAccounts, err := c.api.source().listServiceAccounts(c.sourceOrg, c.sourceProject, c.logger)
 	if err != nil {
 		c.logger.Error("Failed to retrive service accounts",
 			zap.String("Project", c.sourceProject),
@@ -47,6 +47,21 @@ func (c ServiceAccountContext) Copy() error {
 		return err
 	}
 
+	existing := map[string]bool{}
+	if c.api.Plan != nil {
+		targetAccounts, err := c.api.listServiceAccounts(c.targetOrg, c.targetProject, c.logger)
+		if err != nil {
+			c.logger.Error("Failed to list the service accounts of the target project",
+				zap.String("Project", c.targetProject),
+				zap.Error(err),
+			)
+			return err
+		}
+		for _, sa := range targetAccounts {
+			existing[sa.Identifier] = true
+		}
+	}
+
 	var bar *progressbar.ProgressBar
 
 	if c.showPB {
@@ -55,17 +70,42 @@ func (c ServiceAccountContext) Copy() error {
 
 	for _, sa := range serviceAccounts {
 
-		IncrementServiceAccountsTotal()
+		c.api.Stats.IncrementServiceAccountsTotal()
+
+		if c.api.Checkpoint.Done("Service Account", sa.Identifier) {
+			c.logger.Info("Skipping service account copied by a previous run",
+				zap.String("identifier", sa.Identifier),
+			)
+			c.api.Stats.IncrementServiceAccountsMoved()
+			if c.showPB {
+				bar.Add(1)
+			}
+			continue
+		}
 
 		c.logger.Info("Processing service account",
 			zap.String("service account", sa.Email),
 			zap.String("targetProject", c.targetProject),
 		)
 
+		if c.api.Plan != nil {
+			c.api.Plan.Record("Service Account", sa.Identifier, sa.Name, existing[c.api.Rename.Identifier("Service Account", sa.Identifier)], nil)
+			if c.showPB {
+				bar.Add(1)
+			}
+			continue
+		}
+
+		identifier := sa.Identifier
+		c.api.Rename.Entity("Service Account", &sa.Identifier, &sa.Name)
+
 		sa.OrgIdentifier = c.targetOrg
 		sa.ProjectIdentifier = c.targetProject
 
 		err = c.api.createServiceAccount(sa, c.logger)
+		err = c.api.resolveConflict("Service Account", identifier, err, func() error {
+			return c.api.updateServiceAccount(sa, c.logger)
+		}, c.logger)
 
 		if err != nil {
 			c.logger.Error("Failed to create service account",
@@ -73,7 +113,10 @@ func (c ServiceAccountContext) Copy() error {
 				zap.Error(err),
 			)
 		} else {
-			IncrementServiceAccountsMoved()
+			c.api.Stats.IncrementServiceAccountsMoved()
+			if err := c.api.Checkpoint.Record("Service Account", identifier); err != nil {
+				c.logger.Warn("Failed to update checkpoint", zap.Error(err))
+			}
 		}
 		if c.showPB {
 			bar.Add(1)
@@ -93,7 +136,7 @@ func (api *ApiRequest) listServiceAccounts(org, project string, logger *zap.Logg
 		zap.String("project", project),
 	)
 
-	IncrementApiCalls()
+	api.Stats.IncrementApiCalls()
 
 	resp, err := api.Client.R().
 		SetHeader("x-api-key", api.Token).
@@ -138,7 +181,20 @@ func (api *ApiRequest) createServiceAccount(serviceAccount *model.GetServiceAcco
 		zap.String("project", serviceAccount.ProjectIdentifier),
 	)
 
-	IncrementApiCalls()
+	if api.Bundle != nil {
+		return api.Bundle.Add(model.BundleEntry{
+			Entity:      "Service Account",
+			Identifier:  serviceAccount.Identifier,
+			Path:        SERVICEACCOUNTS,
+			ContentType: "application/json",
+			Params: map[string]string{
+				"orgIdentifier":     serviceAccount.OrgIdentifier,
+				"projectIdentifier": serviceAccount.ProjectIdentifier,
+			},
+		}, serviceAccount, nil)
+	}
+
+	api.Stats.IncrementApiCalls()
 
 	resp, err := api.Client.R().
 		SetHeader("x-api-key", api.Token).
@@ -162,11 +218,11 @@ func (api *ApiRequest) createServiceAccount(serviceAccount *model.GetServiceAcco
 		var errorResponse map[string]interface{}
 		if err := json.Unmarshal(resp.Body(), &errorResponse); err == nil {
 			if code, ok := errorResponse["code"].(string); ok && code == "DUPLICATE_FIELD" {
-				// Log as a warning and skip the error
-				logger.Info("Duplicate service account found, ignoring error",
+				// The conflict strategy decides what happens to the existing entity
+				logger.Info("Duplicate service account found",
 					zap.String("service account", serviceAccount.Name),
 				)
-				return nil
+				return ErrAlreadyExists
 			}
 		} else {
 			logger.Error(
@@ -180,5 +236,19 @@ func (api *ApiRequest) createServiceAccount(serviceAccount *model.GetServiceAcco
 		return handleErrorResponse(resp)
 	}
 
+	api.recordCreated(model.JournalEntry{
+		Entity:     "Service Account",
+		Identifier: serviceAccount.Identifier,
+		Org:        serviceAccount.OrgIdentifier,
+		Project:    serviceAccount.ProjectIdentifier,
+	}, logger)
+
 	return nil
 }
+
+func (api *ApiRequest) updateServiceAccount(serviceAccount *model.GetServiceAccountData, logger *zap.Logger) error {
+	return api.updateEntity(SERVICEACCOUNTS+"/"+serviceAccount.Identifier, "application/json", serviceAccount, map[string]string{
+		"orgIdentifier":     serviceAccount.OrgIdentifier,
+		"projectIdentifier": serviceAccount.ProjectIdentifier,
+	}, logger)
+}
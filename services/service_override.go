@@ -2,6 +2,7 @@ package services
 
 import (
 	"encoding/json"
+	"strconv"
 
 	"github.com/schollz/progressbar/v3"
 	"go.uber.org/zap"
@@ -32,7 +33,7 @@ func NewServiceOverrideOperation(api *ApiRequest, sourceOrg, sourceProject, targ
 
 func (c ServiceOverrideContext) Copy() error {
 
-	envs, err := c.api.listEnvironments(c.sourceOrg, c.sourceProject, c.logger)
+	envs, err := c.api.source().listEnvironments(c.sourceOrg, c.sourceProject, c.logger)
 	if err != nil {
 		c.logger.Error("Failed to retrive service overrides",
 			zap.String("Project", c.sourceProject),
@@ -48,7 +49,7 @@ func (c ServiceOverrideContext) Copy() error {
 
 	for _, env := range envs {
 		e := env.Environment
-		overrides, err := c.api.listServiceOverrides(c.sourceOrg, c.sourceProject, e.Identifier, c.logger)
+		overrides, err := c.api.source().listServiceOverrides(c.sourceOrg, c.sourceProject, e.Identifier, c.logger)
 		if err != nil {
 			c.logger.Error("Failed to retrive environments",
 				zap.String("Project", c.sourceProject),
@@ -61,35 +62,91 @@ func (c ServiceOverrideContext) Copy() error {
 			bar.ChangeMax(bar.GetMax() + len(overrides))
 		}
 
+		// WHEN THE TARGET CANNOT BE LISTED IT IS UNKNOWN WHETHER THE ENTITIES EXIST, SO THEIR
+		// PLAN FAILS
+		existing := map[string]bool{}
+		var listErr error
+		if c.api.Plan != nil {
+			targetOverrides, err := c.api.listServiceOverrides(c.targetOrg, c.targetProject, c.api.Rename.Identifier("Environment", e.Identifier), c.logger)
+			if err != nil {
+				c.logger.Error("Failed to list the service overrides of the target project",
+					zap.String("Project", c.targetProject),
+					zap.Error(err),
+				)
+				listErr = err
+			}
+			for _, to := range targetOverrides {
+				existing[to.ServiceRef] = true
+			}
+		}
+
 		for _, o := range overrides {
 
-			IncrementOverridesTotal()
+			c.api.Stats.IncrementOverridesTotal()
+
+			if c.api.Checkpoint.Done("Service Override", o.EnvironmentRef+"/"+o.ServiceRef) {
+				c.logger.Info("Skipping service override copied by a previous run",
+					zap.String("identifier", o.EnvironmentRef+"/"+o.ServiceRef),
+				)
+				c.api.Stats.IncrementOverridesMoved()
+				if c.showPB {
+					bar.Add(1)
+				}
+				continue
+			}
 
 			c.logger.Info("Processing service override",
 				zap.String("service override", o.ServiceRef),
 				zap.String("targetProject", c.targetProject),
 			)
 
+			if c.api.Plan != nil {
+				err := listErr
+				if err == nil {
+					err = checkYaml(o.YAML)
+				}
+				c.api.Plan.Record("Service Override", o.EnvironmentRef+"/"+o.ServiceRef, o.ServiceRef, existing[c.api.Rename.Identifier("Service", o.ServiceRef)], err)
+				if c.showPB {
+					bar.Add(1)
+				}
+				continue
+			}
+
+			if c.api.blocked("Service Override", o.EnvironmentRef+"/"+o.ServiceRef, c.logger) {
+				if c.showPB {
+					bar.Add(1)
+				}
+				continue
+			}
+
 			if len(o.YAML) == 0 {
 				c.logger.Error("YAML file is empty",
 					zap.String("Project", c.sourceProject),
 					zap.Error(err),
 				)
 			} else {
-				err := c.api.createServiceOverride(&model.CreateServiceOverrideRequest{
+				override := &model.CreateServiceOverrideRequest{
 					OrgIdentifier:     c.targetOrg,
 					ProjectIdentifier: c.targetProject,
-					EnvironmentRef:    o.EnvironmentRef,
-					ServiceRef:        o.ServiceRef,
-					YAML:              o.YAML,
+					EnvironmentRef:    c.api.Rename.Identifier("Environment", o.EnvironmentRef),
+					ServiceRef:        c.api.Rename.Identifier("Service", o.ServiceRef),
+					YAML:              c.api.Rename.Yaml(o.YAML),
+				}
+				err := c.api.createServiceOverride(override, c.logger)
+				err = c.api.resolveConflict("Service Override", o.EnvironmentRef+"/"+o.ServiceRef, err, func() error {
+					return c.api.updateServiceOverride(override, c.logger)
 				}, c.logger)
 				if err != nil {
 					c.logger.Error("Failed to create service override",
 						zap.String("service override", o.ServiceRef),
 						zap.Error(err),
 					)
+					c.api.Graph.Failed("Service Override", o.EnvironmentRef+"/"+o.ServiceRef)
 				} else {
-					IncrementOverridesMoved()
+					c.api.Stats.IncrementOverridesMoved()
+					if err := c.api.Checkpoint.Record("Service Override", o.EnvironmentRef+"/"+o.ServiceRef); err != nil {
+						c.logger.Warn("Failed to update checkpoint", zap.Error(err))
+					}
 				}
 			}
 			if c.showPB {
@@ -111,43 +168,59 @@ func (api *ApiRequest) listServiceOverrides(org, project, envId string, logger *
 		zap.String("project", project),
 	)
 
-	IncrementApiCalls()
+	overrides := []*model.ServiceOverride{}
 
-	resp, err := api.Client.R().
-		SetHeader("x-api-key", api.Token).
-		SetQueryParams(map[string]string{
-			"accountIdentifier":     api.Account,
-			"orgIdentifier":         org,
-			"projectIdentifier":     project,
-			"environmentIdentifier": envId,
-			"size":                  "1000",
-		}).
-		Get(api.BaseURL + "/ng/api/environmentsV2/serviceOverrides")
-	if err != nil {
-		logger.Error("Failed to request to list of service overrides",
-			zap.Error(err),
-		)
-		return nil, err
-	}
-	if resp.IsError() {
-		logger.Error("Error response from API when listing service overrides",
-			zap.String("response",
-				resp.String(),
-			),
-		)
-		return nil, handleErrorResponse(resp)
-	}
+	err := api.paginate(PAGE_SIZE, func(page int) (listPage, error) {
+		api.Stats.IncrementApiCalls()
+
+		resp, err := api.Client.R().
+			SetHeader("x-api-key", api.Token).
+			SetQueryParams(map[string]string{
+				"accountIdentifier":     api.Account,
+				"orgIdentifier":         org,
+				"projectIdentifier":     project,
+				"environmentIdentifier": envId,
+				"page":                  strconv.Itoa(page),
+				"size":                  strconv.Itoa(PAGE_SIZE),
+			}).
+			Get(api.BaseURL + "/ng/api/environmentsV2/serviceOverrides")
+		if err != nil {
+			logger.Error("Failed to request to list of service overrides",
+				zap.Error(err),
+			)
+			return listPage{}, err
+		}
+		if resp.IsError() {
+			logger.Error("Error response from API when listing service overrides",
+				zap.String("response",
+					resp.String(),
+				),
+			)
+			return listPage{}, handleErrorResponse(resp)
+		}
 
-	result := model.ListServiceOverridesRequest{}
-	err = json.Unmarshal(resp.Body(), &result)
+		result := model.ListServiceOverridesRequest{}
+		err = json.Unmarshal(resp.Body(), &result)
+		if err != nil {
+			logger.Error("Failed to parse response from API",
+				zap.Error(err),
+			)
+			return listPage{}, err
+		}
+
+		overrides = append(overrides, result.Data.Content...)
+
+		return listPage{
+			index: int(result.Data.PageIndex),
+			total: int(result.Data.TotalPages),
+			items: len(result.Data.Content),
+		}, nil
+	})
 	if err != nil {
-		logger.Error("Failed to parse response from API",
-			zap.Error(err),
-		)
 		return nil, err
 	}
 
-	return result.Data.Content, nil
+	return overrides, nil
 }
 
 func (api *ApiRequest) createServiceOverride(override *model.CreateServiceOverrideRequest, logger *zap.Logger) error {
@@ -156,7 +229,16 @@ func (api *ApiRequest) createServiceOverride(override *model.CreateServiceOverri
 		zap.String("project", override.ProjectIdentifier),
 	)
 
-	IncrementApiCalls()
+	if api.Bundle != nil {
+		return api.Bundle.Add(model.BundleEntry{
+			Entity:      "Service Override",
+			Identifier:  override.EnvironmentRef + "/" + override.ServiceRef,
+			Path:        "/ng/api/environmentsV2/serviceOverrides",
+			ContentType: "application/json",
+		}, override, nil)
+	}
+
+	api.Stats.IncrementApiCalls()
 
 	resp, err := api.Client.R().
 		SetHeader("x-api-key", api.Token).
@@ -174,9 +256,9 @@ func (api *ApiRequest) createServiceOverride(override *model.CreateServiceOverri
 		var errorResponse map[string]interface{}
 		if err := json.Unmarshal(resp.Body(), &errorResponse); err == nil {
 			if code, ok := errorResponse["code"].(string); ok && code == "DUPLICATE_FIELD" {
-				// Log as a warning and skip the error
-				logger.Info("Duplicate connector found, ignoring error")
-				return nil
+				// The conflict strategy decides what happens to the existing entity
+				logger.Info("Duplicate connector found")
+				return ErrAlreadyExists
 			}
 		} else {
 			logger.Error(
@@ -189,5 +271,17 @@ func (api *ApiRequest) createServiceOverride(override *model.CreateServiceOverri
 		return handleErrorResponse(resp)
 	}
 
+	api.recordCreated(model.JournalEntry{
+		Entity:      "Service Override",
+		Identifier:  override.ServiceRef,
+		Org:         override.OrgIdentifier,
+		Project:     override.ProjectIdentifier,
+		Environment: override.EnvironmentRef,
+	}, logger)
+
 	return nil
 }
+
+func (api *ApiRequest) updateServiceOverride(override *model.CreateServiceOverrideRequest, logger *zap.Logger) error {
+	return api.updateEntity("/ng/api/environmentsV2/serviceOverrides", "application/json", override, nil, logger)
+}
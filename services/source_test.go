@@ -0,0 +1,56 @@
+package services
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// requestsOf lists the method and path of the requests sent to an account
+func requestsOf(fake *fakeHarness) []string {
+	requests := []string{}
+	for _, r := range fake.sent("", "") {
+		requests = append(requests, r.Method+" "+r.Path)
+	}
+	return requests
+}
+
+// credentialsOf lists the token and account of the requests sent to an account
+func credentialsOf(fake *fakeHarness) []string {
+	credentials := []string{}
+	for _, r := range fake.sent("", "") {
+		credentials = append(credentials, r.Token+"/"+r.Account)
+	}
+	return credentials
+}
+
+func TestPipelineCopy_SeparateSourceAccount(t *testing.T) {
+	source := newFakeHarness(t)
+	source.addPipeline("src", "src", "build", "pipeline:\n  identifier: build\n  orgIdentifier: src\n  projectIdentifier: src\n")
+	target := newFakeHarness(t)
+
+	api := target.api()
+	api.Token = "target-token"
+	api.Account = "target-account"
+	api.Source = source.api()
+	api.Source.Token = "source-token"
+	api.Source.Account = "source-account"
+	api.Source.Stats = api.Stats
+	stats := api.Stats
+
+	err := NewPipelineOperation(api, "src", "src", "target", "target", zap.NewNop(), false).Copy()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, stats.GetPipelinesMoved())
+
+	// THE SOURCE IS ONLY READ AND THE TARGET ONLY WRITTEN, EACH WITH ITS OWN CREDENTIALS
+	assert.Equal(t, []string{"POST " + LIST_PIPELINES, "GET /pipeline/api/pipelines/build"}, requestsOf(source))
+	assert.Equal(t, []string{"POST " + CREATE_PIPELINE}, requestsOf(target))
+	assert.Equal(t, []string{"source-token/source-account", "source-token/source-account"}, credentialsOf(source))
+	assert.Equal(t, []string{"target-token/target-account"}, credentialsOf(target))
+	created := target.sent(http.MethodPost, CREATE_PIPELINE)
+	if assert.Len(t, created, 1) {
+		assert.Contains(t, created[0].Body, "orgIdentifier: target")
+	}
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"harness-copy-project/model"
+)
+
+// Entities modified shortly before the previous run started are copied again, so a clock
+// that runs behind the one of Harness does not hide a change
+const SYNC_CLOCK_SKEW = 5 * time.Minute
+
+// SyncState records when the copy of each project last completed, so a later run can
+// copy only the entities created or modified since
+type SyncState struct {
+	path     string
+	mu       sync.Mutex
+	projects map[string]int64
+}
+
+// ProjectSync is the part of the sync state that belongs to a single project copy.
+// A nil ProjectSync reports every entity as changed.
+type ProjectSync struct {
+	state *SyncState
+	key   string
+	since int64
+}
+
+// LoadSyncState opens the state file at path, a missing file has no previous run
+func LoadSyncState(path string) (*SyncState, error) {
+	s := &SyncState{
+		path:     path,
+		projects: map[string]int64{},
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error opening sync file: %v", err)
+	}
+
+	state := model.SyncFile{}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("error reading sync file: %v", err)
+	}
+	for project, syncedAt := range state.Projects {
+		s.projects[project] = syncedAt
+	}
+
+	return s, nil
+}
+
+// Project returns the sync state of a single source to target project copy
+func (s *SyncState) Project(sourceOrg, sourceProject, targetOrg, targetProject string) *ProjectSync {
+	if s == nil {
+		return nil
+	}
+	key := fmt.Sprintf("%s/%s->%s/%s", sourceOrg, sourceProject, targetOrg, targetProject)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return &ProjectSync{
+		state: s,
+		key:   key,
+		since: s.projects[key],
+	}
+}
+
+// Since returns when the previous run of the project started, zero without one
+func (p *ProjectSync) Since() time.Time {
+	if p == nil || p.since == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(p.since)
+}
+
+// Changed reports if an entity was created or modified since the previous run. Entities
+// without a modification time are always changed.
+func (p *ProjectSync) Changed(modifiedAt int64) bool {
+	if p == nil || p.since == 0 || modifiedAt == 0 {
+		return true
+	}
+	return modifiedAt >= p.since
+}
+
+// CompleteCopy records the copy of the project like Complete, unless the overwrite
+// strategy had to skip an existing entity, so the next sync tries it again
+func (p *ProjectSync) CompleteCopy(stats *Stats, startedAt time.Time) error {
+	if skipped := NotReplaced(stats); len(skipped) > 0 {
+		return fmt.Errorf("%v existing entities could not be replaced", len(skipped))
+	}
+	return p.Complete(startedAt)
+}
+
+// Complete records that every entity modified before startedAt was copied and persists
+// the state file
+func (p *ProjectSync) Complete(startedAt time.Time) error {
+	if p == nil {
+		return nil
+	}
+	p.state.mu.Lock()
+	defer p.state.mu.Unlock()
+
+	p.state.projects[p.key] = startedAt.Add(-SYNC_CLOCK_SKEW).UnixMilli()
+
+	return p.state.save()
+}
+
+// save writes the state to a temporary file first so an interrupted run never leaves a
+// truncated state behind
+func (s *SyncState) save() error {
+	state := model.SyncFile{
+		UpdatedAt: time.Now().UnixMilli(),
+		Projects:  s.projects,
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding sync state: %v", err)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("error writing sync file: %v", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("error writing sync file: %v", err)
+	}
+
+	return nil
+}
+
+// unchanged reports whether a sync skips an entity that was not modified since the
+// previous run
+func (api *ApiRequest) unchanged(entity, key string, modifiedAt int64, logger *zap.Logger) bool {
+	if api.Sync.Changed(modifiedAt) {
+		return false
+	}
+
+	logger.Info("Skipping entity not modified since the previous run",
+		zap.String("entity", entity),
+		zap.String("identifier", key),
+	)
+	api.Stats.IncrementUnchanged()
+	return true
+}
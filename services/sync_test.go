@@ -0,0 +1,123 @@
+package services
+
+import (
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestSyncState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sync.json")
+
+	state, err := LoadSyncState(path)
+	assert.NoError(t, err)
+
+	// WITHOUT A PREVIOUS RUN EVERY ENTITY IS COPIED
+	project := state.Project("src", "src", "target", "target")
+	assert.True(t, project.Since().IsZero())
+	assert.True(t, project.Changed(time.Now().Add(-24*time.Hour).UnixMilli()))
+
+	startedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	assert.NoError(t, project.Complete(startedAt))
+
+	state, err = LoadSyncState(path)
+	assert.NoError(t, err)
+
+	project = state.Project("src", "src", "target", "target")
+	assert.Equal(t, startedAt.Add(-SYNC_CLOCK_SKEW).UnixMilli(), project.Since().UnixMilli())
+	assert.False(t, project.Changed(startedAt.Add(-time.Hour).UnixMilli()))
+	assert.True(t, project.Changed(startedAt.Add(-time.Minute).UnixMilli()))
+	assert.True(t, project.Changed(startedAt.Add(time.Hour).UnixMilli()))
+	assert.True(t, project.Changed(0))
+
+	// OTHER PROJECTS KEEP THEIR OWN STATE
+	assert.True(t, state.Project("src", "other", "target", "other").Since().IsZero())
+
+	var nilSync *ProjectSync
+	assert.True(t, nilSync.Changed(1))
+	assert.NoError(t, nilSync.Complete(startedAt))
+}
+
+func TestPipelineCopy_SyncOnlyCopiesModified(t *testing.T) {
+	startedAt := time.Now().Add(-time.Hour)
+
+	state, err := LoadSyncState(filepath.Join(t.TempDir(), "sync.json"))
+	assert.NoError(t, err)
+	project := state.Project("src", "src", "target", "target")
+	assert.NoError(t, project.Complete(startedAt))
+
+	fake := newFakeHarness(t)
+	for _, project := range []string{"src", "target"} {
+		fake.addPipeline(project, project, "old", "pipeline:\n  identifier: old\n").Modified = startedAt.Add(-24 * time.Hour).UnixMilli()
+		fake.addPipeline(project, project, "edited", "pipeline:\n  identifier: edited\n").Modified = time.Now().UnixMilli()
+	}
+
+	api := fake.api()
+	api.Conflict = ConflictOverwrite
+	api.Sync = state.Project("src", "src", "target", "target")
+
+	err = NewPipelineOperation(api, "src", "src", "target", "target", zap.NewNop(), false).Copy()
+	assert.NoError(t, err)
+
+	// THE EDITED PIPELINE REPLACES THE ONE OF THE TARGET, THE OLD ONE IS NOT EVEN FETCHED
+	assert.Equal(t, []string{"edited"}, pathsAfter(inProject(fake.sent(http.MethodGet, ""), "src"), "/pipeline/api/pipelines/"))
+	assert.Equal(t, []string{"edited"}, pathsAfter(fake.sent(http.MethodPut, ""), CREATE_PIPELINE+"/"))
+	assert.Equal(t, 1, api.Stats.GetUnchanged())
+	assert.Equal(t, 2, api.Stats.GetPipelinesMoved())
+}
+
+func TestSecretCopy_SyncWithPlaceholderCompletes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sync.json")
+
+	fake := newFakeHarness(t)
+	fake.addSecret("src", "src", textSecret("token"), "")
+	fake.addSecret("target", "target", textSecret("token"), "real value")
+
+	// EVERY SYNC FINDS THE SECRET AGAIN, IT HAS NO MODIFIED TIME TO BE SKIPPED BY
+	for run := 1; run <= 2; run++ {
+		state, err := LoadSyncState(path)
+		assert.NoError(t, err)
+		project := state.Project("src", "src", "target", "target")
+
+		api := fake.api()
+		api.Conflict = ConflictOverwrite
+		api.Sync = project
+
+		startedAt := time.Now()
+		assert.NoError(t, NewSecretOperation(api, "src", "src", "target", "target", nil, zap.NewNop(), false).Copy())
+
+		// THE VALUE OF THE TARGET IS KEPT, THE SECRET IS NOT WAITING TO BE REPLACED
+		assert.Equal(t, "real value", fake.secret("target", "target", "token").Value)
+		assert.Empty(t, NotReplaced(api.Stats))
+		assert.Empty(t, api.Stats.GetSecretsPendingValue())
+		assert.NoError(t, project.CompleteCopy(api.Stats, startedAt))
+
+		state, err = LoadSyncState(path)
+		assert.NoError(t, err)
+		assert.Equal(t, startedAt.Add(-SYNC_CLOCK_SKEW).UnixMilli(), state.Project("src", "src", "target", "target").Since().UnixMilli(), "run %d", run)
+
+		// ONLY A SYNC WITH A PREVIOUS RUN REPORTS THE SECRET AS UNCHANGED
+		assert.Equal(t, run-1, api.Stats.GetUnchanged(), "run %d", run)
+	}
+	assert.Empty(t, fake.sent(http.MethodPut, ""))
+}
+
+func TestProjectSync_CompleteCopyWaitsForNotReplaced(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sync.json")
+	state, err := LoadSyncState(path)
+	assert.NoError(t, err)
+	project := state.Project("src", "src", "target", "target")
+
+	api := &ApiRequest{Stats: NewStats(), Conflict: ConflictOverwrite}
+	api.resolveConflict("Connector", "git", ErrAlreadyExists, nil, zap.NewNop())
+
+	assert.ErrorContains(t, project.CompleteCopy(api.Stats, time.Now()), "1 existing entities could not be replaced")
+
+	state, err = LoadSyncState(path)
+	assert.NoError(t, err)
+	assert.True(t, state.Project("src", "src", "target", "target").Since().IsZero())
+}
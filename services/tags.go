@@ -2,6 +2,7 @@ package services
 
 import (
 	"encoding/json"
+	"strconv"
 
 	"github.com/schollz/progressbar/v3"
 	"go.uber.org/zap"
@@ -41,7 +42,7 @@ func (c TagsContext) Copy() error {
 	projectTags := []*model.Tag{}
 
 	// Leveraging listEnvironments func from environment.go file
-	envs, err := c.api.listEnvironments(c.sourceOrg, c.sourceProject, c.logger)
+	envs, err := c.api.source().listEnvironments(c.sourceOrg, c.sourceProject, c.logger)
 	if err != nil {
 		c.logger.Error("Failed to retrive tags",
 			zap.String("Project", c.sourceProject),
@@ -53,7 +54,7 @@ func (c TagsContext) Copy() error {
 	for _, env := range envs {
 		e := env.Environment
 
-		envTags, err := c.api.listTags(e.Name, c.sourceOrg, c.sourceProject, c.logger)
+		envTags, err := c.api.source().listTags(e.Name, c.sourceOrg, c.sourceProject, c.logger)
 
 		if err != nil {
 			c.logger.Error("Failed to retrive environments",
@@ -65,6 +66,23 @@ func (c TagsContext) Copy() error {
 		projectTags = append(projectTags, envTags...)
 	}
 
+	existing := map[string]bool{}
+	if c.api.Plan != nil {
+		for _, env := range envs {
+			targetTags, err := c.api.listTags(env.Environment.Name, c.targetOrg, c.targetProject, c.logger)
+			if err != nil {
+				c.logger.Error("Failed to list the tags of the target project",
+					zap.String("Project", c.targetProject),
+					zap.Error(err),
+				)
+				return err
+			}
+			for _, t := range targetTags {
+				existing[t.Identifier] = true
+			}
+		}
+	}
+
 	var bar *progressbar.ProgressBar
 
 	if c.showPB {
@@ -73,21 +91,43 @@ func (c TagsContext) Copy() error {
 
 	for _, t := range projectTags {
 
-		IncrementTagsTotal()
+		c.api.Stats.IncrementTagsTotal()
+
+		if c.api.Checkpoint.Done("Tag", t.Identifier) {
+			c.logger.Info("Skipping tag copied by a previous run",
+				zap.String("identifier", t.Identifier),
+			)
+			c.api.Stats.IncrementTagsMoved()
+			if c.showPB {
+				bar.Add(1)
+			}
+			continue
+		}
 
 		c.logger.Info("Processing tag",
 			zap.String("tag", t.Name),
 			zap.String("targetProject", c.targetProject),
 		)
 
+		if c.api.Plan != nil {
+			c.api.Plan.Record("Tag", t.Identifier, t.Name, existing[c.api.Rename.Identifier("Tag", t.Identifier)], nil)
+			if c.showPB {
+				bar.Add(1)
+			}
+			continue
+		}
+
 		newTag := &model.CreateTagRequest{
 			OrgIdentifier:     c.targetOrg,
 			ProjectIdentifier: c.targetProject,
-			Name:              t.Name,
-			Identifier:        t.Identifier,
+			Name:              c.api.Rename.Name("Tag", t.Name),
+			Identifier:        c.api.Rename.Identifier("Tag", t.Identifier),
 		}
 
 		err = c.api.createTags(newTag, c.logger)
+		err = c.api.resolveConflict("Tag", t.Identifier, err, func() error {
+			return c.api.updateTag(newTag, c.logger)
+		}, c.logger)
 
 		if err != nil {
 			c.logger.Error("Failed to create tag",
@@ -95,7 +135,10 @@ func (c TagsContext) Copy() error {
 				zap.Error(err),
 			)
 		} else {
-			IncrementTagsMoved()
+			c.api.Stats.IncrementTagsMoved()
+			if err := c.api.Checkpoint.Record("Tag", t.Identifier); err != nil {
+				c.logger.Warn("Failed to update checkpoint", zap.Error(err))
+			}
 		}
 		if c.showPB {
 			bar.Add(1)
@@ -116,48 +159,62 @@ func (api *ApiRequest) listTags(environment, org, project string, logger *zap.Lo
 		zap.String("environment", environment),
 	)
 
-	IncrementApiCalls()
+	environmentTags := []*model.Tag{}
 
-	resp, err := api.Client.R().
-		SetHeader("x-api-key", api.Token).
-		SetHeader("Content-Type", "application/json").
-		SetQueryParams(map[string]string{
-			"accountIdentifier":     api.Account,
-			"orgIdentifier":         org,
-			"projectIdentifier":     project,
-			"environmentIdentifier": environment,
-		}).
-		Get(api.BaseURL + TAGS)
-	if err != nil {
-		logger.Error("Failed to request to list of tags",
-			zap.Error(err),
-		)
-		return nil, err
-	}
-	if resp.IsError() {
-		logger.Error("Error response from API when listing tags",
-			zap.String("response",
-				resp.String(),
-			),
-		)
-		return nil, handleErrorResponse(resp)
-	}
+	err := api.paginate(PAGE_SIZE, func(page int) (listPage, error) {
+		api.Stats.IncrementApiCalls()
+
+		resp, err := api.Client.R().
+			SetHeader("x-api-key", api.Token).
+			SetHeader("Content-Type", "application/json").
+			SetQueryParams(map[string]string{
+				"accountIdentifier":     api.Account,
+				"orgIdentifier":         org,
+				"projectIdentifier":     project,
+				"pageNumber":            strconv.Itoa(page),
+				"pageSize":              strconv.Itoa(PAGE_SIZE),
+				"environmentIdentifier": environment,
+			}).
+			Get(api.BaseURL + TAGS)
+		if err != nil {
+			logger.Error("Failed to request to list of tags",
+				zap.Error(err),
+			)
+			return listPage{}, err
+		}
+		if resp.IsError() {
+			logger.Error("Error response from API when listing tags",
+				zap.String("response",
+					resp.String(),
+				),
+			)
+			return listPage{}, handleErrorResponse(resp)
+		}
 
-	result := model.TagListResult{}
-	err = json.Unmarshal(resp.Body(), &result)
+		result := model.TagListResult{}
+		err = json.Unmarshal(resp.Body(), &result)
+		if err != nil {
+			logger.Error("Failed to parse response from API",
+				zap.Error(err),
+			)
+			return listPage{}, err
+		}
+
+		for _, c := range result.Tags {
+			tags := c
+			environmentTags = append(environmentTags, &tags)
+		}
+
+		return listPage{
+			index: int(result.PageIndex),
+			total: int(result.PageCount),
+			items: len(result.Tags),
+		}, nil
+	})
 	if err != nil {
-		logger.Error("Failed to parse response from API",
-			zap.Error(err),
-		)
 		return nil, err
 	}
 
-	environmentTags := []*model.Tag{}
-	for _, c := range result.Tags {
-		tags := c
-		environmentTags = append(environmentTags, &tags)
-	}
-
 	return environmentTags, nil
 }
 
@@ -168,7 +225,20 @@ func (api *ApiRequest) createTags(tag *model.CreateTagRequest, logger *zap.Logge
 		zap.String("project", tag.ProjectIdentifier),
 	)
 
-	IncrementApiCalls()
+	if api.Bundle != nil {
+		return api.Bundle.Add(model.BundleEntry{
+			Entity:      "Tag",
+			Identifier:  tag.Identifier,
+			Path:        TAGS,
+			ContentType: "application/json",
+			Params: map[string]string{
+				"orgIdentifier":     tag.OrgIdentifier,
+				"projectIdentifier": tag.ProjectIdentifier,
+			},
+		}, tag, nil)
+	}
+
+	api.Stats.IncrementApiCalls()
 
 	resp, err := api.Client.R().
 		SetHeader("x-api-key", api.Token).
@@ -192,11 +262,11 @@ func (api *ApiRequest) createTags(tag *model.CreateTagRequest, logger *zap.Logge
 		var errorResponse map[string]interface{}
 		if err := json.Unmarshal(resp.Body(), &errorResponse); err == nil {
 			if code, ok := errorResponse["code"].(string); ok && code == "DUPLICATE_FIELD" {
-				// Log as a warning and skip the error
-				logger.Info("Duplicate tag found, ignoring error",
+				// The conflict strategy decides what happens to the existing entity
+				logger.Info("Duplicate tag found",
 					zap.String("tag", tag.Name),
 				)
-				return nil
+				return ErrAlreadyExists
 			}
 		} else {
 			logger.Error(
@@ -210,5 +280,19 @@ func (api *ApiRequest) createTags(tag *model.CreateTagRequest, logger *zap.Logge
 		return handleErrorResponse(resp)
 	}
 
+	api.recordCreated(model.JournalEntry{
+		Entity:     "Tag",
+		Identifier: tag.Identifier,
+		Org:        tag.OrgIdentifier,
+		Project:    tag.ProjectIdentifier,
+	}, logger)
+
 	return nil
 }
+
+func (api *ApiRequest) updateTag(tag *model.CreateTagRequest, logger *zap.Logger) error {
+	return api.updateEntity(TAGS+"/"+tag.Identifier, "application/json", tag, map[string]string{
+		"orgIdentifier":     tag.OrgIdentifier,
+		"projectIdentifier": tag.ProjectIdentifier,
+	}, logger)
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"encoding/json"
+	"strconv"
 
 	"github.com/schollz/progressbar/v3"
 	"go.uber.org/zap"
@@ -38,7 +39,7 @@ func (c TargetGroupContext) Copy() error {
 		zap.String("project", c.sourceProject),
 	)
 
-	envs, err := c.api.listEnvironments(c.sourceOrg, c.sourceProject, c.logger)
+	envs, err := c.api.source().listEnvironments(c.sourceOrg, c.sourceProject, c.logger)
 	if err != nil {
 		c.logger.Error("Failed to retrive environments",
 			zap.String("Project", c.sourceProject),
@@ -55,7 +56,7 @@ func (c TargetGroupContext) Copy() error {
 
 	for _, env := range envs {
 		e := env.Environment
-		targetGroups, err := c.api.listTargetGroups(c.sourceOrg, c.sourceProject, e.Identifier, c.logger)
+		targetGroups, err := c.api.source().listTargetGroups(c.sourceOrg, c.sourceProject, e.Identifier, c.logger)
 		if err != nil {
 			c.logger.Error("Failed to retrive target group",
 				zap.String("Project", c.sourceProject),
@@ -69,9 +70,46 @@ func (c TargetGroupContext) Copy() error {
 			bar.ChangeMax(bar.GetMax() + len(targetGroups))
 		}
 
+		// WHEN THE TARGET CANNOT BE LISTED IT IS UNKNOWN WHETHER THE ENTITIES EXIST, SO THEIR
+		// PLAN FAILS
+		existing := map[string]bool{}
+		var listErr error
+		if c.api.Plan != nil {
+			existingGroups, err := c.api.listTargetGroups(c.targetOrg, c.targetProject, c.api.Rename.Identifier("Environment", e.Identifier), c.logger)
+			if err != nil {
+				c.logger.Error("Failed to list the target groups of the target project",
+					zap.String("Project", c.targetProject),
+					zap.Error(err),
+				)
+				listErr = err
+			}
+			for _, tg := range existingGroups {
+				existing[tg.Identifier] = true
+			}
+		}
+
 		for _, targetGroup := range targetGroups {
 
-			IncrementTargetGroupsTotal()
+			c.api.Stats.IncrementTargetGroupsTotal()
+
+			if c.api.Checkpoint.Done("Target Group", e.Identifier+"/"+targetGroup.Identifier) {
+				c.logger.Info("Skipping target group copied by a previous run",
+					zap.String("identifier", e.Identifier+"/"+targetGroup.Identifier),
+				)
+				c.api.Stats.IncrementTargetGroupsMoved()
+				if c.showPB {
+					bar.Add(1)
+				}
+				continue
+			}
+
+			if c.api.unchanged("Target Group", e.Identifier+"/"+targetGroup.Identifier, targetGroup.ModifiedAt, c.logger) {
+				c.api.Stats.IncrementTargetGroupsMoved()
+				if c.showPB {
+					bar.Add(1)
+				}
+				continue
+			}
 
 			i := targetGroup
 			c.logger.Info("Processing target group",
@@ -79,23 +117,35 @@ func (c TargetGroupContext) Copy() error {
 				zap.String("targetProject", c.targetProject),
 			)
 
+			if c.api.Plan != nil {
+				c.api.Plan.Record("Target Group", e.Identifier+"/"+i.Identifier, i.Name, existing[c.api.Rename.Identifier("Target Group", i.Identifier)], listErr)
+				if c.showPB {
+					bar.Add(1)
+				}
+				continue
+			}
+
 			includedNames := []string{}
 			for _, included := range i.Included {
-				iName := included.Name // Declare iName inside the loop
+				iName := c.api.Rename.Name("Target", included.Name) // Declare iName inside the loop
 				includedNames = append(includedNames, iName)
 			}
 
-			err := c.api.createTargetGroups(&model.NewTargetGroup{
-				Name:         i.Name,
-				Identifier:   i.Identifier,
+			targetGroup := &model.NewTargetGroup{
+				Name:         c.api.Rename.Name("Target Group", i.Name),
+				Identifier:   c.api.Rename.Identifier("Target Group", i.Identifier),
 				Org:          c.targetOrg,
 				Project:      c.targetProject,
 				Account:      i.Account,
-				Environment:  e.Identifier,
+				Environment:  c.api.Rename.Identifier("Environment", e.Identifier),
 				Included:     includedNames,
 				Excluded:     i.Excluded,
 				Rules:        i.Rules,
 				ServingRules: i.ServingRules,
+			}
+			err := c.api.createTargetGroups(targetGroup, c.logger)
+			err = c.api.resolveConflict("Target Group", e.Identifier+"/"+i.Identifier, err, func() error {
+				return c.api.updateTargetGroup(targetGroup, c.logger)
 			}, c.logger)
 			if err != nil {
 				c.logger.Error("Failed to create target group",
@@ -103,7 +153,10 @@ func (c TargetGroupContext) Copy() error {
 					zap.Error(err),
 				)
 			} else {
-				IncrementTargetGroupsMoved()
+				c.api.Stats.IncrementTargetGroupsMoved()
+				if err := c.api.Checkpoint.Record("Target Group", e.Identifier+"/"+i.Identifier); err != nil {
+					c.logger.Warn("Failed to update checkpoint", zap.Error(err))
+				}
 			}
 			if c.showPB {
 				bar.Add(1)
@@ -128,49 +181,62 @@ func (api *ApiRequest) listTargetGroups(org, project, envId string, logger *zap.
 		zap.String("environment", envId),
 	)
 
-	IncrementApiCalls()
+	targetGroups := []*model.TargetGroups{}
 
-	resp, err := api.Client.R().
-		SetHeader("x-api-key", api.Token).
-		SetHeader("Content-Type", "application/json").
-		SetQueryParams(map[string]string{
-			"accountIdentifier":     api.Account,
-			"orgIdentifier":         org,
-			"projectIdentifier":     project,
-			"environmentIdentifier": envId,
-			"size":                  "1000",
-		}).
-		Get(api.BaseURL + TARGETGROUPS)
-	if err != nil {
-		logger.Error("Failed to request to list of target groups",
-			zap.Error(err),
-		)
-		return nil, err
-	}
-	if resp.IsError() {
-		logger.Error("Error response from API when listing target groups",
-			zap.String("response",
-				resp.String(),
-			),
-		)
-		return nil, handleErrorResponse(resp)
-	}
+	err := api.paginate(PAGE_SIZE, func(page int) (listPage, error) {
+		api.Stats.IncrementApiCalls()
+
+		resp, err := api.Client.R().
+			SetHeader("x-api-key", api.Token).
+			SetHeader("Content-Type", "application/json").
+			SetQueryParams(map[string]string{
+				"accountIdentifier":     api.Account,
+				"orgIdentifier":         org,
+				"projectIdentifier":     project,
+				"environmentIdentifier": envId,
+				"pageNumber":            strconv.Itoa(page),
+				"pageSize":              strconv.Itoa(PAGE_SIZE),
+			}).
+			Get(api.BaseURL + TARGETGROUPS)
+		if err != nil {
+			logger.Error("Failed to request to list of target groups",
+				zap.Error(err),
+			)
+			return listPage{}, err
+		}
+		if resp.IsError() {
+			logger.Error("Error response from API when listing target groups",
+				zap.String("response",
+					resp.String(),
+				),
+			)
+			return listPage{}, handleErrorResponse(resp)
+		}
+
+		result := model.TargetGroupListResponse{}
+		err = json.Unmarshal(resp.Body(), &result)
+		if err != nil {
+			logger.Error("Failed to parse response from API",
+				zap.Error(err),
+			)
+			return listPage{}, err
+		}
+
+		for _, c := range result.TargetGroups {
+			tg := c
+			targetGroups = append(targetGroups, &tg)
+		}
 
-	result := model.TargetGroupListResponse{}
-	err = json.Unmarshal(resp.Body(), &result)
+		return listPage{
+			index: int(result.PageIndex),
+			total: int(result.PageCount),
+			items: len(result.TargetGroups),
+		}, nil
+	})
 	if err != nil {
-		logger.Error("Failed to parse response from API",
-			zap.Error(err),
-		)
 		return nil, err
 	}
 
-	targetGroups := []*model.TargetGroups{}
-	for _, c := range result.TargetGroups {
-		tg := c
-		targetGroups = append(targetGroups, &tg)
-	}
-
 	return targetGroups, nil
 }
 
@@ -181,6 +247,18 @@ func (api *ApiRequest) createTargetGroups(targetGroup *model.NewTargetGroup, log
 		zap.String("project", targetGroup.Project),
 	)
 
+	if api.Bundle != nil {
+		return api.Bundle.Add(model.BundleEntry{
+			Entity:      "Target Group",
+			Identifier:  targetGroup.Environment + "/" + targetGroup.Identifier,
+			Path:        TARGETGROUPS,
+			ContentType: "application/json",
+			Params: map[string]string{
+				"orgIdentifier": targetGroup.Org,
+			},
+		}, targetGroup, nil)
+	}
+
 	resp, err := api.Client.R().
 		SetHeader("x-api-key", api.Token).
 		SetHeader("Content-Type", "application/json").
@@ -201,11 +279,11 @@ func (api *ApiRequest) createTargetGroups(targetGroup *model.NewTargetGroup, log
 		var errorResponse map[string]interface{}
 		if err := json.Unmarshal(resp.Body(), &errorResponse); err == nil {
 			if code, ok := errorResponse["code"].(string); ok && code == "409" {
-				// Log as a warning and skip the error
-				logger.Info("Duplicate target group found, ignoring error",
+				// The conflict strategy decides what happens to the existing entity
+				logger.Info("Duplicate target group found",
 					zap.String("target group", targetGroup.Name),
 				)
-				return nil
+				return ErrAlreadyExists
 			}
 		} else {
 			logger.Error(
@@ -219,5 +297,21 @@ func (api *ApiRequest) createTargetGroups(targetGroup *model.NewTargetGroup, log
 		return handleErrorResponse(resp)
 	}
 
+	api.recordCreated(model.JournalEntry{
+		Entity:      "Target Group",
+		Identifier:  targetGroup.Identifier,
+		Org:         targetGroup.Org,
+		Project:     targetGroup.Project,
+		Environment: targetGroup.Environment,
+	}, logger)
+
 	return nil
 }
+
+func (api *ApiRequest) updateTargetGroup(targetGroup *model.NewTargetGroup, logger *zap.Logger) error {
+	return api.updateEntity(TARGETGROUPS+"/"+targetGroup.Identifier, "application/json", targetGroup, map[string]string{
+		"orgIdentifier":         targetGroup.Org,
+		"projectIdentifier":     targetGroup.Project,
+		"environmentIdentifier": targetGroup.Environment,
+	}, logger)
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"encoding/json"
+	"strconv"
 
 	"github.com/schollz/progressbar/v3"
 	"go.uber.org/zap"
@@ -38,7 +39,7 @@ func (c TargetContext) Copy() error {
 		zap.String("project", c.sourceProject),
 	)
 
-	envs, err := c.api.listEnvironments(c.sourceOrg, c.sourceProject, c.logger)
+	envs, err := c.api.source().listEnvironments(c.sourceOrg, c.sourceProject, c.logger)
 	if err != nil {
 		c.logger.Error("Failed to retrive environments",
 			zap.String("Project", c.sourceProject),
@@ -55,7 +56,7 @@ func (c TargetContext) Copy() error {
 
 	for _, env := range envs {
 		e := env.Environment
-		targets, err := c.api.listTargets(c.sourceOrg, c.sourceProject, e.Identifier, c.logger)
+		targets, err := c.api.source().listTargets(c.sourceOrg, c.sourceProject, e.Identifier, c.logger)
 		if err != nil {
 			c.logger.Error("Failed to retrive targets",
 				zap.String("Project", c.sourceProject),
@@ -68,9 +69,38 @@ func (c TargetContext) Copy() error {
 			bar.ChangeMax(bar.GetMax() + len(targets))
 		}
 
+		// WHEN THE TARGET CANNOT BE LISTED IT IS UNKNOWN WHETHER THE ENTITIES EXIST, SO THEIR
+		// PLAN FAILS
+		existing := map[string]bool{}
+		var listErr error
+		if c.api.Plan != nil {
+			targetTargets, err := c.api.listTargets(c.targetOrg, c.targetProject, c.api.Rename.Identifier("Environment", e.Identifier), c.logger)
+			if err != nil {
+				c.logger.Error("Failed to list the targets of the target project",
+					zap.String("Project", c.targetProject),
+					zap.Error(err),
+				)
+				listErr = err
+			}
+			for _, t := range targetTargets {
+				existing[t.Identifier] = true
+			}
+		}
+
 		for _, target := range targets {
 
-			IncrementTargetsTotal()
+			c.api.Stats.IncrementTargetsTotal()
+
+			if c.api.Checkpoint.Done("Target", e.Identifier+"/"+target.Identifier) {
+				c.logger.Info("Skipping target copied by a previous run",
+					zap.String("identifier", e.Identifier+"/"+target.Identifier),
+				)
+				c.api.Stats.IncrementTargetsMoved()
+				if c.showPB {
+					bar.Add(1)
+				}
+				continue
+			}
 
 			i := target
 
@@ -79,14 +109,26 @@ func (c TargetContext) Copy() error {
 				zap.String("targetProject", c.targetProject),
 			)
 
-			err := c.api.createTarget(&model.Target{
-				Name:        i.Name,
-				Identifier:  i.Identifier,
+			if c.api.Plan != nil {
+				c.api.Plan.Record("Target", e.Identifier+"/"+i.Identifier, i.Name, existing[c.api.Rename.Identifier("Target", i.Identifier)], listErr)
+				if c.showPB {
+					bar.Add(1)
+				}
+				continue
+			}
+
+			target := &model.Target{
+				Name:        c.api.Rename.Name("Target", i.Name),
+				Identifier:  c.api.Rename.Identifier("Target", i.Identifier),
 				Org:         c.targetOrg,
 				Project:     c.targetProject,
-				Environment: i.Environment,
+				Environment: c.api.Rename.Identifier("Environment", i.Environment),
 				Attributes:  i.Attributes,
 				Segments:    i.Segments,
+			}
+			err := c.api.createTarget(target, c.logger)
+			err = c.api.resolveConflict("Target", e.Identifier+"/"+i.Identifier, err, func() error {
+				return c.api.updateTarget(target, c.logger)
 			}, c.logger)
 
 			if err != nil {
@@ -95,7 +137,10 @@ func (c TargetContext) Copy() error {
 					zap.Error(err),
 				)
 			} else {
-				IncrementTargetsMoved()
+				c.api.Stats.IncrementTargetsMoved()
+				if err := c.api.Checkpoint.Record("Target", e.Identifier+"/"+i.Identifier); err != nil {
+					c.logger.Warn("Failed to update checkpoint", zap.Error(err))
+				}
 			}
 			if c.showPB {
 				bar.Add(1)
@@ -120,49 +165,62 @@ func (api *ApiRequest) listTargets(org, project, envId string, logger *zap.Logge
 		zap.String("environment", envId),
 	)
 
-	IncrementApiCalls()
+	targets := []*model.Target{}
 
-	resp, err := api.Client.R().
-		SetHeader("x-api-key", api.Token).
-		SetHeader("Content-Type", "application/json").
-		SetQueryParams(map[string]string{
-			"accountIdentifier":     api.Account,
-			"orgIdentifier":         org,
-			"projectIdentifier":     project,
-			"environmentIdentifier": envId,
-			"size":                  "1000",
-		}).
-		Get(api.BaseURL + TARGETS)
-	if err != nil {
-		logger.Error("Failed to request to list of targets",
-			zap.Error(err),
-		)
-		return nil, err
-	}
-	if resp.IsError() {
-		logger.Error("Error response from API when listing targets",
-			zap.String("response",
-				resp.String(),
-			),
-		)
-		return nil, handleErrorResponse(resp)
-	}
+	err := api.paginate(PAGE_SIZE, func(page int) (listPage, error) {
+		api.Stats.IncrementApiCalls()
 
-	result := model.TargetListResponse{}
-	err = json.Unmarshal(resp.Body(), &result)
+		resp, err := api.Client.R().
+			SetHeader("x-api-key", api.Token).
+			SetHeader("Content-Type", "application/json").
+			SetQueryParams(map[string]string{
+				"accountIdentifier":     api.Account,
+				"orgIdentifier":         org,
+				"projectIdentifier":     project,
+				"environmentIdentifier": envId,
+				"pageNumber":            strconv.Itoa(page),
+				"pageSize":              strconv.Itoa(PAGE_SIZE),
+			}).
+			Get(api.BaseURL + TARGETS)
+		if err != nil {
+			logger.Error("Failed to request to list of targets",
+				zap.Error(err),
+			)
+			return listPage{}, err
+		}
+		if resp.IsError() {
+			logger.Error("Error response from API when listing targets",
+				zap.String("response",
+					resp.String(),
+				),
+			)
+			return listPage{}, handleErrorResponse(resp)
+		}
+
+		result := model.TargetListResponse{}
+		err = json.Unmarshal(resp.Body(), &result)
+		if err != nil {
+			logger.Error("Failed to parse response from API",
+				zap.Error(err),
+			)
+			return listPage{}, err
+		}
+
+		for _, c := range result.Targets {
+			target := c
+			targets = append(targets, &target)
+		}
+
+		return listPage{
+			index: int(result.PageIndex),
+			total: int(result.PageCount),
+			items: len(result.Targets),
+		}, nil
+	})
 	if err != nil {
-		logger.Error("Failed to parse response from API",
-			zap.Error(err),
-		)
 		return nil, err
 	}
 
-	targets := []*model.Target{}
-	for _, c := range result.Targets {
-		target := c
-		targets = append(targets, &target)
-	}
-
 	return targets, nil
 }
 
@@ -173,7 +231,19 @@ func (api *ApiRequest) createTarget(target *model.Target, logger *zap.Logger) er
 		zap.String("project", target.Project),
 	)
 
-	IncrementApiCalls()
+	if api.Bundle != nil {
+		return api.Bundle.Add(model.BundleEntry{
+			Entity:      "Target",
+			Identifier:  target.Identifier,
+			Path:        TARGETS,
+			ContentType: "application/json",
+			Params: map[string]string{
+				"orgIdentifier": target.Org,
+			},
+		}, target, nil)
+	}
+
+	api.Stats.IncrementApiCalls()
 
 	resp, err := api.Client.R().
 		SetHeader("x-api-key", api.Token).
@@ -191,11 +261,11 @@ func (api *ApiRequest) createTarget(target *model.Target, logger *zap.Logger) er
 		var errorResponse map[string]interface{}
 		if err := json.Unmarshal(resp.Body(), &errorResponse); err == nil {
 			if code, ok := errorResponse["code"].(string); ok && code == "409" {
-				// Log as a warning and skip the error
-				logger.Info("Duplicate target found, ignoring error",
+				// The conflict strategy decides what happens to the existing entity
+				logger.Info("Duplicate target found",
 					zap.String("target", target.Name),
 				)
-				return nil
+				return ErrAlreadyExists
 			}
 		} else {
 			logger.Error(
@@ -209,5 +279,21 @@ func (api *ApiRequest) createTarget(target *model.Target, logger *zap.Logger) er
 		return handleErrorResponse(resp)
 	}
 
+	api.recordCreated(model.JournalEntry{
+		Entity:      "Target",
+		Identifier:  target.Identifier,
+		Org:         target.Org,
+		Project:     target.Project,
+		Environment: target.Environment,
+	}, logger)
+
 	return nil
 }
+
+func (api *ApiRequest) updateTarget(target *model.Target, logger *zap.Logger) error {
+	return api.updateEntity(TARGETS+"/"+target.Identifier, "application/json", target, map[string]string{
+		"orgIdentifier":         target.Org,
+		"projectIdentifier":     target.Project,
+		"environmentIdentifier": target.Environment,
+	}, logger)
+}
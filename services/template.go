@@ -2,7 +2,11 @@ package services
 
 import (
 	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
 
+	"github.com/go-resty/resty/v2"
 	"github.com/schollz/progressbar/v3"
 	"go.uber.org/zap"
 	"harness-copy-project/model"
@@ -11,6 +15,10 @@ import (
 const LIST_TEMPLATES_ENDPOINT = "/v1/orgs/{org}/projects/{project}/templates"
 const GET_TEMPLATE_ENDPOINT = "/template/api/templates/{templateIdentifier}"
 const CREATE_TEMPLATE_ENDPOINT = "/template/api/templates"
+const STABLE_TEMPLATE_ENDPOINT = "/template/api/templates/updateStableTemplate/%s/%s"
+
+// Lists every version label of the templates, not only the stable ones
+const TEMPLATE_LIST_ALL = "ALL"
 
 type TemplateContext struct {
 	api           *ApiRequest
@@ -40,7 +48,7 @@ func (c TemplateContext) Copy() error {
 		zap.String("project", c.sourceProject),
 	)
 
-	templates, err := c.listTemplates(c.sourceOrg, c.sourceProject, c.logger)
+	templates, err := c.api.source().listTemplates(c.sourceOrg, c.sourceProject, c.logger)
 	if err != nil {
 		c.logger.Error("Failed to retrive templates",
 			zap.String("Project", c.sourceProject),
@@ -49,37 +57,170 @@ func (c TemplateContext) Copy() error {
 		return err
 	}
 
+	existing := map[string]bool{}
+	if c.api.Plan != nil {
+		targetTemplates, err := c.api.listTemplates(c.targetOrg, c.targetProject, c.logger)
+		if err != nil {
+			c.logger.Error("Failed to list the templates of the target project",
+				zap.String("Project", c.targetProject),
+				zap.Error(err),
+			)
+			return err
+		}
+		for _, t := range targetTemplates {
+			existing[t.Identifier+"/"+t.VersionLabel] = true
+		}
+	}
+
 	var bar *progressbar.ProgressBar
 
 	if c.showPB {
 		bar = progressbar.Default(int64(len(templates)), "Templates   ")
 	}
 
-	for _, template := range templates {
+	// VERSIONS OF A TEMPLATE ARE CREATED ONE AFTER ANOTHER, DIFFERENT TEMPLATES AT THE SAME
+	// TIME ONCE THE TEMPLATES THEY REFERENCE ARE CREATED
+	groups := groupTemplateVersions(templates)
+	keys := make([]string, len(groups))
+	for i, group := range groups {
+		keys[i] = group[0].Identifier
+	}
 
-		IncrementTemplatesTotal()
+	c.api.forEachLevel("Template", keys, func(i int) {
+		stable := ""
+		for _, template := range groups[i] {
 
-		c.logger.Info("Processing template",
-			zap.String("template", template.Name),
-			zap.String("targetProject", c.targetProject),
-		)
-		t, err := c.getTemplate(c.sourceOrg, c.sourceProject, template.Identifier, template.VersionLabel, c.logger)
-		if err == nil {
-			newYaml := updateYaml(t.Yaml, c.targetOrg, c.targetProject)
-			err = c.createTemplate(c.targetOrg, c.targetProject, newYaml, c.logger)
-		}
-		if err != nil {
-			c.logger.Error("Failed to create template",
+			c.api.Stats.IncrementTemplatesTotal()
+
+			if c.api.Checkpoint.Done("Template", template.Identifier+"/"+template.VersionLabel) {
+				c.logger.Info("Skipping template copied by a previous run",
+					zap.String("identifier", template.Identifier+"/"+template.VersionLabel),
+				)
+				c.api.Stats.IncrementTemplatesMoved()
+				if c.showPB {
+					bar.Add(1)
+				}
+				continue
+			}
+
+			if c.api.unchanged("Template", template.Identifier+"/"+template.VersionLabel, template.Updated, c.logger) {
+				c.api.Stats.IncrementTemplatesMoved()
+				if c.showPB {
+					bar.Add(1)
+				}
+				continue
+			}
+
+			c.logger.Info("Processing template",
 				zap.String("template", template.Name),
-				zap.Error(err),
+				zap.String("targetProject", c.targetProject),
 			)
-		} else {
-			IncrementTemplatesMoved()
+			t, err := c.api.source().getTemplate(c.sourceOrg, c.sourceProject, template.Identifier, template.VersionLabel, c.logger)
+			if c.api.Plan != nil {
+				if err == nil {
+					err = checkYaml(t.Yaml)
+				}
+				if err == nil && t.StoreType == model.Remote {
+					_, err = gitLocation(t.ConnectorRef, t.GitDetails)
+				}
+				c.api.Plan.Record("Template", template.Identifier+"/"+template.VersionLabel, template.Name, existing[c.api.Rename.Identifier("Template", template.Identifier)+"/"+template.VersionLabel], err)
+				if c.showPB {
+					bar.Add(1)
+				}
+				continue
+			}
+			if c.api.blocked("Template", template.Identifier, c.logger) {
+				if c.showPB {
+					bar.Add(1)
+				}
+				continue
+			}
+			written := false
+			if err == nil && t.StoreType == model.Remote {
+				// A REMOTE TEMPLATE IS IMPORTED FROM ITS FILE, AN INLINE COPY NEVER REPLACES IT
+				var location model.GitLocation
+				if location, err = gitLocation(t.ConnectorRef, t.GitDetails); err == nil {
+					location = c.api.Rename.Git(location)
+					err = c.importTemplate(template, location, false)
+				}
+				written = err == nil
+				err = c.api.resolveConflict("Template", template.Identifier+"/"+template.VersionLabel, err, func() error {
+					if err := c.importTemplate(template, location, true); err != nil {
+						return err
+					}
+					written = true
+					return nil
+				}, c.logger)
+			} else if err == nil && c.api.Git.Stores("Template") {
+				// THE INLINE TEMPLATE IS WRITTEN TO GIT AND IMPORTED FROM THERE
+				identifier := c.api.Rename.Identifier("Template", template.Identifier)
+				newYaml := c.api.Rename.Yaml(updateYaml(t.Yaml, c.targetOrg, c.targetProject))
+				var location model.GitLocation
+				if location, err = c.api.Git.Write("Template", gitFile{
+					org:        c.targetOrg,
+					project:    c.targetProject,
+					identifier: identifier,
+					version:    template.VersionLabel,
+				}, newYaml, c.logger); err == nil {
+					err = c.importTemplate(template, location, false)
+				}
+				written = err == nil
+				err = c.api.resolveConflict("Template", template.Identifier+"/"+template.VersionLabel, err, func() error {
+					if err := c.importTemplate(template, location, true); err != nil {
+						return err
+					}
+					written = true
+					return nil
+				}, c.logger)
+			} else if err == nil {
+				identifier := c.api.Rename.Identifier("Template", template.Identifier)
+				newYaml := c.api.Rename.Yaml(updateYaml(t.Yaml, c.targetOrg, c.targetProject))
+				err = c.createTemplate(c.targetOrg, c.targetProject, newYaml, c.logger)
+				written = err == nil
+				err = c.api.resolveConflict("Template", template.Identifier+"/"+template.VersionLabel, err, func() error {
+					if existing, err := c.api.getTemplate(c.targetOrg, c.targetProject, identifier, template.VersionLabel, c.logger); err == nil && existing.StoreType == model.Remote {
+						return ErrRemoteInTarget
+					}
+					if err := c.api.updateTemplate(c.targetOrg, c.targetProject, identifier, template.VersionLabel, newYaml, c.logger); err != nil {
+						return err
+					}
+					written = true
+					return nil
+				}, c.logger)
+			}
+			if err != nil {
+				c.logger.Error("Failed to create template",
+					zap.String("template", template.Name),
+					zap.Error(err),
+				)
+				c.api.Graph.Failed("Template", template.Identifier)
+			} else {
+				c.api.Stats.IncrementTemplatesMoved()
+				if err := c.api.Checkpoint.Record("Template", template.Identifier+"/"+template.VersionLabel); err != nil {
+					c.logger.Warn("Failed to update checkpoint", zap.Error(err))
+				}
+				// A VERSION THAT WAS ALREADY IN THE TARGET KEEPS THE STABLE VERSION OF THE TARGET
+				if template.StableTemplate && written {
+					stable = template.VersionLabel
+				}
+			}
+			if c.showPB {
+				bar.Add(1)
+			}
 		}
-		if c.showPB {
-			bar.Add(1)
+
+		// THE VERSION CREATED LAST IS NOT ALWAYS THE STABLE ONE IN THE TARGET
+		if stable != "" {
+			identifier := c.api.Rename.Identifier("Template", groups[i][0].Identifier)
+			if err := c.api.markStableTemplate(c.targetOrg, c.targetProject, identifier, stable, c.logger); err != nil {
+				c.logger.Error("Failed to mark stable template version",
+					zap.String("template", identifier),
+					zap.String("versionLabel", stable),
+					zap.Error(err),
+				)
+			}
 		}
-	}
+	})
 	if c.showPB {
 		bar.Finish()
 	}
@@ -87,58 +228,116 @@ func (c TemplateContext) Copy() error {
 	return nil
 }
 
-func (c TemplateContext) listTemplates(org, project string, logger *zap.Logger) (model.TemplateListResult, error) {
+// importTemplate imports a version of a remote template into the target from the file it
+// is stored in
+func (c TemplateContext) importTemplate(template model.TemplateListResultElement, location model.GitLocation, force bool) error {
+	identifier := c.api.Rename.Identifier("Template", template.Identifier)
+	body := model.TemplateImportRequest{
+		TemplateVersion: template.VersionLabel,
+		TemplateName:    c.api.Rename.Name("Template", template.Name),
+	}
+	if template.Description != nil {
+		body.TemplateDescription = *template.Description
+	}
+
+	return c.api.importRemote(fmt.Sprintf(IMPORT_TEMPLATE, identifier), nil, location, body, model.JournalEntry{
+		Entity:     "Template",
+		Identifier: identifier,
+		Org:        c.targetOrg,
+		Project:    c.targetProject,
+		Version:    template.VersionLabel,
+	}, identifier+"/"+template.VersionLabel, force, c.logger)
+}
+
+// groupTemplateVersions groups the versions of each template, keeping the order of the list.
+// The stable version comes first, so the template is created with it.
+func groupTemplateVersions(templates model.TemplateListResult) [][]model.TemplateListResultElement {
+	index := map[string]int{}
+	groups := [][]model.TemplateListResultElement{}
+	for _, t := range templates {
+		i, ok := index[t.Identifier]
+		if !ok {
+			i = len(groups)
+			index[t.Identifier] = i
+			groups = append(groups, []model.TemplateListResultElement{})
+		}
+		groups[i] = append(groups[i], t)
+	}
+	for _, group := range groups {
+		sort.SliceStable(group, func(i, j int) bool {
+			return group[i].StableTemplate && !group[j].StableTemplate
+		})
+	}
+	return groups
+}
+
+func (api *ApiRequest) listTemplates(org, project string, logger *zap.Logger) (model.TemplateListResult, error) {
 
 	logger.Info("Fetching templates",
 		zap.String("org", org),
 		zap.String("project", project),
 	)
 
-	IncrementApiCalls()
+	templates := model.TemplateListResult{}
 
-	api := c.api
-	resp, err := api.Client.R().
-		SetHeader("x-api-key", api.Token).
-		SetHeader("Content-Type", "application/json").
-		SetHeader("Harness-Account", api.Account).
-		SetPathParam("org", org).
-		SetPathParam("project", project).
-		SetQueryParams(map[string]string{
-			"limit": "1000",
-		}).
-		Get(api.BaseURL + LIST_TEMPLATES_ENDPOINT)
-	if err != nil {
-		return nil, err
-	}
-	if resp.IsError() {
-		logger.Error("Failed to request to list of templates",
-			zap.Error(err),
-		)
-		return nil, handleErrorResponse(resp)
-	}
+	err := api.paginate(PAGE_SIZE, func(page int) (listPage, error) {
+		api.Stats.IncrementApiCalls()
 
-	result := model.TemplateListResult{}
-	err = json.Unmarshal(resp.Body(), &result)
+		resp, err := api.Client.R().
+			SetHeader("x-api-key", api.Token).
+			SetHeader("Content-Type", "application/json").
+			SetHeader("Harness-Account", api.Account).
+			SetPathParam("org", org).
+			SetPathParam("project", project).
+			SetQueryParams(map[string]string{
+				"page":  strconv.Itoa(page),
+				"limit": strconv.Itoa(PAGE_SIZE),
+				"type":  TEMPLATE_LIST_ALL,
+			}).
+			Get(api.BaseURL + LIST_TEMPLATES_ENDPOINT)
+		if err != nil {
+			return listPage{}, err
+		}
+		if resp.IsError() {
+			logger.Error("Failed to request to list of templates",
+				zap.Error(err),
+			)
+			return listPage{}, handleErrorResponse(resp)
+		}
+
+		result := model.TemplateListResult{}
+		err = json.Unmarshal(resp.Body(), &result)
+		if err != nil {
+			logger.Error("Failed to parse response from API",
+				zap.Error(err),
+			)
+			return listPage{}, err
+		}
+
+		templates = append(templates, result...)
+
+		first := ""
+		if len(result) > 0 {
+			first = result[0].Identifier + "/" + result[0].VersionLabel
+		}
+		return v1Page(resp, page, PAGE_SIZE, len(result), first), nil
+	})
 	if err != nil {
-		logger.Error("Failed to parse response from API",
-			zap.Error(err),
-		)
 		return nil, err
 	}
 
-	return result, nil
+	return templates, nil
 }
 
-func (c TemplateContext) getTemplate(org, project, templateIdentifier, versionLabel string, logger *zap.Logger) (*model.TemplateGetData, error) {
+func (api *ApiRequest) getTemplate(org, project, templateIdentifier, versionLabel string, logger *zap.Logger) (*model.TemplateGetData, error) {
 
 	logger.Info("Getting template",
 		zap.String("template", templateIdentifier),
 		zap.String("project", project),
 	)
 
-	IncrementApiCalls()
+	api.Stats.IncrementApiCalls()
 
-	api := c.api
 	resp, err := api.Client.R().
 		SetHeader("x-api-key", api.Token).
 		SetHeader("Content-Type", "application/json").
@@ -182,6 +381,19 @@ func (c TemplateContext) getTemplate(org, project, templateIdentifier, versionLa
 func (c TemplateContext) createTemplate(org, project, yaml string, logger *zap.Logger) error {
 
 	api := c.api
+	if api.Bundle != nil {
+		return api.Bundle.Add(model.BundleEntry{
+			Entity:      "Template",
+			Identifier:  yamlIdentifier(yaml),
+			Path:        CREATE_TEMPLATE_ENDPOINT,
+			ContentType: "application/json",
+			Params: map[string]string{
+				"orgIdentifier":     org,
+				"projectIdentifier": project,
+			},
+		}, yaml, nil)
+	}
+
 	resp, err := api.Client.R().
 		SetHeader("x-api-key", api.Token).
 		SetHeader("Content-Type", "application/json").
@@ -202,9 +414,9 @@ func (c TemplateContext) createTemplate(org, project, yaml string, logger *zap.L
 		var errorResponse map[string]interface{}
 		if err := json.Unmarshal(resp.Body(), &errorResponse); err == nil {
 			if code, ok := errorResponse["code"].(string); ok && code == "DUPLICATE_FIELD" {
-				// Log as a warning and skip the error
-				logger.Info("Duplicate template found, ignoring error")
-				return nil
+				// The conflict strategy decides what happens to the existing entity
+				logger.Info("Duplicate template found")
+				return ErrAlreadyExists
 			}
 		} else {
 			logger.Error(
@@ -217,5 +429,42 @@ func (c TemplateContext) createTemplate(org, project, yaml string, logger *zap.L
 		return handleErrorResponse(resp)
 	}
 
+	api.recordCreated(model.JournalEntry{
+		Entity:     "Template",
+		Identifier: yamlValue(yaml, "identifier"),
+		Org:        org,
+		Project:    project,
+		Version:    yamlValue(yaml, "versionLabel"),
+	}, logger)
+
 	return nil
 }
+
+func (api *ApiRequest) updateTemplate(org, project, identifier, versionLabel, yaml string, logger *zap.Logger) error {
+	return api.updateEntity(CREATE_TEMPLATE_ENDPOINT+"/update/"+identifier+"/"+versionLabel, "application/yaml", yaml, map[string]string{
+		"orgIdentifier":     org,
+		"projectIdentifier": project,
+	}, logger)
+}
+
+// markStableTemplate marks a version of a template as the stable one
+func (api *ApiRequest) markStableTemplate(org, project, identifier, versionLabel string, logger *zap.Logger) error {
+	path := fmt.Sprintf(STABLE_TEMPLATE_ENDPOINT, identifier, versionLabel)
+	params := map[string]string{
+		"orgIdentifier":     org,
+		"projectIdentifier": project,
+	}
+
+	if api.Bundle != nil {
+		return api.Bundle.Add(model.BundleEntry{
+			Entity:      "Stable Template",
+			Identifier:  identifier + "/" + versionLabel,
+			Method:      resty.MethodPut,
+			Path:        path,
+			ContentType: "application/json",
+			Params:      params,
+		}, nil, nil)
+	}
+
+	return api.updateEntity(path, "application/json", nil, params, logger)
+}
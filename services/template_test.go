@@ -0,0 +1,80 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// newTemplateHarness serves a template with three versions, v2 being the stable one. The
+// versions listed in existing are already in the target.
+func newTemplateHarness(t *testing.T, existing ...string) *fakeHarness {
+	fake := newFakeHarness(t)
+	for _, version := range []string{"v1", "v2", "v3"} {
+		fake.addTemplate("src", "src", "deploy", version, version == "v2")
+	}
+	for _, version := range existing {
+		fake.addTemplate("target", "target", "deploy", version, false)
+	}
+	return fake
+}
+
+// templateCalls lists the versions created and marked stable, in the order they were sent
+func templateCalls(fake *fakeHarness) []string {
+	calls := []string{}
+	for _, r := range fake.sent("", "") {
+		switch {
+		case r.Method == http.MethodPost && r.Path == CREATE_TEMPLATE_ENDPOINT && r.Status == http.StatusOK:
+			body := map[string]map[string]interface{}{}
+			yaml.Unmarshal([]byte(r.Body), &body)
+			calls = append(calls, fmt.Sprint("create ", body["template"]["versionLabel"]))
+		case strings.HasPrefix(r.Path, CREATE_TEMPLATE_ENDPOINT+"/updateStableTemplate/"):
+			calls = append(calls, r.Method+" stable "+path.Base(r.Path)+" "+r.Query.Get("projectIdentifier"))
+		}
+	}
+	return calls
+}
+
+func TestTemplateCopy_EveryVersionAndStable(t *testing.T) {
+	fake := newTemplateHarness(t)
+	api := fake.api()
+
+	err := NewTemplateOperation(api, "src", "src", "target", "target", zap.NewNop(), false).Copy()
+	assert.NoError(t, err)
+
+	lists := fake.sent(http.MethodGet, "/v1/orgs/src/projects/src/templates")
+	assert.NotEmpty(t, lists)
+	for _, list := range lists {
+		assert.Equal(t, TEMPLATE_LIST_ALL, list.Query.Get("type"))
+	}
+	// THE STABLE VERSION IS CREATED FIRST AND MARKED STABLE ONCE EVERY VERSION EXISTS
+	assert.Equal(t, []string{
+		"create v2",
+		"create v1",
+		"create v3",
+		"PUT stable v2 target",
+	}, templateCalls(fake))
+	assert.Equal(t, 3, api.Stats.GetTemplatesMoved())
+}
+
+func TestTemplateCopy_SkippedStableIsNotMarked(t *testing.T) {
+	fake := newTemplateHarness(t, "v2")
+	api := fake.api()
+	api.Conflict = ConflictSkip
+
+	err := NewTemplateOperation(api, "src", "src", "target", "target", zap.NewNop(), false).Copy()
+	assert.NoError(t, err)
+
+	// THE STABLE VERSION ALREADY IN THE TARGET IS KEPT, SO THE TARGET KEEPS ITS STABLE VERSION
+	assert.Equal(t, []string{
+		"create v1",
+		"create v3",
+	}, templateCalls(fake))
+	assert.Equal(t, 3, api.Stats.GetTemplatesMoved())
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"encoding/json"
+	"strconv"
 
 	"github.com/schollz/progressbar/v3"
 	"go.uber.org/zap"
@@ -41,7 +42,7 @@ func (c TriggerContext) Copy() error {
 	triggers := []*model.TriggerContent{}
 
 	// Leveraging listPipelines func from pipeline.go file
-	pipelines, err := c.api.listPipelines(c.sourceOrg, c.sourceProject, c.logger)
+	pipelines, err := c.api.source().listPipelines(c.sourceOrg, c.sourceProject, c.logger)
 	if err != nil {
 		c.logger.Error("Failed to retrive pipelines for project",
 			zap.String("Project", c.sourceProject),
@@ -51,7 +52,7 @@ func (c TriggerContext) Copy() error {
 	}
 
 	for _, p := range pipelines {
-		triggerLists, err := c.api.listPipelineTriggers(p.Identifier, c.sourceOrg, c.sourceProject, c.logger)
+		triggerLists, err := c.api.source().listPipelineTriggers(p.Identifier, c.sourceOrg, c.sourceProject, c.logger)
 		if err != nil {
 			c.logger.Error("Getting pipeline details",
 				zap.String("pipeline", p.Identifier),
@@ -64,6 +65,23 @@ func (c TriggerContext) Copy() error {
 		triggers = append(triggers, triggerLists...)
 	}
 
+	existing := map[string]bool{}
+	if c.api.Plan != nil {
+		for _, p := range pipelines {
+			targetTriggers, err := c.api.listPipelineTriggers(c.api.Rename.Identifier("Pipeline", p.Identifier), c.targetOrg, c.targetProject, c.logger)
+			if err != nil {
+				c.logger.Error("Failed to list the triggers of the target project",
+					zap.String("Project", c.targetProject),
+					zap.Error(err),
+				)
+				return err
+			}
+			for _, t := range targetTriggers {
+				existing[t.Identifier] = true
+			}
+		}
+	}
+
 	var bar *progressbar.ProgressBar
 
 	if c.showPB {
@@ -72,27 +90,64 @@ func (c TriggerContext) Copy() error {
 
 	for _, t := range triggers {
 
-		IncrementTriggersTotal()
+		c.api.Stats.IncrementTriggersTotal()
+
+		if c.api.Checkpoint.Done("Trigger", t.Identifier) {
+			c.logger.Info("Skipping trigger copied by a previous run",
+				zap.String("identifier", t.Identifier),
+			)
+			c.api.Stats.IncrementTriggersMoved()
+			if c.showPB {
+				bar.Add(1)
+			}
+			continue
+		}
 
 		c.logger.Info("Processing trigger",
 			zap.String("trigger", t.Name),
 			zap.String("targetProject", c.targetProject),
 		)
 
+		if c.api.Plan != nil {
+			c.api.Plan.Record("Trigger", t.Identifier, t.Name, existing[c.api.Rename.Identifier("Trigger", t.Identifier)], checkYaml(t.YAML))
+			if c.showPB {
+				bar.Add(1)
+			}
+			continue
+		}
+
+		pipeline := yamlValue(t.YAML, "pipelineIdentifier")
+		if c.api.blocked("Trigger", pipeline+"/"+t.Identifier, c.logger) {
+			if c.showPB {
+				bar.Add(1)
+			}
+			continue
+		}
+
+		identifier := t.Identifier
+		c.api.Rename.Entity("Trigger", &t.Identifier, &t.Name)
+
 		t.OrgIdentifier = c.targetOrg
 		t.ProjectIdentifier = c.targetProject
-		newYaml := updateYaml(t.YAML, c.targetOrg, c.targetProject)
+		newYaml := c.api.Rename.Yaml(updateYaml(t.YAML, c.targetOrg, c.targetProject))
 		t.YAML = newYaml
 
 		err = c.api.createPipelineTrigger(t, c.logger)
+		err = c.api.resolveConflict("Trigger", identifier, err, func() error {
+			return c.api.updatePipelineTrigger(t, c.logger)
+		}, c.logger)
 
 		if err != nil {
 			c.logger.Error("Failed to create trigger",
 				zap.String("trigger", t.Name),
 				zap.Error(err),
 			)
+			c.api.Graph.Failed("Trigger", pipeline+"/"+identifier)
 		} else {
-			IncrementTriggersMoved()
+			c.api.Stats.IncrementTriggersMoved()
+			if err := c.api.Checkpoint.Record("Trigger", identifier); err != nil {
+				c.logger.Warn("Failed to update checkpoint", zap.Error(err))
+			}
 		}
 		if c.showPB {
 			bar.Add(1)
@@ -112,49 +167,62 @@ func (api *ApiRequest) listPipelineTriggers(piplineId, org, project string, logg
 		zap.String("project", project),
 	)
 
-	IncrementApiCalls()
+	triggers := []*model.TriggerContent{}
 
-	resp, err := api.Client.R().
-		SetHeader("x-api-key", api.Token).
-		SetHeader("Content-Type", "application/json").
-		SetQueryParams(map[string]string{
-			"accountIdentifier": api.Account,
-			"orgIdentifier":     org,
-			"projectIdentifier": project,
-			"targetIdentifier":  piplineId,
-			"size":              "100",
-		}).
-		Get(api.BaseURL + TRIGGER)
-	if err != nil {
-		logger.Error("Failed to request to list of triggers",
-			zap.Error(err),
-		)
-		return nil, err
-	}
-	if resp.IsError() {
-		logger.Error("Error response from API when listing triggers",
-			zap.String("response",
-				resp.String(),
-			),
-		)
-		return nil, handleErrorResponse(resp)
-	}
+	err := api.paginate(PAGE_SIZE, func(page int) (listPage, error) {
+		api.Stats.IncrementApiCalls()
+
+		resp, err := api.Client.R().
+			SetHeader("x-api-key", api.Token).
+			SetHeader("Content-Type", "application/json").
+			SetQueryParams(map[string]string{
+				"accountIdentifier": api.Account,
+				"orgIdentifier":     org,
+				"projectIdentifier": project,
+				"targetIdentifier":  piplineId,
+				"page":              strconv.Itoa(page),
+				"size":              strconv.Itoa(PAGE_SIZE),
+			}).
+			Get(api.BaseURL + TRIGGER)
+		if err != nil {
+			logger.Error("Failed to request to list of triggers",
+				zap.Error(err),
+			)
+			return listPage{}, err
+		}
+		if resp.IsError() {
+			logger.Error("Error response from API when listing triggers",
+				zap.String("response",
+					resp.String(),
+				),
+			)
+			return listPage{}, handleErrorResponse(resp)
+		}
 
-	result := model.GetTriggerResposne{}
-	err = json.Unmarshal(resp.Body(), &result)
+		result := model.GetTriggerResposne{}
+		err = json.Unmarshal(resp.Body(), &result)
+		if err != nil {
+			logger.Error("Failed to parse response from API",
+				zap.Error(err),
+			)
+			return listPage{}, err
+		}
+
+		for _, c := range result.Data.Content {
+			newTrigger := c
+			triggers = append(triggers, &newTrigger)
+		}
+
+		return listPage{
+			index: int(result.Data.PageIndex),
+			total: int(result.Data.TotalPages),
+			items: len(result.Data.Content),
+		}, nil
+	})
 	if err != nil {
-		logger.Error("Failed to parse response from API",
-			zap.Error(err),
-		)
 		return nil, err
 	}
 
-	triggers := []*model.TriggerContent{}
-	for _, c := range result.Data.Content {
-		newTrigger := c
-		triggers = append(triggers, &newTrigger)
-	}
-
 	return triggers, nil
 }
 
@@ -165,7 +233,21 @@ func (api *ApiRequest) createPipelineTrigger(trigger *model.TriggerContent, logg
 		zap.String("project", trigger.ProjectIdentifier),
 	)
 
-	IncrementApiCalls()
+	if api.Bundle != nil {
+		return api.Bundle.Add(model.BundleEntry{
+			Entity:      "Trigger",
+			Identifier:  trigger.Identifier,
+			Path:        TRIGGER,
+			ContentType: "application/json",
+			Params: map[string]string{
+				"orgIdentifier":     trigger.OrgIdentifier,
+				"projectIdentifier": trigger.ProjectIdentifier,
+				"targetIdentifier":  trigger.Identifier,
+			},
+		}, trigger.YAML, nil)
+	}
+
+	api.Stats.IncrementApiCalls()
 
 	resp, err := api.Client.R().
 		SetHeader("x-api-key", api.Token).
@@ -190,11 +272,11 @@ func (api *ApiRequest) createPipelineTrigger(trigger *model.TriggerContent, logg
 		var errorResponse map[string]interface{}
 		if err := json.Unmarshal(resp.Body(), &errorResponse); err == nil {
 			if code, ok := errorResponse["code"].(string); ok && code == "DUPLICATE_FIELD" {
-				// Log as a warning and skip the error
-				logger.Info("Duplicate trigger found, ignoring error",
+				// The conflict strategy decides what happens to the existing entity
+				logger.Info("Duplicate trigger found",
 					zap.String("trigger", trigger.Name),
 				)
-				return nil
+				return ErrAlreadyExists
 			}
 		} else {
 			logger.Error(
@@ -208,5 +290,21 @@ func (api *ApiRequest) createPipelineTrigger(trigger *model.TriggerContent, logg
 		return handleErrorResponse(resp)
 	}
 
+	api.recordCreated(model.JournalEntry{
+		Entity:     "Trigger",
+		Identifier: trigger.Identifier,
+		Org:        trigger.OrgIdentifier,
+		Project:    trigger.ProjectIdentifier,
+		Pipeline:   yamlValue(trigger.YAML, "pipelineIdentifier"),
+	}, logger)
+
 	return nil
 }
+
+func (api *ApiRequest) updatePipelineTrigger(trigger *model.TriggerContent, logger *zap.Logger) error {
+	return api.updateEntity(TRIGGER+"/"+trigger.Identifier, "application/json", trigger.YAML, map[string]string{
+		"orgIdentifier":     trigger.OrgIdentifier,
+		"projectIdentifier": trigger.ProjectIdentifier,
+		"targetIdentifier":  trigger.Identifier,
+	}, logger)
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"encoding/json"
+	"strconv"
 
 	"github.com/schollz/progressbar/v3"
 	"go.uber.org/zap"
@@ -39,7 +40,7 @@ func (c UserGroupContext) Copy() error {
 		zap.String("project", c.sourceProject),
 	)
 
-	groups, err := c.api.listUserGroups(c.sourceOrg, c.sourceProject, c.logger)
+	groups, err := c.api.source().listUserGroups(c.sourceOrg, c.sourceProject, c.logger)
 	if err != nil {
 		c.logger.Error("Failed to retrive user group",
 			zap.String("Project", c.sourceProject),
@@ -48,6 +49,21 @@ func (c UserGroupContext) Copy() error {
 		return err
 	}
 
+	existing := map[string]bool{}
+	if c.api.Plan != nil {
+		targetGroups, err := c.api.listUserGroups(c.targetOrg, c.targetProject, c.logger)
+		if err != nil {
+			c.logger.Error("Failed to list the user groups of the target project",
+				zap.String("Project", c.targetProject),
+				zap.Error(err),
+			)
+			return err
+		}
+		for _, g := range targetGroups {
+			existing[g.Identifier] = true
+		}
+	}
+
 	var bar *progressbar.ProgressBar
 
 	if c.showPB {
@@ -56,13 +72,32 @@ func (c UserGroupContext) Copy() error {
 
 	for _, g := range groups {
 
-		IncrementUserGroupsTotal()
+		c.api.Stats.IncrementUserGroupsTotal()
+
+		if c.api.Checkpoint.Done("User Group", g.Identifier) {
+			c.logger.Info("Skipping user group copied by a previous run",
+				zap.String("identifier", g.Identifier),
+			)
+			c.api.Stats.IncrementUserGroupsMoved()
+			if c.showPB {
+				bar.Add(1)
+			}
+			continue
+		}
 
 		c.logger.Info("Processing user group",
 			zap.String("user group", g.Name),
 			zap.String("sourceProject", c.sourceProject),
 		)
 
+		if c.api.Plan != nil {
+			c.api.Plan.Record("User Group", g.Identifier, g.Name, existing[c.api.Rename.Identifier("User Group", g.Identifier)], nil)
+			if c.showPB {
+				bar.Add(1)
+			}
+			continue
+		}
+
 		for i := range g.Users {
 
 			user := &model.UserGroupLookup{
@@ -71,7 +106,7 @@ func (c UserGroupContext) Copy() error {
 				ProjectIdentifier: c.sourceProject,
 			}
 
-			userEmail, err := c.api.getUsersEmail(user, c.logger)
+			userEmail, err := c.api.source().getUsersEmail(user, c.logger)
 
 			if err != nil {
 				c.logger.Error("Failed to get user email",
@@ -83,10 +118,16 @@ func (c UserGroupContext) Copy() error {
 			g.Users = append(g.Users, userEmail.EmailAddress)
 		}
 
+		identifier := g.Identifier
+		c.api.Rename.Entity("User Group", &g.Identifier, &g.Name)
+
 		g.OrgIdentifier = c.targetOrg
 		g.ProjectIdentifier = c.targetProject
 
 		err = c.api.addUserGroup(g, c.logger)
+		err = c.api.resolveConflict("User Group", identifier, err, func() error {
+			return c.api.updateUserGroup(g, c.logger)
+		}, c.logger)
 
 		if err != nil {
 			c.logger.Error("Failed to create group",
@@ -94,7 +135,10 @@ func (c UserGroupContext) Copy() error {
 				zap.Error(err),
 			)
 		} else {
-			IncrementUserGroupsMoved()
+			c.api.Stats.IncrementUserGroupsMoved()
+			if err := c.api.Checkpoint.Record("User Group", identifier); err != nil {
+				c.logger.Warn("Failed to update checkpoint", zap.Error(err))
+			}
 		}
 		if c.showPB {
 			bar.Add(1)
@@ -114,52 +158,67 @@ func (api *ApiRequest) listUserGroups(org, project string, logger *zap.Logger) (
 		zap.String("project", project),
 	)
 
-	resp, err := api.Client.R().
-		SetHeader("x-api-key", api.Token).
-		SetHeader("Content-Type", "application/json").
-		SetQueryParams(map[string]string{
-			"accountIdentifier": api.Account,
-			"orgIdentifier":     org,
-			"projectIdentifier": project,
-			"pageSize":          "100",
-		}).
-		Get(api.BaseURL + USERGROUP)
-	if err != nil {
-		logger.Error("Failed to request to list of user groups",
-			zap.Error(err),
-		)
-		return nil, err
-	}
-	if resp.IsError() {
-
-		logger.Error("Error response from API when listing user groups",
-			zap.String("response",
-				resp.String(),
-			),
-		)
-		return nil, handleErrorResponse(resp)
-	}
+	userGroups := []*model.UserGroup{}
 
-	result := model.GetUserGroupsResponse{}
-	err = json.Unmarshal(resp.Body(), &result)
-	if err != nil {
-		logger.Error("Failed to parse response from API",
-			zap.Error(err),
-		)
+	err := api.paginate(PAGE_SIZE, func(page int) (listPage, error) {
+		api.Stats.IncrementApiCalls()
+
+		resp, err := api.Client.R().
+			SetHeader("x-api-key", api.Token).
+			SetHeader("Content-Type", "application/json").
+			SetQueryParams(map[string]string{
+				"accountIdentifier": api.Account,
+				"orgIdentifier":     org,
+				"projectIdentifier": project,
+				"pageIndex":         strconv.Itoa(page),
+				"pageSize":          strconv.Itoa(PAGE_SIZE),
+			}).
+			Get(api.BaseURL + USERGROUP)
+		if err != nil {
+			logger.Error("Failed to request to list of user groups",
+				zap.Error(err),
+			)
+			return listPage{}, err
+		}
+		if resp.IsError() {
 
-		return nil, err
-	}
+			logger.Error("Error response from API when listing user groups",
+				zap.String("response",
+					resp.String(),
+				),
+			)
+			return listPage{}, handleErrorResponse(resp)
+		}
 
-	userGroups := []*model.UserGroup{}
-	for _, c := range result.Data.Content {
-		if !c.HarnessManaged {
-			userGroups = append(userGroups, c)
-		} else {
-			logger.Warn("Skipping user group because it is Harness managed",
-				zap.String("user group", c.Name),
-				zap.Bool("harnessManaged", c.HarnessManaged),
+		result := model.GetUserGroupsResponse{}
+		err = json.Unmarshal(resp.Body(), &result)
+		if err != nil {
+			logger.Error("Failed to parse response from API",
+				zap.Error(err),
 			)
+
+			return listPage{}, err
+		}
+
+		for _, c := range result.Data.Content {
+			if !c.HarnessManaged {
+				userGroups = append(userGroups, c)
+			} else {
+				logger.Warn("Skipping user group because it is Harness managed",
+					zap.String("user group", c.Name),
+					zap.Bool("harnessManaged", c.HarnessManaged),
+				)
+			}
 		}
+
+		return listPage{
+			index: int(result.Data.PageIndex),
+			total: int(result.Data.TotalPages),
+			items: len(result.Data.Content),
+		}, nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return userGroups, nil
@@ -171,7 +230,7 @@ func (api *ApiRequest) getUsersEmail(user *model.UserGroupLookup, logger *zap.Lo
 		zap.String("user", user.Identifier),
 	)
 
-	IncrementApiCalls()
+	api.Stats.IncrementApiCalls()
 
 	resp, err := api.Client.R().
 		SetHeader("x-api-key", api.Token).
@@ -218,7 +277,20 @@ func (api *ApiRequest) addUserGroup(userGroup *model.UserGroup, logger *zap.Logg
 		zap.String("project", userGroup.ProjectIdentifier),
 	)
 
-	IncrementApiCalls()
+	if api.Bundle != nil {
+		return api.Bundle.Add(model.BundleEntry{
+			Entity:      "User Group",
+			Identifier:  userGroup.Identifier,
+			Path:        USERGROUP,
+			ContentType: "application/json",
+			Params: map[string]string{
+				"orgIdentifier":     userGroup.OrgIdentifier,
+				"projectIdentifier": userGroup.ProjectIdentifier,
+			},
+		}, userGroup, nil)
+	}
+
+	api.Stats.IncrementApiCalls()
 
 	resp, err := api.Client.R().
 		SetHeader("x-api-key", api.Token).
@@ -242,11 +314,11 @@ func (api *ApiRequest) addUserGroup(userGroup *model.UserGroup, logger *zap.Logg
 		var errorResponse map[string]interface{}
 		if err := json.Unmarshal(resp.Body(), &errorResponse); err == nil {
 			if code, ok := errorResponse["code"].(string); ok && code == "DUPLICATE_FIELD" {
-				// Log as a warning and skip the error
-				logger.Info("Duplicate user group found, ignoring error",
+				// The conflict strategy decides what happens to the existing entity
+				logger.Info("Duplicate user group found",
 					zap.String("user group", userGroup.Name),
 				)
-				return nil
+				return ErrAlreadyExists
 			}
 		} else {
 			logger.Error(
@@ -260,5 +332,19 @@ func (api *ApiRequest) addUserGroup(userGroup *model.UserGroup, logger *zap.Logg
 		return handleErrorResponse(resp)
 	}
 
+	api.recordCreated(model.JournalEntry{
+		Entity:     "User Group",
+		Identifier: userGroup.Identifier,
+		Org:        userGroup.OrgIdentifier,
+		Project:    userGroup.ProjectIdentifier,
+	}, logger)
+
 	return nil
 }
+
+func (api *ApiRequest) updateUserGroup(userGroup *model.UserGroup, logger *zap.Logger) error {
+	return api.updateEntity(USERGROUP, "application/json", userGroup, map[string]string{
+		"orgIdentifier":     userGroup.OrgIdentifier,
+		"projectIdentifier": userGroup.ProjectIdentifier,
+	}, logger)
+}
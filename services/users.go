@@ -2,6 +2,7 @@ package services
 
 import (
 	"encoding/json"
+	"strconv"
 
 	"github.com/schollz/progressbar/v3"
 	"go.uber.org/zap"
@@ -57,7 +58,7 @@ func (c UserScopeContext) Copy() error {
 		zap.String("project", c.sourceProject),
 	)
 
-	users, err := c.api.listUsers(c.sourceOrg, c.sourceProject, c.logger)
+	users, err := c.api.source().listUsers(c.sourceOrg, c.sourceProject, c.logger)
 	if err != nil {
 		c.logger.Error("Failed to retrive users",
 			zap.String("Project", c.sourceProject),
@@ -66,6 +67,21 @@ func (c UserScopeContext) Copy() error {
 		return err
 	}
 
+	existing := map[string]bool{}
+	if c.api.Plan != nil {
+		targetUsers, err := c.api.listUsers(c.targetOrg, c.targetProject, c.logger)
+		if err != nil {
+			c.logger.Error("Failed to list the users of the target project",
+				zap.String("Project", c.targetProject),
+				zap.Error(err),
+			)
+			return err
+		}
+		for _, u := range targetUsers {
+			existing[u.Email] = true
+		}
+	}
+
 	var bar *progressbar.ProgressBar
 
 	if c.showPB {
@@ -74,13 +90,32 @@ func (c UserScopeContext) Copy() error {
 
 	for _, u := range users {
 
-		IncrementUsersTotal()
+		c.api.Stats.IncrementUsersTotal()
+
+		if c.api.Checkpoint.Done("User", u.Email) {
+			c.logger.Info("Skipping user copied by a previous run",
+				zap.String("identifier", u.Email),
+			)
+			c.api.Stats.IncrementUsersMoved()
+			if c.showPB {
+				bar.Add(1)
+			}
+			continue
+		}
 
 		c.logger.Info("Processing user",
 			zap.String("user", u.Name),
 			zap.String("sourceProject", c.sourceProject),
 		)
 
+		if c.api.Plan != nil {
+			c.api.Plan.Record("User", u.Email, u.Name, existing[u.Email], nil)
+			if c.showPB {
+				bar.Add(1)
+			}
+			continue
+		}
+
 		userToAdd := &model.UserEmail{
 			EmailAddress:      []string{u.Email},
 			OrgIdentifier:     c.targetOrg,
@@ -88,6 +123,7 @@ func (c UserScopeContext) Copy() error {
 		}
 
 		err = c.api.addUserToScope(userToAdd, c.logger)
+		err = c.api.resolveConflict("User", u.Email, err, keepExisting, c.logger)
 
 		if err != nil {
 			c.logger.Error("Failed to create user",
@@ -95,7 +131,10 @@ func (c UserScopeContext) Copy() error {
 				zap.Error(err),
 			)
 		} else {
-			IncrementUsersMoved()
+			c.api.Stats.IncrementUsersMoved()
+			if err := c.api.Checkpoint.Record("User", u.Email); err != nil {
+				c.logger.Warn("Failed to update checkpoint", zap.Error(err))
+			}
 		}
 		if c.showPB {
 			bar.Add(1)
@@ -110,6 +149,11 @@ func (c UserScopeContext) Copy() error {
 
 func (c RemoveUserScopeContext) Copy() error {
 
+	// THE CURRENT USER IS ONLY ADDED WHEN THE PROJECT IS CREATED
+	if c.api.Plan != nil {
+		return nil
+	}
+
 	c.logger.Info("Removing current user assignment",
 		zap.String("project", c.targetProject),
 	)
@@ -142,48 +186,62 @@ func (api *ApiRequest) listUsers(org, project string, logger *zap.Logger) ([]*mo
 		zap.String("project", project),
 	)
 
-	IncrementApiCalls()
+	users := []*model.User{}
 
-	resp, err := api.Client.R().
-		SetHeader("x-api-key", api.Token).
-		SetHeader("Content-Type", "application/json").
-		SetQueryParams(map[string]string{
-			"accountIdentifier": api.Account,
-			"orgIdentifier":     org,
-			"projectIdentifier": project,
-			"pageSize":          "100",
-		}).
-		Post(api.BaseURL + LISTUSER)
-	if err != nil {
-		logger.Error("Failed to request to list of users",
-			zap.Error(err),
-		)
-		return nil, err
-	}
-	if resp.IsError() {
-		logger.Error("Error response from API when listing users",
-			zap.String("response",
-				resp.String(),
-			),
-		)
-		return nil, handleErrorResponse(resp)
-	}
+	err := api.paginate(PAGE_SIZE, func(page int) (listPage, error) {
+		api.Stats.IncrementApiCalls()
+
+		resp, err := api.Client.R().
+			AddRetryCondition(retryIdempotent).
+			SetHeader("x-api-key", api.Token).
+			SetHeader("Content-Type", "application/json").
+			SetQueryParams(map[string]string{
+				"accountIdentifier": api.Account,
+				"orgIdentifier":     org,
+				"projectIdentifier": project,
+				"pageIndex":         strconv.Itoa(page),
+				"pageSize":          strconv.Itoa(PAGE_SIZE),
+			}).
+			Post(api.BaseURL + LISTUSER)
+		if err != nil {
+			logger.Error("Failed to request to list of users",
+				zap.Error(err),
+			)
+			return listPage{}, err
+		}
+		if resp.IsError() {
+			logger.Error("Error response from API when listing users",
+				zap.String("response",
+					resp.String(),
+				),
+			)
+			return listPage{}, handleErrorResponse(resp)
+		}
 
-	result := model.GetUserResponse{}
-	err = json.Unmarshal(resp.Body(), &result)
+		result := model.GetUserResponse{}
+		err = json.Unmarshal(resp.Body(), &result)
+		if err != nil {
+			logger.Error("Failed to parse response from API",
+				zap.Error(err),
+			)
+			return listPage{}, err
+		}
+
+		for _, c := range result.Data.Content {
+			newUser := c.User
+			users = append(users, &newUser)
+		}
+
+		return listPage{
+			index: int(result.Data.PageIndex),
+			total: int(result.Data.TotalPages),
+			items: len(result.Data.Content),
+		}, nil
+	})
 	if err != nil {
-		logger.Error("Failed to parse response from API",
-			zap.Error(err),
-		)
 		return nil, err
 	}
 
-	users := []*model.User{}
-	for _, c := range result.Data.Content {
-		newUser := c.User
-		users = append(users, &newUser)
-	}
-
 	return users, nil
 }
 
@@ -194,7 +252,20 @@ func (api *ApiRequest) addUserToScope(user *model.UserEmail, logger *zap.Logger)
 		zap.String("project", user.ProjectIdentifier),
 	)
 
-	IncrementApiCalls()
+	if api.Bundle != nil {
+		return api.Bundle.Add(model.BundleEntry{
+			Entity:      "User",
+			Identifier:  user.EmailAddress[0],
+			Path:        ADDUSER,
+			ContentType: "application/json",
+			Params: map[string]string{
+				"orgIdentifier":     user.OrgIdentifier,
+				"projectIdentifier": user.ProjectIdentifier,
+			},
+		}, user, nil)
+	}
+
+	api.Stats.IncrementApiCalls()
 
 	resp, err := api.Client.R().
 		SetHeader("x-api-key", api.Token).
@@ -218,11 +289,11 @@ func (api *ApiRequest) addUserToScope(user *model.UserEmail, logger *zap.Logger)
 		var errorResponse map[string]interface{}
 		if err := json.Unmarshal(resp.Body(), &errorResponse); err == nil {
 			if code, ok := errorResponse["code"].(string); ok && code == "DUPLICATE_FIELD" {
-				// Log as a warning and skip the error
-				logger.Info("Duplicate user found, ignoring error",
+				// The conflict strategy decides what happens to the existing entity
+				logger.Info("Duplicate user found",
 					zap.String("user", user.EmailAddress[0]),
 				)
-				return nil
+				return ErrAlreadyExists
 			}
 		} else {
 			logger.Error(
@@ -236,6 +307,13 @@ func (api *ApiRequest) addUserToScope(user *model.UserEmail, logger *zap.Logger)
 		return handleErrorResponse(resp)
 	}
 
+	api.recordCreated(model.JournalEntry{
+		Entity:     "User",
+		Identifier: user.EmailAddress[0],
+		Org:        user.OrgIdentifier,
+		Project:    user.ProjectIdentifier,
+	}, logger)
+
 	return nil
 }
 
@@ -246,7 +324,7 @@ func (api *ApiRequest) getCurrentUserInfo(org, project string, logger *zap.Logge
 		zap.String("project", project),
 	)
 
-	IncrementApiCalls()
+	api.Stats.IncrementApiCalls()
 
 	resp, err := api.Client.R().
 		SetHeader("x-api-key", api.Token).
@@ -292,7 +370,7 @@ func (api *ApiRequest) RemoveCurrentUserAccess(user model.User, org, project str
 		zap.String("project", project),
 	)
 
-	IncrementApiCalls()
+	api.Stats.IncrementApiCalls()
 
 	resp, err := api.Client.R().
 		SetHeader("x-api-key", api.Token).
@@ -315,7 +393,7 @@ func (api *ApiRequest) RemoveCurrentUserAccess(user model.User, org, project str
 				resp.String(),
 			),
 		)
-		return handleErrorResponse(resp)
+		return ignoreAlreadyExists(handleErrorResponse(resp))
 	}
 
 	result := model.RemoveUserResponse{}
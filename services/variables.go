@@ -2,6 +2,7 @@ package services
 
 import (
 	"encoding/json"
+	"strconv"
 
 	"github.com/schollz/progressbar/v3"
 	"go.uber.org/zap"
@@ -36,7 +37,7 @@ func (c VariableContext) Copy() error {
 		zap.String("project", c.sourceProject),
 	)
 
-	variables, err := c.api.listVariables(c.sourceOrg, c.sourceProject, c.logger)
+	variables, err := c.api.source().listVariables(c.sourceOrg, c.sourceProject, c.logger)
 	if err != nil {
 		c.logger.Error("Failed to retrive variables",
 			zap.String("Project", c.sourceProject),
@@ -45,6 +46,21 @@ func (c VariableContext) Copy() error {
 		return err
 	}
 
+	existing := map[string]bool{}
+	if c.api.Plan != nil {
+		targetVariables, err := c.api.listVariables(c.targetOrg, c.targetProject, c.logger)
+		if err != nil {
+			c.logger.Error("Failed to list the variables of the target project",
+				zap.String("Project", c.targetProject),
+				zap.Error(err),
+			)
+			return err
+		}
+		for _, v := range targetVariables {
+			existing[v.Identifier] = true
+		}
+	}
+
 	var bar *progressbar.ProgressBar
 
 	if c.showPB {
@@ -53,26 +69,63 @@ func (c VariableContext) Copy() error {
 
 	for _, v := range variables {
 
-		IncrementVariablesTotal()
+		c.api.Stats.IncrementVariablesTotal()
+
+		if c.api.Checkpoint.Done("Variable", v.Identifier) {
+			c.logger.Info("Skipping variable copied by a previous run",
+				zap.String("identifier", v.Identifier),
+			)
+			c.api.Stats.IncrementVariablesMoved()
+			if c.showPB {
+				bar.Add(1)
+			}
+			continue
+		}
 
 		c.logger.Info("Processing variable",
 			zap.String("variable", v.Name),
 			zap.String("targetProject", c.targetProject),
 		)
 
+		if c.api.Plan != nil {
+			c.api.Plan.Record("Variable", v.Identifier, v.Name, existing[c.api.Rename.Identifier("Variable", v.Identifier)], nil)
+			if c.showPB {
+				bar.Add(1)
+			}
+			continue
+		}
+
+		if c.api.blocked("Variable", v.Identifier, c.logger) {
+			if c.showPB {
+				bar.Add(1)
+			}
+			continue
+		}
+
+		identifier := v.Identifier
+		c.api.Rename.Entity("Variable", &v.Identifier, &v.Name)
+
 		v.OrgIdentifier = c.targetOrg
 		v.ProjectIdentifier = c.targetProject
 
-		err = c.api.createVariable(&model.CreateVariableRequest{
+		req := &model.CreateVariableRequest{
 			Variable: v,
+		}
+		err = c.api.createVariable(req, c.logger)
+		err = c.api.resolveConflict("Variable", identifier, err, func() error {
+			return c.api.updateVariable(req, c.logger)
 		}, c.logger)
 		if err != nil {
 			c.logger.Error("Failed to create variable",
 				zap.String("variable", v.Name),
 				zap.Error(err),
 			)
+			c.api.Graph.Failed("Variable", identifier)
 		} else {
-			IncrementVariablesMoved()
+			c.api.Stats.IncrementVariablesMoved()
+			if err := c.api.Checkpoint.Record("Variable", identifier); err != nil {
+				c.logger.Warn("Failed to update checkpoint", zap.Error(err))
+			}
 		}
 		if c.showPB {
 			bar.Add(1)
@@ -92,45 +145,60 @@ func (api *ApiRequest) listVariables(org, project string, logger *zap.Logger) ([
 		zap.String("project", project),
 	)
 
-	resp, err := api.Client.R().
-		SetHeader("x-api-key", api.Token).
-		SetHeader("Content-Type", "application/json").
-		SetQueryParams(map[string]string{
-			"accountIdentifier": api.Account,
-			"orgIdentifier":     org,
-			"projectIdentifier": project,
-			"size":              "1000",
-		}).
-		Get(api.BaseURL + "/ng/api/variables")
-	if err != nil {
-		logger.Error("Failed to request to list of variables",
-			zap.Error(err),
-		)
-		return nil, err
-	}
-	if resp.IsError() {
-		logger.Error("Error response from API when listing variables",
-			zap.String("response",
-				resp.String(),
-			),
-		)
-		return nil, handleErrorResponse(resp)
-	}
+	variables := []*model.Variable{}
+
+	err := api.paginate(PAGE_SIZE, func(page int) (listPage, error) {
+		api.Stats.IncrementApiCalls()
+
+		resp, err := api.Client.R().
+			SetHeader("x-api-key", api.Token).
+			SetHeader("Content-Type", "application/json").
+			SetQueryParams(map[string]string{
+				"accountIdentifier": api.Account,
+				"orgIdentifier":     org,
+				"projectIdentifier": project,
+				"pageIndex":         strconv.Itoa(page),
+				"pageSize":          strconv.Itoa(PAGE_SIZE),
+			}).
+			Get(api.BaseURL + "/ng/api/variables")
+		if err != nil {
+			logger.Error("Failed to request to list of variables",
+				zap.Error(err),
+			)
+			return listPage{}, err
+		}
+		if resp.IsError() {
+			logger.Error("Error response from API when listing variables",
+				zap.String("response",
+					resp.String(),
+				),
+			)
+			return listPage{}, handleErrorResponse(resp)
+		}
 
-	result := model.GetVariablesResponse{}
-	err = json.Unmarshal(resp.Body(), &result)
+		result := model.GetVariablesResponse{}
+		err = json.Unmarshal(resp.Body(), &result)
+		if err != nil {
+			logger.Error("Failed to parse response from API",
+				zap.Error(err),
+			)
+			return listPage{}, err
+		}
+
+		for _, c := range result.Data.Content {
+			variables = append(variables, c.Variable)
+		}
+
+		return listPage{
+			index: int(result.Data.PageIndex),
+			total: int(result.Data.TotalPages),
+			items: len(result.Data.Content),
+		}, nil
+	})
 	if err != nil {
-		logger.Error("Failed to parse response from API",
-			zap.Error(err),
-		)
 		return nil, err
 	}
 
-	variables := []*model.Variable{}
-	for _, c := range result.Data.Content {
-		variables = append(variables, c.Variable)
-	}
-
 	return variables, nil
 }
 
@@ -141,7 +209,16 @@ func (api *ApiRequest) createVariable(variable *model.CreateVariableRequest, log
 		zap.String("project", variable.Variable.ProjectIdentifier),
 	)
 
-	IncrementApiCalls()
+	if api.Bundle != nil {
+		return api.Bundle.Add(model.BundleEntry{
+			Entity:      "Variable",
+			Identifier:  variable.Variable.Identifier,
+			Path:        "/ng/api/variables",
+			ContentType: "application/json",
+		}, variable, nil)
+	}
+
+	api.Stats.IncrementApiCalls()
 
 	resp, err := api.Client.R().
 		SetHeader("x-api-key", api.Token).
@@ -162,11 +239,11 @@ func (api *ApiRequest) createVariable(variable *model.CreateVariableRequest, log
 		var errorResponse map[string]interface{}
 		if err := json.Unmarshal(resp.Body(), &errorResponse); err == nil {
 			if code, ok := errorResponse["code"].(string); ok && code == "DUPLICATE_FIELD" {
-				// Log as a warning and skip the error
-				logger.Info("Duplicate variable found, ignoring error",
+				// The conflict strategy decides what happens to the existing entity
+				logger.Info("Duplicate variable found",
 					zap.String("variable", variable.Variable.Name),
 				)
-				return nil
+				return ErrAlreadyExists
 			}
 		} else {
 			logger.Error(
@@ -180,5 +257,16 @@ func (api *ApiRequest) createVariable(variable *model.CreateVariableRequest, log
 		return handleErrorResponse(resp)
 	}
 
+	api.recordCreated(model.JournalEntry{
+		Entity:     "Variable",
+		Identifier: variable.Variable.Identifier,
+		Org:        variable.Variable.OrgIdentifier,
+		Project:    variable.Variable.ProjectIdentifier,
+	}, logger)
+
 	return nil
 }
+
+func (api *ApiRequest) updateVariable(variable *model.CreateVariableRequest, logger *zap.Logger) error {
+	return api.updateEntity("/ng/api/variables", "application/json", variable, nil, logger)
+}
@@ -0,0 +1,540 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+	"harness-copy-project/model"
+)
+
+const (
+	VerifyMissing   = "missing"
+	VerifyExtra     = "extra"
+	VerifyDivergent = "divergent"
+)
+
+// The number of differing fields reported for a divergent entity
+const MAX_VERIFY_FIELDS = 10
+
+// verifyIgnoredFields are tied to the project or generated by the server, so they always
+// differ between the source and the target and are removed before comparing
+var verifyIgnoredFields = map[string]bool{
+	"accountId":             true,
+	"accountIdentifier":     true,
+	"account":               true,
+	"orgIdentifier":         true,
+	"projectIdentifier":     true,
+	"org":                   true,
+	"project":               true,
+	"createdAt":             true,
+	"modifiedAt":            true,
+	"lastModifiedAt":        true,
+	"lastModifiedBy":        true,
+	"lastUpdatedAt":         true,
+	"version":               true,
+	"entityValidityDetails": true,
+	"gitDetails":            true,
+	"governanceMetadata":    true,
+	"status":                true,
+	"triggerStatus":         true,
+	"executions":            true,
+	"webhookUrl":            true,
+	"webhookCurlCommand":    true,
+}
+
+// verifyLister lists every entity of one type in a project, keyed by the identifier used
+// in the checkpoint and the plan
+type verifyLister struct {
+	entity string
+	list   func(org, project string) (map[string]interface{}, error)
+}
+
+// Verify re-lists every entity type copied to the target project and compares it with the
+// source project. Entities are normalized before comparing, and every entity that is
+// missing, extra or different in the target is reported. Extra entities do not fail the
+// verification, the target may have had them before the copy.
+func (api *ApiRequest) Verify(sourceOrg, sourceProject, targetOrg, targetProject string, copyCD, copyFF bool, logger *zap.Logger) model.ProjectVerification {
+
+	logger.Info("Verifying project",
+		zap.String("sourceProject", sourceProject),
+		zap.String("targetProject", targetProject),
+	)
+
+	result := model.ProjectVerification{
+		SourceOrg:     sourceOrg,
+		SourceProject: sourceProject,
+		TargetOrg:     targetOrg,
+		TargetProject: targetProject,
+		Entries:       []model.VerifyEntry{},
+	}
+
+	// THE SOURCE AND THE TARGET MAY BE IN DIFFERENT ACCOUNTS
+	sourceListers := api.source().verifyListers(copyCD, copyFF, logger)
+	for i, l := range api.verifyListers(copyCD, copyFF, logger) {
+		source, err := sourceListers[i].list(sourceOrg, sourceProject)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: failed to list source: %v", l.entity, err))
+			continue
+		}
+		target, err := l.list(targetOrg, targetProject)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: failed to list target: %v", l.entity, err))
+			continue
+		}
+		// RENAMED ENTITIES ARE COMPARED WITH THE TARGET UNDER THEIR NEW IDENTIFIER AND NAME
+		source = api.Rename.Source(l.entity, source)
+		result.Entries = append(result.Entries, diffEntities(l.entity, source, target)...)
+	}
+
+	result.Passed = len(result.Errors) == 0
+	for _, e := range result.Entries {
+		if e.Status != VerifyExtra {
+			result.Passed = false
+		}
+	}
+
+	logger.Info("Project verification",
+		zap.String("targetProject", targetProject),
+		zap.Bool("passed", result.Passed),
+		zap.Any("entries", result.Entries),
+		zap.Strings("errors", result.Errors),
+	)
+
+	return result
+}
+
+func (api *ApiRequest) verifyListers(copyCD, copyFF bool, logger *zap.Logger) []verifyLister {
+	listers := []verifyLister{}
+
+	if copyCD || copyFF {
+		listers = append(listers,
+			verifyLister{"Secret", func(org, project string) (map[string]interface{}, error) {
+				secrets, err := api.listSecrets(org, project, logger)
+				return keyBy(secrets, err, func(s *model.Secret) (string, interface{}) {
+					return s.Identifier, s
+				})
+			}},
+			verifyLister{"Connector", func(org, project string) (map[string]interface{}, error) {
+				connectors, err := api.listConnectors(org, project, logger)
+				return keyBy(connectors, err, func(c *model.ConnectorContent) (string, interface{}) {
+					return c.Connector.Identifier, c.Connector
+				})
+			}},
+			verifyLister{"Environment", func(org, project string) (map[string]interface{}, error) {
+				envs, err := api.listEnvironments(org, project, logger)
+				return keyBy(envs, err, func(e *model.ListEnvironmentContent) (string, interface{}) {
+					return e.Environment.Identifier, e.Environment
+				})
+			}},
+			verifyLister{"Environment Group", func(org, project string) (map[string]interface{}, error) {
+				groups, err := api.listEnvGroups(org, project, logger)
+				return keyBy(groups, err, func(g model.EnvGroupContent) (string, interface{}) {
+					return g.EnvGroup.Identifier, g.EnvGroup
+				})
+			}},
+		)
+	}
+
+	if copyCD {
+		listers = append(listers,
+			verifyLister{"Variable", func(org, project string) (map[string]interface{}, error) {
+				variables, err := api.listVariables(org, project, logger)
+				return keyBy(variables, err, func(v *model.Variable) (string, interface{}) {
+					return v.Identifier, v
+				})
+			}},
+			verifyLister{"File Store", func(org, project string) (map[string]interface{}, error) {
+				found := map[string]interface{}{}
+				err := api.verifyNodes(org, project, "Root", "Root", nil, found, logger)
+				return found, err
+			}},
+			verifyLister{"Infrastructure", func(org, project string) (map[string]interface{}, error) {
+				return api.perEnvironment(org, project, logger, func(env string) (map[string]interface{}, error) {
+					infras, err := api.listInfraDef(org, project, env, logger)
+					return keyBy(infras, err, func(i *model.InfraDefListContent) (string, interface{}) {
+						return i.Infrastructure.Identifier, i.Infrastructure
+					})
+				})
+			}},
+			verifyLister{"Service", func(org, project string) (map[string]interface{}, error) {
+				list, err := api.listServices(org, project, logger)
+				return keyBy(list, err, func(s *model.ServiceListContent) (string, interface{}) {
+					return s.Service.Identifier, s.Service
+				})
+			}},
+			verifyLister{"Service Override", func(org, project string) (map[string]interface{}, error) {
+				return api.perEnvironment(org, project, logger, func(env string) (map[string]interface{}, error) {
+					overrides, err := api.listServiceOverrides(org, project, env, logger)
+					return keyBy(overrides, err, func(o *model.ServiceOverride) (string, interface{}) {
+						return o.ServiceRef, o
+					})
+				})
+			}},
+			verifyLister{"Template", func(org, project string) (map[string]interface{}, error) {
+				list, err := api.listTemplates(org, project, logger)
+				if err != nil {
+					return nil, err
+				}
+				found := map[string]interface{}{}
+				for _, t := range list {
+					data, err := api.getTemplate(org, project, t.Identifier, t.VersionLabel, logger)
+					if err != nil {
+						return nil, err
+					}
+					found[t.Identifier+"/"+t.VersionLabel] = data
+				}
+				return found, nil
+			}},
+			verifyLister{"Pipeline", func(org, project string) (map[string]interface{}, error) {
+				return api.perPipeline(org, project, logger, func(pipeline string) (map[string]interface{}, error) {
+					data, err := api.getPipeline(org, project, pipeline, logger)
+					if err != nil {
+						return nil, err
+					}
+					return map[string]interface{}{"": data}, nil
+				})
+			}},
+			verifyLister{"Input Set", func(org, project string) (map[string]interface{}, error) {
+				return api.perPipeline(org, project, logger, func(pipeline string) (map[string]interface{}, error) {
+					inputsets, err := api.listInputsets(org, project, pipeline, logger)
+					if err != nil {
+						return nil, err
+					}
+					found := map[string]interface{}{}
+					for _, is := range inputsets {
+						data, err := api.getInputset(org, project, pipeline, is.Identifier, logger)
+						if err != nil {
+							return nil, err
+						}
+						found[is.Identifier] = data
+					}
+					return found, nil
+				})
+			}},
+			verifyLister{"Tag", func(org, project string) (map[string]interface{}, error) {
+				envs, err := api.listEnvironments(org, project, logger)
+				if err != nil {
+					return nil, err
+				}
+				found := map[string]interface{}{}
+				for _, e := range envs {
+					tags, err := api.listTags(e.Environment.Name, org, project, logger)
+					if err != nil {
+						return nil, err
+					}
+					for _, t := range tags {
+						found[t.Identifier] = t
+					}
+				}
+				return found, nil
+			}},
+			verifyLister{"User", func(org, project string) (map[string]interface{}, error) {
+				users, err := api.listUsers(org, project, logger)
+				return keyBy(users, err, func(u *model.User) (string, interface{}) {
+					return u.Email, u
+				})
+			}},
+			verifyLister{"User Group", func(org, project string) (map[string]interface{}, error) {
+				groups, err := api.listUserGroups(org, project, logger)
+				return keyBy(groups, err, func(g *model.UserGroup) (string, interface{}) {
+					return g.Identifier, g
+				})
+			}},
+			verifyLister{"Service Account", func(org, project string) (map[string]interface{}, error) {
+				accounts, err := api.listServiceAccounts(org, project, logger)
+				return keyBy(accounts, err, func(sa *model.GetServiceAccountData) (string, interface{}) {
+					return sa.Identifier, sa
+				})
+			}},
+			verifyLister{"Role", func(org, project string) (map[string]interface{}, error) {
+				roles, err := api.listRoles(org, project, logger)
+				return keyBy(roles, err, func(r *model.ExistingRoles) (string, interface{}) {
+					return r.Identifier, r
+				})
+			}},
+			verifyLister{"Resource Group", func(org, project string) (map[string]interface{}, error) {
+				groups, err := api.listResourceGroups(org, project, logger)
+				return keyBy(groups, err, func(rg *model.ResourceGroup) (string, interface{}) {
+					return rg.Identifier, rg
+				})
+			}},
+			verifyLister{"Role Assignment", func(org, project string) (map[string]interface{}, error) {
+				assignments, err := api.listRoleAssignments(org, project, logger)
+				return keyBy(assignments, err, func(r *model.ExistingRoleAssignment) (string, interface{}) {
+					return r.Identifier, r
+				})
+			}},
+			verifyLister{"Trigger", func(org, project string) (map[string]interface{}, error) {
+				return api.perPipeline(org, project, logger, func(pipeline string) (map[string]interface{}, error) {
+					triggers, err := api.listPipelineTriggers(pipeline, org, project, logger)
+					return keyBy(triggers, err, func(t *model.TriggerContent) (string, interface{}) {
+						return t.Identifier, t
+					})
+				})
+			}},
+		)
+	}
+
+	if copyFF {
+		listers = append(listers,
+			verifyLister{"Feature Flag", func(org, project string) (map[string]interface{}, error) {
+				flags, err := api.listFeatureFlags(org, project, logger)
+				return keyBy(flags, err, func(f *model.FeatureFlag) (string, interface{}) {
+					return f.Identifier, f
+				})
+			}},
+			verifyLister{"Target", func(org, project string) (map[string]interface{}, error) {
+				return api.perEnvironment(org, project, logger, func(env string) (map[string]interface{}, error) {
+					targets, err := api.listTargets(org, project, env, logger)
+					return keyBy(targets, err, func(t *model.Target) (string, interface{}) {
+						return t.Identifier, t
+					})
+				})
+			}},
+			verifyLister{"Target Group", func(org, project string) (map[string]interface{}, error) {
+				return api.perEnvironment(org, project, logger, func(env string) (map[string]interface{}, error) {
+					groups, err := api.listTargetGroups(org, project, env, logger)
+					return keyBy(groups, err, func(tg *model.TargetGroups) (string, interface{}) {
+						return tg.Identifier, tg
+					})
+				})
+			}},
+		)
+	}
+
+	return listers
+}
+
+func keyBy[T any](items []T, err error, key func(T) (string, interface{})) (map[string]interface{}, error) {
+	if err != nil {
+		return nil, err
+	}
+	found := map[string]interface{}{}
+	for _, item := range items {
+		k, v := key(item)
+		found[k] = v
+	}
+	return found, nil
+}
+
+// perEnvironment lists entities that belong to an environment, keyed by environment/identifier
+func (api *ApiRequest) perEnvironment(org, project string, logger *zap.Logger, list func(env string) (map[string]interface{}, error)) (map[string]interface{}, error) {
+	envs, err := api.listEnvironments(org, project, logger)
+	if err != nil {
+		return nil, err
+	}
+	found := map[string]interface{}{}
+	for _, e := range envs {
+		entities, err := list(e.Environment.Identifier)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range entities {
+			found[e.Environment.Identifier+"/"+k] = v
+		}
+	}
+	return found, nil
+}
+
+// perPipeline fetches entities that belong to a pipeline, keyed by pipeline/identifier.
+// An empty key is the pipeline itself. Pipelines are fetched concurrently.
+func (api *ApiRequest) perPipeline(org, project string, logger *zap.Logger, list func(pipeline string) (map[string]interface{}, error)) (map[string]interface{}, error) {
+	pipelines, err := api.listPipelines(org, project, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	var mu sync.Mutex
+	found := map[string]interface{}{}
+
+	api.forEach(len(pipelines), func(i int) {
+		p := pipelines[i].Identifier
+		entities, listErr := list(p)
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if listErr != nil {
+			err = listErr
+			return
+		}
+		for k, v := range entities {
+			if k == "" {
+				found[p] = v
+			} else {
+				found[p+"/"+k] = v
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return found, nil
+}
+
+// verifyNodes collects the file store nodes of a project keyed by path. Only the metadata
+// of files is compared, not their content.
+func (api *ApiRequest) verifyNodes(org, project, identifier, name string, parentIdentifier *string, found map[string]interface{}, logger *zap.Logger) error {
+	nodes, err := api.listNodes(org, project, identifier, name, parentIdentifier, logger)
+	if err != nil {
+		return err
+	}
+	for _, n := range nodes {
+		node := *n
+		node.Children = nil
+		found[n.Path] = node
+		if n.Type == model.Folder {
+			if err := api.verifyNodes(org, project, n.Identifier, n.Name, &n.ParentIdentifier, found, logger); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// diffEntities compares the entities of one type in the source and the target project
+func diffEntities(entity string, source, target map[string]interface{}) []model.VerifyEntry {
+	entries := []model.VerifyEntry{}
+
+	for _, id := range sortedKeys(source) {
+		t, ok := target[id]
+		if !ok {
+			entries = append(entries, model.VerifyEntry{
+				Entity:     entity,
+				Identifier: id,
+				Status:     VerifyMissing,
+			})
+			continue
+		}
+		if fields := diffValues("", normalizeEntity(source[id]), normalizeEntity(t), nil); len(fields) > 0 {
+			entries = append(entries, model.VerifyEntry{
+				Entity:     entity,
+				Identifier: id,
+				Status:     VerifyDivergent,
+				Fields:     fields,
+			})
+		}
+	}
+
+	for _, id := range sortedKeys(target) {
+		if _, ok := source[id]; !ok {
+			entries = append(entries, model.VerifyEntry{
+				Entity:     entity,
+				Identifier: id,
+				Status:     VerifyExtra,
+			})
+		}
+	}
+
+	return entries
+}
+
+// normalizeEntity turns an entity into plain maps and slices without the ignored fields.
+// YAML documents held in string fields are parsed so they are compared field by field.
+func normalizeEntity(entity interface{}) interface{} {
+	b, err := json.Marshal(entity)
+	if err != nil {
+		return entity
+	}
+	var value interface{}
+	if err := json.Unmarshal(b, &value); err != nil {
+		return entity
+	}
+	return normalizeValue(value)
+}
+
+func normalizeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		out := map[string]interface{}{}
+		for k, field := range v {
+			if verifyIgnoredFields[k] {
+				continue
+			}
+			out[k] = normalizeValue(field)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = normalizeValue(item)
+		}
+		return out
+	case string:
+		if strings.Contains(v, "\n") {
+			var doc map[string]interface{}
+			if err := yaml.Unmarshal([]byte(v), &doc); err == nil && doc != nil {
+				return normalizeEntity(doc)
+			}
+		}
+		return v
+	}
+	return value
+}
+
+// diffValues returns the paths of the fields that differ, up to MAX_VERIFY_FIELDS
+func diffValues(path string, source, target interface{}, fields []string) []string {
+	if len(fields) >= MAX_VERIFY_FIELDS {
+		return fields
+	}
+
+	switch s := source.(type) {
+	case map[string]interface{}:
+		t, ok := target.(map[string]interface{})
+		if !ok {
+			return append(fields, fieldPath(path))
+		}
+		keys := map[string]interface{}{}
+		for k := range s {
+			keys[k] = nil
+		}
+		for k := range t {
+			keys[k] = nil
+		}
+		for _, k := range sortedKeys(keys) {
+			child := k
+			if path != "" {
+				child = path + "." + k
+			}
+			fields = diffValues(child, s[k], t[k], fields)
+		}
+		return fields
+	case []interface{}:
+		t, ok := target.([]interface{})
+		if !ok || len(t) != len(s) {
+			return append(fields, fieldPath(path))
+		}
+		for i := range s {
+			fields = diffValues(fmt.Sprintf("%s[%d]", path, i), s[i], t[i], fields)
+		}
+		return fields
+	}
+
+	if !reflect.DeepEqual(source, target) {
+		return append(fields, fieldPath(path))
+	}
+	return fields
+}
+
+func fieldPath(path string) string {
+	if path == "" {
+		return "."
+	}
+	return path
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
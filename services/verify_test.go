@@ -0,0 +1,60 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"harness-copy-project/model"
+)
+
+func TestDiffEntities(t *testing.T) {
+	source := map[string]interface{}{
+		"same": &model.Environment{
+			Identifier:        "same",
+			OrgIdentifier:     "src",
+			ProjectIdentifier: "src",
+			Yaml:              "environment:\n  identifier: same\n  orgIdentifier: src\n  projectIdentifier: src\n  type: Production\n",
+		},
+		"changed": &model.Environment{
+			Identifier: "changed",
+			Yaml:       "environment:\n  identifier: changed\n  type: Production\n",
+		},
+		"missing": &model.Environment{
+			Identifier: "missing",
+		},
+	}
+	target := map[string]interface{}{
+		"same": &model.Environment{
+			Identifier:        "same",
+			OrgIdentifier:     "target",
+			ProjectIdentifier: "target",
+			Yaml:              "environment:\n  identifier: same\n  orgIdentifier: target\n  projectIdentifier: target\n  type: Production\n",
+		},
+		"changed": &model.Environment{
+			Identifier: "changed",
+			Yaml:       "environment:\n  identifier: changed\n  type: PreProduction\n",
+		},
+		"extra": &model.Environment{
+			Identifier: "extra",
+		},
+	}
+
+	entries := diffEntities("Environment", source, target)
+
+	assert.Equal(t, []model.VerifyEntry{
+		{Entity: "Environment", Identifier: "changed", Status: VerifyDivergent, Fields: []string{"yaml.environment.type"}},
+		{Entity: "Environment", Identifier: "missing", Status: VerifyMissing},
+		{Entity: "Environment", Identifier: "extra", Status: VerifyExtra},
+	}, entries)
+}
+
+func TestDiffValues_LimitsFields(t *testing.T) {
+	source := map[string]interface{}{}
+	target := map[string]interface{}{}
+	for i := 0; i < MAX_VERIFY_FIELDS*2; i++ {
+		source[string(rune('a'+i))] = i
+		target[string(rune('a'+i))] = i + 1
+	}
+
+	assert.Len(t, diffValues("", source, target, nil), MAX_VERIFY_FIELDS)
+}